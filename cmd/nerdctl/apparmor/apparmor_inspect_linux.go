@@ -21,25 +21,53 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/completion"
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
 	"github.com/containerd/nerdctl/v2/pkg/cmd/apparmor"
 	"github.com/containerd/nerdctl/v2/pkg/defaults"
 )
 
 func inspectCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:           "inspect",
-		Short:         fmt.Sprintf("Display the default AppArmor profile %q. Other profiles cannot be displayed with this command.", defaults.AppArmorProfileName),
-		Args:          cobra.NoArgs,
-		RunE:          inspectAction,
-		SilenceUsage:  true,
-		SilenceErrors: true,
+		Use: "inspect [CONTAINER]",
+		Short: fmt.Sprintf("Display the default AppArmor profile %q, or the profile generated for CONTAINER from --security-opt apparmor-policy. "+
+			"Other profiles cannot be displayed with this command.", defaults.AppArmorProfileName),
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              inspectAction,
+		ValidArgsFunction: inspectShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
 	}
 	return cmd
 }
 
 func inspectAction(cmd *cobra.Command, args []string) error {
-	return apparmor.Inspect(types.ApparmorInspectOptions{
-		Stdout: cmd.OutOrStdout(),
-	})
+	if len(args) == 0 {
+		return apparmor.Inspect(types.ApparmorInspectOptions{
+			Stdout: cmd.OutOrStdout(),
+		})
+	}
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+	options := types.ApparmorInspectOptions{
+		Stdout:   cmd.OutOrStdout(),
+		GOptions: globalOptions,
+	}
+	client, ctx, cancel, err := clientutil.NewClient(cmd.Context(), options.GOptions.Namespace, options.GOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return apparmor.InspectContainer(ctx, client, args[0], options)
+}
+
+func inspectShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completion.ContainerNames(cmd, nil)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
 }
@@ -42,6 +42,7 @@ func Command() *cobra.Command {
 	}
 	cmd.AddCommand(
 		BuildCommand(),
+		bakeCommand(),
 		pruneCommand(),
 		debugCommand(),
 	)
@@ -0,0 +1,118 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/builder"
+)
+
+func bakeCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "bake [flags] [TARGET...]",
+		Short: "Build the targets of bake files or compose files. Needs buildkitd to be running.",
+		Long: `Build the targets of bake files or compose files. Needs buildkitd to be running.
+The targets are built concurrently. When no target is specified, the "default" group is built.
+When -f is not specified, the following files of the current directory are read:
+compose.yaml, compose.yml, docker-compose.yml, docker-compose.yaml,
+docker-bake.json, docker-bake.override.json, docker-bake.hcl, docker-bake.override.hcl`,
+		RunE:          bakeAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().String("buildkit-host", "", "BuildKit address")
+	cmd.Flags().StringArrayP("file", "f", nil, "Bake file or compose file")
+	cmd.Flags().Bool("print", false, "Print the resolved targets in JSON without building them")
+	cmd.Flags().String("progress", "auto", "Set type of progress output (auto, plain, tty, rawjson). Use plain to show container output")
+	cmd.Flags().Bool("no-cache", false, "Do not use cache when building the images")
+	cmd.Flags().Bool("pull", false, "On true, always attempt to pull latest image version from remote. Default uses the value of each target.")
+	return cmd
+}
+
+func processBakeCommandFlag(cmd *cobra.Command, args []string) (types.BuilderBakeOptions, error) {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return types.BuilderBakeOptions{}, err
+	}
+	files, err := cmd.Flags().GetStringArray("file")
+	if err != nil {
+		return types.BuilderBakeOptions{}, err
+	}
+	print, err := cmd.Flags().GetBool("print")
+	if err != nil {
+		return types.BuilderBakeOptions{}, err
+	}
+	progress, err := cmd.Flags().GetString("progress")
+	if err != nil {
+		return types.BuilderBakeOptions{}, err
+	}
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return types.BuilderBakeOptions{}, err
+	}
+	var pull *bool
+	if cmd.Flags().Changed("pull") {
+		pullFlag, err := cmd.Flags().GetBool("pull")
+		if err != nil {
+			return types.BuilderBakeOptions{}, err
+		}
+		pull = &pullFlag
+	}
+	return types.BuilderBakeOptions{
+		Stdout:   cmd.OutOrStdout(),
+		Stderr:   cmd.ErrOrStderr(),
+		GOptions: globalOptions,
+		Files:    files,
+		Targets:  args,
+		Print:    print,
+		Progress: progress,
+		NoCache:  noCache,
+		Pull:     pull,
+	}, nil
+}
+
+func bakeAction(cmd *cobra.Command, args []string) error {
+	options, err := processBakeCommandFlag(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	targets, err := builder.ResolveBakeTargets(cmd.Context(), options)
+	if err != nil {
+		return err
+	}
+	if options.Print {
+		return builder.PrintBakeTargets(targets, options)
+	}
+
+	options.BuildKitHost, err = GetBuildkitHost(cmd, options.GOptions.Namespace)
+	if err != nil {
+		return err
+	}
+
+	client, ctx, cancel, err := clientutil.NewClient(cmd.Context(), options.GOptions.Namespace, options.GOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return builder.Bake(ctx, client, targets, options)
+}
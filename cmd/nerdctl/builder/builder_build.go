@@ -53,7 +53,7 @@ If Dockerfile is not present and -f is not specified, it will look for Container
 	cmd.Flags().StringArray("build-arg", nil, "Set build-time variables")
 	cmd.Flags().Bool("no-cache", false, "Do not use cache when building the image")
 	cmd.Flags().StringP("output", "o", "", "Output destination (format: type=local,dest=path)")
-	cmd.Flags().String("progress", "auto", "Set type of progress output (auto, plain, tty). Use plain to show container output")
+	cmd.Flags().String("progress", "auto", "Set type of progress output (auto, plain, tty, rawjson). Use plain to show container output")
 	cmd.Flags().String("provenance", "", "Shorthand for \"--attest=type=provenance\"")
 	cmd.Flags().Bool("pull", false, "On true, always attempt to pull latest image version from remote. Default uses buildkit's default.")
 	cmd.Flags().StringArray("secret", nil, "Secret file to expose to the build: id=mysecret,src=/local/secret")
@@ -81,6 +81,7 @@ If Dockerfile is not present and -f is not specified, it will look for Container
 
 	cmd.Flags().String("iidfile", "", "Write the image ID to the file")
 	cmd.Flags().StringArray("label", nil, "Set metadata for an image")
+	cmd.Flags().Bool("squash", false, "Squash all the layers of the built image into one layer")
 
 	return cmd
 }
@@ -210,6 +211,22 @@ func processBuildCommandFlag(cmd *cobra.Command, args []string) (types.BuilderBu
 		return types.BuilderBuildOptions{}, err
 	}
 
+	squash, err := cmd.Flags().GetBool("squash")
+	if err != nil {
+		return types.BuilderBuildOptions{}, err
+	}
+	if squash {
+		if len(tagValue) == 0 {
+			return types.BuilderBuildOptions{}, errors.New("--squash requires --tag")
+		}
+		if output != "" {
+			return types.BuilderBuildOptions{}, errors.New("--squash cannot be used with --output")
+		}
+		if len(platform) > 1 {
+			return types.BuilderBuildOptions{}, errors.New("--squash cannot be used with multiple platforms")
+		}
+	}
+
 	usernsRemap, err := cmd.Flags().GetString("userns-remap")
 	if err != nil {
 		return types.BuilderBuildOptions{}, err
@@ -246,6 +263,7 @@ func processBuildCommandFlag(cmd *cobra.Command, args []string) (types.BuilderBu
 		NetworkMode:          network,
 		ExtendedBuildContext: extendedBuildCtx,
 		ExtraHosts:           extraHosts,
+		Squash:               squash,
 	}, nil
 }
 
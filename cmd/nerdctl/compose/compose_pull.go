@@ -17,6 +17,8 @@
 package compose
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
@@ -34,6 +36,7 @@ func pullCommand() *cobra.Command {
 		SilenceErrors: true,
 	}
 	cmd.Flags().BoolP("quiet", "q", false, "Pull without printing progress information")
+	cmd.Flags().Int("parallel", -1, "Maximum number of images to pull concurrently (-1 for unlimited)")
 	return cmd
 }
 
@@ -61,8 +64,16 @@ func pullAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	parallel, err := cmd.Flags().GetInt("parallel")
+	if err != nil {
+		return err
+	}
+	if parallel == 0 || parallel < -1 {
+		return fmt.Errorf("invalid --parallel value %d, should be a positive number or -1", parallel)
+	}
 	po := composer.PullOptions{
-		Quiet: quiet,
+		Quiet:    quiet,
+		Parallel: parallel,
 	}
 	return c.Pull(ctx, po, args)
 }
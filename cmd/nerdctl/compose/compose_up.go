@@ -51,6 +51,7 @@ func upCommand() *cobra.Command {
 	cmd.Flags().Bool("no-recreate", false, "Don't recreate containers if they exist, conflict with --force-recreate.")
 	cmd.Flags().StringArray("scale", []string{}, "Scale SERVICE to NUM instances. Overrides the `scale` setting in the Compose file if present.")
 	cmd.Flags().String("pull", "", "Pull image before running (\"always\"|\"missing\"|\"never\")")
+	cmd.Flags().Int("parallel", -1, "Maximum number of images to build or pull concurrently (-1 for unlimited)")
 	return cmd
 }
 
@@ -101,6 +102,13 @@ func upAction(cmd *cobra.Command, services []string) error {
 	if err != nil {
 		return err
 	}
+	parallel, err := cmd.Flags().GetInt("parallel")
+	if err != nil {
+		return err
+	}
+	if parallel == 0 || parallel < -1 {
+		return fmt.Errorf("invalid --parallel value %d, should be a positive number or -1", parallel)
+	}
 	removeOrphans, err := cmd.Flags().GetBool("remove-orphans")
 	if err != nil {
 		return err
@@ -162,6 +170,7 @@ func upAction(cmd *cobra.Command, services []string) error {
 		Pull:                 pull,
 		ForceRecreate:        forceRecreate,
 		NoRecreate:           noRecreate,
+		Parallel:             parallel,
 	}
 	return c.Up(ctx, uo, services)
 }
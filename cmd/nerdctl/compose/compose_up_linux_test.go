@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -377,6 +378,60 @@ services:
 	base.ComposeCmd("-f", comp.YAMLFullPath(), "down").AssertOK()
 }
 
+func TestComposeUpKeepsUpdates(t *testing.T) {
+	// docker does not support updating the oom score adjustment
+	testutil.DockerIncompatible(t)
+	base := testutil.NewBase(t)
+
+	const dockerComposeYAML = `
+services:
+  test:
+    image: %s
+    command: "sleep infinity"%s
+    configs:
+      - source: config
+        target: /config.txt
+        mode: 0440
+configs:
+  config:
+    file: ./config.txt
+`
+	comp := testutil.NewComposeDir(t, fmt.Sprintf(dockerComposeYAML, testutil.CommonImage, ""))
+	defer comp.CleanUp()
+	comp.WriteFile("config.txt", "foo")
+	projectName := comp.ProjectName()
+	t.Logf("projectName=%q", projectName)
+	containerName := serviceparser.DefaultContainerName(projectName, "test", "1")
+
+	base.ComposeCmd("-f", comp.YAMLFullPath(), "up", "-d").AssertOK()
+	defer base.ComposeCmd("-f", comp.YAMLFullPath(), "down", "-v").Run()
+	id := base.InspectContainer(containerName).ID
+
+	// the containers which do not diverge from the compose model are kept with their updates
+	base.Cmd("update", "--oom-score-adj", "500", containerName).AssertOK()
+	base.ComposeCmd("-f", comp.YAMLFullPath(), "up", "-d").AssertOK()
+	inspect := base.InspectContainer(containerName)
+	assert.Equal(t, inspect.ID, id)
+	assert.Equal(t, inspect.HostConfig.OomScoreAdj, 500)
+
+	// the contents of the configs are part of the model
+	comp.WriteFile("config.txt", "bar")
+	base.ComposeCmd("-f", comp.YAMLFullPath(), "up", "-d").AssertOK()
+	inspect = base.InspectContainer(containerName)
+	assert.Assert(t, inspect.ID != id, "the container must be recreated when the config is modified")
+	assert.Equal(t, inspect.HostConfig.OomScoreAdj, 0)
+	base.Cmd("exec", containerName, "cat", "/config.txt").AssertOutExactly("bar")
+	id = inspect.ID
+
+	// the modified services are recreated
+	comp.WriteFile(filepath.Base(comp.YAMLFullPath()), fmt.Sprintf(dockerComposeYAML, testutil.CommonImage, `
+    environment:
+      FOO: bar`))
+	base.ComposeCmd("-f", comp.YAMLFullPath(), "up", "-d").AssertOK()
+	assert.Assert(t, base.InspectContainer(containerName).ID != id, "the container must be recreated when the service is modified")
+	base.Cmd("exec", containerName, "printenv", "FOO").AssertOutExactly("bar\n")
+}
+
 func TestComposeUpWithExternalNetwork(t *testing.T) {
 	testCase := nerdtest.Setup()
 
@@ -51,6 +51,7 @@ func CommitCommand() *cobra.Command {
 	cmd.Flags().Bool("zstdchunked", false, "Convert the committed layer to zstd:chunked for lazy pulling")
 	cmd.Flags().Int("zstdchunked-compression-level", 3, "zstd:chunked compression level")
 	cmd.Flags().Int("zstdchunked-chunk-size", 0, "zstd:chunked chunk size")
+	cmd.Flags().Bool("squash", false, "Squash all the layers of the committed image into one layer")
 	return cmd
 }
 
@@ -128,6 +129,14 @@ func commitOptions(cmd *cobra.Command) (types.ContainerCommitOptions, error) {
 		return types.ContainerCommitOptions{}, errors.New("options --estargz and --zstdchunked lead to conflict, only one of them can be used")
 	}
 
+	squash, err := cmd.Flags().GetBool("squash")
+	if err != nil {
+		return types.ContainerCommitOptions{}, err
+	}
+	if squash && (estargz || zstdchunked) {
+		return types.ContainerCommitOptions{}, errors.New("option --squash cannot be used with --estargz or --zstdchunked")
+	}
+
 	return types.ContainerCommitOptions{
 		Stdout:      cmd.OutOrStdout(),
 		GOptions:    globalOptions,
@@ -137,6 +146,7 @@ func commitOptions(cmd *cobra.Command) (types.ContainerCommitOptions, error) {
 		Change:      change,
 		Compression: types.CompressionType(com),
 		Format:      types.ImageFormat(format),
+		Squash:      squash,
 		EstargzOptions: types.EstargzOptions{
 			Estargz:                 estargz,
 			EstargzCompressionLevel: estargzCompressionLevel,
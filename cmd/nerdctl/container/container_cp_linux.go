@@ -35,9 +35,10 @@ func copyCommand() *cobra.Command {
 	shortHelp := "Copy files/folders between a running container and the local filesystem."
 
 	longHelp := shortHelp + `
-This command requires 'tar' to be installed on the host (not in the container).
-Using GNU tar is recommended.
-The path of the 'tar' binary can be specified with an environment variable '$TAR'.
+Use '-' as the source to read a tar archive from stdin and extract it to a directory destination in a container.
+Use '-' as the destination to stream a tar archive of a container source to stdout.
+
+In rootless mode, this command requires 'nsenter' to be installed on the host.
 
 WARNING: 'nerdctl cp' is designed only for use with trusted, cooperating containers.
 Using 'nerdctl cp' with untrusted or malicious containers is unsupported and may not provide protection against unexpected behavior.
@@ -57,6 +58,8 @@ Using 'nerdctl cp' with untrusted or malicious containers is unsupported and may
 	}
 
 	cmd.Flags().BoolP("follow-link", "L", false, "Always follow symbolic link in SRC_PATH.")
+	cmd.Flags().BoolP("archive", "a", false, "Archive mode (copy all uid/gid information)")
+	cmd.Flags().String("chown", "", "Set the owner of the copied files, in the form of USER[:GROUP] (names are looked up in the destination)")
 
 	return cmd
 }
@@ -91,6 +94,18 @@ func copyOptions(cmd *cobra.Command, args []string) (types.ContainerCpOptions, e
 		return types.ContainerCpOptions{}, err
 	}
 
+	archive, err := cmd.Flags().GetBool("archive")
+	if err != nil {
+		return types.ContainerCpOptions{}, err
+	}
+	chown, err := cmd.Flags().GetString("chown")
+	if err != nil {
+		return types.ContainerCpOptions{}, err
+	}
+	if archive && chown != "" {
+		return types.ContainerCpOptions{}, errors.New("--archive and --chown cannot be specified together")
+	}
+
 	srcSpec, err := parseCpFileSpec(args[0])
 	if err != nil {
 		return types.ContainerCpOptions{}, err
@@ -107,12 +122,6 @@ func copyOptions(cmd *cobra.Command, args []string) (types.ContainerCpOptions, e
 	if srcSpec.Container == nil && destSpec.Container == nil {
 		return types.ContainerCpOptions{}, fmt.Errorf("one of src or dest must be a container file specification")
 	}
-	if srcSpec.Path == "-" {
-		return types.ContainerCpOptions{}, fmt.Errorf("support for reading a tar archive from stdin is not implemented yet")
-	}
-	if destSpec.Path == "-" {
-		return types.ContainerCpOptions{}, fmt.Errorf("support for writing a tar archive to stdout is not implemented yet")
-	}
 
 	container2host := srcSpec.Container != nil
 	var containerReq string
@@ -128,6 +137,10 @@ func copyOptions(cmd *cobra.Command, args []string) (types.ContainerCpOptions, e
 		DestPath:       destSpec.Path,
 		SrcPath:        srcSpec.Path,
 		FollowSymLink:  flagL,
+		Archive:        archive,
+		Chown:          chown,
+		Stdin:          cmd.InOrStdin(),
+		Stdout:         cmd.OutOrStdout(),
 	}, nil
 }
 
@@ -218,6 +218,10 @@ func createOptions(cmd *cobra.Command) (types.ContainerCreateOptions, error) {
 		return opt, err
 	}
 
+	opt.DeviceCgroupRules, err = cmd.Flags().GetStringArray("device-cgroup-rule")
+	if err != nil {
+		return opt, err
+	}
 	allDevices, err := cmd.Flags().GetStringSlice("device")
 	if err != nil {
 		return opt, err
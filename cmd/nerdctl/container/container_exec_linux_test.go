@@ -22,6 +22,7 @@ import (
 	"github.com/containerd/nerdctl/mod/tigron/expect"
 	"github.com/containerd/nerdctl/mod/tigron/test"
 
+	"github.com/containerd/nerdctl/v2/pkg/errutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
 )
@@ -112,3 +113,43 @@ func TestExecTTY(t *testing.T) {
 
 	testCase.Run(t)
 }
+
+func TestExecExitCode(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.Setup = func(data test.Data, helpers test.Helpers) {
+		helpers.Ensure("run", "-d", "--name", data.Identifier(), testutil.CommonImage, "sleep", nerdtest.Infinity)
+		nerdtest.EnsureContainerStarted(helpers, data.Identifier())
+		data.Labels().Set("container", data.Identifier())
+	}
+
+	testCase.Cleanup = func(data test.Data, helpers test.Helpers) {
+		helpers.Anyhow("rm", "-f", data.Identifier())
+	}
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "exit code of the command",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("exec", data.Labels().Get("container"), "sh", "-c", "exit 42")
+			},
+			Expected: test.Expects(42, nil, nil),
+		},
+		{
+			Description: "command not found",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("exec", data.Labels().Get("container"), "no-such-command")
+			},
+			Expected: test.Expects(errutil.ExitCodeCommandNotFound, nil, nil),
+		},
+		{
+			Description: "command cannot be invoked",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("exec", data.Labels().Get("container"), "/etc")
+			},
+			Expected: test.Expects(errutil.ExitCodeCannotInvoke, nil, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/labels"
 	"github.com/containerd/nerdctl/v2/pkg/logging"
 	"github.com/containerd/nerdctl/v2/pkg/netutil"
+	"github.com/containerd/nerdctl/v2/pkg/platformutil"
 	"github.com/containerd/nerdctl/v2/pkg/signalutil"
 	"github.com/containerd/nerdctl/v2/pkg/taskutil"
 )
@@ -170,6 +171,7 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().Uint64("cpu-rt-runtime", 0, "Limit CPU real-time runtime in microseconds")
 	// device is defined as StringSlice, not StringArray, to allow specifying "--device=DEV1,DEV2" (compatible with Podman)
 	cmd.Flags().StringSlice("device", nil, "Add a host device to the container")
+	cmd.Flags().StringArray("device-cgroup-rule", nil, "Add a rule to the cgroup allowed devices list")
 	// ulimit is defined as StringSlice, not StringArray, to allow specifying "--ulimit=ULIMIT1,ULIMIT2" (compatible with Podman)
 	cmd.Flags().StringSlice("ulimit", nil, "Ulimit options")
 	cmd.Flags().String("rdt-class", "", "Name of the RDT class (or CLOS) to associate the container with")
@@ -193,11 +195,12 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().StringArray("security-opt", []string{}, "Security options")
 	cmd.RegisterFlagCompletionFunc("security-opt", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
-			"seccomp=", "seccomp=" + defaults.SeccompProfileName, "seccomp=unconfined",
-			"apparmor=", "apparmor=" + defaults.AppArmorProfileName, "apparmor=unconfined",
+			"seccomp=", "seccomp=" + defaults.SeccompProfileName, "seccomp=unconfined", "seccomp-add=", "seccomp-drop=",
+			"apparmor=", "apparmor=" + defaults.AppArmorProfileName, "apparmor=unconfined", "apparmor-policy=",
 			"no-new-privileges",
 			"systempaths=unconfined",
-			"privileged-without-host-devices"}, cobra.ShellCompDirectiveNoFileComp
+			"privileged-without-host-devices",
+			"label=disable", "label=user:", "label=role:", "label=type:", "label=level:"}, cobra.ShellCompDirectiveNoFileComp
 	})
 	// cap-add and cap-drop are defined as StringSlice, not StringArray, to allow specifying "--cap-add=CAP_SYS_ADMIN,CAP_NET_ADMIN" (compatible with Podman)
 	cmd.Flags().StringSlice("cap-add", []string{}, "Add Linux capabilities")
@@ -354,13 +357,25 @@ func processCreateCommandFlagsInRun(cmd *cobra.Command) (types.ContainerCreateOp
 
 // runAction is heavily based on ctr implementation:
 // https://github.com/containerd/containerd/blob/v1.4.3/cmd/ctr/commands/run/run.go
-func runAction(cmd *cobra.Command, args []string) error {
+func runAction(cmd *cobra.Command, args []string) (retErr error) {
 	var isDetached bool
+	started := false
+	defer func() {
+		if !started {
+			retErr = errutil.NewStartError(retErr)
+		}
+	}()
 
 	createOpt, err := processCreateCommandFlagsInRun(cmd)
 	if err != nil {
 		return err
 	}
+	// fail before pulling the image, rather than with "exec format error" in the container
+	if runtime.GOOS == "linux" {
+		if err := platformutil.CheckExec(createOpt.Platform); err != nil {
+			return err
+		}
+	}
 
 	client, ctx, cancel, err := clientutil.NewClientWithPlatform(cmd.Context(), createOpt.GOptions.Namespace, createOpt.GOptions.Address, createOpt.Platform)
 	if err != nil {
@@ -438,6 +453,7 @@ func runAction(cmd *cobra.Command, args []string) error {
 	if err := task.Start(ctx); err != nil {
 		return err
 	}
+	started = true
 
 	if createOpt.Detach {
 		fmt.Fprintln(createOpt.Stdout, id)
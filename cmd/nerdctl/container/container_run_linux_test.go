@@ -39,6 +39,7 @@ import (
 	"github.com/containerd/nerdctl/mod/tigron/tig"
 
 	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/errutil"
 	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/v2/pkg/strutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
@@ -723,3 +724,42 @@ devices:
 	err = os.WriteFile(cdiSpecPath, []byte(testCDIVendor1), 0400)
 	assert.NilError(t, err)
 }
+
+func TestRunStartFailureExitCode(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "command not found",
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("run", "--name", data.Identifier(), testutil.CommonImage, "no-such-command")
+			},
+			Expected: test.Expects(errutil.ExitCodeCommandNotFound, nil, nil),
+		},
+		{
+			Description: "command cannot be invoked",
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("run", "--name", data.Identifier(), testutil.CommonImage, "/etc")
+			},
+			Expected: test.Expects(errutil.ExitCodeCannotInvoke, nil, nil),
+		},
+		{
+			Description: "invalid flag",
+			Command:     test.Command("run", "--rm", "--restart=no-such-policy", testutil.CommonImage),
+			Expected:    test.Expects(errutil.ExitCodeStartFailed, nil, nil),
+		},
+		{
+			Description: "exit code of the container",
+			Command:     test.Command("run", "--rm", testutil.CommonImage, "sh", "-c", "exit "+strconv.Itoa(errutil.ExitCodeStartFailed)),
+			Expected:    test.Expects(errutil.ExitCodeStartFailed, nil, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/containerd/nerdctl/mod/tigron/test"
 	"github.com/containerd/nerdctl/mod/tigron/tig"
 
+	"github.com/containerd/nerdctl/v2/pkg/errutil"
 	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
@@ -635,8 +636,7 @@ func TestSharedNetworkSetup(t *testing.T) {
 						"--network=container:"+data.Labels().Get("container1"),
 						testutil.CommonImage)
 				},
-				// 1 for nerdctl, 125 for docker
-				Expected: test.Expects(expect.ExitCodeGenericFail, nil, nil),
+				Expected: test.Expects(errutil.ExitCodeStartFailed, nil, nil),
 			},
 			{
 				Description: "Test dns options is not  supported",
@@ -655,8 +655,7 @@ func TestSharedNetworkSetup(t *testing.T) {
 						"--network=container:"+data.Labels().Get("container1"),
 						testutil.AlpineImage)
 				},
-				// 1 for nerdctl, 125 for docker
-				Expected: test.Expects(expect.ExitCodeGenericFail, nil, nil),
+				Expected: test.Expects(errutil.ExitCodeStartFailed, nil, nil),
 			},
 			{
 				Description: "Test hostname is not supported",
@@ -665,8 +664,7 @@ func TestSharedNetworkSetup(t *testing.T) {
 						"--network=container:"+data.Labels().Get("container1"),
 						testutil.AlpineImage)
 				},
-				// 1 for nerdctl, 125 for docker
-				Expected: test.Expects(expect.ExitCodeGenericFail, nil, nil),
+				Expected: test.Expects(errutil.ExitCodeStartFailed, nil, nil),
 			},
 		},
 	}
@@ -20,10 +20,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/opencontainers/runtime-spec/specs-go"
 	"gotest.tools/v3/assert"
 
 	"github.com/containerd/nerdctl/v2/pkg/apparmorutil"
@@ -169,6 +172,37 @@ func TestRunSecurityOptSeccomp(t *testing.T) {
 	}
 }
 
+func TestRunSecurityOptSeccompAddDrop(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	t.Parallel()
+	base := testutil.NewBase(t)
+	testContainerName := testutil.Identifier(t)
+
+	// chmod is allowed by the default profile (fchmodat is used instead of chmod on some architectures)
+	base.Cmd("run", "--rm", testutil.AlpineImage, "sh", "-euc", "touch /tmp/foo && chmod 600 /tmp/foo").AssertOK()
+	base.Cmd("run", "--rm", "--security-opt", "seccomp-drop=chmod", "--security-opt", "seccomp-drop=fchmodat",
+		testutil.AlpineImage, "sh", "-euc", "touch /tmp/foo && chmod 600 /tmp/foo").AssertCombinedOutContains("Operation not permitted")
+
+	base.Cmd("run", "-d", "--name", testContainerName, "--security-opt", "seccomp-add=ptrace", "--security-opt", "seccomp-add=personality",
+		testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", testContainerName).Run()
+	base.Cmd("exec", testContainerName, "grep", "-Eq", `^Seccomp:\s*2`, "/proc/1/status").AssertOK()
+	profile := base.InspectContainer(testContainerName).SeccompProfile
+	assert.Assert(t, profile != nil)
+	for _, name := range []string{"ptrace", "personality"} {
+		// the syscalls are allowed unconditionally, personality is allowed only for some arguments by default
+		var rules []specs.LinuxSyscall
+		for _, rule := range profile.Syscalls {
+			if slices.Contains(rule.Names, name) {
+				rules = append(rules, rule)
+			}
+		}
+		assert.Equal(t, len(rules), 1, name)
+		assert.Equal(t, rules[0].Action, specs.ActAllow, name)
+		assert.Equal(t, len(rules[0].Args), 0, name)
+	}
+}
+
 func TestRunApparmor(t *testing.T) {
 	base := testutil.NewBase(t)
 	defaultProfile := fmt.Sprintf("%s-default", base.Target)
@@ -186,6 +220,33 @@ func TestRunApparmor(t *testing.T) {
 	base.Cmd("run", "--rm", "--privileged", testutil.AlpineImage, "cat", attrCurrentPath).AssertOutContains("unconfined")
 }
 
+func TestRunApparmorPolicyReload(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	if !apparmorutil.CanLoadNewProfile() {
+		t.Skip("needs to be able to load AppArmor profiles")
+	}
+	base := testutil.NewBase(t)
+	testContainerName := testutil.Identifier(t)
+	policyPath := filepath.Join(t.TempDir(), "policy.json")
+	assert.NilError(t, os.WriteFile(policyPath, []byte(`{"denyMount": true}`), 0o644))
+	attrCurrentPath := "/proc/self/attr/apparmor/current"
+	if _, err := os.Stat(attrCurrentPath); err != nil {
+		attrCurrentPath = "/proc/self/attr/current"
+	}
+
+	base.Cmd("run", "-d", "--name", testContainerName, "--security-opt", "apparmor-policy="+policyPath,
+		testutil.AlpineImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", testContainerName).Run()
+	profile := apparmorutil.ContainerProfileName(base.InspectContainer(testContainerName).ID)
+	base.Cmd("exec", testContainerName, "cat", attrCurrentPath).AssertOutExactly(profile + " (enforce)\n")
+
+	// the profile is not loaded anymore after rebooting the host, so it is loaded again when the container is started
+	base.Cmd("stop", "-t", "0", testContainerName).AssertOK()
+	assert.NilError(t, apparmorutil.Unload(profile))
+	base.Cmd("start", testContainerName).AssertOK()
+	base.Cmd("exec", testContainerName, "cat", attrCurrentPath).AssertOutExactly(profile + " (enforce)\n")
+}
+
 // TestRunSeccompCapSysPtrace tests https://github.com/containerd/nerdctl/issues/976
 func TestRunSeccompCapSysPtrace(t *testing.T) {
 	base := testutil.NewBase(t)
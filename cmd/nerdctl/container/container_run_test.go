@@ -40,6 +40,7 @@ import (
 	"github.com/containerd/nerdctl/mod/tigron/tig"
 
 	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/errutil"
 	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
@@ -181,8 +182,7 @@ func TestRunCIDFile(t *testing.T) {
 		return helpers.Command("run", "--rm", "--cidfile", data.Temp().Path("cid-file"), testutil.CommonImage)
 	}
 
-	// Docker will return 125 while nerdctl returns 1, so, generic fail instead of specific exit code
-	testCase.Expected = test.Expects(expect.ExitCodeGenericFail, []error{errors.New("container ID file found")}, nil)
+	testCase.Expected = test.Expects(errutil.ExitCodeStartFailed, []error{errors.New("container ID file found")}, nil)
 
 	testCase.Run(t)
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/containerd/nerdctl/mod/tigron/test"
 	"github.com/containerd/nerdctl/mod/tigron/tig"
 
+	"github.com/containerd/nerdctl/v2/pkg/errutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
 )
@@ -77,3 +78,38 @@ func TestStartDetachKeys(t *testing.T) {
 
 	testCase.Run(t)
 }
+
+func TestStartAttachExitCode(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "exit code of the container",
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("create", "--name", data.Identifier(), testutil.CommonImage, "sh", "-c", "exit 3")
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("start", "-a", data.Identifier())
+			},
+			Expected: test.Expects(3, nil, nil),
+		},
+		{
+			Description: "command not found",
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("create", "--name", data.Identifier(), testutil.CommonImage, "no-such-command")
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("start", "-a", data.Identifier())
+			},
+			Expected: test.Expects(errutil.ExitCodeCommandNotFound, nil, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
@@ -17,6 +17,9 @@
 package container
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -45,9 +48,10 @@ func StatsCommand() *cobra.Command {
 
 func addStatsFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolP("all", "a", false, "Show all containers (default shows just running)")
-	cmd.Flags().String("format", "", "Pretty-print images using a Go template, e.g, '{{json .}}'")
+	cmd.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}', or 'json' to print one JSON object per interval")
 	cmd.Flags().Bool("no-stream", false, "Disable streaming stats and only pull the first result")
 	cmd.Flags().Bool("no-trunc", false, "Do not truncate output")
+	cmd.Flags().Duration("interval", 500*time.Millisecond, "Interval of the statistics")
 }
 
 func processStatsCommandFlags(cmd *cobra.Command) (types.ContainerStatsOptions, error) {
@@ -76,6 +80,14 @@ func processStatsCommandFlags(cmd *cobra.Command) (types.ContainerStatsOptions,
 		return types.ContainerStatsOptions{}, err
 	}
 
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return types.ContainerStatsOptions{}, err
+	}
+	if interval <= 0 {
+		return types.ContainerStatsOptions{}, fmt.Errorf("invalid interval %q: must be positive", interval)
+	}
+
 	return types.ContainerStatsOptions{
 		Stdout:   cmd.OutOrStdout(),
 		Stderr:   cmd.ErrOrStderr(),
@@ -84,6 +96,7 @@ func processStatsCommandFlags(cmd *cobra.Command) (types.ContainerStatsOptions,
 		Format:   format,
 		NoStream: noStream,
 		NoTrunc:  noTrunc,
+		Interval: interval,
 	}, nil
 }
 
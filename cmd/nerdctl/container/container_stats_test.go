@@ -17,13 +17,18 @@
 package container
 
 import (
+	"errors"
 	"runtime"
 	"testing"
 
+	"gotest.tools/v3/assert"
+
 	"github.com/containerd/nerdctl/mod/tigron/expect"
 	"github.com/containerd/nerdctl/mod/tigron/require"
 	"github.com/containerd/nerdctl/mod/tigron/test"
+	"github.com/containerd/nerdctl/mod/tigron/tig"
 
+	"github.com/containerd/nerdctl/v2/pkg/statsutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
 )
@@ -105,6 +110,43 @@ func TestStats(t *testing.T) {
 			},
 			Expected: test.Expects(0, nil, expect.Contains("1GiB")),
 		},
+		{
+			Description: "json format",
+			Require:     require.Not(nerdtest.Docker),
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("stats", "--no-stream", "--format", "json", data.Labels().Get("id"))
+			},
+			Expected: func(data test.Data, helpers test.Helpers) *test.Expected {
+				return &test.Expected{
+					Output: expect.JSON(statsutil.StatsJSON{}, func(tick statsutil.StatsJSON, t tig.T) {
+						assert.Assert(t, !tick.Read.IsZero())
+						assert.Equal(t, len(tick.Containers), 1)
+						assert.Equal(t, tick.Containers[0].Name, data.Labels().Get("id"))
+						assert.Assert(t, tick.Containers[0].PidsCurrent > 0)
+					}),
+				}
+			},
+		},
+		{
+			Description: "json template",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("stats", "--no-stream", "--format", "{{json .}}", data.Labels().Get("id"))
+			},
+			Expected: func(data test.Data, helpers test.Helpers) *test.Expected {
+				return &test.Expected{
+					// the templates are executed for each container, with the Docker compatible entries
+					Output: expect.JSON(statsutil.FormattedStatsEntry{}, func(entry statsutil.FormattedStatsEntry, t tig.T) {
+						assert.Equal(t, entry.Name, data.Labels().Get("id"))
+					}),
+				}
+			},
+		},
+		{
+			Description: "invalid interval",
+			Require:     require.Not(nerdtest.Docker),
+			Command:     test.Command("stats", "--no-stream", "--interval", "0s"),
+			Expected:    test.Expects(expect.ExitCodeGenericFail, []error{errors.New("invalid interval")}, nil),
+		},
 	}
 
 	testCase.Run(t)
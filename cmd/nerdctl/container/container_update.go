@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/docker/go-units"
@@ -42,6 +43,8 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/formatter"
 	"github.com/containerd/nerdctl/v2/pkg/idutil/containerwalker"
 	"github.com/containerd/nerdctl/v2/pkg/infoutil"
+	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
+	"github.com/containerd/nerdctl/v2/pkg/labels"
 )
 
 type updateResourceOptions struct {
@@ -55,6 +58,19 @@ type updateResourceOptions struct {
 	CpusetMems         string
 	PidsLimit          int64
 	BlkioWeight        uint16
+	MemorySwappiness   int64
+	OomScoreAdj        int
+	CPURealtimePeriod  uint64
+	CPURealtimeRuntime uint64
+	// DeviceCgroupRules replaces the rules set with --device-cgroup-rule
+	DeviceCgroupRules []string
+	DeviceAdd         []string
+	DeviceRemove      []string
+	// Block IO throttling of the devices, merged into the current throttling
+	BlkioDeviceReadBps   []string
+	BlkioDeviceWriteBps  []string
+	BlkioDeviceReadIOps  []string
+	BlkioDeviceWriteIOps []string
 }
 
 func UpdateCommand() *cobra.Command {
@@ -85,6 +101,17 @@ func setUpdateFlags(cmd *cobra.Command) {
 	cmd.Flags().String("cpuset-mems", "", "MEMs in which to allow execution (0-3, 0,1)")
 	cmd.Flags().Int64("pids-limit", -1, "Tune container pids limit (set -1 for unlimited)")
 	cmd.Flags().Uint16("blkio-weight", 0, "Block IO (relative weight), between 10 and 1000, or 0 to disable (default 0)")
+	cmd.Flags().StringArray("device-read-bps", nil, "Limit read rate (bytes per second) from a device (e.g. /dev/sda:1mb), or 0 to remove the limit")
+	cmd.Flags().StringArray("device-write-bps", nil, "Limit write rate (bytes per second) to a device (e.g. /dev/sda:1mb), or 0 to remove the limit")
+	cmd.Flags().StringArray("device-read-iops", nil, "Limit read rate (IO per second) from a device (e.g. /dev/sda:1000), or 0 to remove the limit")
+	cmd.Flags().StringArray("device-write-iops", nil, "Limit write rate (IO per second) to a device (e.g. /dev/sda:1000), or 0 to remove the limit")
+	cmd.Flags().Uint64("cpu-rt-period", 0, "Limit CPU real-time period in microseconds")
+	cmd.Flags().Uint64("cpu-rt-runtime", 0, "Limit CPU real-time runtime in microseconds")
+	cmd.Flags().Int64("memory-swappiness", -1, "Tune container memory swappiness (0 to 100), or -1 to reset to the system default")
+	cmd.Flags().Int("oom-score-adj", 0, "Tune container’s OOM preferences (-1000 to 1000, rootless: 100 to 1000)")
+	cmd.Flags().StringArray("device-cgroup-rule", nil, "Replace the rules added to the cgroup allowed devices list with --device-cgroup-rule (specify an empty rule to remove them)")
+	cmd.Flags().StringArray("device-add", nil, "Add a host device to the container")
+	cmd.Flags().StringArray("device-rm", nil, "Remove a device added with --device or --device-add, by its path in the container")
 	cmd.Flags().String("restart", "no", `Restart policy to apply when a container exits (implemented values: "no"|"always|on-failure:n|unless-stopped")`)
 	cmd.RegisterFlagCompletionFunc("restart", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"no", "always", "on-failure", "unless-stopped"}, cobra.ShellCompDirectiveNoFileComp
@@ -225,6 +252,62 @@ func getUpdateOption(cmd *cobra.Command, globalOptions types.GlobalCommandOption
 		return options, errors.New("range of blkio weight is from 10 to 1000")
 	}
 
+	memSwappiness, err := cmd.Flags().GetInt64("memory-swappiness")
+	if err != nil {
+		return options, err
+	}
+	if memSwappiness > 100 || memSwappiness < -1 {
+		return options, fmt.Errorf("invalid value: %v, valid memory swappiness range is 0-100", memSwappiness)
+	}
+	oomScoreAdj, err := cmd.Flags().GetInt("oom-score-adj")
+	if err != nil {
+		return options, err
+	}
+	cpuRtPeriod, err := cmd.Flags().GetUint64("cpu-rt-period")
+	if err != nil {
+		return options, err
+	}
+	cpuRtRuntime, err := cmd.Flags().GetUint64("cpu-rt-runtime")
+	if err != nil {
+		return options, err
+	}
+	if cmd.Flags().Changed("cpu-rt-period") || cmd.Flags().Changed("cpu-rt-runtime") {
+		if !infoutil.CPURealtime(globalOptions.CgroupManager) {
+			return options, errors.New("kernel does not support CPU real-time scheduler")
+		}
+		if cpuRtPeriod != 0 && cpuRtRuntime != 0 && cpuRtRuntime > cpuRtPeriod {
+			return options, errors.New("cpu real-time runtime cannot be higher than cpu real-time period")
+		}
+	}
+	deviceCgroupRules, err := cmd.Flags().GetStringArray("device-cgroup-rule")
+	if err != nil {
+		return options, err
+	}
+	deviceAdd, err := cmd.Flags().GetStringArray("device-add")
+	if err != nil {
+		return options, err
+	}
+	deviceRemove, err := cmd.Flags().GetStringArray("device-rm")
+	if err != nil {
+		return options, err
+	}
+	throttleSupported := map[string]func(string) bool{
+		"device-read-bps":   infoutil.BlockIOReadBpsDevice,
+		"device-write-bps":  infoutil.BlockIOWriteBpsDevice,
+		"device-read-iops":  infoutil.BlockIOReadIOpsDevice,
+		"device-write-iops": infoutil.BlockIOWriteIOpsDevice,
+	}
+	var throttleDevices [4][]string
+	for i, name := range []string{"device-read-bps", "device-write-bps", "device-read-iops", "device-write-iops"} {
+		throttleDevices[i], err = cmd.Flags().GetStringArray(name)
+		if err != nil {
+			return options, err
+		}
+		if len(throttleDevices[i]) > 0 && !throttleSupported[name](globalOptions.CgroupManager) {
+			return options, fmt.Errorf("kernel support for cgroup blkio %s missing", strings.TrimPrefix(name, "device-"))
+		}
+	}
+
 	if runtime.GOOS == "linux" {
 		options = updateResourceOptions{
 			CPUPeriod:          cpuPeriod,
@@ -237,6 +320,18 @@ func getUpdateOption(cmd *cobra.Command, globalOptions types.GlobalCommandOption
 			MemorySwapInBytes:  memSwap64,
 			PidsLimit:          pidsLimit,
 			BlkioWeight:        blkioWeight,
+			MemorySwappiness:   memSwappiness,
+			OomScoreAdj:        oomScoreAdj,
+			CPURealtimePeriod:  cpuRtPeriod,
+			CPURealtimeRuntime: cpuRtRuntime,
+			DeviceCgroupRules:  deviceCgroupRules,
+			DeviceAdd:          deviceAdd,
+			DeviceRemove:       deviceRemove,
+
+			BlkioDeviceReadBps:   throttleDevices[0],
+			BlkioDeviceWriteBps:  throttleDevices[1],
+			BlkioDeviceReadIOps:  throttleDevices[2],
+			BlkioDeviceWriteIOps: throttleDevices[3],
 		}
 	}
 	return options, nil
@@ -260,6 +355,12 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 	if err != nil {
 		return err
 	}
+	containerLabels, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	oldHostConfigLabel, hostConfigLabelChanged := containerLabels[labels.HostConfigLabel], false
+	var taskOpts nerdctlcontainer.TaskUpdateOptions
 	if runtime.GOOS == "linux" {
 		if spec.Linux == nil {
 			spec.Linux = &runtimespec.Linux{}
@@ -337,6 +438,86 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 				spec.Linux.Resources.Pids.Limit = opts.PidsLimit
 			}
 		}
+		if cmd.Flags().Changed("memory-swappiness") {
+			if spec.Linux.Resources.Memory == nil {
+				spec.Linux.Resources.Memory = &runtimespec.LinuxMemory{}
+			}
+			if opts.MemorySwappiness < 0 {
+				spec.Linux.Resources.Memory.Swappiness = nil
+			} else {
+				swappiness := uint64(opts.MemorySwappiness)
+				spec.Linux.Resources.Memory.Swappiness = &swappiness
+			}
+		}
+		if cmd.Flags().Changed("cpu-rt-period") || cmd.Flags().Changed("cpu-rt-runtime") {
+			if spec.Linux.Resources.CPU == nil {
+				spec.Linux.Resources.CPU = &runtimespec.LinuxCPU{}
+			}
+		}
+		if cmd.Flags().Changed("cpu-rt-period") {
+			spec.Linux.Resources.CPU.RealtimePeriod = &opts.CPURealtimePeriod
+		}
+		if cmd.Flags().Changed("cpu-rt-runtime") {
+			rtRuntime := int64(opts.CPURealtimeRuntime)
+			spec.Linux.Resources.CPU.RealtimeRuntime = &rtRuntime
+		}
+		if cmd.Flags().Changed("oom-score-adj") {
+			score, err := nerdctlcontainer.ValidateOOMScoreAdj(opts.OomScoreAdj)
+			if err != nil {
+				return err
+			}
+			if spec.Process == nil {
+				spec.Process = &runtimespec.Process{}
+			}
+			spec.Process.OOMScoreAdj = &score
+			taskOpts.OOMScoreAdj = true
+		}
+		if len(opts.BlkioDeviceReadBps) > 0 || len(opts.BlkioDeviceWriteBps) > 0 || len(opts.BlkioDeviceReadIOps) > 0 || len(opts.BlkioDeviceWriteIOps) > 0 {
+			if spec.Linux.Resources.BlockIO == nil {
+				spec.Linux.Resources.BlockIO = &runtimespec.LinuxBlockIO{}
+			}
+			blockIO := spec.Linux.Resources.BlockIO
+			for _, t := range []struct {
+				devices []string
+				iops    bool
+				list    *[]runtimespec.LinuxThrottleDevice
+			}{
+				{opts.BlkioDeviceReadBps, false, &blockIO.ThrottleReadBpsDevice},
+				{opts.BlkioDeviceWriteBps, false, &blockIO.ThrottleWriteBpsDevice},
+				{opts.BlkioDeviceReadIOps, true, &blockIO.ThrottleReadIOPSDevice},
+				{opts.BlkioDeviceWriteIOps, true, &blockIO.ThrottleWriteIOPSDevice},
+			} {
+				devices, err := nerdctlcontainer.ParseThrottleDevices(t.devices, t.iops)
+				if err != nil {
+					return err
+				}
+				*t.list = nerdctlcontainer.MergeThrottleDevices(*t.list, devices)
+			}
+			taskOpts.BlockIO = true
+		}
+		if cmd.Flags().Changed("device-cgroup-rule") || len(opts.DeviceAdd) > 0 || len(opts.DeviceRemove) > 0 {
+			var hostConfigLabel dockercompat.HostConfigLabel
+			if oldHostConfigLabel != "" {
+				if err := json.Unmarshal([]byte(oldHostConfigLabel), &hostConfigLabel); err != nil {
+					return err
+				}
+			}
+			if err := nerdctlcontainer.UpdateSpecDevices(ctx, spec, &hostConfigLabel, nerdctlcontainer.DeviceUpdate{
+				CgroupRules:        opts.DeviceCgroupRules,
+				CgroupRulesChanged: cmd.Flags().Changed("device-cgroup-rule"),
+				Add:                opts.DeviceAdd,
+				Remove:             opts.DeviceRemove,
+			}); err != nil {
+				return err
+			}
+			hostConfigJSON, err := json.Marshal(hostConfigLabel)
+			if err != nil {
+				return err
+			}
+			containerLabels[labels.HostConfigLabel] = string(hostConfigJSON)
+			hostConfigLabelChanged = true
+			taskOpts.Devices = true
+		}
 	}
 
 	if err := updateContainerSpec(ctx, container, spec); err != nil {
@@ -353,6 +534,22 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 		}
 	}()
 
+	if hostConfigLabelChanged {
+		if err := updateHostConfigLabel(ctx, container, containerLabels[labels.HostConfigLabel]); err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				deferCtx, deferCancel := context.WithTimeout(ctx, 1*time.Minute)
+				defer deferCancel()
+				// Reset the label on error.
+				if err := updateHostConfigLabel(deferCtx, container, oldHostConfigLabel); err != nil {
+					log.G(ctx).WithError(err).Errorf("Failed to reset the labels of container %q", id)
+				}
+			}
+		}()
+	}
+
 	restart, err := cmd.Flags().GetString("restart")
 	if err != nil {
 		return err
@@ -376,7 +573,16 @@ func updateContainer(ctx context.Context, client *containerd.Client, id string,
 		}
 		return fmt.Errorf("failed to get task:%w", err)
 	}
-	return task.Update(ctx, containerd.WithResources(spec.Linux.Resources))
+	if err := task.Update(ctx, containerd.WithResources(spec.Linux.Resources)); err != nil {
+		return err
+	}
+	// The OCI runtime does not update the devices, the block IO throttling, nor the OOM score adjustment
+	return nerdctlcontainer.UpdateTaskResources(ctx, task, oldSpec, spec, taskOpts)
+}
+
+func updateHostConfigLabel(ctx context.Context, container containerd.Container, hostConfig string) error {
+	_, err := container.SetLabels(ctx, map[string]string{labels.HostConfigLabel: hostConfig})
+	return err
 }
 
 func updateContainerSpec(ctx context.Context, container containerd.Container, spec *runtimespec.Spec) error {
@@ -19,6 +19,8 @@ package container
 import (
 	"testing"
 
+	"gotest.tools/v3/assert"
+
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 )
 
@@ -31,3 +33,30 @@ func TestUpdateContainer(t *testing.T) {
 	base.Cmd("update", "--memory", "999999999", "--restart", "123", testContainerName).AssertFail()
 	base.Cmd("inspect", "--mode=native", testContainerName).AssertOutNotContains(`"limit": 999999999,`)
 }
+
+func TestUpdateContainerRuntimeKnobs(t *testing.T) {
+	testutil.DockerIncompatible(t)
+	testContainerName := testutil.Identifier(t)
+	base := testutil.NewBase(t)
+	base.Cmd("run", "-d", "--name", testContainerName, "--device-cgroup-rule", "c 1:3 mr", testutil.CommonImage, "sleep", "infinity").AssertOK()
+	defer base.Cmd("rm", "-f", testContainerName).Run()
+	base.Cmd("update", "--oom-score-adj", "500", "--device-cgroup-rule", "c 1:5 rwm", "--device-add", "/dev/kmsg:/dev/foo", testContainerName).AssertOK()
+
+	inspect := base.InspectContainer(testContainerName)
+	assert.Equal(t, inspect.HostConfig.OomScoreAdj, 500)
+	assert.DeepEqual(t, inspect.HostConfig.DeviceCgroupRules, []string{"c 1:5 rwm"})
+	assert.Equal(t, len(inspect.HostConfig.Devices), 1)
+	assert.Equal(t, inspect.HostConfig.Devices[0].PathInContainer, "/dev/foo")
+	base.Cmd("exec", testContainerName, "cat", "/proc/1/oom_score_adj").AssertOutExactly("500\n")
+	// the device is allowed in place (/dev/kmsg is not one of the devices always allowed)
+	base.Cmd("exec", testContainerName, "sh", "-c", "echo "+testContainerName+" >/dev/foo").AssertOK()
+
+	// the changes are kept on restart
+	base.Cmd("restart", testContainerName).AssertOK()
+	base.Cmd("exec", testContainerName, "cat", "/proc/1/oom_score_adj").AssertOutExactly("500\n")
+	base.Cmd("exec", testContainerName, "test", "-c", "/dev/foo").AssertOK()
+	base.Cmd("exec", testContainerName, "sh", "-c", "echo "+testContainerName+" >/dev/foo").AssertOK()
+
+	base.Cmd("update", "--device-rm", "/dev/foo", testContainerName).AssertOK()
+	assert.Equal(t, len(base.InspectContainer(testContainerName).HostConfig.Devices), 0)
+}
@@ -17,6 +17,8 @@
 package container
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -32,12 +34,22 @@ func WaitCommand() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:               "wait [flags] CONTAINER [CONTAINER, ...]",
 		Args:              cobra.MinimumNArgs(1),
-		Short:             "Block until one or more containers stop, then print their exit codes.",
+		Short:             "Block until one or more containers satisfy a condition (default: stop), then print their exit codes.",
 		RunE:              waitAction,
 		ValidArgsFunction: waitShellComplete,
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 	}
+	cmd.Flags().String("condition", container.WaitConditionNotRunning, fmt.Sprintf("Condition to wait for (%q|%q|%q|%q)",
+		container.WaitConditionNotRunning, container.WaitConditionNextExit, container.WaitConditionRemoved, container.WaitConditionHealthy))
+	cmd.RegisterFlagCompletionFunc("condition", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{container.WaitConditionNotRunning, container.WaitConditionNextExit, container.WaitConditionRemoved, container.WaitConditionHealthy}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().Duration("timeout", 0, "Maximum duration to wait for (e.g., 30s). Zero means no timeout")
+	cmd.Flags().String("format", "", "Format the result of each container using the given Go template, e.g, '{{json .}}'")
+	cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	return cmd
 }
 
@@ -46,9 +58,24 @@ func waitOptions(cmd *cobra.Command) (types.ContainerWaitOptions, error) {
 	if err != nil {
 		return types.ContainerWaitOptions{}, err
 	}
+	condition, err := cmd.Flags().GetString("condition")
+	if err != nil {
+		return types.ContainerWaitOptions{}, err
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return types.ContainerWaitOptions{}, err
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return types.ContainerWaitOptions{}, err
+	}
 	return types.ContainerWaitOptions{
-		Stdout:   cmd.OutOrStdout(),
-		GOptions: globalOptions,
+		Stdout:    cmd.OutOrStdout(),
+		GOptions:  globalOptions,
+		Condition: condition,
+		Timeout:   timeout,
+		Format:    format,
 	}, nil
 }
 
@@ -68,6 +95,10 @@ func waitAction(cmd *cobra.Command, args []string) error {
 }
 
 func waitShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if condition, _ := cmd.Flags().GetString("condition"); condition != container.WaitConditionNotRunning {
+		// the other conditions are not limited to the running containers
+		return completion.ContainerNames(cmd, nil)
+	}
 	// show running container names
 	statusFilterFn := func(st containerd.ProcessStatus) bool {
 		return st == containerd.Running
@@ -17,11 +17,18 @@
 package container
 
 import (
+	"errors"
 	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
 
 	"github.com/containerd/nerdctl/mod/tigron/expect"
+	"github.com/containerd/nerdctl/mod/tigron/require"
 	"github.com/containerd/nerdctl/mod/tigron/test"
+	"github.com/containerd/nerdctl/mod/tigron/tig"
 
+	"github.com/containerd/nerdctl/v2/pkg/cmd/container"
 	"github.com/containerd/nerdctl/v2/pkg/testutil"
 	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
 )
@@ -50,3 +57,113 @@ func TestWait(t *testing.T) {
 
 	testCase.Run(t)
 }
+
+func TestWaitCondition(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "not-running returns immediately for a stopped container",
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Command("run", "--name", data.Identifier(), testutil.CommonImage, "sh", "-c", "exit 42").
+					Run(&test.Expected{ExitCode: 42})
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("wait", "--condition=not-running", data.Identifier())
+			},
+			Expected: test.Expects(0, nil, expect.Equals("42\n")),
+		},
+		{
+			Description: "next-exit waits for the container to be started and to exit",
+			Require:     require.Not(nerdtest.Docker),
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("create", "--name", data.Identifier(), testutil.CommonImage, "sh", "-c", "sleep 3; exit 7")
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				cmd := helpers.Command("wait", "--condition=next-exit", "--format={{json .}}", data.Identifier())
+				cmd.Background()
+				time.Sleep(time.Second)
+				helpers.Ensure("start", data.Identifier())
+				return cmd
+			},
+			Expected: func(data test.Data, helpers test.Helpers) *test.Expected {
+				return test.Expects(0, nil, expect.JSON(&container.WaitResult{}, func(res *container.WaitResult, t tig.T) {
+					assert.Equal(t, res.Container, data.Identifier())
+					assert.Equal(t, res.StatusCode, 7)
+					assert.Assert(t, res.Error == nil)
+				}))(data, helpers)
+			},
+		},
+		{
+			Description: "removed",
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("run", "-d", "--name", data.Identifier(), testutil.CommonImage, "sh", "-c", "sleep 3; exit 5")
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				cmd := helpers.Command("wait", "--condition=removed", data.Identifier())
+				cmd.Background()
+				helpers.Ensure("wait", data.Identifier())
+				helpers.Ensure("rm", data.Identifier())
+				return cmd
+			},
+			Expected: test.Expects(0, nil, expect.Equals("5\n")),
+		},
+		{
+			Description: "timeout",
+			Require:     require.Not(nerdtest.Docker),
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("run", "-d", "--name", data.Identifier(), testutil.CommonImage, "sleep", nerdtest.Infinity)
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("wait", "--timeout=1s", "--format=json", data.Identifier())
+			},
+			Expected: test.Expects(expect.ExitCodeGenericFail, []error{errors.New("timed out")}, expect.Contains(`"StatusCode":-1`, `"Error":{"Message":"timed out after 1s`)),
+		},
+		{
+			Description: "healthy",
+			Require:     require.Not(nerdtest.Docker),
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("run", "-d", "--name", data.Identifier(), "--health-cmd", "true", testutil.CommonImage, "sleep", nerdtest.Infinity)
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				cmd := helpers.Command("wait", "--condition=healthy", "--timeout=30s", data.Identifier())
+				cmd.Background()
+				time.Sleep(time.Second)
+				helpers.Ensure("container", "healthcheck", data.Identifier())
+				return cmd
+			},
+			Expected: test.Expects(0, nil, expect.Equals("0\n")),
+		},
+		{
+			Description: "healthy fails without a health check",
+			Require:     require.Not(nerdtest.Docker),
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("run", "-d", "--name", data.Identifier(), testutil.CommonImage, "sleep", nerdtest.Infinity)
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("rm", "-f", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("wait", "--condition=healthy", data.Identifier())
+			},
+			Expected: test.Expects(expect.ExitCodeGenericFail, []error{errors.New("no health check configured")}, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
@@ -286,6 +286,12 @@ func AddPersistentBoolFlag(cmd *cobra.Command, name string, aliases, nonPersiste
 
 // HiddenPersistentStringArrayFlag creates a persistent string slice flag and hides it.
 // Used mainly to pass global config values to individual commands.
+func HiddenPersistentStringFlag(cmd *cobra.Command, name string, value string, usage string) {
+	cmd.PersistentFlags().String(name, value, usage)
+	cmd.PersistentFlags().MarkHidden(name)
+}
+
+// HiddenPersistentStringArrayFlag creates a persistent string slice flag and hides it.
 func HiddenPersistentStringArrayFlag(cmd *cobra.Command, name string, value []string, usage string) {
 	cmd.PersistentFlags().StringSlice(name, value, usage)
 	cmd.PersistentFlags().MarkHidden(name)
@@ -125,6 +125,10 @@ func ProcessRootCmdFlags(cmd *cobra.Command) (types.GlobalCommandOptions, error)
 	if err != nil {
 		return types.GlobalCommandOptions{}, err
 	}
+	registryMirrors, err := cmd.Flags().GetStringSlice("registry-mirror")
+	if err != nil {
+		return types.GlobalCommandOptions{}, err
+	}
 	experimental, err := cmd.Flags().GetBool("experimental")
 	if err != nil {
 		return types.GlobalCommandOptions{}, err
@@ -157,6 +161,14 @@ func ProcessRootCmdFlags(cmd *cobra.Command) (types.GlobalCommandOptions, error)
 	if err != nil {
 		return types.GlobalCommandOptions{}, err
 	}
+	usernsRemap, err := cmd.Flags().GetString("userns-remap")
+	if err != nil {
+		return types.GlobalCommandOptions{}, err
+	}
+	imagePolicy, err := cmd.Flags().GetString("global-image-policy")
+	if err != nil {
+		return types.GlobalCommandOptions{}, err
+	}
 
 	// Point to dataRoot for filesystem-helpers implementing rollback / backups.
 	err = pkg.InitFS(dataRoot)
@@ -181,9 +193,12 @@ func ProcessRootCmdFlags(cmd *cobra.Command) (types.GlobalCommandOptions, error)
 		BridgeIP:         bridgeIP,
 		KubeHideDupe:     kubeHideDupe,
 		CDISpecDirs:      cdiSpecDirs,
+		UsernsRemap:      usernsRemap,
 		DNS:              dns,
 		DNSOpts:          dnsOpts,
 		DNSSearch:        dnsSearch,
+		ImagePolicy:      imagePolicy,
+		RegistryMirrors:  registryMirrors,
 	}, nil
 }
 
@@ -48,6 +48,11 @@ func Command() *cobra.Command {
 		encryptCommand(),
 		decryptCommand(),
 		pruneCommand(),
+		attachCommand(),
+		diffCommand(),
+		usageCommand(),
+		squashCommand(),
+		rebaseCommand(),
 	)
 	return cmd
 }
@@ -0,0 +1,89 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/completion"
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/image"
+)
+
+func attachCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:               "attach [flags] FILE IMAGE",
+		Short:             "Attach a file (e.g., an SBOM) to an image in a registry, as an OCI referrer",
+		Args:              helpers.IsExactArgs(2),
+		RunE:              attachAction,
+		ValidArgsFunction: attachShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+	cmd.Flags().String("artifact-type", "", "Artifact type of the attached file, e.g., \"application/spdx+json\"")
+	cmd.MarkFlagRequired("artifact-type")
+	cmd.Flags().String("media-type", "application/octet-stream", "Media type of the attached file")
+	cmd.Flags().StringArray("annotation", nil, "Add an annotation to the referrer manifest (KEY=VALUE)")
+	return cmd
+}
+
+func attachAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+	artifactType, err := cmd.Flags().GetString("artifact-type")
+	if err != nil {
+		return err
+	}
+	mediaType, err := cmd.Flags().GetString("media-type")
+	if err != nil {
+		return err
+	}
+	annotations, err := cmd.Flags().GetStringArray("annotation")
+	if err != nil {
+		return err
+	}
+	options := types.ImageAttachOptions{
+		Stdout:       cmd.OutOrStdout(),
+		GOptions:     globalOptions,
+		ArtifactType: artifactType,
+		MediaType:    mediaType,
+		Annotations:  annotations,
+	}
+
+	client, ctx, cancel, err := clientutil.NewClient(cmd.Context(), options.GOptions.Namespace, options.GOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return image.Attach(ctx, client, args[0], args[1], options)
+}
+
+func attachShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if len(args) == 1 {
+		// show image names
+		return completion.ImageNames(cmd)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
@@ -56,6 +56,8 @@ func inspectCommand() *cobra.Command {
 	cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
 	// #endregion
 
+	cmd.Flags().Bool("referrers", false, "Show the OCI referrers (e.g., SBOMs, signatures) of the images, from the content store and the registry")
+
 	return cmd
 }
 
@@ -93,6 +95,11 @@ func imageInspectAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	// not in InspectOptions, as `nerdctl inspect` does not have the flag
+	options.Referrers, err = cmd.Flags().GetBool("referrers")
+	if err != nil {
+		return err
+	}
 
 	// Verify we have a valid mode
 	if options.Mode != "native" && options.Mode != "dockercompat" {
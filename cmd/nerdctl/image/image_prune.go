@@ -17,7 +17,9 @@
 package image
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -40,6 +42,9 @@ func pruneCommand() *cobra.Command {
 	cmd.Flags().BoolP("all", "a", false, "Remove all unused images, not just dangling ones")
 	cmd.Flags().StringSlice("filter", []string{}, "Filter output based on conditions provided")
 	cmd.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	cmd.Flags().String("policy", "", "Remove the images selected by the retention policy file, instead of the dangling images")
+	cmd.Flags().Bool("dry-run", false, "Print the images, snapshots and blobs which would be removed, without removing them")
+	cmd.Flags().Duration("interval", 0, "Prune periodically at the interval until interrupted, e.g., \"1h\" (requires --force)")
 	return cmd
 }
 
@@ -65,6 +70,14 @@ func pruneOptions(cmd *cobra.Command) (types.ImagePruneOptions, error) {
 	if err != nil {
 		return types.ImagePruneOptions{}, err
 	}
+	policy, err := cmd.Flags().GetString("policy")
+	if err != nil {
+		return types.ImagePruneOptions{}, err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return types.ImagePruneOptions{}, err
+	}
 
 	return types.ImagePruneOptions{
 		Stdout:   cmd.OutOrStdout(),
@@ -72,6 +85,8 @@ func pruneOptions(cmd *cobra.Command) (types.ImagePruneOptions, error) {
 		All:      all,
 		Filters:  filters,
 		Force:    force,
+		Policy:   policy,
+		DryRun:   dryRun,
 	}, err
 }
 
@@ -81,11 +96,25 @@ func imagePruneAction(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if !options.Force {
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	if interval < 0 {
+		return fmt.Errorf("invalid interval %s", interval)
+	}
+	if interval > 0 && !options.Force && !options.DryRun {
+		return errors.New("--interval requires --force")
+	}
+
+	if !options.Force && !options.DryRun {
 		var msg string
-		if !options.All {
+		switch {
+		case options.Policy != "":
+			msg = fmt.Sprintf("This will remove all images selected by the policy %q, except the images with at least one container associated to them.", options.Policy)
+		case !options.All:
 			msg = "This will remove all dangling images."
-		} else {
+		default:
 			msg = "This will remove all images without at least one container associated to them."
 		}
 
@@ -100,5 +129,19 @@ func imagePruneAction(cmd *cobra.Command, _ []string) error {
 	}
 	defer cancel()
 
-	return image.Prune(ctx, client, options)
+	if interval == 0 {
+		return image.Prune(ctx, client, options)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := image.Prune(ctx, client, options); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
@@ -17,6 +17,8 @@
 package image
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 
 	"github.com/containerd/nerdctl/v2/cmd/nerdctl/completion"
@@ -65,6 +67,14 @@ func PullCommand() *cobra.Command {
 	cmd.Flags().String("soci-index-digest", "", "Specify a particular index digest for SOCI. If left empty, SOCI will automatically use the index determined by the selection policy.")
 	// #endregion
 
+	cmd.Flags().Bool("with-referrers", false, "Pull the OCI referrers (e.g., SBOMs, signatures) of the image too")
+
+	// #region download flags
+	cmd.Flags().Int("max-retries", 3, "Maximum number of retries of a failed layer download, resuming from the bytes already downloaded")
+	cmd.Flags().Duration("layer-timeout", 0, "Time limit of each attempt to download a layer (0 for no limit)")
+	cmd.Flags().Int("max-concurrent-downloads", 0, "Maximum number of layers downloaded in parallel (0 for no limit)")
+	// #endregion
+
 	cmd.Flags().BoolP("quiet", "q", false, "Suppress verbose output")
 
 	cmd.Flags().String("ipfs-address", "", "multiaddr of IPFS API (default uses $IPFS_PATH env variable if defined or local directory ~/.ipfs)")
@@ -113,6 +123,25 @@ func processPullCommandFlags(cmd *cobra.Command) (types.ImagePullOptions, error)
 	if err != nil {
 		return types.ImagePullOptions{}, err
 	}
+	withReferrers, err := cmd.Flags().GetBool("with-referrers")
+	if err != nil {
+		return types.ImagePullOptions{}, err
+	}
+	maxRetries, err := cmd.Flags().GetInt("max-retries")
+	if err != nil {
+		return types.ImagePullOptions{}, err
+	}
+	layerTimeout, err := cmd.Flags().GetDuration("layer-timeout")
+	if err != nil {
+		return types.ImagePullOptions{}, err
+	}
+	maxConcurrentDownloads, err := cmd.Flags().GetInt("max-concurrent-downloads")
+	if err != nil {
+		return types.ImagePullOptions{}, err
+	}
+	if maxRetries < 0 || layerTimeout < 0 || maxConcurrentDownloads < 0 {
+		return types.ImagePullOptions{}, errors.New("--max-retries, --layer-timeout and --max-concurrent-downloads must not be negative")
+	}
 
 	verifyOptions, err := helpers.VerifyOptions(cmd)
 	if err != nil {
@@ -129,6 +158,10 @@ func processPullCommandFlags(cmd *cobra.Command) (types.ImagePullOptions, error)
 		RFlags: types.RemoteSnapshotterFlags{
 			SociIndexDigest: sociIndexDigest,
 		},
+		WithReferrers:          withReferrers,
+		MaxRetries:             maxRetries,
+		LayerTimeout:           layerTimeout,
+		MaxConcurrentDownloads: maxConcurrentDownloads,
 		Stdout:                 cmd.OutOrStdout(),
 		Stderr:                 cmd.OutOrStderr(),
 		ProgressOutputToStdout: true,
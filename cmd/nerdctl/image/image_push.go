@@ -17,6 +17,8 @@
 package image
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 
 	"github.com/containerd/nerdctl/v2/cmd/nerdctl/completion"
@@ -69,6 +71,10 @@ func PushCommand() *cobra.Command {
 
 	cmd.Flags().Bool(allowNonDistFlag, false, "Allow pushing images with non-distributable blobs")
 
+	cmd.Flags().Bool("with-referrers", false, "Push the OCI referrers (e.g., SBOMs, signatures) of the image in the content store too")
+
+	cmd.Flags().Int("max-retries", 3, "Maximum number of retries of a failed layer upload, resuming from the last chunk accepted by the registry")
+
 	return cmd
 }
 
@@ -105,6 +111,17 @@ func pushOptions(cmd *cobra.Command) (types.ImagePushOptions, error) {
 	if err != nil {
 		return types.ImagePushOptions{}, err
 	}
+	withReferrers, err := cmd.Flags().GetBool("with-referrers")
+	if err != nil {
+		return types.ImagePushOptions{}, err
+	}
+	maxRetries, err := cmd.Flags().GetInt("max-retries")
+	if err != nil {
+		return types.ImagePushOptions{}, err
+	}
+	if maxRetries < 0 {
+		return types.ImagePushOptions{}, errors.New("--max-retries must not be negative")
+	}
 	signOptions, err := signOptions(cmd)
 	if err != nil {
 		return types.ImagePushOptions{}, err
@@ -124,6 +141,8 @@ func pushOptions(cmd *cobra.Command) (types.ImagePushOptions, error) {
 		IpfsAddress:                    ipfsAddress,
 		Quiet:                          quiet,
 		AllowNondistributableArtifacts: allowNonDist,
+		WithReferrers:                  withReferrers,
+		MaxRetries:                     maxRetries,
 		Stdout:                         cmd.OutOrStdout(),
 	}, nil
 }
@@ -0,0 +1,107 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/completion"
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/image"
+)
+
+func rebaseCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:               "rebase [flags] --old-base OLD_BASE --new-base NEW_BASE -t TARGET_IMAGE IMAGE",
+		Short:             "Replace the base layers of an image with the layers of another base image",
+		Args:              helpers.IsExactArgs(1),
+		RunE:              rebaseAction,
+		ValidArgsFunction: rebaseShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+	cmd.Flags().String("old-base", "", "Base image of the image")
+	cmd.Flags().String("new-base", "", "Base image to rebase the image onto")
+	cmd.MarkFlagRequired("old-base")
+	cmd.MarkFlagRequired("new-base")
+	cmd.RegisterFlagCompletionFunc("old-base", rebaseFlagComplete)
+	cmd.RegisterFlagCompletionFunc("new-base", rebaseFlagComplete)
+	cmd.Flags().StringP("tag", "t", "", "Name of the rebased image")
+	cmd.MarkFlagRequired("tag")
+	cmd.Flags().StringSlice("platform", []string{}, "Rebase the image for specific platforms, e.g., \"linux/amd64,linux/arm64\"")
+	cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
+	cmd.Flags().Bool("all-platforms", false, "Rebase the image for all the platforms")
+	return cmd
+}
+
+func rebaseAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+	oldBase, err := cmd.Flags().GetString("old-base")
+	if err != nil {
+		return err
+	}
+	newBase, err := cmd.Flags().GetString("new-base")
+	if err != nil {
+		return err
+	}
+	target, err := cmd.Flags().GetString("tag")
+	if err != nil {
+		return err
+	}
+	platform, err := cmd.Flags().GetStringSlice("platform")
+	if err != nil {
+		return err
+	}
+	allPlatforms, err := cmd.Flags().GetBool("all-platforms")
+	if err != nil {
+		return err
+	}
+	options := types.ImageRebaseOptions{
+		Stdout:       cmd.OutOrStdout(),
+		GOptions:     globalOptions,
+		OldBase:      oldBase,
+		NewBase:      newBase,
+		Target:       target,
+		Platforms:    platform,
+		AllPlatforms: allPlatforms,
+	}
+
+	client, ctx, cancel, err := clientutil.NewClient(cmd.Context(), options.GOptions.Namespace, options.GOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return image.Rebase(ctx, client, args[0], options)
+}
+
+func rebaseShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		// show image names
+		return completion.ImageNames(cmd)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func rebaseFlagComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completion.ImageNames(cmd)
+}
@@ -41,7 +41,16 @@ func SaveCommand() *cobra.Command {
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 	}
-	cmd.Flags().StringP("output", "o", "", "Write to a file, instead of STDOUT")
+	cmd.Flags().StringP("output", "o", "", "Write to a file, instead of STDOUT. With --format=oci-layout, the directory of the OCI image layout")
+	cmd.Flags().String("format", image.FormatDockerArchive, "Format of the output (docker-archive, oci-layout)")
+	cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{image.FormatDockerArchive, image.FormatOCILayout}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().String("compress", "", "Compress the archive with zstd or gzip, optionally with a level (e.g., zstd, gzip:9, zstd:19)")
+	cmd.RegisterFlagCompletionFunc("compress", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"zstd", "gzip"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().Bool("skip-existing", false, "Skip the blobs already present in the OCI image layout (requires --format=oci-layout)")
 
 	// #region platform flags
 	// platform is defined as StringSlice, not StringArray, to allow specifying "--platform=amd64,arm64"
@@ -67,11 +76,26 @@ func saveOptions(cmd *cobra.Command) (types.ImageSaveOptions, error) {
 	if err != nil {
 		return types.ImageSaveOptions{}, err
 	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return types.ImageSaveOptions{}, err
+	}
+	compress, err := cmd.Flags().GetString("compress")
+	if err != nil {
+		return types.ImageSaveOptions{}, err
+	}
+	skipExisting, err := cmd.Flags().GetBool("skip-existing")
+	if err != nil {
+		return types.ImageSaveOptions{}, err
+	}
 
 	return types.ImageSaveOptions{
 		GOptions:     globalOptions,
 		AllPlatforms: allPlatforms,
 		Platform:     platform,
+		Format:       format,
+		Compress:     compress,
+		SkipExisting: skipExisting,
 	}, err
 }
 
@@ -85,8 +109,12 @@ func saveAction(cmd *cobra.Command, args []string) error {
 	outputPath, err := cmd.Flags().GetString("output")
 	if err != nil {
 		return err
+	} else if options.Format == image.FormatOCILayout {
+		// the layout directory is written by image.Save, and is kept on failure so that it can be resumed with --skip-existing
+		options.Output = outputPath
+		outputPath = ""
 	} else if outputPath != "" {
-		f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY, 0644)
+		f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 		if err != nil {
 			return err
 		}
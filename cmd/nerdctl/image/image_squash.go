@@ -0,0 +1,99 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/completion"
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/image"
+)
+
+func squashCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:               "squash [flags] SOURCE_IMAGE [TARGET_IMAGE]",
+		Short:             "Squash the layers of an image into one layer",
+		Args:              cobra.RangeArgs(1, 2),
+		RunE:              squashAction,
+		ValidArgsFunction: squashShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+	cmd.Flags().String("layers", "", "Range of the layers to squash from the bottom layer, like a Go slice, e.g., \"-3:\" for the top three layers (default: all the layers)")
+	cmd.Flags().String("platform", "", "Squash the image for a specific platform, e.g., \"linux/arm64\"")
+	cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
+	cmd.Flags().String("compression", "gzip", "Compression of the squashed layer (zstd or gzip)")
+	cmd.RegisterFlagCompletionFunc("compression", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"gzip", "zstd"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().StringP("message", "m", "", "Comment of the history entry of the squashed layer")
+	return cmd
+}
+
+func squashAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+	layers, err := cmd.Flags().GetString("layers")
+	if err != nil {
+		return err
+	}
+	platform, err := cmd.Flags().GetString("platform")
+	if err != nil {
+		return err
+	}
+	compression, err := cmd.Flags().GetString("compression")
+	if err != nil {
+		return err
+	}
+	message, err := cmd.Flags().GetString("message")
+	if err != nil {
+		return err
+	}
+	options := types.ImageSquashOptions{
+		Stdout:      cmd.OutOrStdout(),
+		GOptions:    globalOptions,
+		Platform:    platform,
+		Layers:      layers,
+		Compression: compression,
+		Message:     message,
+	}
+
+	client, ctx, cancel, err := clientutil.NewClient(cmd.Context(), options.GOptions.Namespace, options.GOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	target := ""
+	if len(args) > 1 {
+		target = args[1]
+	}
+	return image.Squash(ctx, client, args[0], target, options)
+}
+
+func squashShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		// show image names
+		return completion.ImageNames(cmd)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/pkg/containerutil"
+)
+
+func addInternalCpCommand(cmd *cobra.Command) {
+	cmd.AddCommand(newInternalCpCommand())
+}
+
+func newInternalCpCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:           "cp (archive|extract) OPTIONS",
+		Short:         "Archive or extract the files of a container in its user namespace",
+		Args:          cobra.ExactArgs(2),
+		RunE:          internalCpAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	return cmd
+}
+
+func internalCpAction(cmd *cobra.Command, args []string) error {
+	if err := containerutil.RunCopyHelper(cmd.InOrStdin(), cmd.OutOrStdout(), args[0], args[1]); err != nil {
+		// print the plain error, which is reported by `nerdctl cp`
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		os.Exit(1)
+	}
+	return nil
+}
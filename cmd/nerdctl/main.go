@@ -181,6 +181,7 @@ func initRootCmdFlags(rootCmd *cobra.Command, tomlPath string) (*pflag.FlagSet,
 	rootCmd.PersistentFlags().Bool("insecure-registry", cfg.InsecureRegistry, "skips verifying HTTPS certs, and allows falling back to plain HTTP")
 	// hosts-dir is defined as StringSlice, not StringArray, to allow specifying "--hosts-dir=/etc/containerd/certs.d,/etc/docker/certs.d"
 	rootCmd.PersistentFlags().StringSlice("hosts-dir", cfg.HostsDir, "A directory that contains <HOST:PORT>/hosts.toml (containerd style) or <HOST:PORT>/{ca.cert, cert.pem, key.pem} (docker style)")
+	rootCmd.PersistentFlags().StringSlice("registry-mirror", cfg.RegistryMirrors, `A registry mirror to pull from before the hosts of --hosts-dir, in the form of "[REGISTRY=]URL" (REGISTRY defaults to "docker.io")`)
 	// Experimental enable experimental feature, see in https://github.com/containerd/nerdctl/blob/main/docs/experimental.md
 	helpers.AddPersistentBoolFlag(rootCmd, "experimental", nil, nil, cfg.Experimental, "NERDCTL_EXPERIMENTAL", "Control experimental: https://github.com/containerd/nerdctl/blob/main/docs/experimental.md")
 	helpers.AddPersistentStringFlag(rootCmd, "host-gateway-ip", nil, nil, nil, aliasToBeInherited, cfg.HostGatewayIP, "NERDCTL_HOST_GATEWAY_IP", "IP address that the special 'host-gateway' string in --add-host resolves to. Defaults to the IP address of the host. It has no effect without setting --add-host")
@@ -191,6 +192,7 @@ func initRootCmdFlags(rootCmd *cobra.Command, tomlPath string) (*pflag.FlagSet,
 	helpers.HiddenPersistentStringArrayFlag(rootCmd, "global-dns", cfg.DNS, "Global DNS servers for containers")
 	helpers.HiddenPersistentStringArrayFlag(rootCmd, "global-dns-opts", cfg.DNSOpts, "Global DNS options for containers")
 	helpers.HiddenPersistentStringArrayFlag(rootCmd, "global-dns-search", cfg.DNSSearch, "Global DNS search domains for containers")
+	helpers.HiddenPersistentStringFlag(rootCmd, "global-image-policy", cfg.ImagePolicy, "Path of the image trust policy file")
 	return aliasToBeInherited, nil
 }
 
@@ -346,6 +348,7 @@ Config file ($NERDCTL_TOML): %s
 		ipfs.NewIPFSCommand(),
 	)
 	addApparmorCommand(rootCmd)
+	addSeccompCommand(rootCmd)
 	container.AddCpCommand(rootCmd)
 
 	// add aliasToBeInherited to subCommand(s) InheritedFlags
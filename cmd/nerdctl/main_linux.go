@@ -21,6 +21,7 @@ import (
 	"golang.org/x/sys/unix"
 
 	"github.com/containerd/nerdctl/v2/cmd/nerdctl/apparmor"
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/seccomp"
 	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
 	"github.com/containerd/nerdctl/v2/pkg/strutil"
 )
@@ -42,7 +43,8 @@ func appNeedsRootlessParentMain(cmd *cobra.Command, args []string) bool {
 	// completion, login, logout, version: false, because it shouldn't require the daemon to be running
 	// apparmor: false, because it requires the initial mount namespace to access /sys/kernel/security
 	// cp, compose cp: false, because it requires the initial mount namespace to inspect file owners
-	case "", "completion", "login", "logout", "apparmor", "cp", "version":
+	// seccomp: false, because it shouldn't require the daemon to be running
+	case "", "completion", "login", "logout", "apparmor", "cp", "seccomp", "version":
 		return false
 	case "container":
 		if len(commands) < 3 {
@@ -68,6 +70,10 @@ func addApparmorCommand(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(apparmor.Command())
 }
 
+func addSeccompCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(seccomp.Command())
+}
+
 // resetSavedSETUID drops the saved UID of a setuid-root process to the original real UID.
 // This ensures the process cannot regain root privileges later.
 // It only performs the operation if the process is currently running with effective UID 0 (root)
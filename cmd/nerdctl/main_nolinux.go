@@ -30,6 +30,10 @@ func addApparmorCommand(rootCmd *cobra.Command) {
 	// NOP
 }
 
+func addSeccompCommand(rootCmd *cobra.Command) {
+	// NOP
+}
+
 func resetSavedSETUID() error {
 	// NOP
 	return nil
@@ -0,0 +1,45 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/seccomp"
+	"github.com/containerd/nerdctl/v2/pkg/defaults"
+)
+
+func diffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "diff PROFILE1 PROFILE2",
+		Short:         fmt.Sprintf("Display the differences between two seccomp profiles. Specify %q for the default profile.", defaults.SeccompProfileName),
+		Args:          cobra.ExactArgs(2),
+		RunE:          diffAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	return cmd
+}
+
+func diffAction(cmd *cobra.Command, args []string) error {
+	return seccomp.Diff(cmd.Context(), args[0], args[1], types.SeccompDiffOptions{
+		Stdout: cmd.OutOrStdout(),
+	})
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/seccomp"
+)
+
+func dumpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "dump",
+		Short:         "Display the default seccomp profile",
+		Args:          cobra.NoArgs,
+		RunE:          dumpAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().StringSlice("cap-add", []string{}, "Display the profile for a container with these capabilities added, e.g., CAP_SYS_ADMIN")
+	return cmd
+}
+
+func dumpAction(cmd *cobra.Command, args []string) error {
+	capAdd, err := cmd.Flags().GetStringSlice("cap-add")
+	if err != nil {
+		return err
+	}
+	return seccomp.Dump(cmd.Context(), types.SeccompDumpOptions{
+		Stdout: cmd.OutOrStdout(),
+		CapAdd: capAdd,
+	})
+}
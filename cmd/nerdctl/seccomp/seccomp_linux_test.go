@@ -0,0 +1,177 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/mod/tigron/expect"
+	"github.com/containerd/nerdctl/mod/tigron/require"
+	"github.com/containerd/nerdctl/mod/tigron/test"
+	"github.com/containerd/nerdctl/mod/tigron/tig"
+
+	"github.com/containerd/nerdctl/v2/pkg/testutil"
+	"github.com/containerd/nerdctl/v2/pkg/testutil/nerdtest"
+)
+
+func TestMain(m *testing.M) {
+	testutil.M(m)
+}
+
+const (
+	// overlayProfile denies chmod, which is allowed by the default profile
+	overlayProfile = `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["chmod"], "action": "SCMP_ACT_ERRNO"}]}`
+	invalidProfile = `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["no_such_syscall"], "action": "SCMP_ACT_ALLOW"}]}`
+)
+
+// allowed returns whether the profile allows the syscall unconditionally.
+func allowed(p specs.LinuxSeccomp, name string) bool {
+	return slices.ContainsFunc(p.Syscalls, func(rule specs.LinuxSyscall) bool {
+		return rule.Action == specs.ActAllow && len(rule.Args) == 0 && slices.Contains(rule.Names, name)
+	})
+}
+
+func TestSeccompDump(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.Require = require.Not(nerdtest.Docker)
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "default profile",
+			Command:     test.Command("seccomp", "dump"),
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.JSON(specs.LinuxSeccomp{}, func(p specs.LinuxSeccomp, t tig.T) {
+				assert.Equal(t, p.DefaultAction, specs.ActErrno)
+				assert.Assert(t, allowed(p, "chmod"))
+				assert.Assert(t, !allowed(p, "mount"))
+			})),
+		},
+		{
+			Description: "with CAP_SYS_ADMIN",
+			Command:     test.Command("seccomp", "dump", "--cap-add", "CAP_SYS_ADMIN"),
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.JSON(specs.LinuxSeccomp{}, func(p specs.LinuxSeccomp, t tig.T) {
+				assert.Assert(t, allowed(p, "mount"))
+			})),
+		},
+	}
+
+	testCase.Run(t)
+}
+
+func TestSeccompMerge(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.Require = require.Not(nerdtest.Docker)
+
+	testCase.Setup = func(data test.Data, helpers test.Helpers) {
+		data.Temp().Save(overlayProfile, "overlay.json")
+		data.Temp().Save(invalidProfile, "invalid.json")
+	}
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "the rules of the overlay replace the default ones",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("seccomp", "merge", "builtin", data.Temp().Path("overlay.json"))
+			},
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.JSON(specs.LinuxSeccomp{}, func(p specs.LinuxSeccomp, t tig.T) {
+				assert.Assert(t, !allowed(p, "chmod"))
+				assert.Assert(t, allowed(p, "fchmod"))
+			})),
+		},
+		{
+			Description: "the merged profile is validated",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("seccomp", "merge", "builtin", data.Temp().Path("invalid.json"))
+			},
+			Expected: test.Expects(expect.ExitCodeGenericFail, []error{errors.New("no_such_syscall")}, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
+
+func TestSeccompValidate(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.Require = require.Not(nerdtest.Docker)
+
+	testCase.Setup = func(data test.Data, helpers test.Helpers) {
+		data.Temp().Save(overlayProfile, "overlay.json")
+		data.Temp().Save(invalidProfile, "invalid.json")
+	}
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "valid profiles",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("seccomp", "validate", "builtin", data.Temp().Path("overlay.json"))
+			},
+			Expected: func(data test.Data, helpers test.Helpers) *test.Expected {
+				return &test.Expected{
+					ExitCode: expect.ExitCodeSuccess,
+					Output:   expect.Equals("builtin\n" + data.Temp().Path("overlay.json") + "\n"),
+				}
+			},
+		},
+		{
+			Description: "invalid syscall name",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("seccomp", "validate", data.Temp().Path("overlay.json"), data.Temp().Path("invalid.json"))
+			},
+			Expected: test.Expects(expect.ExitCodeGenericFail, []error{errors.New("no_such_syscall")}, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
+
+func TestSeccompDiff(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.Require = require.Not(nerdtest.Docker)
+
+	testCase.Setup = func(data test.Data, helpers test.Helpers) {
+		data.Temp().Save(overlayProfile, "overlay.json")
+	}
+
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "same profiles",
+			Command:     test.Command("seccomp", "diff", "builtin", "builtin"),
+			Expected:    test.Expects(expect.ExitCodeSuccess, nil, expect.Equals("")),
+		},
+		{
+			Description: "different profiles",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("seccomp", "diff", "builtin", data.Temp().Path("overlay.json"))
+			},
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.Contains(
+				"- syscall chmod: SCMP_ACT_ALLOW\n",
+				"+ syscall chmod: SCMP_ACT_ERRNO\n",
+				"- syscall fchmod: SCMP_ACT_ALLOW\n",
+			)),
+		},
+	}
+
+	testCase.Run(t)
+}
@@ -0,0 +1,46 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/seccomp"
+	"github.com/containerd/nerdctl/v2/pkg/defaults"
+)
+
+func mergeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "merge BASE OVERLAY [OVERLAY...]",
+		Short: fmt.Sprintf("Display the profile BASE merged with the OVERLAY profiles. "+
+			"The rules of an overlay replace the rules of the syscalls they name. Specify %q for the default profile.", defaults.SeccompProfileName),
+		Args:          cobra.MinimumNArgs(2),
+		RunE:          mergeAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	return cmd
+}
+
+func mergeAction(cmd *cobra.Command, args []string) error {
+	return seccomp.Merge(cmd.Context(), args[0], args[1:], types.SeccompMergeOptions{
+		Stdout: cmd.OutOrStdout(),
+	})
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/seccomp"
+)
+
+func validateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "validate [flags] PROFILE [PROFILE...]",
+		Short:         "Validate the architectures, the actions, the operators and the syscall names of seccomp profiles",
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          validateAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().StringSlice("arch", []string{}, "Validate the syscall names against these architectures (e.g., SCMP_ARCH_AARCH64) instead of those of the profiles")
+	return cmd
+}
+
+func validateAction(cmd *cobra.Command, args []string) error {
+	arches, err := cmd.Flags().GetStringSlice("arch")
+	if err != nil {
+		return err
+	}
+	return seccomp.Validate(cmd.Context(), args, types.SeccompValidateOptions{
+		Stdout: cmd.OutOrStdout(),
+		Arches: arches,
+	})
+}
@@ -36,6 +36,8 @@ func Command() *cobra.Command {
 		EventsCommand(),
 		InfoCommand(),
 		pruneCommand(),
+		binfmtCommand(),
+		metricsCommand(),
 	)
 	return cmd
 }
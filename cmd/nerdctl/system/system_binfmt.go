@@ -0,0 +1,126 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/binfmt"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/system"
+)
+
+func binfmtCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "binfmt",
+		Short:         "Manage the emulation of foreign platforms with binfmt_misc",
+		RunE:          helpers.UnknownSubcommandAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.AddCommand(
+		binfmtListCommand(),
+		binfmtInstallCommand(),
+		binfmtUninstallCommand(),
+	)
+	return cmd
+}
+
+func binfmtListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List the entries of binfmt_misc",
+		Args:          cobra.NoArgs,
+		RunE:          binfmtListAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().BoolP("quiet", "q", false, "Only display entry names")
+	cmd.Flags().String("format", "", "Format the output using the given go template")
+	cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "table", "wide"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func binfmtListAction(cmd *cobra.Command, args []string) error {
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		return err
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	return system.BinfmtList(types.SystemBinfmtListOptions{
+		Stdout: cmd.OutOrStdout(),
+		Quiet:  quiet,
+		Format: format,
+	})
+}
+
+func binfmtInstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "install [flags] [PLATFORM...]",
+		Short:             "Register the static QEMU binaries installed on the host to emulate foreign platforms",
+		Long:              "Register the static QEMU binaries (qemu-<ARCH>-static) found in PATH to emulate foreign platforms, e.g., \"arm64\" or \"linux/riscv64\" (default: \"all\").",
+		RunE:              binfmtInstallAction,
+		ValidArgsFunction: binfmtPlatformComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+	return cmd
+}
+
+func binfmtInstallAction(cmd *cobra.Command, args []string) error {
+	return system.BinfmtInstall(types.SystemBinfmtInstallOptions{
+		Stdout:    cmd.OutOrStdout(),
+		Platforms: args,
+	})
+}
+
+func binfmtUninstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "uninstall [flags] NAME|PLATFORM [NAME|PLATFORM...]",
+		Short:             "Unregister entries of binfmt_misc, by name or by emulated platform (\"all\" for all the foreign platforms)",
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              binfmtUninstallAction,
+		ValidArgsFunction: binfmtPlatformComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+	return cmd
+}
+
+func binfmtUninstallAction(cmd *cobra.Command, args []string) error {
+	return system.BinfmtUninstall(types.SystemBinfmtUninstallOptions{
+		Stdout: cmd.OutOrStdout(),
+		Names:  args,
+	})
+}
+
+func binfmtPlatformComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	candidates := []string{"all"}
+	for _, a := range binfmt.Archs {
+		if !a.Native() {
+			candidates = append(candidates, "linux/"+a.OCI)
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/containerd/nerdctl/v2/cmd/nerdctl/helpers"
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/system"
+)
+
+const (
+	defaultMetricsAddr = "localhost:9323"
+	// defaultMetricsInterval is the default scrape interval of Prometheus
+	defaultMetricsInterval = 15 * time.Second
+)
+
+func metricsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "metrics",
+		Short:         "Manage the metrics of containers",
+		RunE:          helpers.UnknownSubcommandAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.AddCommand(metricsServeCommand())
+	return cmd
+}
+
+func metricsServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the metrics of containers in the Prometheus text format",
+		Long: `Serve the metrics of containers in the Prometheus text format at /metrics.
+
+The metrics include CPU, memory, network, block I/O, PIDs, OOM kills, restarts, health status,
+and the pressure stall information (PSI) of cgroup v2.
+The statistics are collected in the background, in the same way as 'nerdctl stats'.
+
+In rootless mode, the server listens in the network namespace of RootlessKit: use a "unix://" address
+to expose the metrics to the host.`,
+		Args:          cobra.NoArgs,
+		RunE:          metricsServeAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().String("addr", defaultMetricsAddr, "Address to listen on (host:port, or unix:///path/to/socket)")
+	cmd.Flags().StringSlice("namespaces", nil, "Namespaces of the containers (default: the namespace of --namespace)")
+	cmd.Flags().BoolP("all-namespaces", "A", false, "Serve the metrics of the containers of all the namespaces")
+	cmd.Flags().Duration("interval", defaultMetricsInterval, "Interval of the samples of the statistics")
+	return cmd
+}
+
+func metricsServeAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+	addr, err := cmd.Flags().GetString("addr")
+	if err != nil {
+		return err
+	}
+	nsList, err := cmd.Flags().GetStringSlice("namespaces")
+	if err != nil {
+		return err
+	}
+	allNamespaces, err := cmd.Flags().GetBool("all-namespaces")
+	if err != nil {
+		return err
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+
+	client, ctx, cancel, err := clientutil.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return system.MetricsServe(ctx, client, types.SystemMetricsServeOptions{
+		GOptions:      globalOptions,
+		Addr:          addr,
+		Namespaces:    nsList,
+		AllNamespaces: allNamespaces,
+		Interval:      interval,
+	})
+}
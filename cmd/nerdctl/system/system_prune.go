@@ -44,6 +44,7 @@ func pruneCommand() *cobra.Command {
 	cmd.Flags().BoolP("all", "a", false, "Remove all unused images, not just dangling ones")
 	cmd.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
 	cmd.Flags().Bool("volumes", false, "Prune volumes")
+	cmd.Flags().Bool("dry-run", false, "Print the containers, networks, volumes, images, snapshots and blobs which would be removed, without removing them")
 	return cmd
 }
 
@@ -63,6 +64,11 @@ func pruneOptions(cmd *cobra.Command) (types.SystemPruneOptions, error) {
 		return types.SystemPruneOptions{}, err
 	}
 
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return types.SystemPruneOptions{}, err
+	}
+
 	buildkitHost, err := builder.GetBuildkitHost(cmd, globalOptions.Namespace)
 	if err != nil {
 		log.L.WithError(err).Warn("BuildKit is not running. Build caches will not be pruned.")
@@ -77,6 +83,7 @@ func pruneOptions(cmd *cobra.Command) (types.SystemPruneOptions, error) {
 		Volumes:              vFlag,
 		BuildKitHost:         buildkitHost,
 		NetworkDriversToKeep: network.NetworkDriversToKeep,
+		DryRun:               dryRun,
 	}, nil
 }
 
@@ -86,7 +93,7 @@ func grantSystemPrunePermission(cmd *cobra.Command, options types.SystemPruneOpt
 		return false, err
 	}
 
-	if !force {
+	if !force && !options.DryRun {
 		var confirm string
 		msg := `This will remove:
   - all stopped containers
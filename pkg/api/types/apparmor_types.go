@@ -30,4 +30,6 @@ type ApparmorListOptions struct {
 // ApparmorInspectOptions specifies options for `nerdctl apparmor inspect`
 type ApparmorInspectOptions struct {
 	Stdout io.Writer
+	// GOptions is the global options, used to look up the container
+	GOptions GlobalCommandOptions
 }
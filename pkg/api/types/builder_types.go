@@ -39,7 +39,7 @@ type BuilderBuildOptions struct {
 	NoCache bool
 	// Output is the output destination
 	Output string
-	// Progress Set type of progress output (auto, plain, tty). Use plain to show container output
+	// Progress Set type of progress output (auto, plain, tty, rawjson). Use plain to show container output
 	Progress string
 	// Secret file to expose to the build: id=mysecret,src=/local/secret
 	Secret []string
@@ -73,6 +73,30 @@ type BuilderBuildOptions struct {
 	Pull *bool
 	// ExtraHosts is a set of custom host-to-IP mappings.
 	ExtraHosts []string
+	// Squash squashes all the layers of the built image into one layer
+	Squash bool
+}
+
+// BuilderBakeOptions specifies options for `nerdctl builder bake`.
+type BuilderBakeOptions struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// GOptions is the global options
+	GOptions GlobalCommandOptions
+	// BuildKitHost is the buildkit host
+	BuildKitHost string
+	// Files are the bake files and the compose files. The default files of the current directory are used when empty.
+	Files []string
+	// Targets are the targets and the groups to build. The default group is built when empty.
+	Targets []string
+	// Print prints the resolved targets in JSON instead of building them
+	Print bool
+	// Progress Set type of progress output (auto, plain, tty, rawjson). Use plain to show container output
+	Progress string
+	// NoCache disables cache for all the targets
+	NoCache bool
+	// Pull overrides the pull option of all the targets when non-nil
+	Pull *bool
 }
 
 // BuilderPruneOptions specifies options for `nerdctl builder prune`.
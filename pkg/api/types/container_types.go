@@ -152,6 +152,8 @@ type ContainerCreateOptions struct {
 	CgroupParent string
 	// Device specifies add a host device to the container
 	Device []string
+	// DeviceCgroupRules specifies the rules to add to the cgroup allowed devices list, e.g., "c 1:3 mr"
+	DeviceCgroupRules []string
 	// CDIDevices specifies the CDI devices to add to the container
 	CDIDevices []string
 	// #endregion
@@ -396,6 +398,8 @@ type ContainerCommitOptions struct {
 	Compression CompressionType
 	// Format specifies the image format for the committed image (docker or oci)
 	Format ImageFormat
+	// Squash squashes all the layers of the committed image into one layer
+	Squash bool
 	// Embed EstargzOptions for eStargz conversion options
 	EstargzOptions
 	// Embed ZstdChunkedOptions for zstd:chunked conversion options
@@ -451,6 +455,12 @@ type ContainerWaitOptions struct {
 	Stdout io.Writer
 	// GOptions is the global options.
 	GOptions GlobalCommandOptions
+	// Condition is the condition to wait for: "not-running" (default), "next-exit", "removed" or "healthy"
+	Condition string
+	// Timeout is the maximum duration to wait for. Zero means no timeout.
+	Timeout time.Duration
+	// Format the result of each container using the given Go template, e.g., "json"
+	Format string
 }
 
 // ContainerAttachOptions specifies options for `nerdctl (container) attach`.
@@ -516,6 +526,14 @@ type ContainerCpOptions struct {
 	SrcPath string
 	// Follow symbolic links in SRC_PATH
 	FollowSymLink bool
+	// Archive preserves the uid/gid of the files, mapped through the user namespace of the container
+	Archive bool
+	// Chown sets the owner of the copied files, in the form of USER[:GROUP]
+	Chown string
+	// Stdin is the tar archive extracted into the container when SrcPath is "-"
+	Stdin io.Reader
+	// Stdout receives the tar archive of SrcPath when DestPath is "-"
+	Stdout io.Writer
 }
 
 // ContainerStatsOptions specifies options for `nerdctl stats`.
@@ -532,4 +550,6 @@ type ContainerStatsOptions struct {
 	NoStream bool
 	// Do not truncate output.
 	NoTrunc bool
+	// Interval of the statistics (500ms if zero).
+	Interval time.Duration
 }
@@ -18,6 +18,7 @@ package types
 
 import (
 	"io"
+	"time"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -43,6 +44,60 @@ type ImageListOptions struct {
 	Names bool
 	// All (unimplemented yet, always true)
 	All bool
+	// Tree shows the platforms of each image as a tree, with the shared and unique sizes
+	Tree bool
+}
+
+// ImageDiffOptions specifies options for `nerdctl image diff`.
+type ImageDiffOptions struct {
+	Stdout   io.Writer
+	GOptions GlobalCommandOptions
+	// Platform of the compared images, e.g., "linux/arm64" (the default platform if empty)
+	Platform string
+	// Files compares the filesystems of the images, from the layers in the content store
+	Files bool
+	// Format the output, "json" or "" for the text output
+	Format string
+}
+
+// ImageSquashOptions specifies options for `nerdctl image squash`.
+type ImageSquashOptions struct {
+	Stdout   io.Writer
+	GOptions GlobalCommandOptions
+	// Platform of the squashed image, e.g., "linux/arm64" (the default platform if empty)
+	Platform string
+	// Layers is the range of the squashed layers, like a Go slice expression, e.g., "-3:" for the top three layers
+	Layers string
+	// Compression is the compression of the squashed layer, "gzip" or "zstd"
+	Compression string
+	// Message is the comment of the history entry of the squashed layer
+	Message string
+}
+
+// ImageRebaseOptions specifies options for `nerdctl image rebase`.
+type ImageRebaseOptions struct {
+	Stdout   io.Writer
+	GOptions GlobalCommandOptions
+	// OldBase is the base image of the image to rebase
+	OldBase string
+	// NewBase is the base image to rebase the image onto
+	NewBase string
+	// Target is the name of the rebased image
+	Target string
+	// Platforms of the rebased image, e.g., "linux/arm64" (the default platform if empty)
+	Platforms []string
+	// AllPlatforms rebases the image for all the platforms
+	AllPlatforms bool
+}
+
+// ImageUsageOptions specifies options for `nerdctl image usage`.
+type ImageUsageOptions struct {
+	Stdout   io.Writer
+	GOptions GlobalCommandOptions
+	// Format the output using the given Go template, e.g, '{{json .}}'
+	Format string
+	// NoTrunc don't truncate output
+	NoTrunc bool
 }
 
 // ImageConvertOptions specifies options for `nerdctl image convert`.
@@ -182,6 +237,8 @@ type ImageInspectOptions struct {
 	Format string
 	// Platform inspect content for a specific platform
 	Platform string
+	// Referrers shows the OCI referrers (e.g., SBOMs, signatures) of the images, from the content store and the registry
+	Referrers bool
 }
 
 // ImagePushOptions specifies options for `nerdctl (image) push`.
@@ -205,6 +262,10 @@ type ImagePushOptions struct {
 	Quiet bool
 	// AllowNondistributableArtifacts allow pushing non-distributable artifacts
 	AllowNondistributableArtifacts bool
+	// WithReferrers pushes the OCI referrers (e.g., SBOMs, signatures) of the image in the content store too
+	WithReferrers bool
+	// MaxRetries is how many times a failed layer upload is retried, resuming from the last chunk accepted by the registry
+	MaxRetries int
 }
 
 // RemoteSnapshotterFlags are used for pulling with remote snapshotters
@@ -235,6 +296,26 @@ type ImagePullOptions struct {
 	IPFSAddress string
 	// Flags to pass into remote snapshotters
 	RFlags RemoteSnapshotterFlags
+	// WithReferrers fetches the OCI referrers (e.g., SBOMs, signatures) of the image too
+	WithReferrers bool
+	// MaxRetries is how many times a failed layer download is retried, resuming from the bytes already downloaded
+	MaxRetries int
+	// LayerTimeout is the time limit of each attempt to download a layer (0 for no limit)
+	LayerTimeout time.Duration
+	// MaxConcurrentDownloads is the maximum number of layers downloaded in parallel (0 for no limit)
+	MaxConcurrentDownloads int
+}
+
+// ImageAttachOptions specifies options for `nerdctl image attach`.
+type ImageAttachOptions struct {
+	Stdout   io.Writer
+	GOptions GlobalCommandOptions
+	// ArtifactType is the artifact type of the attached manifest, e.g., "application/spdx+json"
+	ArtifactType string
+	// MediaType is the media type of the attached file
+	MediaType string
+	// Annotations are the annotations of the attached manifest, in the form of "KEY=VALUE"
+	Annotations []string
 }
 
 // ImageTagOptions specifies options for `nerdctl (image) tag`.
@@ -269,6 +350,10 @@ type ImagePruneOptions struct {
 	Filters []string
 	// Force will not prompt for confirmation.
 	Force bool
+	// Policy is the path of the retention policy file which selects the images to remove, instead of All
+	Policy string
+	// DryRun prints the images, snapshots and blobs which would be removed, without removing them
+	DryRun bool
 }
 
 // ImageSaveOptions specifies options for `nerdctl (image) save`.
@@ -279,6 +364,14 @@ type ImageSaveOptions struct {
 	AllPlatforms bool
 	// Export content for a specific platform
 	Platform []string
+	// Format of the output, "docker-archive" (default) or "oci-layout"
+	Format string
+	// Output is the directory of the OCI image layout, when Format is "oci-layout"
+	Output string
+	// Compress the archive, e.g., "zstd", "gzip" or "zstd:19"
+	Compress string
+	// SkipExisting skips the blobs already present in the OCI image layout
+	SkipExisting bool
 }
 
 // ImageSignOptions contains options for signing an image. It contains options from
@@ -0,0 +1,43 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import "io"
+
+// SeccompDumpOptions specifies options for `nerdctl seccomp dump`.
+type SeccompDumpOptions struct {
+	Stdout io.Writer
+	// CapAdd is the capabilities added to the default capabilities, which enable the syscalls they require
+	CapAdd []string
+}
+
+// SeccompMergeOptions specifies options for `nerdctl seccomp merge`.
+type SeccompMergeOptions struct {
+	Stdout io.Writer
+}
+
+// SeccompValidateOptions specifies options for `nerdctl seccomp validate`.
+type SeccompValidateOptions struct {
+	Stdout io.Writer
+	// Arches is the architectures to validate the syscall names against, instead of those of the profiles
+	Arches []string
+}
+
+// SeccompDiffOptions specifies options for `nerdctl seccomp diff`.
+type SeccompDiffOptions struct {
+	Stdout io.Writer
+}
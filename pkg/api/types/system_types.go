@@ -16,7 +16,10 @@
 
 package types
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 // SystemInfoOptions specifies options for `nerdctl (system) info`.
 type SystemInfoOptions struct {
@@ -55,4 +58,43 @@ type SystemPruneOptions struct {
 	BuildKitHost string
 	// NetworkDriversToKeep the network drivers which need to keep
 	NetworkDriversToKeep []string
+	// DryRun prints what would be removed, without removing it
+	DryRun bool
+}
+
+// SystemBinfmtListOptions specifies options for `nerdctl system binfmt ls`.
+type SystemBinfmtListOptions struct {
+	Stdout io.Writer
+	// Only display entry names
+	Quiet bool
+	// Format the output using the given go template
+	Format string
+}
+
+// SystemBinfmtInstallOptions specifies options for `nerdctl system binfmt install`.
+type SystemBinfmtInstallOptions struct {
+	Stdout io.Writer
+	// Platforms to emulate, e.g., "arm64" or "linux/arm64" (all the platforms with a QEMU binary if empty or "all")
+	Platforms []string
+}
+
+// SystemBinfmtUninstallOptions specifies options for `nerdctl system binfmt uninstall`.
+type SystemBinfmtUninstallOptions struct {
+	Stdout io.Writer
+	// Names of the entries, or platforms whose entries are unregistered ("all" for all the foreign platforms)
+	Names []string
+}
+
+// SystemMetricsServeOptions specifies options for `nerdctl system metrics serve`.
+type SystemMetricsServeOptions struct {
+	// GOptions is the global options
+	GOptions GlobalCommandOptions
+	// Addr is the address to listen on, e.g., "localhost:9323"
+	Addr string
+	// Namespaces whose containers are served (the namespace of GOptions if empty)
+	Namespaces []string
+	// AllNamespaces serves the containers of all the namespaces
+	AllNamespaces bool
+	// Interval of the samples of the statistics (500ms if zero)
+	Interval time.Duration
 }
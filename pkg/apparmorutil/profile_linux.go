@@ -0,0 +1,358 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package apparmorutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/containerd/containerd/v2/pkg/cap"
+	"github.com/containerd/log"
+)
+
+// ProfileFileName is the name of the file storing the profile generated for a container, in its state directory.
+const ProfileFileName = "apparmor-profile"
+
+// Policy is a declarative AppArmor policy, from which a profile is generated for a container.
+// The rules that are not specified are the same as the default profile.
+type Policy struct {
+	// Files are the allowed file paths with their modes. All the files are allowed when empty.
+	Files []FileRule `json:"files,omitempty"`
+	// Network are the allowed network families, optionally followed by a type or a protocol
+	// (e.g., "inet", "inet6 stream", "unix"). All the networks are allowed when empty.
+	Network []string `json:"network,omitempty"`
+	// Capabilities are the allowed capabilities (e.g., "chown", "CAP_NET_BIND_SERVICE").
+	// All the capabilities are allowed when empty.
+	Capabilities []string `json:"capabilities,omitempty"`
+	// DenyMount denies mount(2). Defaults to true. When false, mount(2) is allowed (still requires CAP_SYS_ADMIN).
+	DenyMount *bool `json:"denyMount,omitempty"`
+}
+
+// FileRule allows access to the files matching Path (an AppArmor glob, e.g., "/data/**") with Mode (e.g., "rw", "rix").
+type FileRule struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+}
+
+var (
+	fileModeRegexp = regexp.MustCompile(`^[rwalkm]*([iupcUPC]?x)?$`)
+
+	networkFamilies = []string{
+		"unix", "inet", "ax25", "ipx", "appletalk", "netrom", "bridge", "atmpvc", "x25", "inet6", "rose", "netbeui",
+		"security", "key", "netlink", "packet", "ash", "econet", "atmsvc", "rds", "sna", "irda", "pppox", "wanpipe",
+		"llc", "ib", "mpls", "can", "tipc", "bluetooth", "iucv", "rxrpc", "isdn", "phonet", "ieee802154", "caif",
+		"alg", "nfc", "vsock", "kcm", "qipcrtr", "smc", "xdp", "mctp",
+	}
+	networkTypesAndProtocols = []string{"stream", "dgram", "seqpacket", "rdm", "raw", "packet", "tcp", "udp", "icmp"}
+)
+
+// LoadPolicy reads and validates the JSON policy file at path.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var p Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse the AppArmor policy %q: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid AppArmor policy %q: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Validate validates the policy, so that its rules cannot inject arbitrary rules in the generated profile.
+func (p *Policy) Validate() error {
+	for _, f := range p.Files {
+		if !validFilePath(f.Path) {
+			return fmt.Errorf("invalid file path %q (must be an absolute path or start with a variable, without whitespaces, quotes and unbalanced braces)", f.Path)
+		}
+		if f.Mode == "" || !fileModeRegexp.MatchString(f.Mode) {
+			return fmt.Errorf("invalid mode %q of file path %q", f.Mode, f.Path)
+		}
+	}
+	for _, n := range p.Network {
+		fields := strings.Fields(n)
+		if len(fields) == 0 || !slices.Contains(networkFamilies, fields[0]) {
+			return fmt.Errorf("invalid network family %q", n)
+		}
+		for _, f := range fields[1:] {
+			if !slices.Contains(networkTypesAndProtocols, f) {
+				return fmt.Errorf("invalid network type or protocol %q of %q", f, n)
+			}
+		}
+	}
+	known := cap.Known()
+	for _, c := range p.Capabilities {
+		if !slices.Contains(known, "CAP_"+strings.TrimPrefix(strings.ToUpper(c), "CAP_")) {
+			return fmt.Errorf("unknown capability %q", c)
+		}
+	}
+	return nil
+}
+
+// validFilePath returns whether path is an absolute path or starts with a variable (e.g., "@{PROC}"),
+// without whitespaces, quotes, comments, unbalanced braces, and commas out of alternations (e.g., "{a,b}"),
+// so that it cannot terminate the rule.
+func validFilePath(path string) bool {
+	if !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "@{") {
+		return false
+	}
+	depth := 0
+	for _, r := range path {
+		switch {
+		case unicode.IsSpace(r), r == '"', r == '#', r == '\x00':
+			return false
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		case r == ',' && depth == 0:
+			return false
+		}
+	}
+	return depth == 0
+}
+
+// NOTE: profileTemplate is derived from the default profile of containerd/contrib/apparmor
+// (<github.com/docker/docker/profiles/apparmor>), with the network, capability and file rules,
+// and the mount denial, generated from a Policy.
+const profileTemplate = `
+{{range $value := .Imports}}
+{{$value}}
+{{end}}
+
+profile {{.Name}} flags=(attach_disconnected,mediate_deleted) {
+{{range $value := .InnerImports}}
+  {{$value}}
+{{end}}
+
+{{- if .Policy.Network}}
+{{range $value := .Policy.Network}}
+  network {{$value}},
+{{- end}}
+{{- else}}
+  network,
+{{- end}}
+{{- if .Policy.Capabilities}}
+{{range $value := .Capabilities}}
+  capability {{$value}},
+{{- end}}
+{{- else}}
+  capability,
+{{- end}}
+{{- if .Policy.Files}}
+{{range $value := .Policy.Files}}
+  {{$value.Path}} {{$value.Mode}},
+{{- end}}
+{{- else}}
+  file,
+{{- end}}
+  umount,
+  # Host (privileged) processes may send signals to container processes.
+  signal (receive) peer=unconfined,
+  # runc may send signals to container processes.
+  signal (receive) peer=runc,
+  # crun may send signals to container processes.
+  signal (receive) peer=crun,
+  # Manager may send signals to container processes.
+  signal (receive) peer={{.DaemonProfile}},
+  # Container processes may send signals amongst themselves.
+  signal (send,receive) peer={{.Name}},
+{{if .RootlessKit}}
+  # https://github.com/containerd/nerdctl/issues/2730
+  signal (receive) peer={{.RootlessKit}},
+{{end}}
+
+  deny @{PROC}/* w,   # deny write for all files directly in /proc (not in a subdir)
+  # deny write to files not in /proc/<number>/** or /proc/sys/**
+  deny @{PROC}/{[^1-9],[^1-9][^0-9],[^1-9s][^0-9y][^0-9s],[^1-9][^0-9][^0-9][^0-9]*}/** w,
+  deny @{PROC}/sys/[^k]** w,  # deny /proc/sys except /proc/sys/k* (effectively /proc/sys/kernel)
+  deny @{PROC}/sys/kernel/{?,??,[^s][^h][^m]**} w,  # deny everything except shm* in /proc/sys/kernel/
+  deny @{PROC}/sysrq-trigger rwklx,
+  deny @{PROC}/mem rwklx,
+  deny @{PROC}/kmem rwklx,
+  deny @{PROC}/kcore rwklx,
+{{if .DenyMount}}
+  deny mount,
+{{else}}
+  mount,
+{{end}}
+  deny /sys/[^f]*/** wklx,
+  deny /sys/f[^s]*/** wklx,
+  deny /sys/fs/[^c]*/** wklx,
+  deny /sys/fs/c[^g]*/** wklx,
+  deny /sys/fs/cg[^r]*/** wklx,
+  deny /sys/firmware/** rwklx,
+  deny /sys/devices/virtual/powercap/** rwklx,
+  deny /sys/kernel/security/** rwklx,
+
+  # allow processes within the container to trace each other,
+  # provided all other LSM and yama setting allow it.
+  ptrace (trace,tracedby,read,readby) peer={{.Name}},
+}
+`
+
+// ProfileData is the data of the template of the profiles generated from policies.
+type ProfileData struct {
+	Name          string
+	Imports       []string
+	InnerImports  []string
+	DaemonProfile string
+	RootlessKit   string
+	Policy        Policy
+}
+
+// Capabilities returns the capabilities of the policy in the syntax of AppArmor (e.g., "net_bind_service").
+func (d ProfileData) Capabilities() []string {
+	res := make([]string, len(d.Policy.Capabilities))
+	for i, c := range d.Policy.Capabilities {
+		res[i] = strings.TrimPrefix(strings.ToLower(c), "cap_")
+	}
+	return res
+}
+
+// DenyMount returns whether mount(2) is denied by the policy.
+func (d ProfileData) DenyMount() bool {
+	return d.Policy.DenyMount == nil || *d.Policy.DenyMount
+}
+
+// GenerateProfile renders the profile of data.
+func GenerateProfile(w io.Writer, data ProfileData) error {
+	t, err := template.New("apparmor_profile").Parse(profileTemplate)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+// ContainerProfileName returns the name of the profile generated for the container id.
+func ContainerProfileName(id string) string {
+	return "nerdctl-" + id
+}
+
+// NewProfileData returns the data of the profile name generated from policy, with the imports, the daemon profile
+// and the RootlessKit binary detected on the host, like containerd does for the default profile.
+func NewProfileData(name string, policy Policy) ProfileData {
+	d := ProfileData{
+		Name:   name,
+		Policy: policy,
+	}
+	if macroExists("tunables/global") {
+		d.Imports = append(d.Imports, "#include <tunables/global>")
+	} else {
+		d.Imports = append(d.Imports, "@{PROC}=/proc/")
+	}
+	if macroExists("abstractions/base") {
+		d.InnerImports = append(d.InnerImports, "#include <abstractions/base>")
+	}
+	d.DaemonProfile = "unconfined"
+	if b, err := os.ReadFile("/proc/self/attr/current"); err == nil {
+		// Normally profiles are suffixed by " (enforce)".
+		if p, _, _ := strings.Cut(string(b), " "); strings.TrimSpace(p) != "" {
+			d.DaemonProfile = strings.TrimSpace(p)
+		}
+	}
+	if rootlessKit, err := exec.LookPath("rootlesskit"); err == nil {
+		d.RootlessKit = rootlessKit
+	} else {
+		log.L.WithError(err).Debug("apparmor: failed to determine the RootlessKit binary path")
+	}
+	return d
+}
+
+func macroExists(m string) bool {
+	_, err := os.Stat(filepath.Join("/etc/apparmor.d", m))
+	return err == nil
+}
+
+// LoadProfile loads (or replaces) the profile text with apparmor_parser. Needs root.
+func LoadProfile(text []byte) error {
+	f, err := os.CreateTemp("", "nerdctl-apparmor-profile")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(text); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	out, err := exec.Command("apparmor_parser", "-Kr", f.Name()).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("parser error(%q): %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// EnsureContainerProfile loads the profile generated for the container id, if the state directory of the container
+// has one and the profile is not loaded, e.g., after rebooting the host or reloading the AppArmor profiles.
+func EnsureContainerProfile(stateDir, id string) error {
+	text, err := os.ReadFile(filepath.Join(stateDir, ProfileFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if !CanLoadNewProfile() {
+		return nil
+	}
+	name := ContainerProfileName(id)
+	if profiles, err := Profiles(); err == nil && slices.ContainsFunc(profiles, func(p Profile) bool { return p.Name == name }) {
+		return nil
+	}
+	return LoadProfile(text)
+}
+
+// UnloadContainerProfile unloads the profile generated for the container id, if the state directory
+// of the container has one.
+func UnloadContainerProfile(stateDir, id string) error {
+	if _, err := os.Stat(filepath.Join(stateDir, ProfileFileName)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if !CanLoadNewProfile() {
+		return nil
+	}
+	// The profile is not loaded anymore after rebooting the host
+	if err := Unload(ContainerProfileName(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package apparmorutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGenerateProfile(t *testing.T) {
+	t.Parallel()
+
+	data := ProfileData{
+		Name:          "nerdctl-foo",
+		Imports:       []string{"#include <tunables/global>"},
+		InnerImports:  []string{"#include <abstractions/base>"},
+		DaemonProfile: "unconfined",
+	}
+
+	var defaultProfile strings.Builder
+	assert.NilError(t, GenerateProfile(&defaultProfile, data))
+	for _, rule := range []string{"\n  network,\n", "\n  capability,\n", "\n  file,\n", "\n  deny mount,\n", "profile nerdctl-foo flags="} {
+		assert.Assert(t, strings.Contains(defaultProfile.String(), rule), "missing %q in:\n%s", rule, defaultProfile.String())
+	}
+
+	denyMount := false
+	data.Policy = Policy{
+		Files:        []FileRule{{Path: "/data/**", Mode: "rw"}, {Path: "/usr/bin/*", Mode: "rix"}},
+		Network:      []string{"inet stream", "unix"},
+		Capabilities: []string{"CAP_NET_BIND_SERVICE", "chown"},
+		DenyMount:    &denyMount,
+	}
+	assert.NilError(t, data.Policy.Validate())
+	var profile strings.Builder
+	assert.NilError(t, GenerateProfile(&profile, data))
+	for _, rule := range []string{
+		"\n  network inet stream,\n  network unix,\n",
+		"\n  capability net_bind_service,\n  capability chown,\n",
+		"\n  /data/** rw,\n  /usr/bin/* rix,\n",
+		"\n  signal (send,receive) peer=nerdctl-foo,\n",
+		"\n  mount,\n",
+	} {
+		assert.Assert(t, strings.Contains(profile.String(), rule), "missing %q in:\n%s", rule, profile.String())
+	}
+	for _, rule := range []string{"\n  network,\n", "\n  capability,\n", "\n  file,\n", "deny mount"} {
+		assert.Assert(t, !strings.Contains(profile.String(), rule), "unexpected %q in:\n%s", rule, profile.String())
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		policy Policy
+		err    string
+	}{
+		{
+			policy: Policy{Files: []FileRule{{Path: "@{PROC}/sys/net/**", Mode: "r"}, {Path: "/opt/{a,b}/**", Mode: "r"}}},
+		},
+		{
+			policy: Policy{Files: []FileRule{{Path: "data/**", Mode: "r"}}},
+			err:    `invalid file path "data/**"`,
+		},
+		{
+			policy: Policy{Files: []FileRule{{Path: "/data/** rw, capability sys_admin", Mode: "r"}}},
+			err:    "invalid file path",
+		},
+		{
+			policy: Policy{Files: []FileRule{{Path: "/data/**}", Mode: "r"}}},
+			err:    "invalid file path",
+		},
+		{
+			policy: Policy{Files: []FileRule{{Path: "/data/**", Mode: "rw,"}}},
+			err:    `invalid mode "rw,"`,
+		},
+		{
+			policy: Policy{Network: []string{"inet6", "netlink raw"}},
+		},
+		{
+			policy: Policy{Network: []string{"inet6,"}},
+			err:    `invalid network family "inet6,"`,
+		},
+		{
+			policy: Policy{Network: []string{"inet foo"}},
+			err:    `invalid network type or protocol "foo"`,
+		},
+		{
+			policy: Policy{Capabilities: []string{"cap_sys_admin", "SETUID"}},
+		},
+		{
+			policy: Policy{Capabilities: []string{"sys_foo"}},
+			err:    `unknown capability "sys_foo"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.policy.Validate()
+		if tc.err == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.ErrorContains(t, err, tc.err)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	assert.NilError(t, os.WriteFile(path, []byte(`{"files": [{"path": "/data/**", "mode": "rw"}], "denyMount": false}`), 0o644))
+	policy, err := LoadPolicy(path)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy.Files, []FileRule{{Path: "/data/**", Mode: "rw"}})
+	assert.Assert(t, !ProfileData{Policy: *policy}.DenyMount())
+
+	assert.NilError(t, os.WriteFile(path, []byte(`{"file": []}`), 0o644))
+	_, err = LoadPolicy(path)
+	assert.ErrorContains(t, err, `unknown field "file"`)
+}
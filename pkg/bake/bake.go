@@ -0,0 +1,323 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package bake implements the file formats of `docker buildx bake`:
+// HCL and JSON bake files, and the `build` sections of compose files.
+package bake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/containerd/errdefs"
+
+	"github.com/containerd/nerdctl/v2/pkg/strutil"
+)
+
+// DefaultGroup is the group built when no target is specified.
+const DefaultGroup = "default"
+
+// DefaultFiles are the files looked up in the current directory when no file is specified.
+// The later files override the earlier ones.
+var DefaultFiles = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yml",
+	"docker-compose.yaml",
+	"docker-bake.json",
+	"docker-bake.override.json",
+	"docker-bake.hcl",
+	"docker-bake.override.hcl",
+}
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Config is the content of a set of bake files.
+type Config struct {
+	Groups  map[string]*Group  `json:"group,omitempty"`
+	Targets map[string]*Target `json:"target,omitempty"`
+}
+
+// Group is a set of targets (or groups) built together.
+type Group struct {
+	Targets []string `json:"targets"`
+}
+
+// Target is the definition of a build.
+// The fields correspond to the options of `nerdctl build`.
+type Target struct {
+	Name string `json:"-"`
+
+	Inherits     []string          `json:"inherits,omitempty" hcl:"inherits,optional"`
+	Context      *string           `json:"context,omitempty" hcl:"context,optional"`
+	Dockerfile   *string           `json:"dockerfile,omitempty" hcl:"dockerfile,optional"`
+	Contexts     map[string]string `json:"contexts,omitempty" hcl:"contexts,optional"`
+	Args         map[string]string `json:"args,omitempty" hcl:"args,optional"`
+	Labels       map[string]string `json:"labels,omitempty" hcl:"labels,optional"`
+	Tags         []string          `json:"tags,omitempty" hcl:"tags,optional"`
+	Target       *string           `json:"target,omitempty" hcl:"target,optional"`
+	Platforms    []string          `json:"platforms,omitempty" hcl:"platforms,optional"`
+	CacheFrom    []string          `json:"cache-from,omitempty" hcl:"cache-from,optional"`
+	CacheTo      []string          `json:"cache-to,omitempty" hcl:"cache-to,optional"`
+	Secrets      []string          `json:"secret,omitempty" hcl:"secret,optional"`
+	SSH          []string          `json:"ssh,omitempty" hcl:"ssh,optional"`
+	Attest       []string          `json:"attest,omitempty" hcl:"attest,optional"`
+	Outputs      []string          `json:"output,omitempty" hcl:"output,optional"`
+	Entitlements []string          `json:"entitlements,omitempty" hcl:"entitlements,optional"`
+	Network      *string           `json:"network,omitempty" hcl:"network,optional"`
+	ExtraHosts   map[string]string `json:"extra-hosts,omitempty" hcl:"extra-hosts,optional"`
+	Pull         *bool             `json:"pull,omitempty" hcl:"pull,optional"`
+	NoCache      *bool             `json:"no-cache,omitempty" hcl:"no-cache,optional"`
+}
+
+// merge overrides t with the fields set in o.
+// Maps are merged key by key. Cache sources, secrets, SSH and attestations are
+// accumulated, the other lists are replaced.
+func (t *Target) merge(o *Target) {
+	if o.Context != nil {
+		t.Context = o.Context
+	}
+	if o.Dockerfile != nil {
+		t.Dockerfile = o.Dockerfile
+	}
+	t.Contexts = mergeMap(t.Contexts, o.Contexts)
+	t.Args = mergeMap(t.Args, o.Args)
+	t.Labels = mergeMap(t.Labels, o.Labels)
+	if o.Tags != nil {
+		t.Tags = o.Tags
+	}
+	if o.Target != nil {
+		t.Target = o.Target
+	}
+	if o.Platforms != nil {
+		t.Platforms = o.Platforms
+	}
+	t.CacheFrom = mergeList(t.CacheFrom, o.CacheFrom)
+	if o.CacheTo != nil {
+		t.CacheTo = o.CacheTo
+	}
+	t.Secrets = mergeList(t.Secrets, o.Secrets)
+	t.SSH = mergeList(t.SSH, o.SSH)
+	t.Attest = mergeList(t.Attest, o.Attest)
+	if o.Outputs != nil {
+		t.Outputs = o.Outputs
+	}
+	if o.Entitlements != nil {
+		t.Entitlements = o.Entitlements
+	}
+	if o.Network != nil {
+		t.Network = o.Network
+	}
+	t.ExtraHosts = mergeMap(t.ExtraHosts, o.ExtraHosts)
+	if o.Pull != nil {
+		t.Pull = o.Pull
+	}
+	if o.NoCache != nil {
+		t.NoCache = o.NoCache
+	}
+}
+
+func mergeMap(m, o map[string]string) map[string]string {
+	if len(o) == 0 {
+		return m
+	}
+	res := make(map[string]string, len(m)+len(o))
+	for k, v := range m {
+		res[k] = v
+	}
+	for k, v := range o {
+		res[k] = v
+	}
+	return res
+}
+
+func mergeList(l, o []string) []string {
+	if len(o) == 0 {
+		return l
+	}
+	return strutil.DedupeStrSlice(append(slices.Clone(l), o...))
+}
+
+// merge merges o into c. The targets defined in both are merged, and so are the groups.
+func (c *Config) merge(o *Config) {
+	if c.Groups == nil {
+		c.Groups = make(map[string]*Group)
+	}
+	if c.Targets == nil {
+		c.Targets = make(map[string]*Target)
+	}
+	for name, g := range o.Groups {
+		if existing, ok := c.Groups[name]; ok {
+			existing.Targets = mergeList(existing.Targets, g.Targets)
+		} else {
+			c.Groups[name] = g
+		}
+	}
+	for name, t := range o.Targets {
+		if existing, ok := c.Targets[name]; ok {
+			existing.Inherits = mergeList(existing.Inherits, t.Inherits)
+			existing.merge(t)
+		} else {
+			c.Targets[name] = t
+		}
+	}
+}
+
+// ReadFiles reads the bake files and the compose files.
+// The files are classified by their extension: `.yml` and `.yaml` files are compose files,
+// the other files are HCL or JSON bake files.
+// The variables of the bake files are read from lookupEnv.
+func ReadFiles(ctx context.Context, files []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no bake file was specified")
+	}
+	var composeFiles, bakeFiles []string
+	for _, f := range files {
+		switch strings.ToLower(filepath.Ext(f)) {
+		case ".yml", ".yaml":
+			composeFiles = append(composeFiles, f)
+		default:
+			bakeFiles = append(bakeFiles, f)
+		}
+	}
+
+	c := &Config{}
+	if len(composeFiles) > 0 {
+		cc, err := parseCompose(ctx, composeFiles)
+		if err != nil {
+			return nil, err
+		}
+		c.merge(cc)
+	}
+	if len(bakeFiles) > 0 {
+		hc, err := parseHCLFiles(bakeFiles, lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		c.merge(hc)
+	}
+	return c, nil
+}
+
+// FindDefaultFiles returns the default files existing in dir.
+func FindDefaultFiles(dir string) ([]string, error) {
+	var files []string
+	for _, f := range DefaultFiles {
+		p := filepath.Join(dir, f)
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no bake file or compose file was found in %q: %w", dir, errdefs.ErrNotFound)
+	}
+	return files, nil
+}
+
+// Resolve returns the targets to build for names, with the inheritance resolved.
+// A name can refer to a group or to a target. When names is empty, the default group is resolved.
+func (c *Config) Resolve(names []string) ([]*Target, error) {
+	if len(names) == 0 {
+		names = []string{DefaultGroup}
+	}
+	var targetNames []string
+	for _, name := range names {
+		expanded, err := c.expandGroup(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		targetNames = append(targetNames, expanded...)
+	}
+
+	var targets []*Target
+	for _, name := range strutil.DedupeStrSlice(targetNames) {
+		t, err := c.resolveTarget(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if t.Context == nil {
+			defaultContext := "."
+			t.Context = &defaultContext
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func (c *Config) expandGroup(name string, visiting []string) ([]string, error) {
+	g, ok := c.Groups[name]
+	if !ok {
+		if _, ok := c.Targets[name]; !ok {
+			return nil, fmt.Errorf("failed to find target or group %q: %w", name, errdefs.ErrNotFound)
+		}
+		return []string{name}, nil
+	}
+	if slices.Contains(visiting, name) {
+		return nil, fmt.Errorf("group %q includes itself: %w", name, errdefs.ErrInvalidArgument)
+	}
+	var res []string
+	for _, member := range g.Targets {
+		// a group can contain a target with the same name as the group
+		if member == name {
+			if _, ok := c.Targets[name]; ok {
+				res = append(res, name)
+				continue
+			}
+		}
+		expanded, err := c.expandGroup(member, append(visiting, name))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, expanded...)
+	}
+	return res, nil
+}
+
+func (c *Config) resolveTarget(name string, visiting []string) (*Target, error) {
+	t, ok := c.Targets[name]
+	if !ok {
+		return nil, fmt.Errorf("failed to find target %q: %w", name, errdefs.ErrNotFound)
+	}
+	if slices.Contains(visiting, name) {
+		return nil, fmt.Errorf("target %q inherits from itself: %w", name, errdefs.ErrInvalidArgument)
+	}
+	res := &Target{}
+	for _, parent := range t.Inherits {
+		p, err := c.resolveTarget(parent, append(visiting, name))
+		if err != nil {
+			return nil, err
+		}
+		res.merge(p)
+	}
+	res.merge(t)
+	res.Name = name
+	return res, nil
+}
+
+func validateName(kind, name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q, must match %s: %w", kind, name, nameRegexp, errdefs.ErrInvalidArgument)
+	}
+	return nil
+}
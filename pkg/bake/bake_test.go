@@ -0,0 +1,233 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bake
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	assert.NilError(t, os.WriteFile(p, []byte(content), 0o644))
+	return p
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func targetNames(targets []*Target) []string {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestReadHCL(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	f := writeFile(t, dir, "docker-bake.hcl", `
+variable "REGISTRY" {
+  default = "example.com"
+}
+
+variable "TAG" {
+  default = "${REGISTRY}/app:latest"
+}
+
+variable "PUSH" {
+  default = false
+}
+
+group "default" {
+  targets = ["app", "tools"]
+}
+
+target "_common" {
+  args = {
+    GO_VERSION = "1.24"
+    DEBUG      = "0"
+  }
+  cache-from = ["type=registry,ref=${REGISTRY}/cache"]
+  platforms  = ["linux/amd64"]
+}
+
+target "app" {
+  inherits   = ["_common"]
+  dockerfile = "Dockerfile.app"
+  args = {
+    DEBUG = "1"
+  }
+  tags       = [TAG]
+  cache-from = ["type=local,src=/tmp/cache"]
+  pull       = PUSH
+}
+
+target "tools" {
+  inherits = ["_common"]
+  matrix = {
+    tool = ["lint", "test"]
+  }
+  name   = "tools-${tool}"
+  target = tool
+  tags   = [upper(tool)]
+}
+`)
+
+	c, err := ReadFiles(context.Background(), []string{f}, func(k string) (string, bool) {
+		if k == "REGISTRY" {
+			return "registry.local", true
+		}
+		return "", false
+	})
+	assert.NilError(t, err)
+
+	targets, err := c.Resolve(nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targetNames(targets), []string{"app", "tools-lint", "tools-test"})
+
+	app := targets[0]
+	assert.Equal(t, *app.Context, ".")
+	assert.Equal(t, *app.Dockerfile, "Dockerfile.app")
+	assert.DeepEqual(t, app.Args, map[string]string{"GO_VERSION": "1.24", "DEBUG": "1"})
+	assert.DeepEqual(t, app.Tags, []string{"registry.local/app:latest"})
+	assert.DeepEqual(t, app.CacheFrom, []string{"type=registry,ref=registry.local/cache", "type=local,src=/tmp/cache"})
+	assert.DeepEqual(t, app.Platforms, []string{"linux/amd64"})
+	assert.Equal(t, *app.Pull, false)
+
+	lint := targets[1]
+	assert.Equal(t, *lint.Target, "lint")
+	assert.DeepEqual(t, lint.Tags, []string{"LINT"})
+	assert.DeepEqual(t, lint.Args, map[string]string{"GO_VERSION": "1.24", "DEBUG": "0"})
+
+	targets, err = c.Resolve([]string{"tools-test"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targetNames(targets), []string{"tools-test"})
+
+	_, err = c.Resolve([]string{"unknown"})
+	assert.ErrorContains(t, err, `failed to find target or group "unknown"`)
+}
+
+func TestReadJSONOverride(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	base := writeFile(t, dir, "docker-bake.json", `{
+  "variable": {"TAG": {"default": "v1"}},
+  "target": {
+    "app": {"context": "./app", "tags": ["app:${TAG}"], "args": {"A": "1"}}
+  }
+}`)
+	override := writeFile(t, dir, "docker-bake.override.hcl", `
+target "app" {
+  args = {
+    B = "2"
+  }
+}
+`)
+
+	c, err := ReadFiles(context.Background(), []string{base, override}, noEnv)
+	assert.NilError(t, err)
+	targets, err := c.Resolve([]string{"app"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(targets), 1)
+	assert.Equal(t, *targets[0].Context, "./app")
+	assert.DeepEqual(t, targets[0].Tags, []string{"app:v1"})
+	assert.DeepEqual(t, targets[0].Args, map[string]string{"A": "1", "B": "2"})
+}
+
+func TestReadInvalid(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	cyclic := writeFile(t, dir, "cyclic.hcl", `
+target "a" {
+  inherits = ["b"]
+}
+target "b" {
+  inherits = ["a"]
+}
+`)
+	c, err := ReadFiles(context.Background(), []string{cyclic}, noEnv)
+	assert.NilError(t, err)
+	_, err = c.Resolve([]string{"a"})
+	assert.ErrorContains(t, err, "inherits from itself")
+
+	vars := writeFile(t, dir, "vars.hcl", `
+variable "A" {
+  default = B
+}
+variable "B" {
+  default = A
+}
+`)
+	_, err = ReadFiles(context.Background(), []string{vars}, noEnv)
+	assert.ErrorContains(t, err, "refer to each other")
+
+	noName := writeFile(t, dir, "noname.hcl", `
+target "a" {
+  matrix = {
+    x = ["1", "2"]
+  }
+}
+`)
+	_, err = ReadFiles(context.Background(), []string{noName}, noEnv)
+	assert.ErrorContains(t, err, "name must be set with a matrix")
+}
+
+func TestReadCompose(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	f := writeFile(t, dir, "compose.yaml", `
+name: proj
+services:
+  web:
+    build:
+      context: ./web
+      dockerfile: Dockerfile.web
+      args:
+        A: "1"
+      target: prod
+      secrets:
+        - token
+  db:
+    image: postgres
+secrets:
+  token:
+    file: ./token.txt
+`)
+
+	c, err := ReadFiles(context.Background(), []string{f}, noEnv)
+	assert.NilError(t, err)
+	targets, err := c.Resolve(nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targetNames(targets), []string{"web"})
+
+	web := targets[0]
+	assert.Equal(t, *web.Context, filepath.Join(dir, "web"))
+	assert.Equal(t, *web.Dockerfile, "Dockerfile.web")
+	assert.Equal(t, *web.Target, "prod")
+	assert.DeepEqual(t, web.Args, map[string]string{"A": "1"})
+	assert.DeepEqual(t, web.Tags, []string{"proj-web"})
+	assert.DeepEqual(t, web.Secrets, []string{"id=token,src=" + filepath.Join(dir, "token.txt")})
+}
@@ -0,0 +1,161 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bake
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	composecli "github.com/compose-spec/compose-go/v2/cli"
+	compose "github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
+	"github.com/containerd/nerdctl/v2/pkg/reflectutil"
+)
+
+// parseCompose converts the services with a `build` section into targets.
+// The targets are named after the services, and are all members of the default group.
+func parseCompose(ctx context.Context, files []string) (*Config, error) {
+	projectOptions, err := composecli.NewProjectOptions(files,
+		composecli.WithOsEnv,
+		composecli.WithWorkingDirectory(filepath.Dir(files[0])),
+		composecli.WithDotEnv,
+	)
+	if err != nil {
+		return nil, err
+	}
+	project, err := projectOptions.LoadProject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{
+		Groups:  map[string]*Group{DefaultGroup: {}},
+		Targets: make(map[string]*Target),
+	}
+	for name, svc := range project.Services {
+		if svc.Build == nil {
+			continue
+		}
+		if err := validateName("target", name); err != nil {
+			return nil, err
+		}
+		image := svc.Image
+		if image == "" {
+			image = serviceparser.DefaultImageName(project.Name, name)
+		}
+		t, err := composeTarget(project, svc.Build, image)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		t.Name = name
+		if len(svc.Platform) > 0 && len(t.Platforms) == 0 {
+			t.Platforms = []string{svc.Platform}
+		}
+		c.Targets[name] = t
+		c.Groups[DefaultGroup].Targets = append(c.Groups[DefaultGroup].Targets, name)
+	}
+	slices.Sort(c.Groups[DefaultGroup].Targets)
+	return c, nil
+}
+
+func composeTarget(project *compose.Project, b *compose.BuildConfig, image string) (*Target, error) {
+	if unknown := reflectutil.UnknownNonEmptyFields(b,
+		"Context", "Dockerfile", "Entitlements", "Args", "SSH", "Labels", "CacheFrom", "CacheTo", "NoCache",
+		"AdditionalContexts", "Pull", "ExtraHosts", "Network", "Target", "Secrets", "Tags", "Platforms",
+	); len(unknown) > 0 {
+		log.L.Warnf("Ignoring: build: %+v", unknown)
+	}
+
+	t := &Target{
+		Context:      &b.Context,
+		Labels:       b.Labels,
+		Tags:         append([]string{image}, b.Tags...),
+		Platforms:    b.Platforms,
+		CacheFrom:    b.CacheFrom,
+		CacheTo:      b.CacheTo,
+		Entitlements: b.Entitlements,
+	}
+	if b.Dockerfile != "" {
+		t.Dockerfile = &b.Dockerfile
+	}
+	if b.Target != "" {
+		t.Target = &b.Target
+	}
+	if b.Network != "" {
+		t.Network = &b.Network
+	}
+	if b.Pull {
+		t.Pull = &b.Pull
+	}
+	if b.NoCache {
+		t.NoCache = &b.NoCache
+	}
+	if len(b.Args) > 0 {
+		t.Args = make(map[string]string, len(b.Args))
+		for k, v := range b.Args {
+			// args without a value are not set in the environment either
+			if v != nil {
+				t.Args[k] = *v
+			}
+		}
+	}
+	if len(b.AdditionalContexts) > 0 {
+		t.Contexts = make(map[string]string, len(b.AdditionalContexts))
+		for k, v := range b.AdditionalContexts {
+			t.Contexts[k] = v
+		}
+	}
+	if len(b.ExtraHosts) > 0 {
+		t.ExtraHosts = make(map[string]string, len(b.ExtraHosts))
+		for host, ips := range b.ExtraHosts {
+			t.ExtraHosts[host] = strings.Join(ips, ",")
+		}
+	}
+	for _, key := range b.SSH {
+		if key.Path == "" {
+			t.SSH = append(t.SSH, key.ID)
+		} else {
+			t.SSH = append(t.SSH, key.ID+"="+key.Path)
+		}
+	}
+	for _, s := range b.Secrets {
+		secret, ok := project.Secrets[s.Source]
+		if !ok {
+			return nil, fmt.Errorf("build: secret %q is undefined: %w", s.Source, errdefs.ErrInvalidArgument)
+		}
+		id := s.Source
+		if s.Target != "" {
+			id = s.Target
+		}
+		switch {
+		case secret.File != "":
+			t.Secrets = append(t.Secrets, "id="+id+",src="+secret.File)
+		case secret.Environment != "":
+			t.Secrets = append(t.Secrets, "id="+id+",env="+secret.Environment)
+		default:
+			return nil, fmt.Errorf("build: secret %q must be a file or an environment variable: %w", s.Source, errdefs.ErrNotImplemented)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,358 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bake
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+
+	"github.com/containerd/errdefs"
+)
+
+var fileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "group", LabelNames: []string{"name"}},
+		{Type: "target", LabelNames: []string{"name"}},
+	},
+}
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "default"},
+		{Name: "description"},
+	},
+}
+
+var groupSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "targets", Required: true},
+	},
+}
+
+// matrixSchema is the part of a target evaluated before the rest of the target,
+// as the other attributes can refer to the values of the matrix.
+var matrixSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "matrix"},
+		{Name: "name"},
+	},
+}
+
+// functions are the functions available in the expressions of the bake files.
+var functions = map[string]function.Function{
+	"and":           stdlib.AndFunc,
+	"coalesce":      stdlib.CoalesceFunc,
+	"concat":        stdlib.ConcatFunc,
+	"contains":      stdlib.ContainsFunc,
+	"distinct":      stdlib.DistinctFunc,
+	"equal":         stdlib.EqualFunc,
+	"format":        stdlib.FormatFunc,
+	"formatlist":    stdlib.FormatListFunc,
+	"join":          stdlib.JoinFunc,
+	"keys":          stdlib.KeysFunc,
+	"length":        stdlib.LengthFunc,
+	"lookup":        stdlib.LookupFunc,
+	"lower":         stdlib.LowerFunc,
+	"merge":         stdlib.MergeFunc,
+	"not":           stdlib.NotFunc,
+	"notequal":      stdlib.NotEqualFunc,
+	"or":            stdlib.OrFunc,
+	"regex":         stdlib.RegexFunc,
+	"regex_replace": stdlib.RegexReplaceFunc,
+	"replace":       stdlib.ReplaceFunc,
+	"split":         stdlib.SplitFunc,
+	"substr":        stdlib.SubstrFunc,
+	"timeadd":       stdlib.TimeAddFunc,
+	"trim":          stdlib.TrimFunc,
+	"trimprefix":    stdlib.TrimPrefixFunc,
+	"trimspace":     stdlib.TrimSpaceFunc,
+	"trimsuffix":    stdlib.TrimSuffixFunc,
+	"upper":         stdlib.UpperFunc,
+	"values":        stdlib.ValuesFunc,
+}
+
+// parseHCLFiles parses HCL and JSON bake files.
+// The files share the variables, and the targets defined in several files are merged.
+func parseHCLFiles(files []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	parser := hclparse.NewParser()
+	var hclFiles []*hcl.File
+	for _, f := range files {
+		var (
+			hf    *hcl.File
+			diags hcl.Diagnostics
+		)
+		if strings.ToLower(filepath.Ext(f)) == ".json" {
+			hf, diags = parser.ParseJSONFile(f)
+		} else {
+			hf, diags = parser.ParseHCLFile(f)
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		hclFiles = append(hclFiles, hf)
+	}
+	return parseHCL(hcl.MergeFiles(hclFiles), lookupEnv)
+}
+
+func parseHCL(body hcl.Body, lookupEnv func(string) (string, bool)) (*Config, error) {
+	content, diags := body.Content(fileSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	vars, err := evalVariables(content.Blocks.OfType("variable"), lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	ectx := &hcl.EvalContext{
+		Variables: vars,
+		Functions: functions,
+	}
+
+	c := &Config{
+		Groups:  make(map[string]*Group),
+		Targets: make(map[string]*Target),
+	}
+	for _, block := range content.Blocks.OfType("group") {
+		name := block.Labels[0]
+		if err := validateName("group", name); err != nil {
+			return nil, err
+		}
+		gc, diags := block.Body.Content(groupSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		g := &Group{}
+		if diags := gohcl.DecodeExpression(gc.Attributes["targets"].Expr, ectx, &g.Targets); diags.HasErrors() {
+			return nil, diags
+		}
+		c.merge(&Config{Groups: map[string]*Group{name: g}})
+	}
+	for _, block := range content.Blocks.OfType("target") {
+		targets, err := decodeTarget(block, ectx)
+		if err != nil {
+			return nil, err
+		}
+		var expanded []string
+		for _, t := range targets {
+			c.merge(&Config{Targets: map[string]*Target{t.Name: t}})
+			expanded = append(expanded, t.Name)
+		}
+		if name := block.Labels[0]; !slices.Equal(expanded, []string{name}) {
+			// the name of a target with a matrix refers to all the targets of the matrix
+			c.merge(&Config{Groups: map[string]*Group{name: {Targets: expanded}}})
+		}
+	}
+	return c, nil
+}
+
+// evalVariables evaluates the default values of the variables.
+// A variable can be overridden by the environment variable of the same name, and its default value
+// can refer to other variables.
+func evalVariables(blocks hcl.Blocks, lookupEnv func(string) (string, bool)) (map[string]cty.Value, error) {
+	exprs := make(map[string]hcl.Expression)
+	for _, block := range blocks {
+		name := block.Labels[0]
+		if err := validateName("variable", name); err != nil {
+			return nil, err
+		}
+		vc, diags := block.Body.Content(variableSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		if attr, ok := vc.Attributes["default"]; ok {
+			exprs[name] = attr.Expr
+		} else {
+			exprs[name] = nil
+		}
+	}
+
+	vars := make(map[string]cty.Value)
+	for len(vars) < len(exprs) {
+		progressed := false
+		for name, expr := range exprs {
+			if _, ok := vars[name]; ok || !dependenciesEvaluated(expr, exprs, vars) {
+				continue
+			}
+			val := cty.StringVal("")
+			if expr != nil {
+				var diags hcl.Diagnostics
+				val, diags = expr.Value(&hcl.EvalContext{Variables: vars, Functions: functions})
+				if diags.HasErrors() {
+					return nil, diags
+				}
+			}
+			if env, ok := lookupEnv(name); ok {
+				var err error
+				val, err = convertEnv(env, val)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value of variable %q: %w", name, err)
+				}
+			}
+			vars[name] = val
+			progressed = true
+		}
+		if !progressed {
+			var names []string
+			for name := range exprs {
+				if _, ok := vars[name]; !ok {
+					names = append(names, name)
+				}
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("variables %v refer to each other: %w", names, errdefs.ErrInvalidArgument)
+		}
+	}
+	return vars, nil
+}
+
+// dependenciesEvaluated returns true if all the variables referred to by expr are already evaluated.
+func dependenciesEvaluated(expr hcl.Expression, exprs map[string]hcl.Expression, vars map[string]cty.Value) bool {
+	if expr == nil {
+		return true
+	}
+	for _, traversal := range expr.Variables() {
+		root := traversal.RootName()
+		if _, isVar := exprs[root]; !isVar {
+			// not a variable; left to the evaluation to report
+			continue
+		}
+		if _, ok := vars[root]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// convertEnv converts the value of an environment variable to the type of the default value.
+func convertEnv(env string, def cty.Value) (cty.Value, error) {
+	switch def.Type() {
+	case cty.Bool:
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.BoolVal(b), nil
+	case cty.Number:
+		return cty.ParseNumberVal(env)
+	default:
+		return cty.StringVal(env), nil
+	}
+}
+
+// decodeTarget decodes a target block.
+// A block with a matrix is expanded into a target per combination of the values of the matrix.
+func decodeTarget(block *hcl.Block, ectx *hcl.EvalContext) ([]*Target, error) {
+	name := block.Labels[0]
+	if err := validateName("target", name); err != nil {
+		return nil, err
+	}
+	mc, remain, diags := block.Body.PartialContent(matrixSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	matrixAttr, hasMatrix := mc.Attributes["matrix"]
+	nameAttr, hasName := mc.Attributes["name"]
+	if !hasMatrix {
+		if hasName {
+			return nil, fmt.Errorf("target %q: name can only be set with a matrix: %w", name, errdefs.ErrInvalidArgument)
+		}
+		t := &Target{Name: name}
+		if diags := gohcl.DecodeBody(remain, ectx, t); diags.HasErrors() {
+			return nil, diags
+		}
+		return []*Target{t}, nil
+	}
+	if !hasName {
+		return nil, fmt.Errorf("target %q: name must be set with a matrix: %w", name, errdefs.ErrInvalidArgument)
+	}
+
+	matrix, diags := matrixAttr.Expr.Value(ectx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	combinations, err := expandMatrix(matrix)
+	if err != nil {
+		return nil, fmt.Errorf("target %q: %w", name, err)
+	}
+	var targets []*Target
+	for _, combination := range combinations {
+		cctx := ectx.NewChild()
+		cctx.Variables = combination
+		var expandedName string
+		if diags := gohcl.DecodeExpression(nameAttr.Expr, cctx, &expandedName); diags.HasErrors() {
+			return nil, diags
+		}
+		if err := validateName("target", expandedName); err != nil {
+			return nil, fmt.Errorf("target %q: %w", name, err)
+		}
+		t := &Target{Name: expandedName}
+		if diags := gohcl.DecodeBody(remain, cctx, t); diags.HasErrors() {
+			return nil, diags
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// expandMatrix returns the combinations of the values of the matrix.
+// The matrix maps the names of the variables to the lists of their values.
+func expandMatrix(matrix cty.Value) ([]map[string]cty.Value, error) {
+	if !matrix.Type().IsObjectType() && !matrix.Type().IsMapType() {
+		return nil, fmt.Errorf("matrix must be a map: %w", errdefs.ErrInvalidArgument)
+	}
+	m := matrix.AsValueMap()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	combinations := []map[string]cty.Value{{}}
+	for _, k := range keys {
+		values := m[k]
+		if !values.CanIterateElements() || values.Type().IsMapType() || values.Type().IsObjectType() {
+			return nil, fmt.Errorf("matrix value %q must be a list: %w", k, errdefs.ErrInvalidArgument)
+		}
+		var next []map[string]cty.Value
+		for _, combination := range combinations {
+			for it := values.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				c := make(map[string]cty.Value, len(combination)+1)
+				for ck, cv := range combination {
+					c[ck] = cv
+				}
+				c[k] = v
+				next = append(next, c)
+			}
+		}
+		combinations = next
+	}
+	return combinations, nil
+}
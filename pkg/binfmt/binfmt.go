@@ -0,0 +1,330 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package binfmt reads and writes the registrations of the binfmt_misc file system of Linux,
+// which runs the binaries of foreign architectures with emulators such as QEMU.
+//
+// See https://docs.kernel.org/admin-guide/binfmt-misc.html
+package binfmt
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/errdefs"
+)
+
+// Dir is the mount point of binfmt_misc.
+const Dir = "/proc/sys/fs/binfmt_misc"
+
+// Entry is a registration of binfmt_misc, read from Dir/<Name>.
+type Entry struct {
+	Name    string
+	Enabled bool
+	// Interpreter is the path of the interpreter, in the mount namespace of the registration
+	Interpreter string
+	// Flags of the registration, e.g., "OCF"
+	Flags string
+	// Offset, Magic and Mask of the registrations of the "magic" type
+	Offset int
+	Magic  []byte
+	Mask   []byte
+	// Extension of the registrations of the "extension" type, without the dot
+	Extension string
+}
+
+// Arch is a CPU architecture which can be emulated.
+type Arch struct {
+	// OCI is the architecture of the OCI platforms, e.g., "arm64"
+	OCI string
+	// QEMU is the name of the architecture in the names of the QEMU binaries, e.g., "aarch64"
+	QEMU string
+	// Magic and Mask match the ELF headers of the executables of the architecture
+	Magic []byte
+	Mask  []byte
+}
+
+// the ELF headers from https://gitlab.com/qemu-project/qemu/-/blob/master/scripts/qemu-binfmt-conf.sh
+var (
+	elfMask     = []byte("\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff")
+	elfx86Mask  = []byte("\xff\xff\xff\xff\xff\xfe\xfe\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff")
+	elf64BEMask = []byte("\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff")
+)
+
+// Archs are the architectures which can be emulated, in the order of their OCI names.
+var Archs = []Arch{
+	{OCI: "386", QEMU: "i386", Magic: []byte("\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x03\x00"), Mask: elfx86Mask},
+	{OCI: "amd64", QEMU: "x86_64", Magic: []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x3e\x00"), Mask: elfx86Mask},
+	{OCI: "arm", QEMU: "arm", Magic: []byte("\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x28\x00"), Mask: elfMask},
+	{OCI: "arm64", QEMU: "aarch64", Magic: []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xb7\x00"), Mask: elfMask},
+	{OCI: "loong64", QEMU: "loongarch64", Magic: []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x02\x01"), Mask: elfMask},
+	{OCI: "mips64", QEMU: "mips64", Magic: []byte("\x7fELF\x02\x02\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x08"), Mask: elf64BEMask},
+	{OCI: "mips64le", QEMU: "mips64el", Magic: []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x08\x00"), Mask: elfMask},
+	{OCI: "ppc64le", QEMU: "ppc64le", Magic: []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x15\x00"), Mask: []byte("\xff\xff\xff\xff\xff\xff\xff\xfc\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\x00")},
+	{OCI: "riscv64", QEMU: "riscv64", Magic: []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xf3\x00"), Mask: elfMask},
+	{OCI: "s390x", QEMU: "s390x", Magic: []byte("\x7fELF\x02\x02\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x16"), Mask: []byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff")},
+}
+
+// LookupArch returns the architecture of the OCI name ociArch.
+func LookupArch(ociArch string) (Arch, error) {
+	for _, a := range Archs {
+		if a.OCI == ociArch {
+			return a, nil
+		}
+	}
+	return Arch{}, fmt.Errorf("unknown OCI architecture string: %q", ociArch)
+}
+
+// Native reports whether the host runs the binaries of the architecture without emulation.
+func (a Arch) Native() bool {
+	return a.OCI == runtime.GOARCH || (a.OCI == "386" && runtime.GOARCH == "amd64")
+}
+
+// Architecture returns the OCI architecture of the executables matched by the entry, or "" when it matches the
+// executables of no (or multiple) architectures in Archs.
+func (e *Entry) Architecture() string {
+	// the magic must include the e_machine field of the ELF header
+	if e.Magic == nil || e.Offset+len(e.Magic) < 20 {
+		return ""
+	}
+	var found string
+	for _, a := range Archs {
+		if !e.matches(a.Magic) {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = a.OCI
+	}
+	return found
+}
+
+// matches reports whether the entry matches the header.
+func (e *Entry) matches(header []byte) bool {
+	for i, m := range e.Magic {
+		j := e.Offset + i
+		if j >= len(header) {
+			return false
+		}
+		mask := byte(0xff)
+		if i < len(e.Mask) {
+			mask = e.Mask[i]
+		}
+		if header[j]&mask != m&mask {
+			return false
+		}
+	}
+	return true
+}
+
+// Problem returns why the entry cannot run the executables in containers, or nil.
+func (e *Entry) Problem() error {
+	if !e.Enabled {
+		return errors.New("the entry is disabled")
+	}
+	// without the "F" flag, the interpreter is opened in the mount namespace of the executable, i.e., of the container
+	if !strings.Contains(e.Flags, "F") {
+		return fmt.Errorf("the entry was registered without the \"F\" (fix binary) flag, so the interpreter %s must exist inside the containers", e.Interpreter)
+	}
+	return nil
+}
+
+// Parse parses the entry name from r, in the format of the files of binfmt_misc.
+func Parse(name string, r io.Reader) (*Entry, error) {
+	e := &Entry{Name: name}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		k, v, _ := strings.Cut(line, " ")
+		v = strings.TrimSpace(v)
+		var err error
+		switch k {
+		case "enabled":
+			e.Enabled = true
+		case "disabled":
+			e.Enabled = false
+		case "interpreter":
+			e.Interpreter = v
+		case "flags:":
+			e.Flags = v
+		case "offset":
+			e.Offset, err = strconv.Atoi(v)
+		case "magic":
+			e.Magic, err = hex.DecodeString(v)
+		case "mask":
+			e.Mask, err = hex.DecodeString(v)
+		case "extension":
+			e.Extension = strings.TrimPrefix(v, ".")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse binfmt_misc entry %q: invalid %s %q: %w", name, k, v, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Enabled reads whether binfmt_misc is enabled in dir.
+func Enabled(dir string) (bool, error) {
+	b, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(b)) == "enabled", nil
+}
+
+// List reads the entries registered in dir, sorted by name.
+func List(dir string) ([]Entry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, d := range dirEntries {
+		if d.IsDir() || d.Name() == "register" || d.Name() == "status" {
+			continue
+		}
+		e, err := Read(dir, d.Name())
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// unregistered meanwhile
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// Read reads the entry name in dir.
+func Read(dir, name string) (*Entry, error) {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(name, f)
+}
+
+// Check returns nil when the executables of the OCI architecture arch can run in containers,
+// or why they cannot otherwise.
+func Check(dir, arch string) error {
+	enabled, err := Enabled(dir)
+	if err != nil {
+		return fmt.Errorf("binfmt_misc is not available: %w", err)
+	}
+	if !enabled {
+		return errors.New("binfmt_misc is disabled")
+	}
+	entries, err := List(dir)
+	if err != nil {
+		return err
+	}
+	var problem error
+	for _, e := range entries {
+		if e.Architecture() != arch {
+			continue
+		}
+		if err := e.Problem(); err != nil {
+			if problem == nil {
+				problem = fmt.Errorf("binfmt_misc entry %q cannot run %s executables: %w", e.Name, arch, err)
+			}
+			continue
+		}
+		return nil
+	}
+	if problem != nil {
+		return problem
+	}
+	return fmt.Errorf("no binfmt_misc entry for %s executables: %w", arch, errdefs.ErrNotFound)
+}
+
+// Emulated returns the OCI architectures whose executables can run in containers with the entries of dir.
+// It returns nil when binfmt_misc is not available.
+func Emulated(dir string) []string {
+	if enabled, err := Enabled(dir); err != nil || !enabled {
+		return nil
+	}
+	entries, err := List(dir)
+	if err != nil {
+		return nil
+	}
+	var archs []string
+	for _, e := range entries {
+		arch := e.Architecture()
+		if arch == "" || e.Problem() != nil {
+			continue
+		}
+		if a, _ := LookupArch(arch); a.Native() {
+			continue
+		}
+		if !slices.Contains(archs, arch) {
+			archs = append(archs, arch)
+		}
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// Register registers the entry in dir. The entry must be of the "magic" type.
+func Register(dir string, e Entry) error {
+	if strings.ContainsAny(e.Name, ":/") || e.Name == "" || e.Name == "." || e.Name == ".." {
+		return fmt.Errorf("invalid binfmt_misc entry name %q", e.Name)
+	}
+	// https://docs.kernel.org/admin-guide/binfmt-misc.html: ":name:type:offset:magic:mask:interpreter:flags"
+	s := fmt.Sprintf(":%s:M:%d:%s:%s:%s:%s", e.Name, e.Offset, escape(e.Magic), escape(e.Mask), e.Interpreter, e.Flags)
+	if err := os.WriteFile(filepath.Join(dir, "register"), []byte(s), 0200); err != nil {
+		return fmt.Errorf("failed to register binfmt_misc entry %q: %w", e.Name, err)
+	}
+	return nil
+}
+
+// Unregister unregisters the entry name in dir.
+func Unregister(dir, name string) error {
+	if strings.ContainsAny(name, "/") || name == "register" || name == "status" {
+		return fmt.Errorf("invalid binfmt_misc entry name %q", name)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("-1"), 0200); err != nil {
+		return fmt.Errorf("failed to unregister binfmt_misc entry %q: %w", name, err)
+	}
+	return nil
+}
+
+// escape escapes the bytes in the format of the magic and mask of the register file.
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		fmt.Fprintf(&sb, "\\x%02x", c)
+	}
+	return sb.String()
+}
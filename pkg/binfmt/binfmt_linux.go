@@ -0,0 +1,33 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package binfmt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// Mount mounts binfmt_misc on dir, unless it is already mounted.
+func Mount(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "register")); err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return unix.Mount("binfmt_misc", dir, "binfmt_misc", 0, "")
+}
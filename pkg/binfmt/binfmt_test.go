@@ -0,0 +1,119 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package binfmt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const qemuAarch64 = `enabled
+interpreter /usr/bin/qemu-aarch64-static
+flags: OCF
+offset 0
+magic 7f454c460201010000000000000000000200b700
+mask ffffffffffffff00fffffffffffffffffeffffff
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	e, err := Parse("qemu-aarch64", strings.NewReader(qemuAarch64))
+	assert.NilError(t, err)
+	assert.Equal(t, e.Name, "qemu-aarch64")
+	assert.Equal(t, e.Enabled, true)
+	assert.Equal(t, e.Interpreter, "/usr/bin/qemu-aarch64-static")
+	assert.Equal(t, e.Flags, "OCF")
+	assert.Equal(t, e.Offset, 0)
+	assert.Equal(t, e.Architecture(), "arm64")
+	assert.NilError(t, e.Problem())
+
+	e, err = Parse("qemu-s390x", strings.NewReader(`disabled
+interpreter /usr/bin/qemu-s390x
+flags: 
+offset 0
+magic 7f454c46020201000000000000000000000200160
+`))
+	assert.ErrorContains(t, err, "invalid magic")
+	assert.Assert(t, e == nil)
+
+	e, err = Parse("qemu-s390x", strings.NewReader(`disabled
+interpreter /usr/bin/qemu-s390x
+flags: 
+offset 0
+magic 7f454c4602020100000000000000000000020016
+mask ffffffffffffffffffffffffffffffffffffffff
+`))
+	assert.NilError(t, err)
+	assert.Equal(t, e.Architecture(), "s390x")
+	assert.ErrorContains(t, e.Problem(), "disabled")
+	e.Enabled = true
+	assert.ErrorContains(t, e.Problem(), "\"F\"")
+
+	e, err = Parse("DOSWin", strings.NewReader("enabled\ninterpreter /usr/bin/wine\nflags: \nextension .exe\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, e.Extension, "exe")
+	assert.Equal(t, e.Architecture(), "")
+}
+
+func TestArchitecture(t *testing.T) {
+	t.Parallel()
+	for _, a := range Archs {
+		e := Entry{Magic: a.Magic, Mask: a.Mask}
+		assert.Equal(t, e.Architecture(), a.OCI)
+	}
+	// the magic of all the ELF executables
+	e := Entry{Magic: []byte("\x7fELF")}
+	assert.Equal(t, e.Architecture(), "")
+}
+
+func TestListAndCheck(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "status"), []byte("enabled\n"), 0644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "register"), nil, 0200))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "qemu-aarch64"), []byte(qemuAarch64), 0644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "qemu-riscv64"), []byte(`enabled
+interpreter /usr/bin/qemu-riscv64
+flags: 
+offset 0
+magic 7f454c460201010000000000000000000200f300
+mask ffffffffffffff00fffffffffffffffffeffffff
+`), 0644))
+
+	entries, err := List(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Name, "qemu-aarch64")
+	assert.Equal(t, entries[1].Name, "qemu-riscv64")
+
+	assert.NilError(t, Check(dir, "arm64"))
+	assert.ErrorContains(t, Check(dir, "riscv64"), "\"F\"")
+	assert.ErrorContains(t, Check(dir, "s390x"), "no binfmt_misc entry")
+
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "status"), []byte("disabled\n"), 0644))
+	assert.ErrorContains(t, Check(dir, "arm64"), "binfmt_misc is disabled")
+	assert.Assert(t, Emulated(dir) == nil)
+}
+
+func TestEscape(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, escape([]byte("\x7fELF")), `\x7f\x45\x4c\x46`)
+}
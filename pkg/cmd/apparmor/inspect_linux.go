@@ -17,12 +17,20 @@
 package apparmor
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
+	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/contrib/apparmor"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/apparmorutil"
 	"github.com/containerd/nerdctl/v2/pkg/defaults"
+	"github.com/containerd/nerdctl/v2/pkg/idutil/containerwalker"
+	"github.com/containerd/nerdctl/v2/pkg/labels"
 )
 
 func Inspect(options types.ApparmorInspectOptions) error {
@@ -33,3 +41,35 @@ func Inspect(options types.ApparmorInspectOptions) error {
 	_, err = fmt.Fprint(options.Stdout, b)
 	return err
 }
+
+// InspectContainer displays the profile generated for a container from `--security-opt apparmor-policy`.
+func InspectContainer(ctx context.Context, client *containerd.Client, req string, options types.ApparmorInspectOptions) error {
+	walker := &containerwalker.ContainerWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found containerwalker.Found) error {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
+			}
+			containerLabels, err := found.Container.Labels(ctx)
+			if err != nil {
+				return err
+			}
+			b, err := os.ReadFile(filepath.Join(containerLabels[labels.StateDir], apparmorutil.ProfileFileName))
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("container %q has no AppArmor profile generated from a policy", found.Req)
+				}
+				return err
+			}
+			_, err = options.Stdout.Write(b)
+			return err
+		},
+	}
+	n, err := walker.Walk(ctx, req)
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("no such container %s", req)
+	}
+	return nil
+}
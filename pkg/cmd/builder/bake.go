@@ -0,0 +1,190 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	bkclient "github.com/moby/buildkit/client"
+	"golang.org/x/sync/errgroup"
+
+	containerd "github.com/containerd/containerd/v2/client"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/bake"
+)
+
+// ResolveBakeTargets reads the bake files and resolves the targets of options.
+func ResolveBakeTargets(ctx context.Context, options types.BuilderBakeOptions) ([]*bake.Target, error) {
+	files := options.Files
+	if len(files) == 0 {
+		var err error
+		files, err = bake.FindDefaultFiles(".")
+		if err != nil {
+			return nil, err
+		}
+	}
+	c, err := bake.ReadFiles(ctx, files, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	return c.Resolve(options.Targets)
+}
+
+// PrintBakeTargets prints the targets in the JSON bake file format.
+func PrintBakeTargets(targets []*bake.Target, options types.BuilderBakeOptions) error {
+	c := bake.Config{
+		Groups:  map[string]*bake.Group{bake.DefaultGroup: {}},
+		Targets: make(map[string]*bake.Target, len(targets)),
+	}
+	for _, t := range targets {
+		c.Groups[bake.DefaultGroup].Targets = append(c.Groups[bake.DefaultGroup].Targets, t.Name)
+		c.Targets[t.Name] = t
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(options.Stdout, string(b))
+	return err
+}
+
+// Bake builds the targets concurrently.
+// The progress of the builds is shown in a single display, with the steps prefixed by the name of their target.
+func Bake(ctx context.Context, client *containerd.Client, targets []*bake.Target, options types.BuilderBakeOptions) error {
+	var buildOpts []types.BuilderBuildOptions
+	for _, t := range targets {
+		bo, err := bakeBuildOptions(t, options)
+		if err != nil {
+			return fmt.Errorf("target %s: %w", t.Name, err)
+		}
+		buildOpts = append(buildOpts, bo)
+	}
+
+	display, err := newDisplay(options.Stderr, options.Progress, false)
+	if err != nil {
+		return err
+	}
+	statusCh := make(chan *bkclient.SolveStatus)
+	displayDone := make(chan error, 1)
+	go func() {
+		_, err := display.UpdateFrom(context.WithoutCancel(ctx), statusCh)
+		displayDone <- err
+	}()
+
+	var forwarders sync.WaitGroup
+	eg, ectx := errgroup.WithContext(ctx)
+	for i, t := range targets {
+		ch := make(chan *bkclient.SolveStatus)
+		forwarders.Add(1)
+		go func() {
+			defer forwarders.Done()
+			for s := range ch {
+				for _, v := range s.Vertexes {
+					v.Name = "[" + t.Name + "] " + v.Name
+				}
+				statusCh <- s
+			}
+		}()
+		eg.Go(func() error {
+			if err := build(ectx, client, buildOpts[i], ch); err != nil {
+				return fmt.Errorf("target %s: %w", t.Name, err)
+			}
+			return nil
+		})
+	}
+	err = eg.Wait()
+	forwarders.Wait()
+	close(statusCh)
+	if displayErr := <-displayDone; err == nil {
+		err = displayErr
+	}
+	return err
+}
+
+func bakeBuildOptions(t *bake.Target, options types.BuilderBakeOptions) (types.BuilderBuildOptions, error) {
+	bo := types.BuilderBuildOptions{
+		Stdout:       options.Stdout,
+		Stderr:       options.Stderr,
+		GOptions:     options.GOptions,
+		BuildKitHost: options.BuildKitHost,
+		BuildContext: *t.Context,
+		Tag:          t.Tags,
+		Platform:     t.Platforms,
+		CacheFrom:    t.CacheFrom,
+		CacheTo:      t.CacheTo,
+		Secret:       t.Secrets,
+		SSH:          t.SSH,
+		Attest:       t.Attest,
+		Allow:        t.Entitlements,
+		NoCache:      options.NoCache,
+		Pull:         options.Pull,
+		Progress:     options.Progress,
+		Rm:           true,
+	}
+	if t.Dockerfile != nil {
+		bo.File = *t.Dockerfile
+		if !filepath.IsAbs(bo.File) {
+			bo.File = filepath.Join(bo.BuildContext, bo.File)
+		}
+	}
+	if t.Target != nil {
+		bo.Target = *t.Target
+	}
+	if t.Network != nil {
+		bo.NetworkMode = *t.Network
+	}
+	if t.NoCache != nil && *t.NoCache {
+		bo.NoCache = true
+	}
+	if bo.Pull == nil {
+		bo.Pull = t.Pull
+	}
+	switch len(t.Outputs) {
+	case 0:
+	case 1:
+		bo.Output = t.Outputs[0]
+	default:
+		return bo, fmt.Errorf("multiple outputs are not supported: %v", t.Outputs)
+	}
+	bo.BuildArgs = kvList(t.Args)
+	bo.Label = kvList(t.Labels)
+	bo.ExtendedBuildContext = kvList(t.Contexts)
+	for _, host := range slices.Sorted(maps.Keys(t.ExtraHosts)) {
+		for _, ip := range strings.Split(t.ExtraHosts[host], ",") {
+			bo.ExtraHosts = append(bo.ExtraHosts, host+":"+ip)
+		}
+	}
+	return bo, nil
+}
+
+// kvList converts m into sorted `key=value` strings.
+func kvList(m map[string]string) []string {
+	var res []string
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		res = append(res, k+"="+m[k])
+	}
+	return res
+}
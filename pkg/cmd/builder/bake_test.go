@@ -0,0 +1,59 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/bake"
+)
+
+func TestBakeBuildOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx, dockerfile, stage := "app", "Dockerfile.app", "prod"
+	pull := true
+	target := &bake.Target{
+		Name:       "app",
+		Context:    &ctx,
+		Dockerfile: &dockerfile,
+		Target:     &stage,
+		Args:       map[string]string{"B": "2", "A": "1"},
+		ExtraHosts: map[string]string{"db": "10.0.0.1,10.0.0.2"},
+		Pull:       &pull,
+		Tags:       []string{"app:latest"},
+	}
+
+	bo, err := bakeBuildOptions(target, types.BuilderBakeOptions{NoCache: true})
+	assert.NilError(t, err)
+	assert.Equal(t, bo.BuildContext, "app")
+	assert.Equal(t, bo.File, "app/Dockerfile.app")
+	assert.Equal(t, bo.Target, "prod")
+	assert.DeepEqual(t, bo.BuildArgs, []string{"A=1", "B=2"})
+	assert.DeepEqual(t, bo.ExtraHosts, []string{"db:10.0.0.1", "db:10.0.0.2"})
+	assert.DeepEqual(t, bo.Tag, []string{"app:latest"})
+	assert.Equal(t, *bo.Pull, true)
+	assert.Assert(t, bo.NoCache)
+	assert.Assert(t, bo.Rm)
+
+	target.Outputs = []string{"type=docker", "type=oci"}
+	_, err = bakeBuildOptions(target, types.BuilderBakeOptions{})
+	assert.ErrorContains(t, err, "multiple outputs are not supported")
+}
@@ -23,16 +23,25 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	dockerconfig "github.com/docker/cli/cli/config"
+	bkclient "github.com/moby/buildkit/client"
+	bkbuild "github.com/moby/buildkit/cmd/buildctl/build"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/session/auth/authprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	"github.com/moby/buildkit/util/progress/progressui"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/tonistiigi/fsutil"
 
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/images/archive"
+	"github.com/containerd/containerd/v2/plugins/content/local"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 	"github.com/containerd/platforms"
@@ -40,7 +49,9 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/buildkitutil"
 	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/compression"
 	"github.com/containerd/nerdctl/v2/pkg/containerutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/squash"
 	"github.com/containerd/nerdctl/v2/pkg/internal/filesystem"
 	"github.com/containerd/nerdctl/v2/pkg/platformutil"
 	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
@@ -63,67 +74,129 @@ func (p platformParser) DefaultSpec() platforms.Platform {
 }
 
 func Build(ctx context.Context, client *containerd.Client, options types.BuilderBuildOptions) error {
-	buildctlBinary, buildctlArgs, needsLoading, metaFile, tags, cleanup, err := generateBuildctlArgs(ctx, client, options)
+	display, err := newDisplay(options.Stderr, options.Progress, options.Quiet)
 	if err != nil {
 		return err
 	}
-	if cleanup != nil {
-		defer cleanup()
+	ch := make(chan *bkclient.SolveStatus)
+	displayDone := make(chan error, 1)
+	go func() {
+		// the display must drain ch until it is closed, even when the build is canceled
+		_, err := display.UpdateFrom(context.WithoutCancel(ctx), ch)
+		displayDone <- err
+	}()
+	err = build(ctx, client, options, ch)
+	if displayErr := <-displayDone; err == nil {
+		err = displayErr
 	}
+	return err
+}
 
-	log.L.Debugf("running %s %v", buildctlBinary, buildctlArgs)
-	buildctlCmd := exec.Command(buildctlBinary, buildctlArgs...)
-	buildctlCmd.Env = os.Environ()
+func newDisplay(out io.Writer, progress string, quiet bool) (progressui.Display, error) {
+	mode := progressui.DisplayMode(progress)
+	if quiet {
+		mode = progressui.QuietMode
+	}
+	return progressui.NewDisplay(out, mode)
+}
 
-	var buildctlStdout io.Reader
-	if needsLoading {
-		buildctlStdout, err = buildctlCmd.StdoutPipe()
-		if err != nil {
-			return err
+// build runs the build specified by options, and sends the progress of the build to ch.
+// ch is closed when build returns.
+func build(ctx context.Context, client *containerd.Client, options types.BuilderBuildOptions, ch chan *bkclient.SolveStatus) error {
+	solving := false
+	defer func() {
+		if !solving {
+			close(ch)
 		}
-	} else {
-		buildctlCmd.Stdout = options.Stdout
+	}()
+
+	bc, err := generateBuildConfig(ctx, client, options)
+	if bc != nil && bc.cleanup != nil {
+		defer bc.cleanup()
 	}
-	if !options.Quiet {
-		buildctlCmd.Stderr = options.Stderr
+	if err != nil {
+		return err
 	}
 
-	if err := buildctlCmd.Start(); err != nil {
+	bkClient, err := buildkitutil.NewClient(ctx, options.BuildKitHost)
+	if err != nil {
 		return err
 	}
+	defer bkClient.Close()
 
-	if needsLoading {
+	var (
+		pw       *io.PipeWriter
+		loadDone chan error
+	)
+	if bc.needsLoading {
 		platMC, err := platformutil.NewMatchComparer(false, options.Platform)
 		if err != nil {
 			return err
 		}
-		if err = loadImage(ctx, buildctlStdout, options.GOptions.Namespace, options.GOptions.Address, options.GOptions.Snapshotter, options.Stdout, platMC, options.Quiet); err != nil {
-			return err
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		bc.solveOpt.Exports[0].Output = func(map[string]string) (io.WriteCloser, error) {
+			return pw, nil
 		}
+		loadDone = make(chan error, 1)
+		go func() {
+			err := loadImage(ctx, pr, options.GOptions.Namespace, options.GOptions.Address, options.GOptions.Snapshotter, options.Stdout, platMC, options.Quiet)
+			if err != nil {
+				pr.CloseWithError(err)
+			} else {
+				// do not block BuildKit on the trailing bytes of the archive
+				_, _ = io.Copy(io.Discard, pr)
+			}
+			loadDone <- err
+		}()
 	}
 
-	if err = buildctlCmd.Wait(); err != nil {
+	// Solve closes ch
+	solving = true
+	resp, err := bkClient.Solve(ctx, nil, bc.solveOpt, ch)
+	if pw != nil {
+		pw.CloseWithError(err)
+	}
+	if loadDone != nil {
+		if loadErr := <-loadDone; err == nil {
+			err = loadErr
+		}
+	}
+	if err != nil {
 		return err
 	}
 
-	if options.IidFile != "" {
-		id, err := getDigestFromMetaFile(metaFile)
+	var squashedID string
+	if options.Squash {
+		squashed, err := squashImage(ctx, client, bc.tags[0], options)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to squash the built image: %w", err)
+		}
+		squashedID = squashed.Target.Digest.String()
+	}
+
+	if options.IidFile != "" {
+		id := squashedID
+		if id == "" {
+			var ok bool
+			id, ok = resp.ExporterResponse[exptypes.ExporterImageDigestKey]
+			if !ok {
+				return errors.New("failed to find containerimage.digest in the exporter response")
+			}
 		}
 		if err := filesystem.WriteFile(options.IidFile, []byte(id), 0644); err != nil {
 			return err
 		}
 	}
 
-	if len(tags) > 1 {
+	if len(bc.tags) > 1 {
 		log.L.Debug("Found more than 1 tag")
 		imageService := client.ImageService()
-		image, err := imageService.Get(ctx, tags[0])
+		image, err := imageService.Get(ctx, bc.tags[0])
 		if err != nil {
 			return fmt.Errorf("unable to tag image: %w", err)
 		}
-		for _, targetRef := range tags[1:] {
+		for _, targetRef := range bc.tags[1:] {
 			image.Name = targetRef
 			if _, err := imageService.Create(ctx, image); err != nil {
 				// if already exists; skip.
@@ -144,6 +217,27 @@ func Build(ctx context.Context, client *containerd.Client, options types.Builder
 	return nil
 }
 
+// squashImage squashes all the layers of the built image name.
+func squashImage(ctx context.Context, client *containerd.Client, name string, options types.BuilderBuildOptions) (images.Image, error) {
+	platMC, err := platformutil.NewMatchComparer(false, options.Platform)
+	if err != nil {
+		return images.Image{}, err
+	}
+	img, err := client.ImageService().Get(ctx, name)
+	if err != nil {
+		return images.Image{}, err
+	}
+	spec, err := squash.CompressionSpec(compression.Gzip, options.GOptions.Compression)
+	if err != nil {
+		return images.Image{}, err
+	}
+	return squash.Squash(ctx, client, containerd.NewImageWithPlatform(client, img, platMC), name, squash.Opts{
+		Compression: spec,
+		Snapshotter: options.GOptions.Snapshotter,
+		Platform:    platMC,
+	})
+}
+
 // TODO: This struct and `loadImage` are duplicated with the code in `cmd/load.go`, remove it after `load.go` has been refactor
 type readCounter struct {
 	io.Reader
@@ -194,76 +288,76 @@ func loadImage(ctx context.Context, in io.Reader, namespace, address, snapshotte
 	return nil
 }
 
-func generateBuildctlArgs(ctx context.Context, client *containerd.Client, options types.BuilderBuildOptions) (buildCtlBinary string,
-	buildctlArgs []string, needsLoading bool, metaFile string, tags []string, cleanup func(), err error) {
+// buildConfig is the BuildKit solve request generated from BuilderBuildOptions.
+type buildConfig struct {
+	solveOpt bkclient.SolveOpt
+	// needsLoading is true when the image is exported as an archive that has to be loaded into containerd.
+	needsLoading bool
+	tags         []string
+	cleanup      func()
+}
 
-	buildctlBinary, err := buildkitutil.BuildctlBinary()
-	if err != nil {
-		return "", nil, false, "", nil, nil, err
+func generateBuildConfig(ctx context.Context, client *containerd.Client, options types.BuilderBuildOptions) (*buildConfig, error) {
+	bc := &buildConfig{
+		solveOpt: bkclient.SolveOpt{
+			Frontend:      "dockerfile.v0",
+			FrontendAttrs: make(map[string]string),
+			LocalMounts:   make(map[string]fsutil.FS),
+			OCIStores:     make(map[string]content.Store),
+		},
 	}
+	solveOpt := &bc.solveOpt
 
-	output := options.Output
-	if output == "" {
+	var export bkclient.ExportEntry
+	if options.Output == "" {
 		info, err := client.Server(ctx)
 		if err != nil {
-			return "", nil, false, "", nil, nil, err
+			return bc, err
 		}
-		sharable, err := isImageSharable(options.BuildKitHost, options.GOptions.Namespace, info.UUID, options.GOptions.Snapshotter, options.Platform)
+		sharable, err := isImageSharable(ctx, options.BuildKitHost, options.GOptions.Namespace, info.UUID, options.GOptions.Snapshotter, options.Platform)
 		if err != nil {
-			return "", nil, false, "", nil, nil, err
+			return bc, err
 		}
 		if sharable {
-			output = "type=image,unpack=true" // ensure the target stage is unlazied (needed for any snapshotters)
+			// the worker writes the image directly into the containerd image store
+			// ensure the target stage is unlazied (needed for any snapshotters)
+			export = bkclient.ExportEntry{Type: bkclient.ExporterImage, Attrs: map[string]string{"unpack": "true"}}
 		} else {
-			output = "type=docker"
+			export = bkclient.ExportEntry{Type: bkclient.ExporterDocker, Attrs: map[string]string{}}
 			if len(options.Platform) > 1 {
 				// For avoiding `error: failed to solve: docker exporter does not currently support exporting manifest lists`
 				// TODO: consider using type=oci for single-options.Platform build too
-				output = "type=oci"
+				export.Type = bkclient.ExporterOCI
 			}
-			needsLoading = true
+			bc.needsLoading = true
 		}
 	} else {
-		if !strings.Contains(output, "type=") {
-			// should accept --output <DIR> as an alias of --output
-			// type=local,dest=<DIR>
-			output = fmt.Sprintf("type=local,dest=%s", output)
-		}
-		if strings.Contains(output, "type=docker") || strings.Contains(output, "type=oci") {
-			if !strings.Contains(output, "dest=") {
-				needsLoading = true
-			}
-		}
-	}
-	if tags = strutil.DedupeStrSlice(options.Tag); len(tags) > 0 {
-		ref := tags[0]
-		parsedReference, err := referenceutil.Parse(ref)
+		var err error
+		export, bc.needsLoading, err = parseOutput(options.Output, options.Stdout)
 		if err != nil {
-			return "", nil, false, "", nil, nil, err
+			return bc, err
 		}
-		output += ",name=" + parsedReference.String()
-
+	}
+	if bc.tags = strutil.DedupeStrSlice(options.Tag); len(bc.tags) > 0 {
 		// pick the first tag and add it to output
-		for idx, tag := range tags {
-			parsedReference, err = referenceutil.Parse(tag)
+		for idx, tag := range bc.tags {
+			parsedReference, err := referenceutil.Parse(tag)
 			if err != nil {
-				return "", nil, false, "", nil, nil, err
+				return bc, err
 			}
-			tags[idx] = parsedReference.String()
+			bc.tags[idx] = parsedReference.String()
 		}
-	} else if len(tags) == 0 {
-		output = output + ",dangling-name-prefix=<none>"
+		export.Attrs["name"] = bc.tags[0]
+	} else {
+		export.Attrs["dangling-name-prefix"] = "<none>"
 	}
+	solveOpt.Exports = []bkclient.ExportEntry{export}
 
-	buildctlArgs = buildkitutil.BuildctlBaseArgs(options.BuildKitHost)
-
-	buildctlArgs = append(buildctlArgs, []string{
-		"build",
-		"--progress=" + options.Progress,
-		"--frontend=dockerfile.v0",
-		"--local=context=" + options.BuildContext,
-		"--output=" + output,
-	}...)
+	contextFS, err := fsutil.NewFS(options.BuildContext)
+	if err != nil {
+		return bc, err
+	}
+	solveOpt.LocalMounts["context"] = contextFS
 
 	dir := options.BuildContext
 	file := buildkitutil.DefaultDockerfileName
@@ -273,10 +367,11 @@ func generateBuildctlArgs(ctx context.Context, client *containerd.Client, option
 			var err error
 			dir, err = buildkitutil.WriteTempDockerfile(options.Stdin)
 			if err != nil {
-				return "", nil, false, "", nil, nil, err
+				return bc, err
 			}
-			cleanup = func() {
-				os.RemoveAll(dir)
+			tmpDir := dir
+			bc.cleanup = func() {
+				os.RemoveAll(tmpDir)
 			}
 		} else {
 			dir, file = filepath.Split(options.File)
@@ -288,12 +383,12 @@ func generateBuildctlArgs(ctx context.Context, client *containerd.Client, option
 	}
 	dir, file, err = buildkitutil.BuildKitFile(dir, file)
 	if err != nil {
-		return "", nil, false, "", nil, nil, err
+		return bc, err
 	}
 
 	buildCtx, err := parseContextNames(options.ExtendedBuildContext)
 	if err != nil {
-		return "", nil, false, "", nil, nil, err
+		return bc, err
 	}
 
 	for k, v := range buildCtx {
@@ -301,37 +396,47 @@ func generateBuildctlArgs(ctx context.Context, client *containerd.Client, option
 		isDockerImage := strings.HasPrefix(v, "docker-image://") || strings.HasPrefix(v, "target:")
 
 		if isURL || isDockerImage {
-			buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=context:%s=%s", k, v))
+			solveOpt.FrontendAttrs["context:"+k] = v
 			continue
 		}
 
 		if isOCILayout := strings.HasPrefix(v, "oci-layout://"); isOCILayout {
-			args, err := parseBuildContextFromOCILayout(k, v)
+			layout, err := parseBuildContextFromOCILayout(k, v)
 			if err != nil {
-				return "", nil, false, "", nil, nil, err
+				return bc, err
 			}
-
-			buildctlArgs = append(buildctlArgs, args...)
+			store, err := local.NewStore(layout.Path)
+			if err != nil {
+				return bc, fmt.Errorf("failed to open the OCI layout %s: %w", layout.Path, err)
+			}
+			solveOpt.OCIStores[layout.StoreID] = store
+			solveOpt.FrontendAttrs["context:"+k] = fmt.Sprintf("oci-layout:%s@%s", layout.StoreID, layout.Digest)
 			continue
 		}
 
 		path, err := filepath.Abs(v)
 		if err != nil {
-			return "", nil, false, "", nil, nil, err
+			return bc, err
 		}
-		buildctlArgs = append(buildctlArgs, fmt.Sprintf("--local=%s=%s", k, path))
-		buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=context:%s=local:%s", k, k))
+		solveOpt.LocalMounts[k], err = fsutil.NewFS(path)
+		if err != nil {
+			return bc, err
+		}
+		solveOpt.FrontendAttrs["context:"+k] = "local:" + k
 	}
 
-	buildctlArgs = append(buildctlArgs, "--local=dockerfile="+dir)
-	buildctlArgs = append(buildctlArgs, "--opt=filename="+file)
+	solveOpt.LocalMounts["dockerfile"], err = fsutil.NewFS(dir)
+	if err != nil {
+		return bc, err
+	}
+	solveOpt.FrontendAttrs["filename"] = file
 
 	if options.Target != "" {
-		buildctlArgs = append(buildctlArgs, "--opt=target="+options.Target)
+		solveOpt.FrontendAttrs["target"] = options.Target
 	}
 
 	if len(options.Platform) > 0 {
-		buildctlArgs = append(buildctlArgs, "--opt=platform="+strings.Join(options.Platform, ","))
+		solveOpt.FrontendAttrs["platform"] = strings.Join(options.Platform, ",")
 	}
 
 	seenBuildArgs := make(map[string]struct{})
@@ -343,28 +448,28 @@ func generateBuildctlArgs(ctx context.Context, client *containerd.Client, option
 			// https://github.com/moby/moby/issues/24101
 			val, ok := os.LookupEnv(arr[0])
 			if ok {
-				buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=build-arg:%s=%s", ba, val))
+				solveOpt.FrontendAttrs["build-arg:"+ba] = val
 			} else {
 				log.L.Debugf("ignoring unset build arg %q", ba)
 			}
 		} else if len(arr) > 1 && len(arr[0]) > 0 {
-			buildctlArgs = append(buildctlArgs, "--opt=build-arg:"+ba)
+			key, val, _ := strings.Cut(ba, "=")
+			solveOpt.FrontendAttrs["build-arg:"+key] = val
 
 			// Support `--build-arg BUILDKIT_INLINE_CACHE=1` for compatibility with `docker buildx build`
 			// https://github.com/docker/buildx/blob/v0.6.3/docs/reference/buildx_build.md#-export-build-cache-to-an-external-cache-destination---cache-to
-			if strings.HasPrefix(ba, "BUILDKIT_INLINE_CACHE=") {
-				bic := strings.TrimPrefix(ba, "BUILDKIT_INLINE_CACHE=")
-				bicParsed, err := strconv.ParseBool(bic)
+			if key == "BUILDKIT_INLINE_CACHE" {
+				bicParsed, err := strconv.ParseBool(val)
 				if err == nil {
 					if bicParsed {
-						buildctlArgs = append(buildctlArgs, "--export-cache=type=inline")
+						solveOpt.CacheExports = append(solveOpt.CacheExports, bkclient.CacheOptionsEntry{Type: "inline", Attrs: map[string]string{}})
 					}
 				} else {
-					log.L.WithError(err).Warnf("invalid BUILDKIT_INLINE_CACHE: %q", bic)
+					log.L.WithError(err).Warnf("invalid BUILDKIT_INLINE_CACHE: %q", val)
 				}
 			}
 		} else {
-			return "", nil, false, "", nil, nil, fmt.Errorf("invalid build arg %q", ba)
+			return bc, fmt.Errorf("invalid build arg %q", ba)
 		}
 	}
 
@@ -372,83 +477,106 @@ func generateBuildctlArgs(ctx context.Context, client *containerd.Client, option
 	// https://github.com/docker/buildx/pull/1482
 	if v := os.Getenv("SOURCE_DATE_EPOCH"); v != "" {
 		if _, ok := seenBuildArgs["SOURCE_DATE_EPOCH"]; !ok {
-			buildctlArgs = append(buildctlArgs, "--opt=build-arg:SOURCE_DATE_EPOCH="+v)
+			solveOpt.FrontendAttrs["build-arg:SOURCE_DATE_EPOCH"] = v
 		}
 	}
 
 	for _, l := range strutil.DedupeStrSlice(options.Label) {
-		buildctlArgs = append(buildctlArgs, "--opt=label:"+l)
+		key, val, _ := strings.Cut(l, "=")
+		solveOpt.FrontendAttrs["label:"+key] = val
 	}
 
 	if options.NoCache {
-		buildctlArgs = append(buildctlArgs, "--no-cache")
+		solveOpt.FrontendAttrs["no-cache"] = ""
 	}
 
 	if options.Pull != nil {
 		switch *options.Pull {
 		case true:
-			buildctlArgs = append(buildctlArgs, "--opt=image-resolve-mode=pull")
+			solveOpt.FrontendAttrs["image-resolve-mode"] = "pull"
 		case false:
-			buildctlArgs = append(buildctlArgs, "--opt=image-resolve-mode=local")
+			solveOpt.FrontendAttrs["image-resolve-mode"] = "local"
 		}
 	}
 
-	for _, s := range strutil.DedupeStrSlice(options.Secret) {
-		buildctlArgs = append(buildctlArgs, "--secret="+s)
+	// the registry credentials are provided to BuildKit by the client, as `buildctl` does
+	solveOpt.Session = append(solveOpt.Session, authprovider.NewDockerAuthProvider(authprovider.DockerAuthProviderConfig{
+		ConfigFile: dockerconfig.LoadDefaultConfigFile(options.Stderr),
+	}))
+
+	if secrets := strutil.DedupeStrSlice(options.Secret); len(secrets) > 0 {
+		secretProvider, err := bkbuild.ParseSecret(secrets)
+		if err != nil {
+			return bc, err
+		}
+		solveOpt.Session = append(solveOpt.Session, secretProvider)
 	}
 
-	for _, s := range strutil.DedupeStrSlice(options.Allow) {
-		buildctlArgs = append(buildctlArgs, "--allow="+s)
+	allow := strutil.DedupeStrSlice(options.Allow)
+	if err := bkbuild.ValidateAllow(allow); err != nil {
+		return bc, err
 	}
+	solveOpt.AllowedEntitlements = append(solveOpt.AllowedEntitlements, allow...)
 
 	for _, s := range strutil.DedupeStrSlice(options.Attest) {
 		optAttestType, optAttestAttrs, _ := strings.Cut(s, ",")
 		if strings.HasPrefix(optAttestType, "type=") {
 			optAttestType := strings.TrimPrefix(optAttestType, "type=")
-			buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=attest:%s=%s", optAttestType, optAttestAttrs))
+			solveOpt.FrontendAttrs["attest:"+optAttestType] = optAttestAttrs
 		} else {
-			return "", nil, false, "", nil, nil, fmt.Errorf("attestation type not specified")
+			return bc, fmt.Errorf("attestation type not specified")
 		}
 	}
 
-	for _, s := range strutil.DedupeStrSlice(options.SSH) {
-		buildctlArgs = append(buildctlArgs, "--ssh="+s)
+	if ssh := strutil.DedupeStrSlice(options.SSH); len(ssh) > 0 {
+		configs, err := bkbuild.ParseSSH(ssh)
+		if err != nil {
+			return bc, err
+		}
+		sshProvider, err := sshprovider.NewSSHAgentProvider(configs)
+		if err != nil {
+			return bc, err
+		}
+		solveOpt.Session = append(solveOpt.Session, sshProvider)
 	}
 
+	var cacheFrom []string
 	for _, s := range strutil.DedupeStrSlice(options.CacheFrom) {
 		if !strings.Contains(s, "type=") {
 			s = "type=registry,ref=" + s
 		}
-		buildctlArgs = append(buildctlArgs, "--import-cache="+s)
+		cacheFrom = append(cacheFrom, s)
+	}
+	cacheImports, err := bkbuild.ParseImportCache(cacheFrom)
+	if err != nil {
+		return bc, err
 	}
+	solveOpt.CacheImports = cacheImports
 
+	var cacheTo []string
 	for _, s := range strutil.DedupeStrSlice(options.CacheTo) {
 		if !strings.Contains(s, "type=") {
 			s = "type=registry,ref=" + s
 		}
-		buildctlArgs = append(buildctlArgs, "--export-cache="+s)
+		cacheTo = append(cacheTo, s)
+	}
+	cacheExports, err := bkbuild.ParseExportCache(cacheTo)
+	if err != nil {
+		return bc, err
 	}
+	solveOpt.CacheExports = append(solveOpt.CacheExports, cacheExports...)
 
 	if !options.Rm {
 		log.L.Warn("ignoring deprecated flag: '--rm=false'")
 	}
 
-	if options.IidFile != "" {
-		file, err := os.CreateTemp("", "buildkit-meta-*")
-		if err != nil {
-			return "", nil, false, "", nil, cleanup, err
-		}
-		defer file.Close()
-		metaFile = file.Name()
-		buildctlArgs = append(buildctlArgs, "--metadata-file="+metaFile)
-	}
-
 	if options.NetworkMode != "" {
 		switch options.NetworkMode {
 		case "none":
-			buildctlArgs = append(buildctlArgs, "--opt=force-network-mode="+options.NetworkMode)
+			solveOpt.FrontendAttrs["force-network-mode"] = options.NetworkMode
 		case "host":
-			buildctlArgs = append(buildctlArgs, "--opt=force-network-mode="+options.NetworkMode, "--allow=network.host", "--allow=security.insecure")
+			solveOpt.FrontendAttrs["force-network-mode"] = options.NetworkMode
+			solveOpt.AllowedEntitlements = append(solveOpt.AllowedEntitlements, "network.host", "security.insecure")
 		case "", "default":
 		default:
 			log.L.Debugf("ignoring network build arg %s", options.NetworkMode)
@@ -458,36 +586,81 @@ func generateBuildctlArgs(ctx context.Context, client *containerd.Client, option
 	if len(options.ExtraHosts) > 0 {
 		extraHosts, err := containerutil.ParseExtraHosts(options.ExtraHosts, options.GOptions.HostGatewayIP, "=")
 		if err != nil {
-			return "", nil, false, "", nil, nil, err
+			return bc, err
 		}
-		buildctlArgs = append(buildctlArgs, "--opt=add-hosts="+strings.Join(extraHosts, ","))
+		solveOpt.FrontendAttrs["add-hosts"] = strings.Join(extraHosts, ",")
 	}
 
-	return buildctlBinary, buildctlArgs, needsLoading, metaFile, tags, cleanup, nil
+	return bc, nil
 }
 
-func getDigestFromMetaFile(path string) (string, error) {
-	data, err := filesystem.ReadFile(path)
+// parseOutput parses the value of `--output` into a BuildKit export entry.
+// needsLoading is true when the exported image archive has to be loaded into containerd.
+func parseOutput(output string, stdout io.Writer) (entry bkclient.ExportEntry, needsLoading bool, err error) {
+	if !strings.Contains(output, "type=") {
+		// should accept --output <DIR> as an alias of --output
+		// type=local,dest=<DIR>
+		return bkclient.ExportEntry{Type: bkclient.ExporterLocal, Attrs: map[string]string{}, OutputDir: output}, false, nil
+	}
+	attrs, err := strutil.ParseCSVMap(output)
 	if err != nil {
-		return "", err
+		return entry, false, err
+	}
+	entry.Type = attrs["type"]
+	dest := attrs["dest"]
+	delete(attrs, "type")
+	delete(attrs, "dest")
+	entry.Attrs = attrs
+
+	switch entry.Type {
+	case bkclient.ExporterLocal:
+		if dest == "" {
+			return entry, false, fmt.Errorf("output directory is required for %s exporter", entry.Type)
+		}
+		entry.OutputDir = dest
+	case bkclient.ExporterTar, bkclient.ExporterDocker, bkclient.ExporterOCI:
+		if entry.Type != bkclient.ExporterTar {
+			if tar, err := strconv.ParseBool(attrs["tar"]); err == nil && !tar {
+				if dest == "" {
+					return entry, false, fmt.Errorf("output directory is required for %s exporter with tar=false", entry.Type)
+				}
+				entry.OutputDir = dest
+				return entry, false, nil
+			}
+		}
+		switch dest {
+		case "":
+			if entry.Type != bkclient.ExporterTar {
+				return entry, true, nil
+			}
+			entry.Output = stdoutOutput(stdout)
+		case "-":
+			entry.Output = stdoutOutput(stdout)
+		default:
+			entry.Output = func(map[string]string) (io.WriteCloser, error) {
+				return os.Create(dest)
+			}
+		}
+	default:
+		if dest != "" {
+			return entry, false, fmt.Errorf("output %s is not supported by %s exporter", dest, entry.Type)
+		}
 	}
-	defer os.Remove(path)
+	return entry, false, nil
+}
 
-	metadata := map[string]json.RawMessage{}
-	if err := json.Unmarshal(data, &metadata); err != nil {
-		log.L.WithError(err).Errorf("failed to unmarshal metadata file %s", path)
-		return "", err
-	}
-	digestRaw, ok := metadata["containerimage.digest"]
-	if !ok {
-		return "", errors.New("failed to find containerimage.digest in metadata file")
-	}
-	var digest string
-	if err := json.Unmarshal(digestRaw, &digest); err != nil {
-		log.L.WithError(err).Errorf("failed to unmarshal digset")
-		return "", err
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func stdoutOutput(stdout io.Writer) func(map[string]string) (io.WriteCloser, error) {
+	return func(map[string]string) (io.WriteCloser, error) {
+		return nopWriteCloser{stdout}, nil
 	}
-	return digest, nil
 }
 
 func isMatchingRuntimePlatform(platform string, parser PlatformParser) bool {
@@ -513,8 +686,8 @@ func isBuildPlatformDefault(platform []string, parser PlatformParser) bool {
 	return false
 }
 
-func isImageSharable(buildkitHost, namespace, uuid, snapshotter string, platform []string) (bool, error) {
-	labels, err := buildkitutil.GetWorkerLabels(buildkitHost)
+func isImageSharable(ctx context.Context, buildkitHost, namespace, uuid, snapshotter string, platform []string) (bool, error) {
+	labels, err := buildkitutil.GetWorkerLabels(ctx, buildkitHost)
 	if err != nil {
 		return false, err
 	}
@@ -563,20 +736,28 @@ var (
 	ErrOCILayoutEmptyDigest    = errors.New("OCI layout cannot have empty digest")
 )
 
-func parseBuildContextFromOCILayout(name, path string) ([]string, error) {
+// ociLayoutContext is a named build context read from an OCI layout directory.
+type ociLayoutContext struct {
+	// StoreID is the key of the layout in SolveOpt.OCIStores
+	StoreID string
+	Path    string
+	Digest  string
+}
+
+func parseBuildContextFromOCILayout(name, path string) (*ociLayoutContext, error) {
 	path, found := strings.CutPrefix(path, "oci-layout://")
 	if !found {
-		return []string{}, ErrOCILayoutPrefixNotFound
+		return nil, ErrOCILayoutPrefixNotFound
 	}
 
 	abspath, err := filepath.Abs(path)
 	if err != nil {
-		return []string{}, err
+		return nil, err
 	}
 
 	ociIndex, err := readOCIIndexFromPath(abspath)
 	if err != nil {
-		return []string{}, err
+		return nil, err
 	}
 
 	var digest string
@@ -587,12 +768,14 @@ func parseBuildContextFromOCILayout(name, path string) ([]string, error) {
 	}
 
 	if digest == "" {
-		return []string{}, ErrOCILayoutEmptyDigest
+		return nil, ErrOCILayoutEmptyDigest
 	}
 
-	return []string{
-		fmt.Sprintf("--oci-layout=parent-image-key=%s", abspath),
-		fmt.Sprintf("--opt=context:%s=oci-layout:parent-image-key@%s", name, digest),
+	return &ociLayoutContext{
+		// each context gets its own store, so that several layouts can be used in a build
+		StoreID: "oci-layout-" + name,
+		Path:    abspath,
+		Digest:  digest,
 	}, nil
 }
 
@@ -191,12 +191,11 @@ func TestIsBuildPlatformDefault(t *testing.T) {
 	}
 }
 
-func TestParseBuildctlArgsForOCILayout(t *testing.T) {
+func TestParseBuildContextFromOCILayout(t *testing.T) {
 	tests := []struct {
 		name          string
 		ociLayoutName string
 		ociLayoutPath string
-		expectedArgs  []string
 		errorIsNil    bool
 		expectedErr   string
 	}{
@@ -204,14 +203,12 @@ func TestParseBuildctlArgsForOCILayout(t *testing.T) {
 			name:          "PrefixNotFoundError",
 			ociLayoutName: "unit-test",
 			ociLayoutPath: "/tmp/oci-layout/",
-			expectedArgs:  []string{},
 			expectedErr:   ErrOCILayoutPrefixNotFound.Error(),
 		},
 		{
 			name:          "DirectoryNotFoundError",
 			ociLayoutName: "unit-test",
 			ociLayoutPath: "oci-layout:///tmp/oci-layout",
-			expectedArgs:  []string{},
 			expectedErr:   "open /tmp/oci-layout/index.json: no such file or directory",
 		},
 	}
@@ -227,14 +224,44 @@ func TestParseBuildctlArgsForOCILayout(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			args, err := parseBuildContextFromOCILayout(test.ociLayoutName, test.ociLayoutPath)
+			layout, err := parseBuildContextFromOCILayout(test.ociLayoutName, test.ociLayoutPath)
 			if test.errorIsNil {
 				assert.NilError(t, err)
 			} else {
 				assert.Error(t, err, test.expectedErr)
+				assert.Assert(t, layout == nil)
 			}
-			assert.Equal(t, len(args), len(test.expectedArgs))
-			assert.DeepEqual(t, args, test.expectedArgs)
 		})
 	}
 }
+
+func TestParseOutput(t *testing.T) {
+	t.Parallel()
+
+	entry, needsLoading, err := parseOutput("/tmp/out", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, entry.Type, "local")
+	assert.Equal(t, entry.OutputDir, "/tmp/out")
+	assert.Assert(t, !needsLoading)
+
+	entry, needsLoading, err = parseOutput("type=docker", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, entry.Type, "docker")
+	assert.Assert(t, needsLoading)
+
+	entry, needsLoading, err = parseOutput("type=oci,dest=/tmp/out.tar,oci-mediatypes=true", nil)
+	assert.NilError(t, err)
+	assert.Assert(t, entry.Output != nil)
+	assert.DeepEqual(t, entry.Attrs, map[string]string{"oci-mediatypes": "true"})
+	assert.Assert(t, !needsLoading)
+
+	entry, _, err = parseOutput("type=oci,tar=false,dest=/tmp/out", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, entry.OutputDir, "/tmp/out")
+
+	_, _, err = parseOutput("type=local", nil)
+	assert.ErrorContains(t, err, "output directory is required")
+
+	_, _, err = parseOutput("type=image,dest=/tmp/out", nil)
+	assert.ErrorContains(t, err, "not supported by image exporter")
+}
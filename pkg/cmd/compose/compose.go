@@ -30,6 +30,8 @@ import (
 	"github.com/containerd/platforms"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/clientutil"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/container"
 	"github.com/containerd/nerdctl/v2/pkg/cmd/volume"
 	"github.com/containerd/nerdctl/v2/pkg/composer"
 	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
@@ -88,6 +90,16 @@ func New(client *containerd.Client, globalOptions types.GlobalCommandOptions, op
 	// FIXME: this is racy. See note in up_volume.go
 	options.VolumeExists = volStore.Exists
 
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+	options.FileObjectsDir = filepath.Join(dataStore, "compose", globalOptions.Namespace)
+	options.ToHostUser = func(uid, gid uint32) (uint32, uint32, error) {
+		u, err := container.RemappedUser(globalOptions.UsernsRemap, container.User{Uid: uid, Gid: gid})
+		return u.Uid, u.Gid, err
+	}
+
 	options.ImageExists = func(ctx context.Context, rawRef string) (bool, error) {
 		parsedReference, err := referenceutil.Parse(rawRef)
 		if err != nil {
@@ -142,8 +154,11 @@ func New(client *containerd.Client, globalOptions types.GlobalCommandOptions, op
 				}
 				ipfsPath = dir
 			}
-			_, err = ipfs.EnsureImage(ctx, client, string(parsedReference.Protocol), parsedReference.String(), ipfsPath, imgPullOpts)
-			return err
+			ensured, err := ipfs.EnsureImage(ctx, client, string(parsedReference.Protocol), parsedReference.String(), ipfsPath, imgPullOpts)
+			if err != nil {
+				return err
+			}
+			return imgutil.CheckPolicy(ctx, ensured, globalOptions)
 		}
 
 		imageVerifyOptions := imageVerifyOptionsFromCompose(ps)
@@ -51,6 +51,7 @@ func Commit(ctx context.Context, client *containerd.Client, rawRef string, req s
 		Changes:            changes,
 		Compression:        options.Compression,
 		Format:             options.Format,
+		Squash:             options.Squash,
 		EstargzOptions:     options.EstargzOptions,
 		ZstdChunkedOptions: options.ZstdChunkedOptions,
 	}
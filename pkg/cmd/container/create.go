@@ -29,6 +29,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	dockercliopts "github.com/docker/cli/opts"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -128,6 +129,12 @@ func Create(ctx context.Context, client *containerd.Client, args []string, netMa
 	}
 	opts = append(opts, platformOpts...)
 
+	selinuxOpts, err := generateSELinuxOpts(dataStore, &internalLabels, options)
+	if err != nil {
+		return nil, generateRemoveStateDirFunc(ctx, id, internalLabels), err
+	}
+	opts = append(opts, selinuxOpts...)
+
 	opts = append(opts, withCDIDevices(options.GOptions.CDISpecDirs, options.CDIDevices...))
 
 	if _, err := referenceutil.Parse(args[0]); errors.Is(err, referenceutil.ErrLoadOCIArchiveRequired) {
@@ -183,6 +190,10 @@ func Create(ctx context.Context, client *containerd.Client, args []string, netMa
 		if err != nil {
 			return nil, generateRemoveStateDirFunc(ctx, id, internalLabels), err
 		}
+		// for the retention policies of `nerdctl image prune --policy`
+		if err := imgutil.MarkUsed(ctx, client.ImageService(), ensuredImage.Image.Name(), time.Now()); err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to record the last use of image %q", ensuredImage.Image.Name())
+		}
 	}
 
 	if ensuredImage != nil && ensuredImage.ImageConfig.User != "" {
@@ -250,7 +261,7 @@ func Create(ctx context.Context, client *containerd.Client, args []string, netMa
 	}
 
 	var mountOpts []oci.SpecOpts
-	mountOpts, internalLabels.anonVolumes, internalLabels.mountPoints, err = generateMountOpts(ctx, client, ensuredImage, volStore, options)
+	mountOpts, internalLabels.anonVolumes, internalLabels.mountPoints, internalLabels.imageMounts, err = generateMountOpts(ctx, client, id, ensuredImage, volStore, internalLabels.mountLabel, options)
 	if err != nil {
 		return nil, generateRemoveStateDirFunc(ctx, id, internalLabels), err
 	}
@@ -705,6 +716,8 @@ type internalLabels struct {
 	// volume
 	mountPoints []*mountutil.Processed
 	anonVolumes []string
+	// the snapshots of the image mounts, by the labels referencing them
+	imageMounts map[string]string
 	// pid namespace
 	pidContainer string
 	// ipc namespace & dev/shm
@@ -724,6 +737,12 @@ type internalLabels struct {
 	// label for device mapping set by the --device flag
 	deviceMapping []dockercompat.DeviceMapping
 
+	// label for the device cgroup rules set by the --device-cgroup-rule flag
+	deviceCgroupRules []string
+
+	// SELinux mount label, for relabeling the mounts with the z and Z options (not stored as a label)
+	mountLabel string
+
 	user string
 
 	healthcheck string
@@ -764,6 +783,9 @@ func withInternalLabels(internalLabels internalLabels) (containerd.NewContainerO
 		}
 		m[labels.AnonymousVolumes] = string(anonVolumeJSON)
 	}
+	for label, key := range internalLabels.imageMounts {
+		m[label] = key
+	}
 
 	if internalLabels.pidFile != "" {
 		m[labels.PIDFile] = internalLabels.pidFile
@@ -827,6 +849,10 @@ func withInternalLabels(internalLabels internalLabels) (containerd.NewContainerO
 		hostConfigLabel.Devices = append(hostConfigLabel.Devices, internalLabels.deviceMapping...)
 	}
 
+	if len(internalLabels.deviceCgroupRules) > 0 {
+		hostConfigLabel.DeviceCgroupRules = internalLabels.deviceCgroupRules
+	}
+
 	hostConfigJSON, err := json.Marshal(hostConfigLabel)
 	if err != nil {
 		return nil, err
@@ -1043,6 +1069,7 @@ func generateLogConfig(dataStore string, id string, logDriver string, logOpt []s
 
 func generateRemoveStateDirFunc(ctx context.Context, id string, internalLabels internalLabels) func() {
 	return func() {
+		unloadAppArmorProfile(ctx, id, internalLabels.stateDir)
 		if rmErr := os.RemoveAll(internalLabels.stateDir); rmErr != nil {
 			log.G(ctx).WithError(rmErr).Warnf("failed to remove container %q state dir %q", id, internalLabels.stateDir)
 		}
@@ -1051,6 +1078,7 @@ func generateRemoveStateDirFunc(ctx context.Context, id string, internalLabels i
 
 func generateRemoveOrphanedDirsFunc(ctx context.Context, id, dataStore string, internalLabels internalLabels) func() {
 	return func() {
+		unloadAppArmorProfile(ctx, id, internalLabels.stateDir)
 		if rmErr := os.RemoveAll(internalLabels.stateDir); rmErr != nil {
 			log.G(ctx).WithError(rmErr).Warnf("failed to remove container %q state dir %q", id, internalLabels.stateDir)
 		}
@@ -1091,6 +1119,7 @@ func generateGcFunc(ctx context.Context, container containerd.Container, ns, id,
 		if ipcErr := ipcutil.CleanUp(ipc); ipcErr != nil {
 			log.G(ctx).WithError(ipcErr).Warnf("failed to clean up ipc for container %q", id)
 		}
+		unloadAppArmorProfile(ctx, id, internalLabels.stateDir)
 		if rmErr := os.RemoveAll(internalLabels.stateDir); rmErr != nil {
 			log.G(ctx).WithError(rmErr).Warnf("failed to remove container %q state dir %q", id, internalLabels.stateDir)
 		}
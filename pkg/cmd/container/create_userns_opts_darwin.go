@@ -18,6 +18,7 @@ package container
 
 import (
 	"context"
+	"errors"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/pkg/oci"
@@ -44,3 +45,12 @@ func getContainerUserNamespaceNetOpts(
 ) ([]oci.SpecOpts, error) {
 	return []oci.SpecOpts{}, nil
 }
+
+// RemappedUser returns the host user for the container user pair under the
+// userns-remap specification userNS. The pair is returned as is when userNS is empty or "host".
+func RemappedUser(userNS string, pair User) (User, error) {
+	if userNS == "" || userNS == "host" {
+		return pair, nil
+	}
+	return invalidUser, errors.New("userns-remap is only supported on linux")
+}
@@ -426,6 +426,21 @@ func lookupGroupSubRangesFile(path string, grp user.Group) ([]IDMap, error) {
 	return idMap, nil
 }
 
+// RemappedUser returns the host user for the container user pair under the
+// userns-remap specification userNS. The pair is returned as is when userNS is empty or "host".
+func RemappedUser(userNS string, pair User) (User, error) {
+	if userNS == "" || userNS == "host" {
+		return pair, nil
+	}
+	idMapping, err := loadAndValidateIDMapping(userNS)
+	if err != nil {
+		return invalidUser, err
+	}
+	uidMaps, gidMaps := convertMappings(idMapping)
+	idMap := ContainerdIDMap{UidMap: uidMaps, GidMap: gidMaps}
+	return idMap.ToHost(pair)
+}
+
 // Loads and validates the ID mapping from the given UserNS.
 func loadAndValidateIDMapping(userNS string) (IdentityMapping, error) {
 	idMapping, err := LoadIdentityMapping(userNS)
@@ -32,6 +32,7 @@ import (
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/consoleutil"
+	"github.com/containerd/nerdctl/v2/pkg/errutil"
 	"github.com/containerd/nerdctl/v2/pkg/flagutil"
 	"github.com/containerd/nerdctl/v2/pkg/idgen"
 	"github.com/containerd/nerdctl/v2/pkg/idutil/containerwalker"
@@ -60,7 +61,7 @@ func Exec(ctx context.Context, client *containerd.Client, args []string, options
 	return nil
 }
 
-func execActionWithContainer(ctx context.Context, client *containerd.Client, container containerd.Container, args []string, options types.ContainerExecOptions) error {
+func execActionWithContainer(ctx context.Context, client *containerd.Client, container containerd.Container, args []string, options types.ContainerExecOptions) (retErr error) {
 	pspec, err := generateExecProcessSpec(ctx, client, container, args, options)
 	if err != nil {
 		return err
@@ -70,6 +71,12 @@ func execActionWithContainer(ctx context.Context, client *containerd.Client, con
 	if err != nil {
 		return err
 	}
+	started := false
+	defer func() {
+		if !started {
+			retErr = errutil.NewStartError(retErr)
+		}
+	}()
 	var (
 		ioCreator cio.Creator
 		in        io.Reader
@@ -130,6 +137,7 @@ func execActionWithContainer(ctx context.Context, client *containerd.Client, con
 	if err := process.Start(ctx); err != nil {
 		return err
 	}
+	started = true
 	if options.Detach {
 		return nil
 	}
@@ -143,7 +151,7 @@ func execActionWithContainer(ctx context.Context, client *containerd.Client, con
 		return err
 	}
 	if code != 0 {
-		return fmt.Errorf("exec failed with exit code %d", code)
+		return errutil.NewExitCoderErr(int(code))
 	}
 	return nil
 }
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
@@ -54,3 +55,32 @@ func Prune(ctx context.Context, client *containerd.Client, options types.Contain
 
 	return nil
 }
+
+// PruneCandidates returns the containers which Prune would remove, i.e., the containers which are not running or paused.
+func PruneCandidates(ctx context.Context, client *containerd.Client) ([]containerd.Container, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []containerd.Container
+	for _, c := range containers {
+		task, err := c.Task(ctx, nil)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				candidates = append(candidates, c)
+				continue
+			}
+			return nil, err
+		}
+		status, err := task.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch status.Status {
+		case containerd.Created, containerd.Stopped:
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
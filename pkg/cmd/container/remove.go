@@ -139,6 +139,7 @@ func RemoveContainer(ctx context.Context, c containerd.Container, globalOptions
 		retErr = errors.Join(lf.Release(), retErr)
 		// Note: technically, this is racy...
 		if retErr == nil {
+			unloadAppArmorProfile(ctx, c.ID(), containerLabels[labels.StateDir])
 			retErr = os.RemoveAll(containerLabels[labels.StateDir])
 		}
 	}()
@@ -250,6 +251,9 @@ func RemoveContainer(ctx context.Context, c containerd.Container, globalOptions
 			log.G(ctx).WithError(err).Warnf("failed to remove hosts file for container %q", id)
 		}
 
+		// Remove the snapshots of the image mounts - soft failure
+		removeImageMountSnapshots(ctx, client, containerLabels)
+
 		// Volume removal is not handled by the poststop hook lifecycle because it depends on removeAnonVolumes option
 		// Note that the anonymous volume list has been obtained earlier, without locking the volume store.
 		// Technically, a concurrent operation MAY have deleted these anonymous volumes already at this point, which
@@ -0,0 +1,75 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd/v2/contrib/apparmor"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/apparmorutil"
+)
+
+// generateAppArmorPolicyOpts generates the AppArmor profile of the container from the policy file of
+// `--security-opt apparmor-policy=FILE`, stores it in the state directory of the container and loads it.
+// The profile is unloaded when the container is removed.
+func generateAppArmorPolicyOpts(id, stateDir string, securityOptsMap map[string]string) ([]oci.SpecOpts, error) {
+	policyPath, ok := securityOptsMap["apparmor-policy"]
+	if !ok {
+		return nil, nil
+	}
+	if policyPath == "" {
+		return nil, errors.New("invalid security-opt \"apparmor-policy\"")
+	}
+	policy, err := apparmorutil.LoadPolicy(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	if !apparmorutil.CanApplyExistingProfile() {
+		log.L.Warnf("the host does not support AppArmor. Ignoring policy %q", policyPath)
+		return nil, nil
+	}
+	if !apparmorutil.CanLoadNewProfile() {
+		return nil, fmt.Errorf("loading the AppArmor profile generated from policy %q needs the root", policyPath)
+	}
+	name := apparmorutil.ContainerProfileName(id)
+	var profile bytes.Buffer
+	if err := apparmorutil.GenerateProfile(&profile, apparmorutil.NewProfileData(name, *policy)); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, apparmorutil.ProfileFileName), profile.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	if err := apparmorutil.LoadProfile(profile.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to load the AppArmor profile generated from policy %q: %w", policyPath, err)
+	}
+	return []oci.SpecOpts{apparmor.WithProfile(name)}, nil
+}
+
+// unloadAppArmorProfile unloads the AppArmor profile generated for the container, if any.
+func unloadAppArmorProfile(ctx context.Context, id, stateDir string) {
+	if err := apparmorutil.UnloadContainerProfile(stateDir, id); err != nil {
+		log.G(ctx).WithError(err).Warnf("failed to unload the AppArmor profile of container %q", id)
+	}
+}
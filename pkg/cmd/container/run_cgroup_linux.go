@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/docker/go-units"
@@ -224,6 +225,18 @@ func generateCgroupOpts(id string, options types.ContainerCreateOptions, interna
 		internalLabels.deviceMapping = append(internalLabels.deviceMapping, deviceMap)
 	}
 
+	if len(options.DeviceCgroupRules) > 0 {
+		rules := make([]specs.LinuxDeviceCgroup, len(options.DeviceCgroupRules))
+		for i, r := range options.DeviceCgroupRules {
+			rules[i], err = ParseDeviceCgroupRule(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, withDeviceCgroupRules(rules))
+		internalLabels.deviceCgroupRules = options.DeviceCgroupRules
+	}
+
 	return opts, nil
 }
 
@@ -300,6 +313,59 @@ func ParseDevice(s string) (hostDevPath string, containerPath string, mode strin
 	return hostDevPath, containerDevPath, mode, nil
 }
 
+// ParseDeviceCgroupRule parses a device cgroup rule formatted as "TYPE MAJOR:MINOR ACCESS", e.g., "c 1:3 mr" or "a *:* rwm".
+func ParseDeviceCgroupRule(s string) (specs.LinuxDeviceCgroup, error) {
+	rule := specs.LinuxDeviceCgroup{Allow: true}
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return rule, fmt.Errorf("invalid device cgroup rule %q: must be formatted as \"TYPE MAJOR:MINOR ACCESS\"", s)
+	}
+	switch fields[0] {
+	case "a", "b", "c":
+		rule.Type = fields[0]
+	default:
+		return rule, fmt.Errorf("invalid device cgroup rule %q: unknown device type %q", s, fields[0])
+	}
+	major, minor, ok := strings.Cut(fields[1], ":")
+	if !ok {
+		return rule, fmt.Errorf("invalid device cgroup rule %q: device numbers must be formatted as \"MAJOR:MINOR\"", s)
+	}
+	var err error
+	if rule.Major, err = parseDeviceNumber(major); err != nil {
+		return rule, fmt.Errorf("invalid device cgroup rule %q: %w", s, err)
+	}
+	if rule.Minor, err = parseDeviceNumber(minor); err != nil {
+		return rule, fmt.Errorf("invalid device cgroup rule %q: %w", s, err)
+	}
+	if err := validateDeviceMode(fields[2]); err != nil {
+		return rule, fmt.Errorf("invalid device cgroup rule %q: %w", s, err)
+	}
+	rule.Access = fields[2]
+	return rule, nil
+}
+
+// parseDeviceNumber parses a major or minor device number, or "*" for any number (nil).
+func parseDeviceNumber(s string) (*int64, error) {
+	if s == "*" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid device number %q", s)
+	}
+	return &n, nil
+}
+
+func withDeviceCgroupRules(rules []specs.LinuxDeviceCgroup) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		s.Linux.Resources.Devices = append(s.Linux.Resources.Devices, rules...)
+		return nil
+	}
+}
+
 func validateDeviceMode(mode string) error {
 	for _, r := range mode {
 		switch r {
@@ -21,14 +21,12 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/moby/sys/userns"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/contrib/nvidia"
 	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/pkg/oci"
-	"github.com/containerd/log"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/bypass4netnsutil"
@@ -71,13 +69,19 @@ func setPlatformOptions(ctx context.Context, client *containerd.Client, id, uts
 		return nil, err
 	}
 	opts = append(opts, capOpts...)
-	securityOptsMaps := strutil.ConvertKVStringsToMap(strutil.DedupeStrSlice(options.SecurityOpt))
+	securityOptsMaps := strutil.ConvertKVStringsToMap(joinSeccompOverrides(strutil.DedupeStrSlice(options.SecurityOpt)))
 	secOpts, err := generateSecurityOpts(options.Privileged, securityOptsMaps)
 	if err != nil {
 		return nil, err
 	}
 	opts = append(opts, secOpts...)
 
+	aaPolicyOpts, err := generateAppArmorPolicyOpts(id, internalLabels.stateDir, securityOptsMaps)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, aaPolicyOpts...)
+
 	b4nnOpts, err := bypass4netnsutil.GenerateBypass4netnsOpts(securityOptsMaps, annotations, id)
 	if err != nil {
 		return nil, err
@@ -232,25 +236,10 @@ func setOOMScoreAdj(opts []oci.SpecOpts, oomScoreAdjChanged bool, oomScoreAdj in
 	if !oomScoreAdjChanged {
 		return opts, nil
 	}
-	// score=0 means literally zero, not "unchanged"
-	if oomScoreAdj < -1000 || oomScoreAdj > 1000 {
-		return nil, fmt.Errorf("invalid value %d, range for oom score adj is [-1000, 1000]", oomScoreAdj)
-	}
-
-	if userns.RunningInUserNS() {
-		// > The value of /proc/<pid>/oom_score_adj may be reduced no lower than the last value set by a CAP_SYS_RESOURCE process.
-		// > To reduce the value any lower requires CAP_SYS_RESOURCE.
-		// https://github.com/torvalds/linux/blob/v6.0/Documentation/filesystems/proc.rst#31-procpidoom_adj--procpidoom_score_adj--adjust-the-oom-killer-score
-		//
-		// The minimum=100 is from `/proc/$(pgrep -u $(id -u) systemd)/oom_score_adj`
-		// (FIXME: find a more robust way to get the current minimum value)
-		const minimum = 100
-		if oomScoreAdj < minimum {
-			log.L.Warnf("Limiting oom_score_adj (%d -> %d)", oomScoreAdj, minimum)
-			oomScoreAdj = minimum
-		}
+	oomScoreAdj, err := ValidateOOMScoreAdj(oomScoreAdj)
+	if err != nil {
+		return nil, err
 	}
-
 	opts = append(opts, withOOMScoreAdj(oomScoreAdj))
 	return opts, nil
 }
@@ -124,12 +124,15 @@ func parseMountFlags(volStore volumestore.VolumeStore, options types.ContainerCr
 
 // generateMountOpts generates volume-related mount opts.
 // Other mounts such as procfs mount are not handled here.
-func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredImage *imgutil.EnsuredImage,
-	volStore volumestore.VolumeStore, options types.ContainerCreateOptions) ([]oci.SpecOpts, []string, []*mountutil.Processed, error) {
+// The snapshots of the mounts of type image are returned as a map of the labels referencing them to their keys.
+// The sources of the mounts with the z and Z options are relabeled with the SELinux mountLabel.
+func generateMountOpts(ctx context.Context, client *containerd.Client, id string, ensuredImage *imgutil.EnsuredImage,
+	volStore volumestore.VolumeStore, mountLabel string, options types.ContainerCreateOptions) ([]oci.SpecOpts, []string, []*mountutil.Processed, map[string]string, error) {
 	//nolint:prealloc
 	var (
 		opts        []oci.SpecOpts
 		anonVolumes []string
+		imageMounts = make(map[string]string)
 		userMounts  []specs.Mount
 		mountPoints []*mountutil.Processed
 	)
@@ -140,19 +143,19 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 		imageVolumes = ensuredImage.ImageConfig.Volumes
 
 		if err := ensuredImage.Image.Unpack(ctx, options.GOptions.Snapshotter); err != nil {
-			return nil, nil, nil, fmt.Errorf("error unpacking image: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("error unpacking image: %w", err)
 		}
 
 		diffIDs, err := ensuredImage.Image.RootFS(ctx)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		chainID := identity.ChainID(diffIDs).String()
 
 		s := client.SnapshotService(options.GOptions.Snapshotter)
 		tempDir, err = os.MkdirTemp("", "initialC")
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		// We use Remove here instead of RemoveAll.
 		// The RemoveAll will delete the temp dir and all children it contains.
@@ -163,14 +166,14 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 		// Note(gsamfira): should we make this shorter?
 		ctx, done, err := client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to create lease: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("failed to create lease: %w", err)
 		}
 		defer done(ctx)
 
 		var mounts []mount.Mount
 		mounts, err = s.View(ctx, tempDir, chainID)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		// windows has additional steps for mounting see
@@ -192,45 +195,65 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 					// For https://github.com/containerd/nerdctl/issues/2056
 					unpriv, err := mountutil.UnprivilegedMountFlags(m.Source)
 					if err != nil {
-						return nil, nil, nil, err
+						return nil, nil, nil, nil, err
 					}
 					m.Options = strutil.DedupeStrSlice(append(m.Options, unpriv...))
 				}
 				if err := m.Mount(tempDir); err != nil {
 					if rmErr := s.Remove(ctx, tempDir); rmErr != nil && !errdefs.IsNotFound(rmErr) {
-						return nil, nil, nil, rmErr
+						return nil, nil, nil, nil, rmErr
 					}
-					return nil, nil, nil, fmt.Errorf("failed to mount %+v on %q: %w", m, tempDir, err)
+					return nil, nil, nil, nil, fmt.Errorf("failed to mount %+v on %q: %w", m, tempDir, err)
 				}
 			}
 		} else {
 			defer unmounter(tempDir)
 			if err := mount.All(mounts, tempDir); err != nil {
 				if err := s.Remove(ctx, tempDir); err != nil && !errdefs.IsNotFound(err) {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
 				}
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
 		}
 	}
 
 	if parsed, err := parseMountFlags(volStore, options); err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	} else if len(parsed) > 0 {
 		ociMounts := make([]specs.Mount, len(parsed))
 		for i, x := range parsed {
+			if x.Type == mountutil.Image {
+				label, key, err := prepareImageMount(ctx, client, id, i, x, options)
+				if err != nil {
+					return nil, nil, nil, nil, err
+				}
+				imageMounts[label] = key
+			}
 			ociMounts[i] = x.Mount
 			mounted[filepath.Clean(x.Mount.Destination)] = struct{}{}
 
 			target, err := securejoin.SecureJoin(tempDir, x.Mount.Destination)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
 
 			// Copying content in AnonymousVolume and namedVolume
-			if x.Type == "volume" {
+			if x.Type == "volume" && !x.NoCopy {
 				if err := copyExistingContents(target, x.Mount.Source); err != nil {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
+				}
+			}
+			// Mapping the owners of the files after copying the content, as the content may have to be chowned
+			if x.IDMap != "" {
+				if err := idmapMount(ctx, x, options.UserNS); err != nil {
+					return nil, nil, nil, nil, err
+				}
+				ociMounts[i] = x.Mount
+			}
+			// Relabeling after copying the content too, for the copied files to be relabeled
+			if x.Relabel != "" {
+				if err := relabelMount(x, mountLabel); err != nil {
+					return nil, nil, nil, nil, err
 				}
 			}
 			if x.AnonymousVolume != "" {
@@ -249,7 +272,7 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 		imgVol := filepath.Clean(imgVolRaw)
 		switch imgVol {
 		case "/", "/dev", "/sys", "proc":
-			return nil, nil, nil, fmt.Errorf("invalid VOLUME: %q", imgVolRaw)
+			return nil, nil, nil, nil, fmt.Errorf("invalid VOLUME: %q", imgVolRaw)
 		}
 		if _, ok := mounted[imgVol]; ok {
 			continue
@@ -260,17 +283,17 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 			anonVolName, imgVolRaw)
 		anonVol, err := volStore.CreateWithoutLock(anonVolName, []string{})
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		target, err := securejoin.SecureJoin(tempDir, imgVol)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		//copying up initial contents of the mount point directory
 		if err := copyExistingContents(target, anonVol.Mountpoint); err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		m := specs.Mount{
@@ -294,7 +317,7 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 
 	containers, err := client.Containers(ctx)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	vfSet := strutil.SliceToSet(options.VolumesFrom)
@@ -310,7 +333,7 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 				log.G(ctx).Debugf("container %q is gone - ignoring", c.ID())
 				continue
 			}
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		_, idMatch := vfSet[c.ID()]
 		nameMatch := false
@@ -322,20 +345,20 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 			if av, found := ls[labels.AnonymousVolumes]; found {
 				err = json.Unmarshal([]byte(av), &vfAnonVolumes)
 				if err != nil {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
 				}
 			}
 			if m, found := ls[labels.Mounts]; found {
 				err = json.Unmarshal([]byte(m), &vfMountPoints)
 				if err != nil {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
 				}
 			}
 
 			ps := processeds(vfMountPoints)
 			s, err := c.Spec(ctx)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
 			opts = append(opts, withMounts(s.Mounts))
 			anonVolumes = append(anonVolumes, vfAnonVolumes...)
@@ -343,7 +366,7 @@ func generateMountOpts(ctx context.Context, client *containerd.Client, ensuredIm
 		}
 	}
 
-	return opts, anonVolumes, mountPoints, nil
+	return opts, anonVolumes, mountPoints, imageMounts, nil
 }
 
 // copyExistingContents copies from the source to the destination and
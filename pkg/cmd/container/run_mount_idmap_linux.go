@@ -0,0 +1,129 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/mountutil"
+)
+
+// idmapMount maps the owners of the files of the mount x to the user namespace of the container (--userns-remap)
+// with an ID-mapped mount. When ID-mapped mounts are not supported by the kernel or the filesystem of the source,
+// the volume is chowned recursively on first use if idmap=chown is specified, and an error is returned otherwise.
+func idmapMount(ctx context.Context, x *mountutil.Processed, userNS string) error {
+	if userNS == "" || userNS == "host" {
+		return fmt.Errorf("idmap option of the mount on %q requires --userns-remap", x.Mount.Destination)
+	}
+	idMapping, err := loadAndValidateIDMapping(userNS)
+	if err != nil {
+		return err
+	}
+	uidMaps, gidMaps := convertMappings(idMapping)
+	idMap := ContainerdIDMap{UidMap: uidMaps, GidMap: gidMaps}
+	err = checkIDMappedMount(x.Mount.Source, idMap)
+	if err == nil {
+		x.Mount.UIDMappings = uidMaps
+		x.Mount.GIDMappings = gidMaps
+		return nil
+	}
+	if x.IDMap != mountutil.IDMapChown {
+		return fmt.Errorf("ID-mapped mounts are not supported by the kernel or the filesystem of %q (use idmap=chown for volumes): %w", x.Mount.Source, err)
+	}
+	log.G(ctx).WithError(err).Warnf("ID-mapped mounts are not supported for volume %q, falling back to chown", x.Mount.Source)
+	return chownVolume(x.Mount.Source, idMap)
+}
+
+// checkIDMappedMount checks whether source can be ID-mapped by cloning its mount, without attaching it.
+func checkIDMappedMount(source string, idMap ContainerdIDMap) error {
+	uidmap, gidmap := idMap.Marshal()
+	usernsFD, err := mount.GetUsernsFD(uidmap, gidmap)
+	if err != nil {
+		return err
+	}
+	defer usernsFD.Close()
+
+	fd, err := unix.OpenTree(-int(unix.EBADF), source, unix.OPEN_TREE_CLONE|unix.OPEN_TREE_CLOEXEC)
+	if err != nil {
+		return &fs.PathError{Op: "open_tree", Path: source, Err: err}
+	}
+	defer unix.Close(fd)
+	attr := unix.MountAttr{
+		Attr_set:  unix.MOUNT_ATTR_IDMAP,
+		Userns_fd: uint64(usernsFD.Fd()),
+	}
+	if err := unix.MountSetattr(fd, "", unix.AT_EMPTY_PATH, &attr); err != nil {
+		return &fs.PathError{Op: "mount_setattr", Path: source, Err: err}
+	}
+	return nil
+}
+
+// chownVolume chowns the files of the volume mounted from source to the user namespace of idMap.
+// The volume is chowned only on first use, i.e., when the owner of its root is not mapped in the user namespace.
+func chownVolume(source string, idMap ContainerdIDMap) error {
+	fi, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	if hostIDMapped(st.Uid, idMap.UidMap) && hostIDMapped(st.Gid, idMap.GidMap) {
+		return nil
+	}
+	return filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		st := fi.Sys().(*syscall.Stat_t)
+		pair, err := idMap.ToHost(User{Uid: st.Uid, Gid: st.Gid})
+		if err != nil {
+			return fmt.Errorf("failed to chown %q: %w", p, err)
+		}
+		if err := os.Lchown(p, int(pair.Uid), int(pair.Gid)); err != nil {
+			return err
+		}
+		if fi.Mode()&(fs.ModeSetuid|fs.ModeSetgid) != 0 && fi.Mode()&fs.ModeSymlink == 0 {
+			// chown clears the setuid and setgid bits
+			return os.Chmod(p, fi.Mode())
+		}
+		return nil
+	})
+}
+
+// hostIDMapped reports whether the host id is mapped in the user namespace.
+func hostIDMapped(id uint32, idMap []specs.LinuxIDMapping) bool {
+	for _, m := range idMap {
+		if id >= m.HostID && id-m.HostID < m.Size {
+			return true
+		}
+	}
+	return false
+}
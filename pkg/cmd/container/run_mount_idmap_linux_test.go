@@ -0,0 +1,84 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gotest.tools/v3/assert"
+)
+
+func TestHostIDMapped(t *testing.T) {
+	t.Parallel()
+	idMap := []specs.LinuxIDMapping{
+		{ContainerID: 0, HostID: 100000, Size: 65536},
+		{ContainerID: 65536, HostID: 300000, Size: 1},
+	}
+	assert.Assert(t, !hostIDMapped(0, idMap))
+	assert.Assert(t, !hostIDMapped(99999, idMap))
+	assert.Assert(t, hostIDMapped(100000, idMap))
+	assert.Assert(t, hostIDMapped(165535, idMap))
+	assert.Assert(t, !hostIDMapped(165536, idMap))
+	assert.Assert(t, hostIDMapped(300000, idMap))
+	assert.Assert(t, !hostIDMapped(300001, idMap))
+}
+
+func TestChownVolume(t *testing.T) {
+	t.Parallel()
+	if os.Geteuid() != 0 {
+		t.Skip("test requires root")
+	}
+	source := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(source, "dir"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(source, "dir", "file"), nil, 0o644))
+	assert.NilError(t, os.Chown(filepath.Join(source, "dir", "file"), 1000, 1001))
+	assert.NilError(t, os.Chmod(filepath.Join(source, "dir", "file"), 0o644|os.ModeSetuid))
+	assert.NilError(t, os.Symlink("dir/file", filepath.Join(source, "link")))
+
+	idMap := ContainerdIDMap{
+		UidMap: []specs.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GidMap: []specs.LinuxIDMapping{{ContainerID: 0, HostID: 200000, Size: 65536}},
+	}
+	owner := func(p string) (uint32, uint32) {
+		var st unix.Stat_t
+		assert.NilError(t, unix.Lstat(filepath.Join(source, p), &st))
+		return st.Uid, st.Gid
+	}
+
+	assert.NilError(t, chownVolume(source, idMap))
+	for p, expected := range map[string][2]uint32{
+		".":        {100000, 200000},
+		"dir":      {100000, 200000},
+		"dir/file": {101000, 201001},
+		"link":     {100000, 200000},
+	} {
+		uid, gid := owner(p)
+		assert.DeepEqual(t, [2]uint32{uid, gid}, expected)
+	}
+	fi, err := os.Stat(filepath.Join(source, "dir", "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode(), 0o644|os.ModeSetuid)
+
+	// the volume is chowned only once
+	assert.NilError(t, chownVolume(source, idMap))
+	uid, gid := owner("dir/file")
+	assert.DeepEqual(t, [2]uint32{uid, gid}, [2]uint32{101000, 201001})
+}
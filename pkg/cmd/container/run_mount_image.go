@@ -0,0 +1,160 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/opencontainers/image-spec/identity"
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/image"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/mountutil"
+)
+
+const (
+	// the label of a container which references the snapshot of an image mount, to protect it from the garbage
+	// collection of containerd until the container is removed: "containerd.io/gc.ref.snapshot.<SNAPSHOTTER>/<SUFFIX>"
+	labelGCSnapRef        = "containerd.io/gc.ref.snapshot."
+	imageMountLabelSuffix = "/nerdctl-image-mount-"
+)
+
+// prepareImageMount prepares the mount of type image x, the i-th mount of the container id.
+// It creates a view snapshot of the image, and fills the mount of x with the mount of the snapshot.
+// It returns the label referencing the snapshot, which is removed with the container.
+func prepareImageMount(ctx context.Context, client *containerd.Client, id string, i int, x *mountutil.Processed, options types.ContainerCreateOptions) (string, string, error) {
+	pullOpt := options.ImagePullOpt
+	pullOpt.Mode = options.Pull
+	pullOpt.Unpack = nil
+	ensured, err := image.EnsureImage(ctx, client, x.Name, pullOpt)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to ensure the image %q of the mount on %q: %w", x.Name, x.Mount.Destination, err)
+	}
+	if err := imgutil.MarkUsed(ctx, client.ImageService(), ensured.Image.Name(), time.Now()); err != nil {
+		log.G(ctx).WithError(err).Warnf("failed to record the last use of image %q", ensured.Image.Name())
+	}
+	if err := ensured.Image.Unpack(ctx, ensured.Snapshotter); err != nil {
+		return "", "", fmt.Errorf("error unpacking image %q: %w", x.Name, err)
+	}
+	diffIDs, err := ensured.Image.RootFS(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	// Add a lease of 1 hour to the view so that it is not garbage collected until the container referencing it is
+	// created. The lease is left to expire, so that the view is collected if the creation of the container fails.
+	ctx, _, err = client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create lease: %w", err)
+	}
+	key := fmt.Sprintf("nerdctl-image-mount-%s-%d", id, i)
+	sn := client.SnapshotService(ensured.Snapshotter)
+	mounts, err := sn.View(ctx, key, identity.ChainID(diffIDs).String())
+	if err != nil {
+		return "", "", err
+	}
+	m, err := imageMount(mounts, x.ImagePath)
+	if err != nil {
+		if rmErr := sn.Remove(ctx, key); rmErr != nil && !errdefs.IsNotFound(rmErr) {
+			log.G(ctx).WithError(rmErr).Warnf("failed to remove snapshot %q", key)
+		}
+		return "", "", fmt.Errorf("failed to mount image %q: %w", x.Name, err)
+	}
+	m.Destination = x.Mount.Destination
+	x.Mount = m
+	return labelGCSnapRef + ensured.Snapshotter + imageMountLabelSuffix + strconv.Itoa(i), key, nil
+}
+
+// imageMount returns the read-only mount of the path p of the view snapshot mounted with mounts.
+func imageMount(mounts []mount.Mount, p string) (specs.Mount, error) {
+	if len(mounts) != 1 {
+		return specs.Mount{}, fmt.Errorf("unsupported mounts of the snapshot: %+v", mounts)
+	}
+	m := mounts[0]
+	options := slices.DeleteFunc(slices.Clone(m.Options), func(o string) bool { return o == "rw" })
+	if !slices.Contains(options, "ro") {
+		options = append(options, "ro")
+	}
+	switch m.Type {
+	case "bind", "rbind":
+		source := m.Source
+		if p != "" {
+			var err error
+			if source, err = securejoin.SecureJoin(source, p); err != nil {
+				return specs.Mount{}, err
+			}
+		}
+		if !slices.Contains(options, "rbind") && !slices.Contains(options, "bind") {
+			options = append(options, "rbind")
+		}
+		return specs.Mount{Type: "bind", Source: source, Options: options}, nil
+	case "overlay":
+		if p == "" || p == "/" {
+			return specs.Mount{Type: m.Type, Source: m.Source, Options: options}, nil
+		}
+		var lowerdirs []string
+		for _, o := range options {
+			if v, ok := strings.CutPrefix(o, "lowerdir="); ok {
+				lowerdirs = strings.Split(v, ":")
+			}
+		}
+		dirs, err := overlaySubpath(lowerdirs, filepath.Clean(p))
+		if err != nil {
+			return specs.Mount{}, err
+		}
+		if len(dirs) == 1 {
+			return specs.Mount{Type: "bind", Source: dirs[0], Options: []string{"ro", "rbind"}}, nil
+		}
+		for i, o := range options {
+			if strings.HasPrefix(o, "lowerdir=") {
+				options[i] = "lowerdir=" + strings.Join(dirs, ":")
+			}
+		}
+		return specs.Mount{Type: m.Type, Source: m.Source, Options: options}, nil
+	default:
+		return specs.Mount{}, fmt.Errorf("unsupported mount type %q of the snapshot", m.Type)
+	}
+}
+
+// removeImageMountSnapshots removes the snapshots of the image mounts of a container, referenced by the labels of
+// the container (see prepareImageMount).
+func removeImageMountSnapshots(ctx context.Context, client *containerd.Client, containerLabels map[string]string) {
+	for k, key := range containerLabels {
+		ref, ok := strings.CutPrefix(k, labelGCSnapRef)
+		if !ok || !strings.Contains(ref, imageMountLabelSuffix) {
+			continue
+		}
+		snapshotter, _, _ := strings.Cut(ref, "/")
+		if err := client.SnapshotService(snapshotter).Remove(ctx, key); err != nil && !errdefs.IsNotFound(err) {
+			log.G(ctx).WithError(err).Warnf("failed to remove the snapshot %q of an image mount", key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// overlaySubpath returns the directories of path p in the layers of an overlay (lowerdirs, top first), which make up
+// the content of p in the overlay. The layers below a whiteout or an opaque directory on p are excluded.
+// p must not contain symbolic links.
+func overlaySubpath(lowerdirs []string, p string) ([]string, error) {
+	components := strings.Split(strings.Trim(p, "/"), "/")
+	var dirs []string
+	for _, layer := range lowerdirs {
+		var (
+			dir   = layer
+			found = true
+			last  = false
+		)
+		for i, c := range components {
+			dir = filepath.Join(dir, c)
+			fi, err := os.Lstat(dir)
+			if errors.Is(err, os.ErrNotExist) {
+				found = false
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if fi.Mode()&fs.ModeCharDevice != 0 && fi.Sys().(*syscall.Stat_t).Rdev == 0 {
+				// whiteout: p is removed from the lower layers
+				found, last = false, true
+				break
+			}
+			if !fi.IsDir() {
+				// a file hides the lower layers
+				found, last = i == len(components)-1 && fi.Mode().IsRegular() && len(dirs) == 0, true
+				break
+			}
+			if isOpaque(dir) {
+				last = true
+			}
+		}
+		if found {
+			dirs = append(dirs, dir)
+		}
+		if last {
+			break
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("path %q does not exist in the image", p)
+	}
+	return dirs, nil
+}
+
+// isOpaque reports whether the directory of an overlay layer hides the content of the lower layers.
+func isOpaque(dir string) bool {
+	for _, attr := range []string{"trusted.overlay.opaque", "user.overlay.opaque"} {
+		b := make([]byte, 1)
+		if n, err := unix.Lgetxattr(dir, attr, b); err == nil && n == 1 && b[0] == 'y' {
+			return true
+		}
+	}
+	return false
+}
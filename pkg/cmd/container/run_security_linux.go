@@ -17,14 +17,17 @@
 package container
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/containerd/containerd/v2/contrib/apparmor"
 	"github.com/containerd/containerd/v2/contrib/seccomp"
+	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/pkg/cap"
 	"github.com/containerd/containerd/v2/pkg/oci"
 	"github.com/containerd/log"
@@ -32,6 +35,7 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/apparmorutil"
 	"github.com/containerd/nerdctl/v2/pkg/defaults"
 	"github.com/containerd/nerdctl/v2/pkg/maputil"
+	"github.com/containerd/nerdctl/v2/pkg/seccomputil"
 	"github.com/containerd/nerdctl/v2/pkg/strutil"
 )
 
@@ -54,12 +58,14 @@ const (
 func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([]oci.SpecOpts, error) {
 	for k := range securityOptsMap {
 		switch k {
-		case "seccomp", "apparmor", "no-new-privileges", "systempaths", "privileged-without-host-devices", "writable-cgroups":
+		case "seccomp", "apparmor", "no-new-privileges", "systempaths", "privileged-without-host-devices", "writable-cgroups", "label", "apparmor-policy", "seccomp-add", "seccomp-drop":
+			// label is handled by generateSELinuxOpts, apparmor-policy by generateAppArmorPolicyOpts
 		default:
 			log.L.Warnf("unknown security-opt: %q", k)
 		}
 	}
 	var opts []oci.SpecOpts
+	seccompUnconfined := false
 	if seccompProfile, ok := securityOptsMap["seccomp"]; ok && seccompProfile != defaults.SeccompProfileName {
 		if seccompProfile == "" {
 			return nil, errors.New("invalid security-opt \"seccomp\"")
@@ -67,14 +73,25 @@ func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([
 
 		if seccompProfile != "unconfined" {
 			opts = append(opts, seccomp.WithProfile(seccompProfile))
+		} else {
+			seccompUnconfined = true
 		}
 	} else {
 		opts = append(opts, seccomp.WithDefaultProfile())
 	}
+	seccompOverrideOpts, err := generateSeccompOverrideOpts(seccompUnconfined, securityOptsMap)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, seccompOverrideOpts...)
 
 	canLoadNewAppArmor := apparmorutil.CanLoadNewProfile()
 	canApplyExistingProfile := apparmorutil.CanApplyExistingProfile()
-	if aaProfile, ok := securityOptsMap["apparmor"]; ok {
+	if _, ok := securityOptsMap["apparmor-policy"]; ok {
+		if _, ok := securityOptsMap["apparmor"]; ok {
+			return nil, errors.New("security-opt \"apparmor\" and \"apparmor-policy\" cannot be used together")
+		}
+	} else if aaProfile, ok := securityOptsMap["apparmor"]; ok {
 		if aaProfile == "" {
 			return nil, errors.New("invalid security-opt \"apparmor\"")
 		}
@@ -141,6 +158,74 @@ func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([
 	return opts, nil
 }
 
+// joinSeccompOverrides joins the values of the repeated `--security-opt seccomp-add` and `--security-opt seccomp-drop`,
+// e.g., "seccomp-add=ptrace" and "seccomp-add=personality" into "seccomp-add=ptrace,personality".
+func joinSeccompOverrides(securityOpts []string) []string {
+	var res []string
+	values := make(map[string][]string)
+	for _, o := range securityOpts {
+		k, v, _ := strings.Cut(o, "=")
+		if k != "seccomp-add" && k != "seccomp-drop" {
+			res = append(res, o)
+			continue
+		}
+		if _, ok := values[k]; !ok {
+			// keep the position of the first one
+			res = append(res, k)
+		}
+		values[k] = append(values[k], v)
+	}
+	for i, o := range res {
+		if v, ok := values[o]; ok {
+			res[i] = o + "=" + strings.Join(v, ",")
+		}
+	}
+	return res
+}
+
+// generateSeccompOverrideOpts returns the opts for `--security-opt seccomp-add` and `--security-opt seccomp-drop`,
+// which derive the profile from the one set by `--security-opt seccomp` (the default profile by default).
+func generateSeccompOverrideOpts(unconfined bool, securityOptsMap map[string]string) ([]oci.SpecOpts, error) {
+	var add, drop []string
+	for _, k := range []string{"seccomp-add", "seccomp-drop"} {
+		value, ok := securityOptsMap[k]
+		if !ok {
+			continue
+		}
+		if unconfined {
+			return nil, fmt.Errorf("security-opt %q cannot be used with \"seccomp=unconfined\"", k)
+		}
+		names := strutil.DedupeStrSlice(strings.Split(value, ","))
+		if slices.Contains(names, "") {
+			return nil, fmt.Errorf("invalid security-opt %q", k)
+		}
+		if err := seccomputil.ValidateSyscalls(names, seccomputil.NativeArches()); err != nil {
+			return nil, fmt.Errorf("invalid security-opt %q: %w", k, err)
+		}
+		if k == "seccomp-add" {
+			add = names
+		} else {
+			drop = names
+		}
+	}
+	if len(add) == 0 && len(drop) == 0 {
+		return nil, nil
+	}
+	return []oci.SpecOpts{
+		func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+			if s.Linux == nil || s.Linux.Seccomp == nil {
+				return errors.New("seccomp-add and seccomp-drop require a seccomp profile")
+			}
+			profile, err := seccomputil.Override(s.Linux.Seccomp, add, drop)
+			if err != nil {
+				return err
+			}
+			s.Linux.Seccomp = profile
+			return nil
+		},
+	}, nil
+}
+
 func canonicalizeCapName(s string) string {
 	if s == "" {
 		return ""
@@ -0,0 +1,82 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/pkg/oci"
+)
+
+func TestGenerateSeccompOverrideOpts(t *testing.T) {
+	t.Parallel()
+
+	opts, err := generateSeccompOverrideOpts(false, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(opts), 0)
+
+	opts, err = generateSeccompOverrideOpts(false, map[string]string{"seccomp-add": "ptrace,personality", "seccomp-drop": "chmod"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(opts), 1)
+	spec := oci.Spec{
+		Linux: &specs.Linux{
+			Seccomp: &specs.LinuxSeccomp{
+				DefaultAction: specs.ActErrno,
+				Syscalls: []specs.LinuxSyscall{
+					{Names: []string{"chmod", "read"}, Action: specs.ActAllow},
+				},
+			},
+		},
+	}
+	assert.NilError(t, opts[0](context.Background(), nil, nil, &spec))
+	assert.DeepEqual(t, spec.Linux.Seccomp.Syscalls, []specs.LinuxSyscall{
+		{Names: []string{"read"}, Action: specs.ActAllow},
+		{Names: []string{"ptrace", "personality"}, Action: specs.ActAllow},
+	})
+	assert.Assert(t, !slices.ContainsFunc(spec.Linux.Seccomp.Syscalls, func(rule specs.LinuxSyscall) bool {
+		return slices.Contains(rule.Names, "chmod")
+	}))
+
+	_, err = generateSeccompOverrideOpts(true, map[string]string{"seccomp-add": "ptrace"})
+	assert.ErrorContains(t, err, "cannot be used with \"seccomp=unconfined\"")
+	_, err = generateSeccompOverrideOpts(false, map[string]string{"seccomp-drop": "no_such_syscall"})
+	assert.ErrorContains(t, err, "\"no_such_syscall\"")
+	_, err = generateSeccompOverrideOpts(false, map[string]string{"seccomp-add": ""})
+	assert.ErrorContains(t, err, "invalid security-opt \"seccomp-add\"")
+}
+
+func TestJoinSeccompOverrides(t *testing.T) {
+	t.Parallel()
+
+	assert.DeepEqual(t, joinSeccompOverrides([]string{
+		"seccomp-add=ptrace",
+		"no-new-privileges",
+		"seccomp-drop=chmod",
+		"seccomp-add=personality,bpf",
+		"seccomp-drop=fchmod",
+	}), []string{
+		"seccomp-add=ptrace,personality,bpf",
+		"no-new-privileges",
+		"seccomp-drop=chmod,fchmod",
+	})
+	assert.Equal(t, len(joinSeccompOverrides(nil)), 0)
+}
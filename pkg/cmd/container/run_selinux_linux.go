@@ -0,0 +1,101 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/mountutil"
+	"github.com/containerd/nerdctl/v2/pkg/selinuxutil"
+	"github.com/containerd/nerdctl/v2/pkg/strutil"
+)
+
+// generateSELinuxOpts generates the SELinux process and mount labels of the container when SELinux is enabled.
+// A unique MCS level is allocated to the container, unless it is specified with `--security-opt label=level:LEVEL`.
+// The mount label is stored in internalLabels.mountLabel for relabeling the mounts with `:z` and `:Z`.
+func generateSELinuxOpts(dataStore string, internalLabels *internalLabels, options types.ContainerCreateOptions) ([]oci.SpecOpts, error) {
+	selinuxOpts, err := selinuxutil.ParseOptions(strutil.DedupeStrSlice(options.SecurityOpt))
+	if err != nil {
+		return nil, err
+	}
+	if !selinuxutil.Enabled() {
+		if selinuxOpts != (selinuxutil.Options{}) && !selinuxOpts.Disable {
+			log.L.Warn("the host does not support SELinux. Ignoring the label security options")
+		}
+		return nil, nil
+	}
+	catRange := selinuxutil.CategoryRange()
+	allocateLevel := func() (string, error) {
+		return selinuxutil.AllocateLevel(dataStore, internalLabels.stateDir, catRange)
+	}
+	selinuxLabels, err := generateSELinuxLabels(selinuxutil.HostLabels(), selinuxOpts, options.Privileged, catRange, allocateLevel)
+	if err != nil {
+		return nil, err
+	}
+	internalLabels.mountLabel = selinuxLabels.MountLabel
+	return []oci.SpecOpts{withSELinuxLabels(selinuxLabels)}, nil
+}
+
+// generateSELinuxLabels generates the labels of a container from the base labels of the host policy.
+// Privileged containers are not confined, like with `--security-opt label=disable`.
+func generateSELinuxLabels(base selinuxutil.Labels, opts selinuxutil.Options, privileged bool, catRange uint32,
+	allocateLevel func() (string, error)) (selinuxutil.Labels, error) {
+	if privileged {
+		opts.Disable = true
+	}
+	var level string
+	if !opts.Disable && opts.Level == "" {
+		var err error
+		level, err = allocateLevel()
+		if err != nil {
+			return selinuxutil.Labels{}, fmt.Errorf("failed to allocate an SELinux MCS level: %w", err)
+		}
+	}
+	return selinuxutil.GenerateLabels(base, opts, level, catRange)
+}
+
+// withSELinuxLabels sets the process label and the mount label of the spec.
+func withSELinuxLabels(selinuxLabels selinuxutil.Labels) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Process == nil {
+			s.Process = &specs.Process{}
+		}
+		s.Process.SelinuxLabel = selinuxLabels.ProcessLabel
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+		s.Linux.MountLabel = selinuxLabels.MountLabel
+		return nil
+	}
+}
+
+// relabelMount relabels the source of the mount x with the mount label of the container (`:Z`),
+// or with the mount label shared by all the containers (`:z`).
+func relabelMount(x *mountutil.Processed, mountLabel string) error {
+	if err := selinuxutil.Relabel(x.Mount.Source, mountLabel, x.Relabel == "z"); err != nil {
+		return fmt.Errorf("failed to relabel the source of the mount on %q: %w", x.Mount.Destination, err)
+	}
+	return nil
+}
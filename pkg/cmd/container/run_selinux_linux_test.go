@@ -0,0 +1,98 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/pkg/oci"
+
+	"github.com/containerd/nerdctl/v2/pkg/selinuxutil"
+)
+
+func TestGenerateSELinuxLabels(t *testing.T) {
+	t.Parallel()
+
+	base := selinuxutil.Labels{ProcessLabel: selinuxutil.DefaultProcessLabel, MountLabel: selinuxutil.DefaultMountLabel}
+	allocated := 0
+	allocateLevel := func() (string, error) {
+		allocated++
+		return "s0:c10,c20", nil
+	}
+
+	testCases := []struct {
+		securityOpts []string
+		privileged   bool
+		expected     selinuxutil.Labels
+		allocated    bool
+	}{
+		{
+			expected: selinuxutil.Labels{
+				ProcessLabel: "system_u:system_r:container_t:s0:c10,c20",
+				MountLabel:   "system_u:object_r:container_file_t:s0:c10,c20",
+			},
+			allocated: true,
+		},
+		{
+			securityOpts: []string{"label=type:spc_t", "seccomp=unconfined"},
+			expected: selinuxutil.Labels{
+				ProcessLabel: "system_u:system_r:spc_t:s0:c10,c20",
+				MountLabel:   "system_u:object_r:container_file_t:s0:c10,c20",
+			},
+			allocated: true,
+		},
+		{
+			securityOpts: []string{"label=level:s0:c1,c2"},
+			expected: selinuxutil.Labels{
+				ProcessLabel: "system_u:system_r:container_t:s0:c1,c2",
+				MountLabel:   "system_u:object_r:container_file_t:s0:c1,c2",
+			},
+		},
+		{
+			securityOpts: []string{"label=disable"},
+			expected:     selinuxutil.Labels{MountLabel: "system_u:object_r:container_file_t:s0:c1022,c1023"},
+		},
+		{
+			privileged: true,
+			expected:   selinuxutil.Labels{MountLabel: "system_u:object_r:container_file_t:s0:c1022,c1023"},
+		},
+	}
+
+	for _, tc := range testCases {
+		allocated = 0
+		opts, err := selinuxutil.ParseOptions(tc.securityOpts)
+		assert.NilError(t, err)
+		labels, err := generateSELinuxLabels(base, opts, tc.privileged, 1024, allocateLevel)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, labels, tc.expected)
+		assert.Equal(t, allocated == 1, tc.allocated)
+
+		var spec oci.Spec
+		assert.NilError(t, withSELinuxLabels(labels)(context.Background(), nil, nil, &spec))
+		assert.Equal(t, spec.Process.SelinuxLabel, tc.expected.ProcessLabel)
+		assert.Equal(t, spec.Linux.MountLabel, tc.expected.MountLabel)
+	}
+
+	_, err := generateSELinuxLabels(base, selinuxutil.Options{}, false, 1024, func() (string, error) {
+		return "", errors.New("no SELinux MCS level is available")
+	})
+	assert.ErrorContains(t, err, "failed to allocate an SELinux MCS level")
+}
@@ -53,5 +53,6 @@ func Start(ctx context.Context, client *containerd.Client, reqs []string, option
 		},
 	}
 
-	return walker.WalkAll(ctx, reqs, true)
+	// keep the exit code of the attached container
+	return walker.WalkAll(ctx, reqs, !options.Attach)
 }
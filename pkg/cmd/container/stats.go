@@ -19,6 +19,7 @@ package container
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -30,6 +31,7 @@ import (
 	eventstypes "github.com/containerd/containerd/api/events"
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/events"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 	"github.com/containerd/typeurl/v2"
@@ -81,8 +83,188 @@ func (s *stats) isKnownContainer(cid string) (int, bool) {
 	return -1, false
 }
 
+// StatsCollector collects the statistics of containers in the background.
+// The collection is shared by `nerdctl container stats` and `nerdctl system metrics serve`.
+type StatsCollector struct {
+	client   *containerd.Client
+	gOptions types.GlobalCommandOptions
+	// all collects the statistics of the containers which are not running too
+	all   bool
+	stats stats
+	// cancels stops the collection of the statistics of each container (guarded by stats.mu)
+	cancels map[string]context.CancelFunc
+	// waitFirst is a WaitGroup to wait first stat data's reach for each container
+	waitFirst sync.WaitGroup
+	errCh     chan error
+	// interval is the interval of the samples of each container
+	interval time.Duration
+}
+
+// defaultStatsInterval is the default interval of the samples of the statistics
+const defaultStatsInterval = 500 * time.Millisecond
+
+func newStatsCollector(client *containerd.Client, gOptions types.GlobalCommandOptions, all bool, interval time.Duration) *StatsCollector {
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+	return &StatsCollector{
+		client:   client,
+		gOptions: gOptions,
+		all:      all,
+		cancels:  make(map[string]context.CancelFunc),
+		errCh:    make(chan error, 1),
+		interval: interval,
+	}
+}
+
+// NewStatsCollector starts collecting the statistics of all the containers of the namespace of gOptions, including
+// the containers created later, until ctx is done. The containers which are not running are included if all is true.
+// The statistics are sampled at the interval (500ms if zero).
+// It returns once the first statistics of the existing containers are collected.
+func NewStatsCollector(ctx context.Context, client *containerd.Client, gOptions types.GlobalCommandOptions, all bool, interval time.Duration) (*StatsCollector, error) {
+	sc := newStatsCollector(client, gOptions, all, interval)
+	if err := sc.watch(ctx); err != nil {
+		return nil, err
+	}
+	sc.waitFirst.Wait()
+	return sc, nil
+}
+
+// Stats returns the last statistics of the containers.
+func (sc *StatsCollector) Stats() []statsutil.StatsEntry {
+	sc.stats.mu.Lock()
+	defer sc.stats.mu.Unlock()
+	entries := make([]statsutil.StatsEntry, 0, len(sc.stats.cs))
+	for _, c := range sc.stats.cs {
+		entries = append(entries, c.GetStatistics())
+	}
+	return entries
+}
+
+// Err returns the channel receiving the error which stopped watching the containers.
+func (sc *StatsCollector) Err() <-chan error {
+	return sc.errCh
+}
+
+func (sc *StatsCollector) setErr(err error) {
+	select {
+	case sc.errCh <- err:
+	default:
+	}
+}
+
+// add starts collecting the statistics of the container c, unless they are already collected.
+func (sc *StatsCollector) add(ctx context.Context, c containerd.Container) {
+	// if an error occurs when getting labels, the ID alone is sufficient for the stats screen.
+	clabels, _ := c.Labels(ctx)
+	s := statsutil.NewStats(c.ID(), containerutil.GetContainerName(clabels))
+	if sc.stats.add(s) {
+		ctx, cancel := context.WithCancel(ctx)
+		sc.stats.mu.Lock()
+		sc.cancels[c.ID()] = cancel
+		sc.stats.mu.Unlock()
+		sc.waitFirst.Add(1)
+		go collect(ctx, sc.gOptions, s, &sc.waitFirst, c.ID(), sc.interval)
+	}
+}
+
+// remove stops collecting the statistics of the container id.
+func (sc *StatsCollector) remove(id string) {
+	sc.stats.remove(id)
+	sc.stats.mu.Lock()
+	cancel, ok := sc.cancels[id]
+	delete(sc.cancels, id)
+	sc.stats.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// watch starts collecting the statistics of the existing containers, and of the containers created later until ctx
+// is done.
+func (sc *StatsCollector) watch(ctx context.Context) error {
+	ns, err := namespaces.NamespaceRequired(ctx)
+	if err != nil {
+		return err
+	}
+
+	eh := eventutil.InitEventHandler()
+	eh.Handle("/containers/create", func(e events.Envelope) {
+		if e.Event == nil {
+			return
+		}
+		anydata, err := typeurl.UnmarshalAny(e.Event)
+		if err != nil {
+			// just skip
+			return
+		}
+		v, ok := anydata.(*eventstypes.ContainerCreate)
+		if !ok {
+			return
+		}
+		c, err := sc.client.LoadContainer(ctx, v.ID)
+		if err != nil {
+			// the container may be already removed
+			return
+		}
+		sc.add(ctx, c)
+	})
+	eh.Handle("/containers/delete", func(e events.Envelope) {
+		if e.Event == nil {
+			return
+		}
+		anydata, err := typeurl.UnmarshalAny(e.Event)
+		if err != nil {
+			// just skip
+			return
+		}
+		if v, ok := anydata.(*eventstypes.ContainerDelete); ok {
+			sc.remove(v.ID)
+		}
+	})
+
+	// Subscribe before listing the containers, not to miss the containers created in the meantime.
+	eventsCh, errCh := sc.client.EventService().Subscribe(ctx, fmt.Sprintf(`namespace==%s,topic~="^/containers/"`, ns))
+	eventChan := make(chan *events.Envelope)
+	go eh.Watch(eventChan)
+	go func() {
+		defer close(eventChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-eventsCh:
+				eventChan <- event
+			case err := <-errCh:
+				if err != nil {
+					sc.setErr(err)
+				}
+				return
+			}
+		}
+	}()
+
+	containers, err := sc.client.Containers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if !sc.all {
+			cStatus := formatter.ContainerStatus(ctx, c)
+			if !strings.HasPrefix(cStatus, "Up") {
+				continue
+			}
+		}
+		sc.add(ctx, c)
+	}
+	return nil
+}
+
 // Stats displays a live stream of container(s) resource usage statistics.
 func Stats(ctx context.Context, client *containerd.Client, containerIDs []string, options types.ContainerStatsOptions) error {
+	if options.Interval < 0 {
+		return fmt.Errorf("invalid interval %s: must be positive", options.Interval)
+	}
 	// NOTE: rootless container does not rely on cgroupv1.
 	// more details about possible ways to resolve this concern: #223
 	if rootlessutil.IsRootless() && infoutil.CgroupsVersion() == "1" {
@@ -90,16 +272,19 @@ func Stats(ctx context.Context, client *containerd.Client, containerIDs []string
 	}
 
 	showAll := len(containerIDs) == 0
-	closeChan := make(chan error)
 
 	var err error
 	w := options.Stdout
 	var tmpl *template.Template
+	var jsonStream bool
 	switch options.Format {
 	case "", "table":
 		w = tabwriter.NewWriter(options.Stdout, 10, 1, 3, ' ', 0)
 	case "raw":
 		return errors.New("unsupported format: \"raw\"")
+	case "json":
+		// one object with the statistics of all the containers per tick
+		jsonStream = true
 	default:
 		tmpl, err = formatter.ParseTemplate(options.Format)
 		if err != nil {
@@ -107,138 +292,20 @@ func Stats(ctx context.Context, client *containerd.Client, containerIDs []string
 		}
 	}
 
-	// waitFirst is a WaitGroup to wait first stat data's reach for each container
-	waitFirst := &sync.WaitGroup{}
-	cStats := stats{}
-
-	monitorContainerEvents := func(started chan<- struct{}, c chan *events.Envelope, closeEventChan chan struct{}) {
-		eventsClient := client.EventService()
-		eventsCh, errCh := eventsClient.Subscribe(ctx)
-
-		// Whether we successfully subscribed to eventsCh or not, we can now
-		// unblock the main goroutine.
-		close(started)
-
-		for {
-			select {
-			case <-closeEventChan:
-				// c is closed, so we can stop monitoring events
-				return
-			case event := <-eventsCh:
-				c <- event
-			case err = <-errCh:
-				closeChan <- err
-				return
-			}
-		}
-	}
-
-	// getContainerList get all existing containers (only used when calling `nerdctl stats` without arguments).
-	getContainerList := func() {
-		containers, err := client.Containers(ctx)
-		if err != nil {
-			closeChan <- err
-		}
-
-		for _, c := range containers {
-			cStatus := formatter.ContainerStatus(ctx, c)
-			if !options.All {
-				if !strings.HasPrefix(cStatus, "Up") {
-					continue
-				}
-			}
-			// if an error occurs when getting labels, the ID alone is sufficient for the stats screen.
-			clabels, _ := c.Labels(ctx)
-			s := statsutil.NewStats(c.ID(), containerutil.GetContainerName(clabels))
-			if cStats.add(s) {
-				waitFirst.Add(1)
-				go collect(ctx, options.GOptions, s, waitFirst, c.ID(), !options.NoStream)
-			}
-		}
-	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sc := newStatsCollector(client, options.GOptions, options.All, options.Interval)
+	cStats := &sc.stats
 
 	if showAll {
-		started := make(chan struct{})
-		var (
-			datacc *eventstypes.ContainerCreate
-			datacd *eventstypes.ContainerDelete
-		)
-
-		eh := eventutil.InitEventHandler()
-		eh.Handle("/containers/create", func(e events.Envelope) {
-			if e.Event != nil {
-				anydata, err := typeurl.UnmarshalAny(e.Event)
-				if err != nil {
-					// just skip
-					return
-				}
-				switch v := anydata.(type) {
-				case *eventstypes.ContainerCreate:
-					datacc = v
-				default:
-					// just skip
-					return
-				}
-			}
-			// Load the container to get its ID for retrieving the container name from Labels.
-			// if an error occurs, the ID alone is sufficient for the stats screen.
-			container, _ := client.LoadContainer(ctx, datacc.ID)
-			clabels, _ := container.Labels(ctx)
-			s := statsutil.NewStats(datacc.ID, containerutil.GetContainerName(clabels))
-			if cStats.add(s) {
-				waitFirst.Add(1)
-				go collect(ctx, options.GOptions, s, waitFirst, datacc.ID, !options.NoStream)
-			}
-		})
-
-		eh.Handle("/containers/delete", func(e events.Envelope) {
-			if e.Event != nil {
-				anydata, err := typeurl.UnmarshalAny(e.Event)
-				if err != nil {
-					// just skip
-					return
-				}
-				switch v := anydata.(type) {
-				case *eventstypes.ContainerDelete:
-					datacd = v
-				default:
-					// just skip
-					return
-				}
-			}
-			cStats.remove(datacd.ID)
-		})
-
-		eventChan := make(chan *events.Envelope)
-		closeEventChan := make(chan struct{})
-
-		go eh.Watch(eventChan)
-		go monitorContainerEvents(started, eventChan, closeEventChan)
-
-		defer func() {
-			closeEventChan <- struct{}{}
-			close(eventChan)
-		}()
-
-		<-started
-
-		// Start a goroutine to retrieve the initial list of containers stats.
-		getContainerList()
-
-		// make sure each container get at least one valid stat data
-		waitFirst.Wait()
-
+		if err := sc.watch(ctx); err != nil {
+			return err
+		}
 	} else {
 		walker := &containerwalker.ContainerWalker{
 			Client: client,
 			OnFound: func(ctx context.Context, found containerwalker.Found) error {
-				// if an error occurs when getting labels, the ID alone is sufficient for the stats screen.
-				clabels, _ := found.Container.Labels(ctx)
-				s := statsutil.NewStats(found.Container.ID(), containerutil.GetContainerName(clabels))
-				if cStats.add(s) {
-					waitFirst.Add(1)
-					go collect(ctx, options.GOptions, s, waitFirst, found.Container.ID(), !options.NoStream)
-				}
+				sc.add(ctx, found.Container)
 				return nil
 			},
 		}
@@ -246,20 +313,18 @@ func Stats(ctx context.Context, client *containerd.Client, containerIDs []string
 		if err := walker.WalkAll(ctx, containerIDs, false); err != nil {
 			return err
 		}
-
-		// make sure each container get at least one valid stat data
-		waitFirst.Wait()
-
 	}
+	// make sure each container get at least one valid stat data
+	sc.waitFirst.Wait()
 
 	cleanScreen := func() {
-		if !options.NoStream {
+		if !options.NoStream && !jsonStream {
 			fmt.Fprint(options.Stdout, "\033[2J")
 			fmt.Fprint(options.Stdout, "\033[H")
 		}
 	}
 
-	ticker := time.NewTicker(500 * time.Millisecond)
+	ticker := time.NewTicker(sc.interval)
 	defer ticker.Stop()
 
 	// firstTick is for creating distant CPU readings.
@@ -284,6 +349,21 @@ func Stats(ctx context.Context, client *containerd.Client, containerIDs []string
 			}
 		}
 
+		if jsonStream {
+			if !firstTick {
+				tick := statsutil.StatsJSON{Read: time.Now(), Containers: []statsutil.StatsEntry{}}
+				for _, c := range ccstats {
+					if c.ID != "" {
+						tick.Containers = append(tick.Containers, c)
+					}
+				}
+				if err := json.NewEncoder(options.Stdout).Encode(tick); err != nil {
+					return err
+				}
+			}
+			ccstats = nil
+		}
+
 		for _, c := range ccstats {
 			if c.ID == "" {
 				continue
@@ -325,12 +405,8 @@ func Stats(ctx context.Context, client *containerd.Client, containerIDs []string
 			break
 		}
 		select {
-		case err, ok := <-closeChan:
-			if ok {
-				if err != nil {
-					return err
-				}
-			}
+		case err := <-sc.Err():
+			return err
 		default:
 			// just skip
 		}
@@ -340,7 +416,7 @@ func Stats(ctx context.Context, client *containerd.Client, containerIDs []string
 	return err
 }
 
-func collect(ctx context.Context, globalOptions types.GlobalCommandOptions, s *statsutil.Stats, waitFirst *sync.WaitGroup, id string, noStream bool) {
+func collect(ctx context.Context, globalOptions types.GlobalCommandOptions, s *statsutil.Stats, waitFirst *sync.WaitGroup, id string, interval time.Duration) {
 	log.G(ctx).Debugf("collecting stats for %s", s.ID)
 	var (
 		getFirst = true
@@ -372,61 +448,33 @@ func collect(ctx context.Context, globalOptions types.GlobalCommandOptions, s *s
 		previousStats := new(statsutil.ContainerStats)
 		firstSet := true
 		for {
-			// task is in the for loop to avoid nil task just after Container creation
-			task, err := container.Task(ctx, nil)
-			if err != nil {
-				u <- err
-				continue
-			}
-
-			// Sample system CPU usage close to container usage to avoid
-			// noise in metric calculations.
-			systemUsage, onlineCPUs, err := getSystemCPUUsage()
-			if err != nil {
-				u <- err
-				continue
-			}
-			systemInfo := statsutil.SystemInfo{
-				OnlineCPUs:  onlineCPUs,
-				SystemUsage: systemUsage,
-			}
-			metric, err := task.Metrics(ctx)
-			if err != nil {
-				u <- err
-				continue
-			}
-			anydata, err := typeurl.UnmarshalAny(metric.Data)
-			if err != nil {
-				u <- err
-				continue
-			}
-
-			netNS, err := containerinspector.InspectNetNS(ctx, int(task.Pid()))
-			if err != nil {
-				u <- err
-				continue
-			}
-
 			// when (firstSet == true), we only set container stats without rendering stat entry
-			statsEntry, err := setContainerStatsAndRenderStatsEntry(previousStats, firstSet, anydata, int(task.Pid()), netNS.Interfaces, systemInfo)
-			if err != nil {
-				u <- err
-				continue
+			statsEntry, err := sampleStats(ctx, container, previousStats, firstSet)
+			if err == nil {
+				if firstSet {
+					firstSet = false
+				} else {
+					s.SetStatistics(statsEntry)
+				}
 			}
-
-			if firstSet {
-				firstSet = false
-			} else {
-				s.SetStatistics(statsEntry)
+			select {
+			case u <- err:
+			case <-ctx.Done():
+				return
 			}
-			u <- nil
 			// sleep to create distant CPU readings
-			time.Sleep(500 * time.Millisecond)
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	for {
 		select {
-		case <-time.After(6 * time.Second):
+		case <-ctx.Done():
+			return
+		case <-time.After(max(6*time.Second, 2*interval)):
 			// zero out the values if we have not received an update within
 			// the specified duration.
 			s.SetErrorAndReset(errors.New("timeout waiting for stats"))
@@ -450,3 +498,39 @@ func collect(ctx context.Context, globalOptions types.GlobalCommandOptions, s *s
 		}
 	}
 }
+
+// sampleStats samples the statistics of the task of the container. When firstSet is true, the sample only sets
+// previousStats, to compute the CPU usage of the next samples.
+func sampleStats(ctx context.Context, container containerd.Container, previousStats *statsutil.ContainerStats, firstSet bool) (statsutil.StatsEntry, error) {
+	// task is loaded for each sample to avoid nil task just after Container creation
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return statsutil.StatsEntry{}, err
+	}
+
+	// Sample system CPU usage close to container usage to avoid
+	// noise in metric calculations.
+	systemUsage, onlineCPUs, err := getSystemCPUUsage()
+	if err != nil {
+		return statsutil.StatsEntry{}, err
+	}
+	systemInfo := statsutil.SystemInfo{
+		OnlineCPUs:  onlineCPUs,
+		SystemUsage: systemUsage,
+	}
+	metric, err := task.Metrics(ctx)
+	if err != nil {
+		return statsutil.StatsEntry{}, err
+	}
+	anydata, err := typeurl.UnmarshalAny(metric.Data)
+	if err != nil {
+		return statsutil.StatsEntry{}, err
+	}
+
+	netNS, err := containerinspector.InspectNetNS(ctx, int(task.Pid()))
+	if err != nil {
+		return statsutil.StatsEntry{}, err
+	}
+
+	return setContainerStatsAndRenderStatsEntry(previousStats, firstSet, anydata, int(task.Pid()), netNS.Interfaces, systemInfo)
+}
@@ -0,0 +1,42 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+)
+
+func TestStatsInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	err := Stats(context.Background(), nil, []string{"foo"}, types.ContainerStatsOptions{Interval: -time.Second})
+	assert.ErrorContains(t, err, "invalid interval -1s")
+}
+
+func TestNewStatsCollectorInterval(t *testing.T) {
+	t.Parallel()
+
+	// the default interval is used when the interval is not specified
+	assert.Equal(t, newStatsCollector(nil, types.GlobalCommandOptions{}, false, 0).interval, defaultStatsInterval)
+	assert.Equal(t, newStatsCollector(nil, types.GlobalCommandOptions{}, false, 2*time.Second).interval, 2*time.Second)
+}
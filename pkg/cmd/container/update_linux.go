@@ -0,0 +1,647 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	systemddbus "github.com/coreos/go-systemd/v22/dbus"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/godbus/dbus/v5"
+	"github.com/moby/sys/userns"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/cgroups/v3"
+	"github.com/containerd/cgroups/v3/cgroup2"
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
+	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
+)
+
+// DeviceUpdate specifies the changes of the devices of a container.
+type DeviceUpdate struct {
+	// CgroupRules replaces the rules set with --device-cgroup-rule, if CgroupRulesChanged is true
+	CgroupRules        []string
+	CgroupRulesChanged bool
+	// Add specifies the host devices to add, in the same format as --device
+	Add []string
+	// Remove specifies the devices to remove, by their path in the container (or on the host)
+	Remove []string
+}
+
+// UpdateSpecDevices applies the changes of the devices to the spec and to the HostConfig label of a container.
+func UpdateSpecDevices(ctx context.Context, spec *specs.Spec, hostConfig *dockercompat.HostConfigLabel, u DeviceUpdate) error {
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &specs.LinuxResources{}
+	}
+
+	if u.CgroupRulesChanged {
+		var rules []string
+		for _, r := range u.CgroupRules {
+			if r != "" {
+				rules = append(rules, r)
+			}
+		}
+		for _, r := range hostConfig.DeviceCgroupRules {
+			old, err := ParseDeviceCgroupRule(r)
+			if err != nil {
+				return err
+			}
+			spec.Linux.Resources.Devices = removeDeviceCgroup(spec.Linux.Resources.Devices, old)
+		}
+		for _, r := range rules {
+			rule, err := ParseDeviceCgroupRule(r)
+			if err != nil {
+				return err
+			}
+			spec.Linux.Resources.Devices = append(spec.Linux.Resources.Devices, rule)
+		}
+		hostConfig.DeviceCgroupRules = rules
+	}
+
+	for _, d := range u.Remove {
+		i := slices.IndexFunc(hostConfig.Devices, func(m dockercompat.DeviceMapping) bool {
+			return m.PathInContainer == d || m.PathOnHost == d
+		})
+		if i < 0 {
+			return fmt.Errorf("device %q is not added to the container", d)
+		}
+		m := hostConfig.Devices[i]
+		var devices []specs.LinuxDevice
+		for _, dev := range spec.Linux.Devices {
+			if dev.Path != m.PathInContainer && !strings.HasPrefix(dev.Path, strings.TrimSuffix(m.PathInContainer, "/")+"/") {
+				devices = append(devices, dev)
+				continue
+			}
+			spec.Linux.Resources.Devices = removeDeviceCgroup(spec.Linux.Resources.Devices, specs.LinuxDeviceCgroup{
+				Allow:  true,
+				Type:   dev.Type,
+				Major:  &dev.Major,
+				Minor:  &dev.Minor,
+				Access: m.CgroupPermissions,
+			})
+		}
+		spec.Linux.Devices = devices
+		hostConfig.Devices = slices.Delete(hostConfig.Devices, i, i+1)
+	}
+
+	for _, d := range u.Add {
+		devPath, conPath, mode, err := ParseDevice(d)
+		if err != nil {
+			return fmt.Errorf("failed to parse device %q: %w", d, err)
+		}
+		if slices.ContainsFunc(hostConfig.Devices, func(m dockercompat.DeviceMapping) bool {
+			return m.PathInContainer == conPath
+		}) {
+			return fmt.Errorf("device %q is already added to the container", conPath)
+		}
+		if err := oci.WithDevices(devPath, conPath, mode)(ctx, nil, nil, spec); err != nil {
+			return err
+		}
+		hostConfig.Devices = append(hostConfig.Devices, dockercompat.DeviceMapping{
+			PathOnHost:        devPath,
+			PathInContainer:   conPath,
+			CgroupPermissions: mode,
+		})
+	}
+	return nil
+}
+
+// removeDeviceCgroup removes the first rule that equals to the given rule.
+func removeDeviceCgroup(rules []specs.LinuxDeviceCgroup, rule specs.LinuxDeviceCgroup) []specs.LinuxDeviceCgroup {
+	i := slices.IndexFunc(rules, func(r specs.LinuxDeviceCgroup) bool {
+		return r.Allow == rule.Allow && r.Type == rule.Type && r.Access == rule.Access &&
+			equalDeviceNumber(r.Major, rule.Major) && equalDeviceNumber(r.Minor, rule.Minor)
+	})
+	if i < 0 {
+		return rules
+	}
+	return slices.Delete(rules, i, i+1)
+}
+
+func equalDeviceNumber(a, b *int64) bool {
+	if a == nil || *a == -1 {
+		return b == nil || *b == -1
+	}
+	return b != nil && *a == *b
+}
+
+// ParseThrottleDevices parses device-rate strings (e.g., "/dev/sda:1mb") for --device-{read,write}-bps,
+// or for --device-{read,write}-iops if iops is true.
+func ParseThrottleDevices(vals []string, iops bool) ([]specs.LinuxThrottleDevice, error) {
+	var (
+		devices []*ThrottleDevice
+		err     error
+	)
+	if iops {
+		devices, err = validateThrottleIOpsDevices(vals)
+	} else {
+		devices, err = validateThrottleBpsDevices(vals)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toOCIThrottleDevices(devices)
+}
+
+// MergeThrottleDevices sets the rates of the devices in the list. A device with rate 0 is removed from the list.
+func MergeThrottleDevices(current, devices []specs.LinuxThrottleDevice) []specs.LinuxThrottleDevice {
+	res := slices.Clone(current)
+	for _, d := range devices {
+		i := slices.IndexFunc(res, func(c specs.LinuxThrottleDevice) bool {
+			return c.Major == d.Major && c.Minor == d.Minor
+		})
+		switch {
+		case i < 0 && d.Rate != 0:
+			res = append(res, d)
+		case i >= 0 && d.Rate != 0:
+			res[i].Rate = d.Rate
+		case i >= 0:
+			res = slices.Delete(res, i, i+1)
+		}
+	}
+	return res
+}
+
+// ValidateOOMScoreAdj validates the OOM score adjustment, and limits it to the minimum value allowed in a user namespace.
+func ValidateOOMScoreAdj(oomScoreAdj int) (int, error) {
+	// score=0 means literally zero, not "unchanged"
+	if oomScoreAdj < -1000 || oomScoreAdj > 1000 {
+		return 0, fmt.Errorf("invalid value %d, range for oom score adj is [-1000, 1000]", oomScoreAdj)
+	}
+
+	if userns.RunningInUserNS() {
+		// > The value of /proc/<pid>/oom_score_adj may be reduced no lower than the last value set by a CAP_SYS_RESOURCE process.
+		// > To reduce the value any lower requires CAP_SYS_RESOURCE.
+		// https://github.com/torvalds/linux/blob/v6.0/Documentation/filesystems/proc.rst#31-procpidoom_adj--procpidoom_score_adj--adjust-the-oom-killer-score
+		//
+		// The minimum=100 is from `/proc/$(pgrep -u $(id -u) systemd)/oom_score_adj`
+		// (FIXME: find a more robust way to get the current minimum value)
+		const minimum = 100
+		if oomScoreAdj < minimum {
+			log.L.Warnf("Limiting oom_score_adj (%d -> %d)", oomScoreAdj, minimum)
+			oomScoreAdj = minimum
+		}
+	}
+	return oomScoreAdj, nil
+}
+
+// TaskUpdateOptions specifies the resources of a running container to update,
+// in addition to the ones updated by the OCI runtime with containerd.WithResources.
+type TaskUpdateOptions struct {
+	// Devices updates the device cgroup and the device nodes
+	Devices bool
+	// BlockIO updates the block IO throttling of the devices
+	BlockIO bool
+	// OOMScoreAdj updates the OOM score adjustment of the processes
+	OOMScoreAdj bool
+}
+
+// UpdateTaskResources applies the resources of the spec that the OCI runtime does not update, to a running container.
+// oldSpec is the spec the container is running with.
+func UpdateTaskResources(ctx context.Context, task containerd.Task, oldSpec, spec *specs.Spec, options TaskUpdateOptions) error {
+	pid := int(task.Pid())
+	if options.Devices {
+		if rootlessutil.IsRootless() {
+			log.G(ctx).Warn("updating the devices of a running container is not supported in rootless mode, the devices will be updated when the container is restarted")
+		} else {
+			if err := updateDeviceNodes(pid, oldSpec, spec); err != nil {
+				return err
+			}
+			if err := updateDeviceCgroup(ctx, pid, spec); err != nil {
+				return err
+			}
+		}
+	}
+	if options.BlockIO {
+		if err := updateBlkioThrottle(pid, oldSpec, spec); err != nil {
+			return err
+		}
+	}
+	if options.OOMScoreAdj && spec.Process != nil && spec.Process.OOMScoreAdj != nil {
+		processes, err := task.Pids(ctx)
+		if err != nil {
+			return err
+		}
+		score := []byte(strconv.Itoa(*spec.Process.OOMScoreAdj))
+		for _, p := range processes {
+			if err := os.WriteFile(fmt.Sprintf("/proc/%d/oom_score_adj", p.Pid), score, 0o644); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to set oom_score_adj of process %d: %w", p.Pid, err)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultAllowedDevices are the devices always allowed by the OCI runtime (runc), in addition to the rules of the spec.
+var defaultAllowedDevices = []specs.LinuxDeviceCgroup{
+	{Allow: true, Type: "c", Access: "m"},
+	{Allow: true, Type: "b", Access: "m"},
+	{Allow: true, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(3), Access: "rwm"},    // /dev/null
+	{Allow: true, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(8), Access: "rwm"},    // /dev/random
+	{Allow: true, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(7), Access: "rwm"},    // /dev/full
+	{Allow: true, Type: "c", Major: deviceNumber(5), Minor: deviceNumber(0), Access: "rwm"},    // /dev/tty
+	{Allow: true, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(5), Access: "rwm"},    // /dev/zero
+	{Allow: true, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(9), Access: "rwm"},    // /dev/urandom
+	{Allow: true, Type: "c", Major: deviceNumber(136), Access: "rwm"},                          // /dev/pts/*
+	{Allow: true, Type: "c", Major: deviceNumber(5), Minor: deviceNumber(2), Access: "rwm"},    // /dev/ptmx
+	{Allow: true, Type: "c", Major: deviceNumber(10), Minor: deviceNumber(200), Access: "rwm"}, // /dev/net/tun
+}
+
+func deviceNumber(n int64) *int64 {
+	return &n
+}
+
+// cgroupPath returns the path of the cgroup of the process, for the given cgroup v1 controller.
+func cgroupPath(pid int, controller string) (string, error) {
+	legacy, unified, err := cgroups.ParseCgroupFileUnified(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	if cgroups.Mode() == cgroups.Unified {
+		return filepath.Join("/sys/fs/cgroup", unified), nil
+	}
+	p, ok := legacy[controller]
+	if !ok {
+		return "", fmt.Errorf("cgroup controller %q is not available", controller)
+	}
+	return filepath.Join("/sys/fs/cgroup", controller, p), nil
+}
+
+// updateDeviceCgroup replaces the device rules of the cgroup of the container with the rules of the spec.
+func updateDeviceCgroup(ctx context.Context, pid int, spec *specs.Spec) error {
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return nil
+	}
+	rules := append(slices.Clone(spec.Linux.Resources.Devices), defaultAllowedDevices...)
+	dir, err := cgroupPath(pid, "devices")
+	if err != nil {
+		return err
+	}
+	if cgroups.Mode() != cgroups.Unified {
+		// the rules are applied in order, and the first rule of the spec denies all the devices
+		for _, r := range rules {
+			file := "devices.deny"
+			if r.Allow {
+				file = "devices.allow"
+			}
+			typ := r.Type
+			if typ == "" {
+				typ = "a"
+			}
+			rule := fmt.Sprintf("%s %s:%s %s", typ, formatDeviceNumber(r.Major), formatDeviceNumber(r.Minor), r.Access)
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(rule), 0o600); err != nil {
+				return fmt.Errorf("failed to write %q to %s: %w", rule, file, err)
+			}
+		}
+		return nil
+	}
+
+	// With cgroup v2, the devices are controlled by an eBPF program attached to the cgroup.
+	// Attach a program for the new rules, then detach the programs of the previous rules.
+	// With the systemd cgroup manager, systemd attaches its own program for the DeviceAllow= property of the unit,
+	// which is replaced by systemd when the property is updated.
+	if unit, ok := systemdUnitName(spec.Linux.CgroupsPath); ok {
+		if err := updateSystemdDeviceAllow(ctx, unit, rules); err != nil {
+			return err
+		}
+	}
+	dirFD, err := unix.Open(dir, unix.O_DIRECTORY|unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	defer unix.Close(dirFD)
+	attached, err := link.QueryPrograms(link.QueryOptions{Target: dirFD, Attach: ebpf.AttachCGroupDevice})
+	if err != nil {
+		return fmt.Errorf("failed to query the device programs of %s: %w", dir, err)
+	}
+	insts, license, err := cgroup2.DeviceFilter(rules)
+	if err != nil {
+		return err
+	}
+	if _, err := cgroup2.LoadAttachCgroupDeviceFilter(insts, license, dirFD); err != nil {
+		return err
+	}
+	for _, p := range attached.Programs {
+		prog, err := ebpf.NewProgramFromID(p.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load the device program %d: %w", p.ID, err)
+		}
+		if info, err := prog.Info(); err == nil && isSystemdDeviceProgram(info.Name) {
+			prog.Close()
+			continue
+		}
+		err = link.RawDetachProgram(link.RawDetachProgramOptions{Target: dirFD, Program: prog, Attach: ebpf.AttachCGroupDevice})
+		prog.Close()
+		if err != nil {
+			return fmt.Errorf("failed to detach the device program %d: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// isSystemdDeviceProgram returns true for the device programs attached by systemd (for the DeviceAllow= property),
+// as opposed to the ones attached by the OCI runtime or by updateDeviceCgroup, which have no names.
+func isSystemdDeviceProgram(name string) bool {
+	return strings.HasPrefix(name, "sd_")
+}
+
+// systemdUnitName returns the unit of the container from its cgroups path, if it is a systemd one ("slice:prefix:name").
+func systemdUnitName(cgroupsPath string) (string, bool) {
+	parts := strings.Split(cgroupsPath, ":")
+	if len(parts) != 3 {
+		return "", false
+	}
+	if strings.HasSuffix(parts[2], ".slice") {
+		return parts[2], true
+	}
+	return parts[1] + "-" + parts[2] + ".scope", true
+}
+
+// updateSystemdDeviceAllow replaces the DeviceAllow= property of the systemd unit of the container with the rules.
+func updateSystemdDeviceAllow(ctx context.Context, unit string, rules []specs.LinuxDeviceCgroup) error {
+	props, err := systemdDeviceProperties(ctx, rules)
+	if err != nil {
+		return err
+	}
+	conn, err := systemddbus.NewSystemConnectionContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetUnitPropertiesContext(ctx, unit, true, props...); err != nil {
+		return fmt.Errorf("failed to set the device properties of unit %s: %w", unit, err)
+	}
+	return nil
+}
+
+// deviceAllowEntry is an entry of the DeviceAllow= property, with the D-Bus signature (ss).
+type deviceAllowEntry struct {
+	Path  string
+	Perms string
+}
+
+// systemdDeviceProperties converts the device rules to the DevicePolicy= and DeviceAllow= properties, like runc.
+// The rules must start with a rule denying all the devices, followed by the allowed devices.
+func systemdDeviceProperties(ctx context.Context, rules []specs.LinuxDeviceCgroup) ([]systemddbus.Property, error) {
+	entries := []deviceAllowEntry{}
+	for _, r := range rules {
+		all := r.Type == "" || r.Type == "a"
+		if !r.Allow {
+			if !all || formatDeviceNumber(r.Major) != "*" || formatDeviceNumber(r.Minor) != "*" {
+				return nil, fmt.Errorf("the device rule \"%s %s:%s %s\" cannot be set with systemd", r.Type, formatDeviceNumber(r.Major), formatDeviceNumber(r.Minor), r.Access)
+			}
+			entries = []deviceAllowEntry{}
+			continue
+		}
+		if r.Access == "" {
+			continue
+		}
+		if all {
+			entries = append(entries, deviceAllowEntry{"char-*", r.Access}, deviceAllowEntry{"block-*", r.Access})
+			continue
+		}
+		group, dir := "char", "/dev/char"
+		if r.Type == "b" {
+			group, dir = "block", "/dev/block"
+		}
+		switch {
+		case formatDeviceNumber(r.Major) == "*":
+			entries = append(entries, deviceAllowEntry{group + "-*", r.Access})
+		case formatDeviceNumber(r.Minor) == "*":
+			// systemd only allows all the minor numbers of a major number by the name of its driver
+			name, err := deviceDriverName(r.Type, *r.Major)
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				log.G(ctx).Warnf("the driver of the %s devices with the major number %d is unknown, the devices are not allowed by systemd", group, *r.Major)
+				continue
+			}
+			entries = append(entries, deviceAllowEntry{group + "-" + name, r.Access})
+		default:
+			entries = append(entries, deviceAllowEntry{fmt.Sprintf("%s/%d:%d", dir, *r.Major, *r.Minor), r.Access})
+		}
+	}
+	return []systemddbus.Property{
+		{Name: "DevicePolicy", Value: dbus.MakeVariant("strict")},
+		// an empty list resets the property, the next one adds the entries
+		{Name: "DeviceAllow", Value: dbus.MakeVariant([]deviceAllowEntry{})},
+		{Name: "DeviceAllow", Value: dbus.MakeVariant(entries)},
+	}, nil
+}
+
+// deviceDriverName returns the name of the driver of the major number in /proc/devices, or "" if it is not found.
+func deviceDriverName(typ string, major int64) (string, error) {
+	f, err := os.Open("/proc/devices")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	section := "Character devices:"
+	if typ == "b" {
+		section = "Block devices:"
+	}
+	var found bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, ":") {
+			found = line == section
+			continue
+		}
+		fields := strings.Fields(line)
+		if found && len(fields) == 2 && fields[0] == strconv.FormatInt(major, 10) {
+			return fields[1], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+func formatDeviceNumber(n *int64) string {
+	if n == nil || *n == -1 {
+		return "*"
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+// updateDeviceNodes creates the device nodes added to the spec, and removes the ones removed from the spec,
+// in the root filesystem of the container.
+func updateDeviceNodes(pid int, oldSpec, spec *specs.Spec) error {
+	var oldDevices, devices []specs.LinuxDevice
+	if oldSpec.Linux != nil {
+		oldDevices = oldSpec.Linux.Devices
+	}
+	if spec.Linux != nil {
+		devices = spec.Linux.Devices
+	}
+	root := fmt.Sprintf("/proc/%d/root", pid)
+	for _, d := range oldDevices {
+		if slices.ContainsFunc(devices, func(n specs.LinuxDevice) bool { return n.Path == d.Path }) {
+			continue
+		}
+		p, err := securejoin.SecureJoin(root, d.Path)
+		if err != nil {
+			return err
+		}
+		var st unix.Stat_t
+		if err := unix.Lstat(p, &st); err != nil {
+			if errors.Is(err, unix.ENOENT) {
+				continue
+			}
+			return err
+		}
+		// do not remove a file that is not the device node created for the device
+		if st.Mode&unix.S_IFMT != deviceFileType(d.Type) || st.Rdev != unix.Mkdev(uint32(d.Major), uint32(d.Minor)) {
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("failed to remove device %q: %w", d.Path, err)
+		}
+	}
+	for _, d := range devices {
+		if slices.ContainsFunc(oldDevices, func(o specs.LinuxDevice) bool { return o.Path == d.Path }) {
+			continue
+		}
+		p, err := securejoin.SecureJoin(root, d.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		var mode uint32 = 0o666
+		if d.FileMode != nil {
+			mode = uint32(d.FileMode.Perm())
+		}
+		if err := unix.Mknod(p, deviceFileType(d.Type)|mode, int(unix.Mkdev(uint32(d.Major), uint32(d.Minor)))); err != nil {
+			if errors.Is(err, unix.EEXIST) {
+				continue
+			}
+			return fmt.Errorf("failed to create device %q: %w", d.Path, err)
+		}
+		var uid, gid int
+		if d.UID != nil {
+			uid = int(*d.UID)
+		}
+		if d.GID != nil {
+			gid = int(*d.GID)
+		}
+		if err := os.Lchown(p, uid, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deviceFileType(typ string) uint32 {
+	switch typ {
+	case "b":
+		return unix.S_IFBLK
+	case "p":
+		return unix.S_IFIFO
+	default:
+		return unix.S_IFCHR
+	}
+}
+
+// updateBlkioThrottle updates the block IO throttling of the cgroup of the container,
+// and resets the throttling of the devices removed from the spec.
+func updateBlkioThrottle(pid int, oldSpec, spec *specs.Spec) error {
+	var oldIO, blockIO specs.LinuxBlockIO
+	if oldSpec.Linux != nil && oldSpec.Linux.Resources != nil && oldSpec.Linux.Resources.BlockIO != nil {
+		oldIO = *oldSpec.Linux.Resources.BlockIO
+	}
+	if spec.Linux != nil && spec.Linux.Resources != nil && spec.Linux.Resources.BlockIO != nil {
+		blockIO = *spec.Linux.Resources.BlockIO
+	}
+	throttles := []struct {
+		v1, v2   string
+		old, new []specs.LinuxThrottleDevice
+	}{
+		{"blkio.throttle.read_bps_device", "rbps", oldIO.ThrottleReadBpsDevice, blockIO.ThrottleReadBpsDevice},
+		{"blkio.throttle.write_bps_device", "wbps", oldIO.ThrottleWriteBpsDevice, blockIO.ThrottleWriteBpsDevice},
+		{"blkio.throttle.read_iops_device", "riops", oldIO.ThrottleReadIOPSDevice, blockIO.ThrottleReadIOPSDevice},
+		{"blkio.throttle.write_iops_device", "wiops", oldIO.ThrottleWriteIOPSDevice, blockIO.ThrottleWriteIOPSDevice},
+	}
+
+	dir, err := cgroupPath(pid, "blkio")
+	if err != nil {
+		return err
+	}
+	type device struct{ major, minor int64 }
+	// rates of each device, in the order of the throttles
+	rates := make(map[device][]uint64)
+	var order []device
+	for i, t := range throttles {
+		for _, list := range [][]specs.LinuxThrottleDevice{t.old, t.new} {
+			for _, d := range list {
+				dev := device{d.Major, d.Minor}
+				if _, ok := rates[dev]; !ok {
+					rates[dev] = make([]uint64, len(throttles))
+					order = append(order, dev)
+				}
+			}
+		}
+		for _, d := range t.new {
+			rates[device{d.Major, d.Minor}][i] = d.Rate
+		}
+	}
+
+	for _, dev := range order {
+		if cgroups.Mode() == cgroups.Unified {
+			// 0 means "max" (unlimited) in io.max
+			line := fmt.Sprintf("%d:%d", dev.major, dev.minor)
+			for i, t := range throttles {
+				rate := "max"
+				if r := rates[dev][i]; r != 0 {
+					rate = strconv.FormatUint(r, 10)
+				}
+				line += fmt.Sprintf(" %s=%s", t.v2, rate)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "io.max"), []byte(line), 0o600); err != nil {
+				return fmt.Errorf("failed to write %q to io.max: %w", line, err)
+			}
+			continue
+		}
+		// 0 removes the limit with cgroup v1
+		for i, t := range throttles {
+			line := fmt.Sprintf("%d:%d %d", dev.major, dev.minor, rates[dev][i])
+			if err := os.WriteFile(filepath.Join(dir, t.v1), []byte(line), 0o600); err != nil {
+				return fmt.Errorf("failed to write %q to %s: %w", line, t.v1, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
+)
+
+func TestParseDeviceCgroupRule(t *testing.T) {
+	t.Parallel()
+	major, minor := int64(1), int64(3)
+	tests := []struct {
+		rule     string
+		expected specs.LinuxDeviceCgroup
+		err      string
+	}{
+		{
+			rule:     "c 1:3 mr",
+			expected: specs.LinuxDeviceCgroup{Allow: true, Type: "c", Major: &major, Minor: &minor, Access: "mr"},
+		},
+		{
+			rule:     "b 1:* rwm",
+			expected: specs.LinuxDeviceCgroup{Allow: true, Type: "b", Major: &major, Access: "rwm"},
+		},
+		{
+			rule:     "a *:* rwm",
+			expected: specs.LinuxDeviceCgroup{Allow: true, Type: "a", Access: "rwm"},
+		},
+		{rule: "c 1:3", err: "must be formatted"},
+		{rule: "x 1:3 rwm", err: "unknown device type"},
+		{rule: "c 1 rwm", err: "MAJOR:MINOR"},
+		{rule: "c -1:3 rwm", err: "invalid device number"},
+		{rule: "c 1:3 rwx", err: "invalid mode"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.rule, func(t *testing.T) {
+			t.Parallel()
+			rule, err := ParseDeviceCgroupRule(tc.rule)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, rule, tc.expected)
+		})
+	}
+}
+
+func TestUpdateSpecDevices(t *testing.T) {
+	t.Parallel()
+	denyAll := specs.LinuxDeviceCgroup{Allow: false, Access: "rwm"}
+	spec := &specs.Spec{
+		Linux: &specs.Linux{
+			Resources: &specs.LinuxResources{Devices: []specs.LinuxDeviceCgroup{denyAll}},
+		},
+	}
+	var hostConfig dockercompat.HostConfigLabel
+
+	err := UpdateSpecDevices(context.Background(), spec, &hostConfig, DeviceUpdate{
+		CgroupRules:        []string{"c 10:200 rwm"},
+		CgroupRulesChanged: true,
+		Add:                []string{"/dev/null:/dev/foo:rw"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hostConfig.DeviceCgroupRules, []string{"c 10:200 rwm"})
+	assert.DeepEqual(t, hostConfig.Devices, []dockercompat.DeviceMapping{
+		{PathOnHost: "/dev/null", PathInContainer: "/dev/foo", CgroupPermissions: "rw"},
+	})
+	assert.Equal(t, len(spec.Linux.Devices), 1)
+	assert.Equal(t, spec.Linux.Devices[0].Path, "/dev/foo")
+	assert.Equal(t, len(spec.Linux.Resources.Devices), 3)
+
+	// the same device cannot be added twice
+	err = UpdateSpecDevices(context.Background(), spec, &hostConfig, DeviceUpdate{Add: []string{"/dev/null:/dev/foo"}})
+	assert.ErrorContains(t, err, "already added")
+
+	// replace the rules, and remove the device
+	err = UpdateSpecDevices(context.Background(), spec, &hostConfig, DeviceUpdate{
+		CgroupRules:        []string{"c 1:3 r"},
+		CgroupRulesChanged: true,
+		Remove:             []string{"/dev/foo"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hostConfig.DeviceCgroupRules, []string{"c 1:3 r"})
+	assert.Equal(t, len(hostConfig.Devices), 0)
+	assert.Equal(t, len(spec.Linux.Devices), 0)
+	major, minor := int64(1), int64(3)
+	assert.DeepEqual(t, spec.Linux.Resources.Devices, []specs.LinuxDeviceCgroup{
+		denyAll,
+		{Allow: true, Type: "c", Major: &major, Minor: &minor, Access: "r"},
+	})
+
+	err = UpdateSpecDevices(context.Background(), spec, &hostConfig, DeviceUpdate{Remove: []string{"/dev/foo"}})
+	assert.ErrorContains(t, err, "not added")
+
+	// an empty rule removes the rules
+	err = UpdateSpecDevices(context.Background(), spec, &hostConfig, DeviceUpdate{
+		CgroupRules:        []string{""},
+		CgroupRulesChanged: true,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(hostConfig.DeviceCgroupRules), 0)
+	assert.DeepEqual(t, spec.Linux.Resources.Devices, []specs.LinuxDeviceCgroup{denyAll})
+}
+
+func TestMergeThrottleDevices(t *testing.T) {
+	t.Parallel()
+	current := []specs.LinuxThrottleDevice{
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 0}, Rate: 100},
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 16}, Rate: 200},
+	}
+	merged := MergeThrottleDevices(current, []specs.LinuxThrottleDevice{
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 0}, Rate: 0},
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 16}, Rate: 300},
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 32}, Rate: 400},
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 48}, Rate: 0},
+	})
+	assert.DeepEqual(t, merged, []specs.LinuxThrottleDevice{
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 16}, Rate: 300},
+		{LinuxBlockIODevice: specs.LinuxBlockIODevice{Major: 8, Minor: 32}, Rate: 400},
+	})
+	// the current list is not modified
+	assert.Equal(t, current[1].Rate, uint64(200))
+}
+
+func TestIsSystemdDeviceProgram(t *testing.T) {
+	t.Parallel()
+
+	// the names are truncated to 15 characters by the kernel
+	assert.Assert(t, isSystemdDeviceProgram("sd_devices"))
+	// the programs of runc and of updateDeviceCgroup have no names
+	assert.Assert(t, !isSystemdDeviceProgram(""))
+	assert.Assert(t, !isSystemdDeviceProgram("devices"))
+}
+
+func TestSystemdUnitName(t *testing.T) {
+	t.Parallel()
+
+	unit, ok := systemdUnitName("system.slice:nerdctl:abc")
+	assert.Assert(t, ok)
+	assert.Equal(t, unit, "nerdctl-abc.scope")
+	unit, ok = systemdUnitName("system.slice:nerdctl:abc.slice")
+	assert.Assert(t, ok)
+	assert.Equal(t, unit, "abc.slice")
+	// the cgroupfs paths
+	_, ok = systemdUnitName("/default/abc")
+	assert.Assert(t, !ok)
+	_, ok = systemdUnitName("")
+	assert.Assert(t, !ok)
+}
+
+func TestSystemdDeviceProperties(t *testing.T) {
+	t.Parallel()
+
+	props, err := systemdDeviceProperties(context.Background(), []specs.LinuxDeviceCgroup{
+		{Allow: false, Access: "rwm"},
+		{Allow: true, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(5), Access: "rwm"},
+		{Allow: true, Type: "c", Major: deviceNumber(136), Minor: deviceNumber(-1), Access: "rw"},
+		// skipped, the major number is not in /proc/devices
+		{Allow: true, Type: "b", Major: deviceNumber(4000), Access: "r"},
+		{Allow: true, Type: "c", Access: "m"},
+		{Allow: true, Type: "a", Access: "m"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(props), 3)
+	assert.Equal(t, props[0].Value.Value(), "strict")
+	// the previous entries are reset
+	assert.DeepEqual(t, props[1].Value.Value(), []deviceAllowEntry{})
+	assert.DeepEqual(t, props[2].Value.Value(), []deviceAllowEntry{
+		{"/dev/char/1:5", "rwm"},
+		{"char-pts", "rw"},
+		{"char-*", "m"},
+		{"char-*", "m"},
+		{"block-*", "m"},
+	})
+
+	// only the rule denying all the devices can be set
+	_, err = systemdDeviceProperties(context.Background(), []specs.LinuxDeviceCgroup{
+		{Allow: false, Type: "c", Major: deviceNumber(1), Minor: deviceNumber(5), Access: "rwm"},
+	})
+	assert.ErrorContains(t, err, "cannot be set with systemd")
+}
@@ -0,0 +1,66 @@
+//go:build !linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	containerd "github.com/containerd/containerd/v2/client"
+
+	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
+)
+
+// DeviceUpdate specifies the changes of the devices of a container.
+type DeviceUpdate struct {
+	CgroupRules        []string
+	CgroupRulesChanged bool
+	Add                []string
+	Remove             []string
+}
+
+func UpdateSpecDevices(ctx context.Context, spec *specs.Spec, hostConfig *dockercompat.HostConfigLabel, u DeviceUpdate) error {
+	return errors.New("updating devices is only supported on Linux")
+}
+
+func ParseThrottleDevices(vals []string, iops bool) ([]specs.LinuxThrottleDevice, error) {
+	return nil, errors.New("block IO throttling is only supported on Linux")
+}
+
+func MergeThrottleDevices(current, devices []specs.LinuxThrottleDevice) []specs.LinuxThrottleDevice {
+	return current
+}
+
+func ValidateOOMScoreAdj(oomScoreAdj int) (int, error) {
+	return 0, errors.New("oom score adj is only supported on Linux")
+}
+
+// TaskUpdateOptions specifies the resources of a running container to update,
+// in addition to the ones updated by the OCI runtime with containerd.WithResources.
+type TaskUpdateOptions struct {
+	Devices     bool
+	BlockIO     bool
+	OOMScoreAdj bool
+}
+
+func UpdateTaskResources(ctx context.Context, task containerd.Task, oldSpec, spec *specs.Spec, options TaskUpdateOptions) error {
+	return nil
+}
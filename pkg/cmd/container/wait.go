@@ -20,59 +20,295 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
+	"sync"
+	"text/template"
 
+	eventstypes "github.com/containerd/containerd/api/events"
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/typeurl/v2"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/formatter"
+	"github.com/containerd/nerdctl/v2/pkg/healthcheck"
 	"github.com/containerd/nerdctl/v2/pkg/idutil/containerwalker"
+	"github.com/containerd/nerdctl/v2/pkg/labels"
 )
 
-// Wait blocks until all the containers specified by reqs have stopped, then print their exit codes.
+// The conditions of `nerdctl wait --condition`.
+const (
+	// WaitConditionNotRunning waits until the container is not running. It is satisfied immediately for a stopped container.
+	WaitConditionNotRunning = "not-running"
+	// WaitConditionNextExit waits for the next exit of the container, even when it is not running yet.
+	WaitConditionNextExit = "next-exit"
+	// WaitConditionRemoved waits until the container is removed.
+	WaitConditionRemoved = "removed"
+	// WaitConditionHealthy waits until the health status of the container is healthy.
+	WaitConditionHealthy = "healthy"
+)
+
+// WaitResult is the result of waiting for a container.
+// StatusCode and Error are compatible with the response of the wait API of Docker.
+type WaitResult struct {
+	// Container is the name or the ID of the container, as specified in the request
+	Container string
+	// StatusCode is the exit code of the container, or -1 when it is unknown
+	StatusCode int
+	// Error is the error that happened while waiting, if any
+	Error *WaitError `json:",omitempty"`
+}
+
+// WaitError is the error of a WaitResult.
+type WaitError struct {
+	Message string
+}
+
+// Wait blocks until all the containers specified by reqs satisfy options.Condition, then print their exit codes.
+// The containers are waited for concurrently, and the results are printed in the order of reqs.
 func Wait(ctx context.Context, client *containerd.Client, reqs []string, options types.ContainerWaitOptions) error {
-	var containers []containerd.Container
+	condition := options.Condition
+	switch condition {
+	case "":
+		condition = WaitConditionNotRunning
+	case WaitConditionNotRunning, WaitConditionNextExit, WaitConditionRemoved, WaitConditionHealthy:
+	default:
+		return fmt.Errorf("invalid condition %q, must be one of %q, %q, %q or %q", condition,
+			WaitConditionNotRunning, WaitConditionNextExit, WaitConditionRemoved, WaitConditionHealthy)
+	}
+	if options.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %s", options.Timeout)
+	}
+	var tmpl *template.Template
+	if options.Format != "" {
+		var err error
+		tmpl, err = formatter.ParseTemplate(options.Format)
+		if err != nil {
+			return err
+		}
+	}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	results := make([]WaitResult, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, err := waitRequest(ctx, client, req, condition)
+			results[i] = WaitResult{Container: req, StatusCode: code}
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					err = fmt.Errorf("timed out after %s waiting for condition %q", options.Timeout, condition)
+				}
+				results[i].Error = &WaitError{Message: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	w := options.Stdout
+	for _, res := range results {
+		if res.Error != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", res.Container, res.Error.Message))
+		}
+		if tmpl != nil {
+			if err := tmpl.Execute(w, res); err != nil {
+				return err
+			}
+			fmt.Fprintln(w)
+		} else if res.Error == nil {
+			fmt.Fprintln(w, res.StatusCode)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors:\n%w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// waitRequest waits for the container specified by req.
+func waitRequest(ctx context.Context, client *containerd.Client, req string, condition string) (int, error) {
+	var container containerd.Container
 	walker := &containerwalker.ContainerWalker{
 		Client: client,
 		OnFound: func(ctx context.Context, found containerwalker.Found) error {
 			if found.MatchCount > 1 {
 				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
 			}
-			containers = append(containers, found.Container)
+			container = found.Container
 			return nil
 		},
 	}
+	n, err := walker.Walk(ctx, req)
+	if err != nil {
+		return -1, err
+	} else if n == 0 {
+		return -1, fmt.Errorf("no such container: %s", req)
+	}
+	return waitContainer(ctx, client, container, condition)
+}
 
-	// check if all containers from `reqs` exist
-	if err := walker.WalkAll(ctx, reqs, false); err != nil {
-		return err
+// waitContainer waits until container satisfies condition, and returns the exit code of its last exit.
+// The exits, the updates of the health status and the removal of the container are watched with the containerd events.
+func waitContainer(ctx context.Context, client *containerd.Client, container containerd.Container, condition string) (int, error) {
+	ns, err := namespaces.NamespaceRequired(ctx)
+	if err != nil {
+		return -1, err
 	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Subscribe before checking the status of the container, not to miss the events in the meantime.
+	eventsCh, errCh := client.EventService().Subscribe(ctx,
+		fmt.Sprintf(`namespace==%s,topic=="/tasks/exit"`, ns),
+		fmt.Sprintf(`namespace==%s,topic=="/containers/update"`, ns),
+		fmt.Sprintf(`namespace==%s,topic=="/containers/delete"`, ns),
+	)
 
-	var errs []error
-	w := options.Stdout
-	for _, container := range containers {
-		if waitErr := waitContainer(ctx, w, container); waitErr != nil {
-			errs = append(errs, waitErr)
+	id := container.ID()
+	statusCode, running := 0, false
+	// exitC receives the exit of the running task, in case the subscription is not ready yet when it exits
+	var exitC <-chan containerd.ExitStatus
+	task, err := container.Task(ctx, nil)
+	if err == nil {
+		status, err := task.Status(ctx)
+		if err != nil {
+			return -1, err
+		}
+		switch status.Status {
+		case containerd.Stopped:
+			statusCode = int(status.ExitStatus)
+		case containerd.Running, containerd.Paused, containerd.Pausing:
+			running = true
+			exitC, err = task.Wait(ctx)
+			if err != nil {
+				return -1, err
+			}
 		}
+	} else if !errdefs.IsNotFound(err) {
+		return -1, err
+	}
+	// onExit returns true when the exit satisfies the condition or makes it impossible to satisfy
+	onExit := func(code int) (bool, error) {
+		statusCode = code
+		switch condition {
+		case WaitConditionNotRunning, WaitConditionNextExit:
+			return true, nil
+		case WaitConditionHealthy:
+			return true, errors.New("container exited before becoming healthy")
+		}
+		return false, nil
 	}
-	return errors.Join(errs...)
-}
 
-func waitContainer(ctx context.Context, w io.Writer, container containerd.Container) error {
-	task, err := container.Task(ctx, nil)
-	if err != nil {
-		return err
+	switch condition {
+	case WaitConditionNotRunning:
+		if !running {
+			return statusCode, nil
+		}
+	case WaitConditionHealthy:
+		info, err := container.Info(ctx)
+		if err != nil {
+			return -1, err
+		}
+		if !hasHealthCheck(info.Labels) {
+			return -1, errors.New("container has no health check configured")
+		}
+		if !running {
+			return statusCode, errors.New("container is not running")
+		}
+		if done, err := checkHealthStatus(info.Labels); done {
+			return statusCode, err
+		}
 	}
 
-	statusC, err := task.Wait(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case err := <-errCh:
+			if err == nil {
+				err = errors.New("the event stream was closed")
+			}
+			return -1, err
+		case status := <-exitC:
+			exitC = nil
+			code, _, err := status.Result()
+			if err != nil {
+				return -1, err
+			}
+			if done, err := onExit(int(code)); done {
+				return statusCode, err
+			}
+		case e := <-eventsCh:
+			if e.Event == nil {
+				continue
+			}
+			v, err := typeurl.UnmarshalAny(e.Event)
+			if err != nil {
+				continue
+			}
+			switch ev := v.(type) {
+			case *eventstypes.TaskExit:
+				// ignore the exits of the exec processes, e.g., the health checks
+				if ev.ContainerID != id || ev.ID != id {
+					continue
+				}
+				if done, err := onExit(int(ev.ExitStatus)); done {
+					return statusCode, err
+				}
+			case *eventstypes.ContainerUpdate:
+				if ev.ID != id || condition != WaitConditionHealthy {
+					continue
+				}
+				if done, err := checkHealthStatus(ev.Labels); done {
+					return statusCode, err
+				}
+			case *eventstypes.ContainerDelete:
+				if ev.ID != id {
+					continue
+				}
+				if condition == WaitConditionRemoved {
+					return statusCode, nil
+				}
+				return statusCode, errors.New("container was removed")
+			}
+		}
+	}
+}
+
+func hasHealthCheck(lbs map[string]string) bool {
+	hcJSON, ok := lbs[labels.HealthCheck]
+	if !ok {
+		return false
+	}
+	hc, err := healthcheck.HealthCheckFromJSON(hcJSON)
 	if err != nil {
-		return err
+		return false
 	}
+	return len(hc.Test) > 0 && hc.Test[0] != healthcheck.CmdNone
+}
 
-	status := <-statusC
-	code, _, err := status.Result()
+// checkHealthStatus returns true when the health status in lbs is either healthy or unhealthy,
+// with an error for the latter.
+func checkHealthStatus(lbs map[string]string) (bool, error) {
+	stateJSON, ok := lbs[labels.HealthState]
+	if !ok {
+		return false, nil
+	}
+	state, err := healthcheck.HealthStateFromJSON(stateJSON)
 	if err != nil {
-		return err
+		return false, nil
 	}
-	fmt.Fprintln(w, code)
-	return nil
+	switch state.Status {
+	case healthcheck.Healthy:
+		return true, nil
+	case healthcheck.Unhealthy:
+		return true, errors.New("container is unhealthy")
+	}
+	return false, nil
 }
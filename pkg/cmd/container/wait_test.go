@@ -0,0 +1,72 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/labels"
+)
+
+func TestWaitInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	err := Wait(context.Background(), nil, []string{"foo"}, types.ContainerWaitOptions{Condition: "stopped"})
+	assert.ErrorContains(t, err, `invalid condition "stopped"`)
+	err = Wait(context.Background(), nil, []string{"foo"}, types.ContainerWaitOptions{Timeout: -1})
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestHasHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	assert.Assert(t, !hasHealthCheck(map[string]string{}))
+	assert.Assert(t, !hasHealthCheck(map[string]string{labels.HealthCheck: `{"Test":["NONE"]}`}))
+	assert.Assert(t, hasHealthCheck(map[string]string{labels.HealthCheck: `{"Test":["CMD-SHELL","true"]}`}))
+}
+
+func TestCheckHealthStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		state string
+		done  bool
+		err   string
+	}{
+		{state: "", done: false},
+		{state: `{"Status":"starting","FailingStreak":0}`, done: false},
+		{state: `{"Status":"healthy","FailingStreak":0}`, done: true},
+		{state: `{"Status":"unhealthy","FailingStreak":3}`, done: true, err: "container is unhealthy"},
+	}
+	for _, tc := range testCases {
+		lbs := map[string]string{}
+		if tc.state != "" {
+			lbs[labels.HealthState] = tc.state
+		}
+		done, err := checkHealthStatus(lbs)
+		assert.Equal(t, done, tc.done, tc.state)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+		} else {
+			assert.NilError(t, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/referrers"
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+	"github.com/containerd/nerdctl/v2/pkg/strutil"
+)
+
+// Attach attaches the file to the image rawRef in the registry, as an OCI 1.1 referrer (e.g., an SBOM),
+// and prints the digest of the referrer manifest.
+func Attach(ctx context.Context, client *containerd.Client, file, rawRef string, options types.ImageAttachOptions) error {
+	if options.ArtifactType == "" {
+		return fmt.Errorf("an artifact type must be specified")
+	}
+	parsedReference, err := referenceutil.Parse(rawRef)
+	if err != nil {
+		return err
+	}
+	if parsedReference.Protocol != "" {
+		return fmt.Errorf("attaching to %q images is not supported", parsedReference.Protocol)
+	}
+
+	var dOpts []dockerconfigresolver.Opt
+	if options.GOptions.InsecureRegistry {
+		log.G(ctx).Warnf("skipping verifying HTTPS certs for %q", parsedReference.Domain)
+		dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
+	}
+	dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.GOptions.HostsDir))
+	resolver, err := dockerconfigresolver.New(ctx, parsedReference.Domain, dOpts...)
+	if err != nil {
+		return err
+	}
+	hosts, err := dockerconfigresolver.NewRegistryHosts(ctx, parsedReference.Domain, dOpts...)
+	if err != nil {
+		return err
+	}
+	_, subject, err := resolver.Resolve(ctx, parsedReference.String())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %w", parsedReference, err)
+	}
+
+	ctx, done, err := client.WithLease(ctx)
+	if err != nil {
+		return err
+	}
+	defer done(ctx)
+
+	cs := client.ContentStore()
+	layer, err := writeFileBlob(ctx, cs, file, options.MediaType)
+	if err != nil {
+		return err
+	}
+	config := ocispec.DescriptorEmptyJSON
+	if err := content.WriteBlob(ctx, cs, config.Digest.String(), bytes.NewReader(config.Data), config); err != nil {
+		return err
+	}
+
+	annotations := map[string]string{ocispec.AnnotationCreated: time.Now().UTC().Format(time.RFC3339)}
+	for k, v := range strutil.ConvertKVStringsToMap(options.Annotations) {
+		annotations[k] = v
+	}
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: options.ArtifactType,
+		Config:       config,
+		Layers:       []ocispec.Descriptor{layer},
+		Subject: &ocispec.Descriptor{
+			MediaType: subject.MediaType,
+			Digest:    subject.Digest,
+			Size:      subject.Size,
+		},
+		Annotations: annotations,
+	}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	desc := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: options.ArtifactType,
+		Digest:       digest.FromBytes(b),
+		Size:         int64(len(b)),
+		Annotations:  annotations,
+	}
+	if err := content.WriteBlob(ctx, cs, desc.Digest.String(), bytes.NewReader(b), desc, content.WithLabels(map[string]string{
+		"containerd.io/gc.ref.content.config": config.Digest.String(),
+		"containerd.io/gc.ref.content.l.0":    layer.Digest.String(),
+	})); err != nil {
+		return err
+	}
+
+	if err := referrers.Push(ctx, cs, hosts, resolver, parsedReference.Name(), subject.Digest, []ocispec.Descriptor{desc}); err != nil {
+		return err
+	}
+	// record the referrer when the subject is present locally, for `nerdctl push --with-referrers`
+	if err := referrers.Record(ctx, cs, subject.Digest, desc); err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+	fmt.Fprintln(options.Stdout, desc.Digest)
+	return nil
+}
+
+// writeFileBlob writes the file to the content store.
+func writeFileBlob(ctx context.Context, cs content.Store, file, mediaType string) (ocispec.Descriptor, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer f.Close()
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), f)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType:   mediaType,
+		Digest:      digester.Digest(),
+		Size:        size,
+		Annotations: map[string]string{ocispec.AnnotationTitle: filepath.Base(file)},
+	}
+	if err := content.WriteBlob(ctx, cs, desc.Digest.String(), f, desc); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to write %s to the content store: %w", file, err)
+	}
+	return desc, nil
+}
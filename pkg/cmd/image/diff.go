@@ -0,0 +1,200 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/go-units"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/idutil/imagewalker"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/imagediff"
+	"github.com/containerd/nerdctl/v2/pkg/platformutil"
+)
+
+type imageDiff struct {
+	Config []imagediff.ConfigChange
+	Layers struct {
+		Shared  []ocispec.Descriptor
+		Removed []ocispec.Descriptor
+		Added   []ocispec.Descriptor
+		// DownloadSize is the size of the layers to pull for the second image when the first one is present
+		DownloadSize int64
+	}
+	Files []imagediff.FileChange `json:",omitempty"`
+}
+
+// Diff compares the configs and the layers of two images, and their filesystems with options.Files.
+func Diff(ctx context.Context, client *containerd.Client, ref1, ref2 string, options types.ImageDiffOptions) error {
+	var platMC platforms.MatchComparer = platforms.DefaultStrict()
+	if options.Platform != "" {
+		var err error
+		platMC, err = platformutil.NewMatchComparer(false, []string{options.Platform})
+		if err != nil {
+			return err
+		}
+	}
+	img1, err := findImage(ctx, client, ref1, platMC)
+	if err != nil {
+		return err
+	}
+	img2, err := findImage(ctx, client, ref2, platMC)
+	if err != nil {
+		return err
+	}
+	config1, layers1, err := readConfigAndLayers(ctx, img1, platMC)
+	if err != nil {
+		return fmt.Errorf("failed to read image %q: %w", ref1, err)
+	}
+	config2, layers2, err := readConfigAndLayers(ctx, img2, platMC)
+	if err != nil {
+		return fmt.Errorf("failed to read image %q: %w", ref2, err)
+	}
+
+	var d imageDiff
+	d.Config = imagediff.DiffConfigs(config1.Config, config2.Config)
+	layerDiff := imagediff.DiffLayers(layers1, layers2)
+	d.Layers.Shared, d.Layers.Removed, d.Layers.Added = layerDiff.Shared, layerDiff.Removed, layerDiff.Added
+	d.Layers.DownloadSize = layerDiff.DownloadSize()
+	if options.Files {
+		tree1, err := readTree(ctx, client.ContentStore(), ref1, layers1)
+		if err != nil {
+			return err
+		}
+		tree2, err := readTree(ctx, client.ContentStore(), ref2, layers2)
+		if err != nil {
+			return err
+		}
+		d.Files = imagediff.DiffTrees(tree1, tree2)
+	}
+
+	switch options.Format {
+	case "":
+		return printImageDiff(options.Stdout, d, options.Files)
+	case "json":
+		b, err := json.MarshalIndent(d, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(options.Stdout, string(b))
+		return err
+	default:
+		return fmt.Errorf("unsupported format: %q", options.Format)
+	}
+}
+
+func findImage(ctx context.Context, client *containerd.Client, ref string, platMC platforms.MatchComparer) (containerd.Image, error) {
+	var img containerd.Image
+	walker := &imagewalker.ImageWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found imagewalker.Found) error {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
+			}
+			img = containerd.NewImageWithPlatform(client, found.Image, platMC)
+			return nil
+		},
+	}
+	n, err := walker.Walk(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no such image: %s", ref)
+	}
+	return img, nil
+}
+
+func readConfigAndLayers(ctx context.Context, img containerd.Image, platMC platforms.MatchComparer) (ocispec.Image, []ocispec.Descriptor, error) {
+	config, _, err := imgutil.ReadImageConfig(ctx, img)
+	if err != nil {
+		return config, nil, err
+	}
+	layers, err := platformutil.LayerDescs(ctx, img.ContentStore(), img.Target(), platMC)
+	if err != nil {
+		return config, nil, err
+	}
+	return config, layers, nil
+}
+
+func readTree(ctx context.Context, provider content.Provider, ref string, layers []ocispec.Descriptor) (imagediff.Tree, error) {
+	tree := imagediff.Tree{}
+	for _, l := range layers {
+		ra, err := provider.ReaderAt(ctx, l)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil, fmt.Errorf("layer %s of image %q is not present in the content store (hint: pull the image again): %w", l.Digest, ref, err)
+			}
+			return nil, err
+		}
+		err = tree.Apply(content.NewReader(ra))
+		ra.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %s of image %q: %w", l.Digest, ref, err)
+		}
+	}
+	return tree, nil
+}
+
+func printImageDiff(w io.Writer, d imageDiff, files bool) error {
+	fmt.Fprintln(w, "Config:")
+	for _, c := range d.Config {
+		field := c.Field
+		if c.Key != "" {
+			field += " " + c.Key
+		}
+		switch c.Kind {
+		case imagediff.Added:
+			fmt.Fprintf(w, "  %s %s %s\n", c.Kind, field, c.New)
+		case imagediff.Deleted:
+			fmt.Fprintf(w, "  %s %s %s\n", c.Kind, field, c.Old)
+		default:
+			fmt.Fprintf(w, "  %s %s %s -> %s\n", c.Kind, field, c.Old, c.New)
+		}
+	}
+	fmt.Fprintln(w, "Layers:")
+	for _, l := range d.Layers.Shared {
+		fmt.Fprintf(w, "  = %s %s\n", l.Digest, units.HumanSize(float64(l.Size)))
+	}
+	for _, l := range d.Layers.Removed {
+		fmt.Fprintf(w, "  - %s %s\n", l.Digest, units.HumanSize(float64(l.Size)))
+	}
+	for _, l := range d.Layers.Added {
+		fmt.Fprintf(w, "  + %s %s\n", l.Digest, units.HumanSize(float64(l.Size)))
+	}
+	fmt.Fprintf(w, "  download size: %s\n", units.HumanSize(float64(d.Layers.DownloadSize)))
+	if files {
+		fmt.Fprintln(w, "Files:")
+		for _, f := range d.Files {
+			if _, err := fmt.Fprintf(w, "  %s %s\n", f.Kind, f.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -83,7 +83,7 @@ func ensureOne(ctx context.Context, client *containerd.Client, rawRef string, ta
 			log.G(ctx).Warnf("skipping verifying HTTPS certs for %q", parsedReference.Domain)
 			dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
 		}
-		dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.HostsDir))
+		dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.HostsDir), dockerconfigresolver.WithRegistryMirrors(options.RegistryMirrors))
 		resolver, err := dockerconfigresolver.New(ctx, parsedReference.Domain, dOpts...)
 		if err != nil {
 			return err
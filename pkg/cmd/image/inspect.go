@@ -21,9 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/log"
@@ -31,6 +34,8 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/containerdutil"
 	"github.com/containerd/nerdctl/v2/pkg/imageinspector"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/referrers"
 	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
 	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
 )
@@ -87,7 +92,8 @@ func inspectIdentifier(ctx context.Context, client *containerd.Client, identifie
 
 // Inspect prints detailed information of each image in `images`.
 func Inspect(ctx context.Context, client *containerd.Client, identifiers []string, options types.ImageInspectOptions) ([]any, error) {
-	// Set a timeout
+	// Set a timeout, not applied to the registry requests of --referrers
+	parentCtx := ctx
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -116,6 +122,9 @@ func Inspect(ctx context.Context, client *containerd.Client, identifiers []strin
 				log.G(ctx).WithError(err).WithField("name", candidateImage.Name).Error("failure inspecting image")
 				continue
 			}
+			if options.Referrers {
+				candidateNativeImage.Referrers = inspectReferrers(parentCtx, client, candidateImage, options.GOptions)
+			}
 
 			// If native, we just add everything in there and that's it
 			if options.Mode == "native" {
@@ -194,3 +203,42 @@ func Inspect(ctx context.Context, client *containerd.Client, identifiers []strin
 
 	return entries, nil
 }
+
+// inspectReferrers returns the OCI referrers of the image, both in the content store and in the registry.
+// Failures to query the registry are logged as warnings, as the image may have never been pushed.
+func inspectReferrers(ctx context.Context, client *containerd.Client, img images.Image, gOptions types.GlobalCommandOptions) []ocispec.Descriptor {
+	descs, err := referrers.Local(ctx, client.ContentStore(), img.Target.Digest)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("name", img.Name).Warn("failed to list the local referrers")
+	}
+	parsedReference, err := referenceutil.Parse(img.Name)
+	if err != nil || parsedReference.Domain == "" || parsedReference.Protocol != "" {
+		return descs
+	}
+	var dOpts []dockerconfigresolver.Opt
+	if gOptions.InsecureRegistry {
+		dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
+	}
+	dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(gOptions.HostsDir))
+	resolver, err := dockerconfigresolver.New(ctx, parsedReference.Domain, dOpts...)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("name", img.Name).Warn("failed to list the referrers in the registry")
+		return descs
+	}
+	hosts, err := dockerconfigresolver.NewRegistryHosts(ctx, parsedReference.Domain, dOpts...)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("name", img.Name).Warn("failed to list the referrers in the registry")
+		return descs
+	}
+	remote, err := referrers.List(ctx, hosts, resolver, parsedReference.Name(), img.Target.Digest, "")
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("name", img.Name).Warn("failed to list the referrers in the registry")
+		return descs
+	}
+	for _, desc := range remote {
+		if !slices.ContainsFunc(descs, func(d ocispec.Descriptor) bool { return d.Digest == desc.Digest }) {
+			descs = append(descs, desc)
+		}
+	}
+	return descs
+}
@@ -175,6 +175,12 @@ func printImages(ctx context.Context, client *containerd.Client, imageList []ima
 		digestsFlag = true
 	}
 	var tmpl *template.Template
+	if options.Tree {
+		if options.Quiet || (options.Format != "" && options.Format != "table") {
+			return errors.New("tree must not be specified with format or quiet")
+		}
+		return printImageTree(ctx, client, finalImageList, options)
+	}
 	switch options.Format {
 	case "", "table", "wide":
 		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
@@ -419,3 +425,50 @@ func (x *imagePrinter) printImageSinglePlatform(desc ocispec.Descriptor, img ima
 	}
 	return nil
 }
+
+// printImageTree prints the images with their platforms as children, and the content store usage of each image.
+//
+//	IMAGE            ID              SIZE         BLOB SIZE    SHARED SIZE    UNIQUE SIZE
+//	alpine:latest    beefdbd8a1da    16.3 MiB     7.7 MiB      0B             7.7 MiB
+//	├─ linux/amd64                   8.2 MiB      3.6 MiB
+//	└─ linux/arm64                   8.1 MiB      4.1 MiB
+func printImageTree(ctx context.Context, client *containerd.Client, imageList []images.Image, options *types.ImageListOptions) error {
+	usage, err := imageUsage(ctx, client)
+	if err != nil {
+		return err
+	}
+	provider := containerdutil.NewProvider(client)
+	snapshotter := containerdutil.SnapshotService(client, options.GOptions.Snapshotter)
+	w := tabwriter.NewWriter(options.Stdout, 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tID\tSIZE\tBLOB SIZE\tSHARED SIZE\tUNIQUE SIZE")
+	for _, img := range imageList {
+		candidateImages, err := read(ctx, provider, snapshotter, img.Target)
+		if err != nil {
+			log.G(ctx).Warn(err)
+			continue
+		}
+		plats := make([]string, 0, len(candidateImages))
+		var size int64
+		for plat, desc := range candidateImages {
+			plats = append(plats, plat)
+			size += desc.size
+		}
+		sort.Strings(plats)
+		id := img.Target.Digest.String()
+		if !options.NoTrunc {
+			id = strings.Split(id, ":")[1][:12]
+		}
+		u := usage[img.Target.Digest]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", img.Name, id, units.HumanSize(float64(size)),
+			units.HumanSize(float64(u.Size)), units.HumanSize(float64(u.Shared)), units.HumanSize(float64(u.Unique)))
+		for i, plat := range plats {
+			branch := "├─"
+			if i == len(plats)-1 {
+				branch = "└─"
+			}
+			desc := candidateImages[plat]
+			fmt.Fprintf(w, "%s %s\t\t%s\t%s\t\t\n", branch, plat, units.HumanSize(float64(desc.size)), units.HumanSize(float64(desc.blobSize)))
+		}
+	}
+	return w.Flush()
+}
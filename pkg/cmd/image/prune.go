@@ -18,36 +18,98 @@ package image
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/docker/go-units"
 	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/snapshots"
 	"github.com/containerd/log"
 	"github.com/containerd/platforms"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/prunepolicy"
 )
 
+// the prefix of the labels of the configs which reference the snapshots of the images, per snapshotter
+const gcRefSnapshotPrefix = "containerd.io/gc.ref.snapshot."
+
 // Prune will remove all dangling images. If all is specified, will also remove all images not referenced by any container.
+// If a policy is specified, the images removed by the policy are removed instead.
+// If dry-run is specified, the images, snapshots and blobs which would be removed are printed instead.
 func Prune(ctx context.Context, client *containerd.Client, options types.ImagePruneOptions) error {
+	plan, err := NewPrunePlan(ctx, client, options, nil)
+	if err != nil {
+		return err
+	}
+	if options.DryRun {
+		return plan.Print(options.Stdout)
+	}
+
 	var (
 		imageStore   = client.ImageService()
 		contentStore = client.ContentStore()
 	)
+	delOpts := []images.DeleteOpt{images.SynchronousDelete()}
+	removedImages := make(map[string][]digest.Digest)
+	for _, image := range plan.Images {
+		digests, err := image.RootFS(ctx, contentStore, platforms.DefaultStrict())
+		if err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to enumerate rootfs")
+		}
+		if err := imageStore.Delete(ctx, image.Name, delOpts...); err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to delete image %s", image.Name)
+			continue
+		}
+		removedImages[image.Name] = digests
+	}
 
-	var (
-		imagesToBeRemoved []images.Image
-		err               error
-	)
+	if len(removedImages) > 0 {
+		fmt.Fprintln(options.Stdout, "Deleted Images:")
+		for image, digests := range removedImages {
+			fmt.Fprintf(options.Stdout, "Untagged: %s\n", image)
+			for _, digest := range digests {
+				fmt.Fprintf(options.Stdout, "deleted: %s\n", digest)
+			}
+		}
+		fmt.Fprintln(options.Stdout, "")
+	}
+	return nil
+}
 
+// PrunePlan is what `nerdctl image prune` removes.
+type PrunePlan struct {
+	Images []images.Image
+	// Snapshots are the keys of the snapshots removed with the images and the ignored containers, per snapshotter.
+	// Only resolved for dry-run.
+	Snapshots map[string][]string
+	// Blobs are the blobs removed with the images. Only resolved for dry-run.
+	Blobs []ocispec.Descriptor
+}
+
+// NewPrunePlan selects the images removed by options.
+// The containers in ignoredContainers do not use their images, as they are removed before the images
+// (e.g., the stopped containers in `nerdctl system prune`), and their snapshots are removed too.
+func NewPrunePlan(ctx context.Context, client *containerd.Client, options types.ImagePruneOptions, ignoredContainers []containers.Container) (*PrunePlan, error) {
+	if options.Policy != "" && options.All {
+		return nil, errors.New("--policy and --all must not be specified together")
+	}
 	filters := []imgutil.Filter{}
 	if len(options.Filters) > 0 {
 		parsedFilters, err := imgutil.ParseFilters(options.Filters)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(parsedFilters.Labels) > 0 {
 			filters = append(filters, imgutil.FilterByLabel(ctx, client, parsedFilters.Labels))
@@ -57,40 +119,264 @@ func Prune(ctx context.Context, client *containerd.Client, options types.ImagePr
 		}
 	}
 
-	if options.All {
+	allImages, err := client.ImageService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ignored := make(map[string]bool, len(ignoredContainers))
+	for _, c := range ignoredContainers {
+		ignored[c.ID] = true
+	}
+	allContainers, err := client.ContainerService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usedImages := make(map[string]bool)
+	for _, c := range allContainers {
+		if !ignored[c.ID] {
+			usedImages[c.Image] = true
+		}
+	}
+
+	var candidates []images.Image
+	switch {
+	case options.Policy != "":
+		policy, err := prunepolicy.Load(options.Policy)
+		if err != nil {
+			return nil, err
+		}
+		candidates, err = selectByPolicy(ctx, client, policy, allImages, usedImages)
+		if err != nil {
+			return nil, err
+		}
+	case options.All:
 		// Remove all unused images; not just dangling ones
-		imagesToBeRemoved, err = imgutil.GetUnusedImages(ctx, client, filters...)
-	} else {
+		for _, img := range allImages {
+			if !usedImages[img.Name] {
+				candidates = append(candidates, img)
+			}
+		}
+	default:
 		// Remove dangling images only
-		imagesToBeRemoved, err = imgutil.GetDanglingImages(ctx, client, filters...)
+		filters = append([]imgutil.Filter{imgutil.FilterDanglingImages()}, filters...)
+		candidates = allImages
 	}
+	candidates, err = imgutil.ApplyFilters(candidates, filters...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	delOpts := []images.DeleteOpt{images.SynchronousDelete()}
-	removedImages := make(map[string][]digest.Digest)
-	for _, image := range imagesToBeRemoved {
-		digests, err := image.RootFS(ctx, contentStore, platforms.DefaultStrict())
+	plan := &PrunePlan{Images: candidates}
+	if options.DryRun {
+		if err := plan.resolve(ctx, client, allImages, ignoredContainers); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func selectByPolicy(ctx context.Context, client *containerd.Client, policy *prunepolicy.Policy, allImages []images.Image, usedImages map[string]bool) ([]images.Image, error) {
+	policyImages := make([]prunepolicy.Image, len(allImages))
+	for i, img := range allImages {
+		descs, err := presentBlobs(ctx, client.ContentStore(), img.Target)
 		if err != nil {
-			log.G(ctx).WithError(err).Warnf("failed to enumerate rootfs")
+			return nil, err
 		}
-		if err := imageStore.Delete(ctx, image.Name, delOpts...); err != nil {
-			log.G(ctx).WithError(err).Warnf("failed to delete image %s", image.Name)
+		blobs := make(map[digest.Digest]int64, len(descs))
+		for _, desc := range descs {
+			blobs[desc.Digest] = desc.Size
+		}
+		policyImages[i] = prunepolicy.Image{
+			Name:     img.Name,
+			LastUsed: imgutil.LastUsed(img),
+			InUse:    usedImages[img.Name],
+			Blobs:    blobs,
+		}
+	}
+	removed := make(map[string]bool)
+	for _, name := range policy.Select(policyImages, time.Now()) {
+		removed[name] = true
+	}
+	var selected []images.Image
+	for _, img := range allImages {
+		if removed[img.Name] {
+			selected = append(selected, img)
+		}
+	}
+	return selected, nil
+}
+
+// resolve resolves the blobs and the snapshots which are removed with the images of the plan,
+// i.e., which are not referenced by the other images of allImages.
+func (p *PrunePlan) resolve(ctx context.Context, client *containerd.Client, allImages []images.Image, ignoredContainers []containers.Container) error {
+	cs := client.ContentStore()
+	removedImages := make(map[string]bool, len(p.Images))
+	for _, img := range p.Images {
+		removedImages[img.Name] = true
+	}
+	keptBlobs := make(map[digest.Digest]bool)
+	removedBlobs := make(map[digest.Digest]ocispec.Descriptor)
+	var keptConfigs []digest.Digest
+	for _, img := range allImages {
+		descs, err := presentBlobs(ctx, cs, img.Target)
+		if err != nil {
+			return err
+		}
+		for _, desc := range descs {
+			if removedImages[img.Name] {
+				removedBlobs[desc.Digest] = desc
+				continue
+			}
+			if !keptBlobs[desc.Digest] && images.IsConfigType(desc.MediaType) {
+				keptConfigs = append(keptConfigs, desc.Digest)
+			}
+			keptBlobs[desc.Digest] = true
+		}
+	}
+
+	var removedConfigs []digest.Digest
+	for dgst, desc := range removedBlobs {
+		if keptBlobs[dgst] {
 			continue
 		}
-		removedImages[image.Name] = digests
+		p.Blobs = append(p.Blobs, desc)
+		if images.IsConfigType(desc.MediaType) {
+			removedConfigs = append(removedConfigs, dgst)
+		}
 	}
+	sort.Slice(p.Blobs, func(i, j int) bool {
+		return p.Blobs[i].Digest < p.Blobs[j].Digest
+	})
 
-	if len(removedImages) > 0 {
-		fmt.Fprintln(options.Stdout, "Deleted Images:")
-		for image, digests := range removedImages {
-			fmt.Fprintf(options.Stdout, "Untagged: %s\n", image)
-			for _, digest := range digests {
-				fmt.Fprintf(options.Stdout, "deleted: %s\n", digest)
+	snapshotService := func(sn string) snapshots.Snapshotter {
+		return client.SnapshotService(sn)
+	}
+	keys, err := resolveSnapshots(ctx, cs, snapshotService, removedConfigs, keptConfigs, ignoredContainers)
+	if err != nil {
+		return err
+	}
+	p.Snapshots = keys
+	return nil
+}
+
+// resolveSnapshots returns the snapshots which are removed with the images of removedConfigs and with ignoredContainers,
+// keyed by the snapshotters. The snapshot chains of the images of keptConfigs are protected, including
+// the chains shared with the removed images, e.g., the layers of a base image.
+func resolveSnapshots(ctx context.Context, cs content.InfoProvider, snapshotService func(string) snapshots.Snapshotter,
+	removedConfigs, keptConfigs []digest.Digest, ignoredContainers []containers.Container) (map[string][]string, error) {
+	removedRoots, err := snapshotRoots(ctx, cs, removedConfigs)
+	if err != nil {
+		return nil, err
+	}
+	keptRoots, err := snapshotRoots(ctx, cs, keptConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range ignoredContainers {
+		if c.Snapshotter != "" && c.SnapshotKey != "" {
+			removedRoots[c.Snapshotter] = append(removedRoots[c.Snapshotter], c.SnapshotKey)
+		}
+	}
+
+	res := make(map[string][]string)
+	for sn, roots := range removedRoots {
+		keys, err := removedSnapshots(ctx, snapshotService(sn), roots, keptRoots[sn])
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk the snapshots of snapshotter %q: %w", sn, err)
+		}
+		if len(keys) > 0 {
+			res[sn] = keys
+		}
+	}
+	return res, nil
+}
+
+// snapshotRoots returns the snapshots referenced by the labels of the configs, keyed by the snapshotters.
+func snapshotRoots(ctx context.Context, cs content.InfoProvider, configs []digest.Digest) (map[string][]string, error) {
+	roots := make(map[string][]string)
+	for _, dgst := range configs {
+		info, err := cs.Info(ctx, dgst)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range info.Labels {
+			if sn, ok := strings.CutPrefix(k, gcRefSnapshotPrefix); ok {
+				roots[sn] = append(roots[sn], v)
 			}
 		}
-		fmt.Fprintln(options.Stdout, "")
+	}
+	return roots, nil
+}
+
+// removedSnapshots returns the snapshots which are removed with the snapshots removedRoots and their parents,
+// i.e., which are not the parents of the other snapshots or of keptRoots.
+func removedSnapshots(ctx context.Context, sn snapshots.Snapshotter, removedRoots, keptRoots []string) ([]string, error) {
+	parents := make(map[string]string)
+	if err := sn.Walk(ctx, func(ctx context.Context, info snapshots.Info) error {
+		parents[info.Name] = info.Parent
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	closure := func(roots []string) map[string]bool {
+		m := make(map[string]bool)
+		for _, k := range roots {
+			for ; k != "" && !m[k]; k = parents[k] {
+				if _, ok := parents[k]; !ok {
+					break
+				}
+				m[k] = true
+			}
+		}
+		return m
+	}
+	removed := closure(removedRoots)
+	for k := range parents {
+		if !removed[k] {
+			keptRoots = append(keptRoots, k)
+		}
+	}
+	kept := closure(keptRoots)
+	var keys []string
+	for k := range removed {
+		if !kept[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Print prints the plan.
+func (p *PrunePlan) Print(w io.Writer) error {
+	if len(p.Images) > 0 {
+		fmt.Fprintln(w, "Would delete images:")
+		for _, img := range p.Images {
+			fmt.Fprintf(w, "%s\t%s\n", img.Name, img.Target.Digest)
+		}
+		fmt.Fprintln(w, "")
+	}
+	var snapshotters []string
+	for sn := range p.Snapshots {
+		snapshotters = append(snapshotters, sn)
+	}
+	sort.Strings(snapshotters)
+	for _, sn := range snapshotters {
+		fmt.Fprintf(w, "Would delete snapshots (%s):\n", sn)
+		for _, k := range p.Snapshots[sn] {
+			fmt.Fprintln(w, k)
+		}
+		fmt.Fprintln(w, "")
+	}
+	if len(p.Blobs) > 0 {
+		var total int64
+		fmt.Fprintln(w, "Would delete blobs:")
+		for _, desc := range p.Blobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", desc.Digest, desc.MediaType, units.HumanSize(float64(desc.Size)))
+			total += desc.Size
+		}
+		fmt.Fprintln(w, "")
+		fmt.Fprintf(w, "Total reclaimed space (blobs): %s\n", units.HumanSize(float64(total)))
 	}
 	return nil
 }
@@ -0,0 +1,109 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/errdefs"
+)
+
+type fakeInfoProvider map[digest.Digest]content.Info
+
+func (f fakeInfoProvider) Info(_ context.Context, dgst digest.Digest) (content.Info, error) {
+	info, ok := f[dgst]
+	if !ok {
+		return content.Info{}, errdefs.ErrNotFound
+	}
+	return info, nil
+}
+
+// fakeSnapshotter only implements Walk, over the snapshots keyed by their names with their parents as values.
+type fakeSnapshotter struct {
+	snapshots.Snapshotter
+	parents map[string]string
+}
+
+func (f *fakeSnapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, _ ...string) error {
+	for name, parent := range f.parents {
+		if err := fn(ctx, snapshots.Info{Name: name, Parent: parent}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestResolveSnapshotsSharedBase(t *testing.T) {
+	t.Parallel()
+
+	// "app" is built FROM "alpine", so the snapshot chain of "alpine" is the base of the chain of "app"
+	parents := map[string]string{
+		"alpine-1": "",
+		"alpine-2": "alpine-1",
+		"app-1":    "alpine-2",
+		"app-2":    "app-1",
+		"other-1":  "",
+	}
+	alpineConfig := digest.FromString("alpine")
+	appConfig := digest.FromString("app")
+	cs := fakeInfoProvider{
+		alpineConfig: {Labels: map[string]string{gcRefSnapshotPrefix + "overlayfs": "alpine-2"}},
+		appConfig:    {Labels: map[string]string{gcRefSnapshotPrefix + "overlayfs": "app-2"}},
+	}
+	snapshotService := func(string) snapshots.Snapshotter {
+		return &fakeSnapshotter{parents: parents}
+	}
+
+	// pruning "app" keeps the layers of "alpine"
+	res, err := resolveSnapshots(context.Background(), cs, snapshotService, []digest.Digest{appConfig}, []digest.Digest{alpineConfig}, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res, map[string][]string{"overlayfs": {"app-1", "app-2"}})
+
+	// pruning "alpine" keeps the layers used by "app"
+	res, err = resolveSnapshots(context.Background(), cs, snapshotService, []digest.Digest{alpineConfig}, []digest.Digest{appConfig}, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res, map[string][]string{})
+
+	// pruning both removes the whole chain
+	res, err = resolveSnapshots(context.Background(), cs, snapshotService, []digest.Digest{appConfig, alpineConfig}, nil, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res, map[string][]string{"overlayfs": {"alpine-1", "alpine-2", "app-1", "app-2"}})
+
+	// the snapshots of the containers protect their parents, unless the containers are ignored
+	containerParents := map[string]string{"container": "app-2"}
+	for k, v := range parents {
+		containerParents[k] = v
+	}
+	snapshotService = func(string) snapshots.Snapshotter {
+		return &fakeSnapshotter{parents: containerParents}
+	}
+	res, err = resolveSnapshots(context.Background(), cs, snapshotService, []digest.Digest{appConfig}, []digest.Digest{alpineConfig}, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res, map[string][]string{})
+
+	ignored := []containers.Container{{Snapshotter: "overlayfs", SnapshotKey: "container"}}
+	res, err = resolveSnapshots(context.Background(), cs, snapshotService, []digest.Digest{appConfig}, []digest.Digest{alpineConfig}, ignored)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res, map[string][]string{"overlayfs": {"app-1", "app-2", "container"}})
+}
@@ -73,6 +73,9 @@ func EnsureImage(ctx context.Context, client *containerd.Client, rawRef string,
 		if err != nil {
 			return nil, err
 		}
+		if err := imgutil.CheckPolicy(ctx, ensured, options.GOptions); err != nil {
+			return nil, err
+		}
 		return ensured, nil
 	}
 
@@ -34,7 +34,6 @@ import (
 	"github.com/containerd/containerd/v2/core/images/converter"
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/containerd/containerd/v2/core/remotes/docker"
-	dockerconfig "github.com/containerd/containerd/v2/core/remotes/docker/config"
 	"github.com/containerd/containerd/v2/pkg/reference"
 	"github.com/containerd/log"
 	"github.com/containerd/stargz-snapshotter/estargz"
@@ -46,6 +45,7 @@ import (
 	nerdconverter "github.com/containerd/nerdctl/v2/pkg/imgutil/converter"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil/push"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/referrers"
 	"github.com/containerd/nerdctl/v2/pkg/internal/filesystem"
 	"github.com/containerd/nerdctl/v2/pkg/ipfs"
 	"github.com/containerd/nerdctl/v2/pkg/platformutil"
@@ -163,42 +163,43 @@ func Push(ctx context.Context, client *containerd.Client, rawRef string, options
 		log.G(ctx).Warnf("skipping verifying HTTPS certs for %q", refDomain)
 		dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
 	}
-	dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.GOptions.HostsDir))
+	dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.GOptions.HostsDir),
+		dockerconfigresolver.WithTracker(pushTracker), dockerconfigresolver.WithMaxRetries(options.MaxRetries))
 
-	ho, err := dockerconfigresolver.NewHostOptions(ctx, refDomain, dOpts...)
+	resolver, err := dockerconfigresolver.New(ctx, refDomain, dOpts...)
 	if err != nil {
 		return err
 	}
-
-	resolverOpts := docker.ResolverOptions{
-		Tracker: pushTracker,
-		Hosts:   dockerconfig.ConfigureHosts(ctx, *ho),
-	}
-
-	resolver := docker.NewResolver(resolverOpts)
 	if err = pushFunc(resolver); err != nil {
 		// In some circumstance (e.g. people just use 80 port to support pure http), the error will contain message like "dial tcp <port>: connection refused"
 		if !errors.Is(err, http.ErrSchemeMismatch) && !errutil.IsErrConnectionRefused(err) {
 			return err
 		}
-		if options.GOptions.InsecureRegistry {
-			log.G(ctx).WithError(err).Warnf("server %q does not seem to support HTTPS, falling back to plain HTTP", refDomain)
-			dOpts = append(dOpts, dockerconfigresolver.WithPlainHTTP(true))
-			resolver, err = dockerconfigresolver.New(ctx, refDomain, dOpts...)
-			if err != nil {
-				return err
-			}
-			return pushFunc(resolver)
+		if !options.GOptions.InsecureRegistry {
+			log.G(ctx).WithError(err).Errorf("server %q does not seem to support HTTPS", refDomain)
+			log.G(ctx).Info("Hint: you may want to try --insecure-registry to allow plain HTTP (if you are in a trusted network)")
+			return err
+		}
+		log.G(ctx).WithError(err).Warnf("server %q does not seem to support HTTPS, falling back to plain HTTP", refDomain)
+		dOpts = append(dOpts, dockerconfigresolver.WithPlainHTTP(true))
+		resolver, err = dockerconfigresolver.New(ctx, refDomain, dOpts...)
+		if err != nil {
+			return err
+		}
+		if err = pushFunc(resolver); err != nil {
+			return err
 		}
-		log.G(ctx).WithError(err).Errorf("server %q does not seem to support HTTPS", refDomain)
-		log.G(ctx).Info("Hint: you may want to try --insecure-registry to allow plain HTTP (if you are in a trusted network)")
-		return err
 	}
 
 	img, err := client.ImageService().Get(ctx, pushRef)
 	if err != nil {
 		return err
 	}
+	if options.WithReferrers {
+		if err = pushReferrers(ctx, client, resolver, parsedReference, img, dOpts); err != nil {
+			return err
+		}
+	}
 	refSpec, err := reference.Parse(pushRef)
 	if err != nil {
 		return err
@@ -223,6 +224,37 @@ func Push(ctx context.Context, client *containerd.Client, rawRef string, options
 	return nil
 }
 
+// pushReferrers pushes the OCI referrers of the image ref recorded in the content store,
+// when the pushed image (img) is the same as the local one.
+func pushReferrers(ctx context.Context, client *containerd.Client, resolver remotes.Resolver, parsedReference *referenceutil.ImageReference, img images.Image, dOpts []dockerconfigresolver.Opt) error {
+	orig, err := client.ImageService().Get(ctx, parsedReference.String())
+	if err != nil {
+		return err
+	}
+	descs, err := referrers.Local(ctx, client.ContentStore(), orig.Target.Digest)
+	if err != nil {
+		return err
+	}
+	if len(descs) == 0 {
+		log.G(ctx).Infof("no referrers of %s found in the content store", orig.Target.Digest)
+		return nil
+	}
+	if img.Target.Digest != orig.Target.Digest {
+		log.G(ctx).Warnf("not pushing the %d referrer(s) of %s, as the pushed image %s differs from it (Hint: try --all-platforms)",
+			len(descs), orig.Target.Digest, img.Target.Digest)
+		return nil
+	}
+	hosts, err := dockerconfigresolver.NewRegistryHosts(ctx, parsedReference.Domain, dOpts...)
+	if err != nil {
+		return err
+	}
+	if err = referrers.Push(ctx, client.ContentStore(), hosts, resolver, parsedReference.Name(), orig.Target.Digest, descs); err != nil {
+		return fmt.Errorf("failed to push the referrers of %s: %w", parsedReference, err)
+	}
+	log.G(ctx).Infof("pushed %d referrer(s) of %s", len(descs), orig.Target.Digest)
+	return nil
+}
+
 func eStargzConvertFunc() converter.ConvertFunc {
 	convertToESGZ := estargzconvert.LayerConvertFunc()
 	return func(ctx context.Context, cs content.Store, desc ocispec.Descriptor) (*ocispec.Descriptor, error) {
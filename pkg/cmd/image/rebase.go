@@ -0,0 +1,79 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/platforms"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/rebase"
+	"github.com/containerd/nerdctl/v2/pkg/platformutil"
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+)
+
+// Rebase replaces the layers of options.OldBase at the bottom of the image rawRef with the layers of options.NewBase,
+// and stores the result as options.Target. The ID of the rebased image is printed.
+func Rebase(ctx context.Context, client *containerd.Client, rawRef string, options types.ImageRebaseOptions) error {
+	if options.OldBase == "" || options.NewBase == "" {
+		return errors.New("both --old-base and --new-base must be specified")
+	}
+	if options.Target == "" {
+		return errors.New("the name of the rebased image must be specified with --tag")
+	}
+	var platMC platforms.MatchComparer = platforms.DefaultStrict()
+	if options.AllPlatforms || len(options.Platforms) > 0 {
+		var err error
+		platMC, err = platformutil.NewMatchComparer(options.AllPlatforms, options.Platforms)
+		if err != nil {
+			return err
+		}
+	}
+	parsedReference, err := referenceutil.Parse(options.Target)
+	if err != nil {
+		return err
+	}
+
+	img, err := findImage(ctx, client, rawRef, platMC)
+	if err != nil {
+		return err
+	}
+	oldBase, err := findImage(ctx, client, options.OldBase, platMC)
+	if err != nil {
+		return err
+	}
+	newBase, err := findImage(ctx, client, options.NewBase, platMC)
+	if err != nil {
+		return err
+	}
+	// the rebased image references the layers of the image and of the new base, which must be present to push it
+	for _, x := range []containerd.Image{img, newBase} {
+		if err := EnsureAllContent(ctx, client, x.Name(), platMC, options.GOptions); err != nil {
+			return fmt.Errorf("failed to fetch the layers of image %q: %w", x.Name(), err)
+		}
+	}
+	rebased, err := rebase.Rebase(ctx, client, img.Metadata(), oldBase.Metadata(), newBase.Metadata(), parsedReference.String(), platMC)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(options.Stdout, rebased.Target.Digest)
+	return err
+}
@@ -21,17 +21,51 @@ import (
 	"fmt"
 
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/images/archive"
+	"github.com/containerd/errdefs"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/compression"
 	"github.com/containerd/nerdctl/v2/pkg/idutil/imagewalker"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/ocilayout"
 	"github.com/containerd/nerdctl/v2/pkg/platformutil"
 	"github.com/containerd/nerdctl/v2/pkg/strutil"
 )
 
+const (
+	FormatDockerArchive = "docker-archive"
+	FormatOCILayout     = "oci-layout"
+)
+
 // Save exports `images` to a `io.Writer` (e.g., a file writer, or os.Stdout) specified by `options.Stdout`.
-func Save(ctx context.Context, client *containerd.Client, images []string, options types.ImageSaveOptions, exportOpts ...archive.ExportOpt) error {
-	images = strutil.DedupeStrSlice(images)
+// With the "oci-layout" format, the images are written to the OCI image layout directory specified by `options.Output` instead.
+func Save(ctx context.Context, client *containerd.Client, imageNames []string, options types.ImageSaveOptions, exportOpts ...archive.ExportOpt) error {
+	imageNames = strutil.DedupeStrSlice(imageNames)
+
+	var compressionSpec compression.Spec
+	switch options.Format {
+	case "", FormatDockerArchive:
+		if options.SkipExisting {
+			return fmt.Errorf("--skip-existing requires --format=%s: %w", FormatOCILayout, errdefs.ErrInvalidArgument)
+		}
+		if options.Compress != "" {
+			var err error
+			compressionSpec, err = compression.ParseSpec(options.Compress)
+			if err != nil {
+				return err
+			}
+		}
+	case FormatOCILayout:
+		if options.Output == "" {
+			return fmt.Errorf("--format=%s requires the output directory to be specified with -o: %w", FormatOCILayout, errdefs.ErrInvalidArgument)
+		}
+		if options.Compress != "" {
+			return fmt.Errorf("--compress cannot be used with --format=%s: %w", FormatOCILayout, errdefs.ErrInvalidArgument)
+		}
+	default:
+		return fmt.Errorf("unsupported format %q, should be %q or %q: %w", options.Format, FormatDockerArchive, FormatOCILayout, errdefs.ErrInvalidArgument)
+	}
 
 	platMC, err := platformutil.NewMatchComparer(options.AllPlatforms, options.Platform)
 	if err != nil {
@@ -42,6 +76,7 @@ func Save(ctx context.Context, client *containerd.Client, images []string, optio
 	imageStore := client.ImageService()
 
 	savedImages := make(map[string]struct{})
+	var layoutImages []images.Image
 	walker := &imagewalker.ImageWalker{
 		Client: client,
 		OnFound: func(ctx context.Context, found imagewalker.Found) error {
@@ -59,15 +94,30 @@ func Save(ctx context.Context, client *containerd.Client, images []string, optio
 			if _, ok := savedImages[imgName]; !ok {
 				savedImages[imgName] = struct{}{}
 				exportOpts = append(exportOpts, archive.WithImage(imageStore, imgName))
+				layoutImages = append(layoutImages, found.Image)
 			}
 			return nil
 		},
 	}
 
 	// check if all images exist
-	if err := walker.WalkAll(ctx, images, false); err != nil {
+	if err := walker.WalkAll(ctx, imageNames, false); err != nil {
 		return err
 	}
 
-	return client.Export(ctx, options.Stdout, exportOpts...)
+	if options.Format == FormatOCILayout {
+		return ocilayout.Write(ctx, client.ContentStore(), options.Output, layoutImages, platMC, options.SkipExisting)
+	}
+	if options.Compress == "" {
+		return client.Export(ctx, options.Stdout, exportOpts...)
+	}
+	w, err := compression.NewWriter(options.Stdout, compressionSpec)
+	if err != nil {
+		return err
+	}
+	if err := client.Export(ctx, w, exportOpts...); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
 }
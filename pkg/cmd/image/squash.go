@@ -0,0 +1,76 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"fmt"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/platforms"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/squash"
+	"github.com/containerd/nerdctl/v2/pkg/platformutil"
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+)
+
+// Squash squashes a range of the layers of the image source into one layer, and stores the result as target
+// (source when empty). The ID of the squashed image is printed.
+func Squash(ctx context.Context, client *containerd.Client, source, target string, options types.ImageSquashOptions) error {
+	var platMC platforms.MatchComparer = platforms.DefaultStrict()
+	if options.Platform != "" {
+		var err error
+		platMC, err = platformutil.NewMatchComparer(false, []string{options.Platform})
+		if err != nil {
+			return err
+		}
+	}
+	spec, err := squash.CompressionSpec(options.Compression, options.GOptions.Compression)
+	if err != nil {
+		return err
+	}
+	img, err := findImage(ctx, client, source, platMC)
+	if err != nil {
+		return err
+	}
+	name := img.Name()
+	if target != "" {
+		parsedReference, err := referenceutil.Parse(target)
+		if err != nil {
+			return err
+		}
+		name = parsedReference.String()
+	}
+
+	// the layers of lazily pulled images may be missing
+	if err := EnsureAllContent(ctx, client, img.Name(), platMC, options.GOptions); err != nil {
+		return fmt.Errorf("failed to fetch the layers of image %q: %w", source, err)
+	}
+	squashed, err := squash.Squash(ctx, client, img, name, squash.Opts{
+		Layers:      options.Layers,
+		Compression: spec,
+		Snapshotter: options.GOptions.Snapshotter,
+		Message:     options.Message,
+		Platform:    platMC,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(options.Stdout, squashed.Target.Digest)
+	return err
+}
@@ -0,0 +1,167 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/docker/go-units"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/formatter"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/imagediff"
+)
+
+type imageUsagePrintable struct {
+	Repository string
+	Tag        string
+	ID         string
+	BlobSize   string
+	SharedSize string // the size of the blobs also used by other images
+	UniqueSize string // the size of the blobs freed by removing the image
+}
+
+// Usage prints the content store usage of each image, with the bytes shared with other images
+// and the bytes only used by the image.
+func Usage(ctx context.Context, client *containerd.Client, options types.ImageUsageOptions) error {
+	imageList, err := List(ctx, client, nil, nil)
+	if err != nil {
+		return err
+	}
+	usage, err := imageUsage(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	w := options.Stdout
+	var tmpl *template.Template
+	switch options.Format {
+	case "", "table":
+		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
+		fmt.Fprintln(w, "REPOSITORY\tTAG\tIMAGE ID\tBLOB SIZE\tSHARED SIZE\tUNIQUE SIZE")
+	case "raw":
+		return errors.New("unsupported format: \"raw\"")
+	default:
+		tmpl, err = formatter.ParseTemplate(options.Format)
+		if err != nil {
+			return err
+		}
+	}
+	for _, img := range imageList {
+		u, ok := usage[img.Target.Digest]
+		if !ok {
+			continue
+		}
+		if err := printImageUsage(w, tmpl, img, u, options.NoTrunc); err != nil {
+			return err
+		}
+	}
+	if f, ok := w.(formatter.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func printImageUsage(w io.Writer, tmpl *template.Template, img images.Image, u imagediff.Usage, noTrunc bool) error {
+	repository, tag := imgutil.ParseRepoTag(img.Name)
+	p := imageUsagePrintable{
+		Repository: repository,
+		Tag:        tag,
+		ID:         img.Target.Digest.String(),
+		BlobSize:   units.HumanSize(float64(u.Size)),
+		SharedSize: units.HumanSize(float64(u.Shared)),
+		UniqueSize: units.HumanSize(float64(u.Unique)),
+	}
+	if p.Repository == "" {
+		p.Repository = "<none>"
+	}
+	if p.Tag == "" {
+		p.Tag = "<none>"
+	}
+	if !noTrunc {
+		p.ID = strings.Split(p.ID, ":")[1][:12]
+	}
+	if tmpl != nil {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, p); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w, b.String())
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Repository, p.Tag, p.ID, p.BlobSize, p.SharedSize, p.UniqueSize)
+	return err
+}
+
+// imageUsage computes the usage of all the images of the namespace, keyed by their target digests.
+func imageUsage(ctx context.Context, client *containerd.Client) (map[digest.Digest]imagediff.Usage, error) {
+	all, err := client.ImageService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var blobs []imagediff.ImageBlobs
+	for _, img := range all {
+		descs, err := presentBlobs(ctx, client.ContentStore(), img.Target)
+		if err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to walk the blobs of image %q", img.Name)
+			continue
+		}
+		blobs = append(blobs, imagediff.ImageBlobs{Target: img.Target.Digest, Blobs: descs})
+	}
+	usage := make(map[digest.Digest]imagediff.Usage, len(blobs))
+	for i, u := range imagediff.ComputeUsage(blobs) {
+		usage[blobs[i].Target] = u
+	}
+	return usage, nil
+}
+
+// presentBlobs returns the blobs of the image present in the content store,
+// skipping the platforms that were not pulled.
+func presentBlobs(ctx context.Context, cs content.Store, target ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	var descs []ocispec.Descriptor
+	children := images.ChildrenHandler(cs)
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if _, err := cs.Info(ctx, desc.Digest); err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil, images.ErrSkipDesc
+			}
+			return nil, err
+		}
+		descs = append(descs, desc)
+		return children(ctx, desc)
+	})
+	if err := images.Walk(ctx, handler, target); err != nil {
+		return nil, err
+	}
+	return descs, nil
+}
@@ -34,17 +34,62 @@ func Prune(ctx context.Context, client *containerd.Client, options types.Network
 		return err
 	}
 
-	usedNetworks, err := netutil.UsedNetworks(ctx, client)
+	candidates, err := pruneCandidates(ctx, client, e, options, nil)
 	if err != nil {
 		return err
 	}
 
+	var removedNetworks []string // nolint: prealloc
+	for _, net := range candidates {
+		if err := e.RemoveNetwork(net); err != nil {
+			log.G(ctx).WithError(err).Errorf("failed to remove network %s", net.Name)
+			continue
+		}
+		removedNetworks = append(removedNetworks, net.Name)
+	}
+
+	if len(removedNetworks) > 0 {
+		fmt.Fprintln(options.Stdout, "Deleted Networks:")
+		for _, name := range removedNetworks {
+			fmt.Fprintln(options.Stdout, name)
+		}
+		fmt.Fprintln(options.Stdout, "")
+	}
+	return nil
+}
+
+// PruneCandidates returns the names of the networks which Prune would remove, as if the containers ignoredContainers
+// were removed (e.g., the stopped containers in `nerdctl system prune`).
+func PruneCandidates(ctx context.Context, client *containerd.Client, options types.NetworkPruneOptions, ignoredContainers map[string]bool) ([]string, error) {
+	e, err := netutil.NewCNIEnv(options.GOptions.CNIPath, options.GOptions.CNINetConfPath, netutil.WithNamespace(options.GOptions.Namespace))
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := pruneCandidates(ctx, client, e, options, ignoredContainers)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(candidates))
+	for i, net := range candidates {
+		names[i] = net.Name
+	}
+	return names, nil
+}
+
+// pruneCandidates returns the networks created by nerdctl which are not used by the containers,
+// except the containers ignoredContainers.
+func pruneCandidates(ctx context.Context, client *containerd.Client, e *netutil.CNIEnv, options types.NetworkPruneOptions, ignoredContainers map[string]bool) ([]*netutil.NetworkConfig, error) {
+	usedNetworks, err := netutil.UsedNetworks(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
 	networkConfigs, err := e.NetworkList()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var removedNetworks []string // nolint: prealloc
+	var candidates []*netutil.NetworkConfig
 	for _, net := range networkConfigs {
 		if strutil.InStringSlice(options.NetworkDriversToKeep, net.Name) {
 			continue
@@ -52,22 +97,20 @@ func Prune(ctx context.Context, client *containerd.Client, options types.Network
 		if net.NerdctlID == nil || net.File == "" {
 			continue
 		}
-		if _, ok := usedNetworks[net.Name]; ok {
-			continue
-		}
-		if err := e.RemoveNetwork(net); err != nil {
-			log.G(ctx).WithError(err).Errorf("failed to remove network %s", net.Name)
+		if isUsed(usedNetworks[net.Name], ignoredContainers) {
 			continue
 		}
-		removedNetworks = append(removedNetworks, net.Name)
+		candidates = append(candidates, net)
 	}
+	return candidates, nil
+}
 
-	if len(removedNetworks) > 0 {
-		fmt.Fprintln(options.Stdout, "Deleted Networks:")
-		for _, name := range removedNetworks {
-			fmt.Fprintln(options.Stdout, name)
+// isUsed returns whether one of the containers is not ignored.
+func isUsed(containers []string, ignoredContainers map[string]bool) bool {
+	for _, id := range containers {
+		if !ignoredContainers[id] {
+			return true
 		}
-		fmt.Fprintln(options.Stdout, "")
 	}
-	return nil
+	return false
 }
@@ -0,0 +1,41 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/seccomputil"
+)
+
+// Diff prints the differences between the profiles a and b.
+func Diff(ctx context.Context, a, b string, options types.SeccompDiffOptions) error {
+	pa, err := loadProfile(ctx, a)
+	if err != nil {
+		return err
+	}
+	pb, err := loadProfile(ctx, b)
+	if err != nil {
+		return err
+	}
+	for _, line := range seccomputil.Diff(pa, pb) {
+		fmt.Fprintln(options.Stdout, line)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/seccomputil"
+)
+
+// Merge prints the profile base merged with the overlays.
+func Merge(ctx context.Context, base string, overlays []string, options types.SeccompMergeOptions) error {
+	p, err := loadProfile(ctx, base)
+	if err != nil {
+		return err
+	}
+	var ps []*specs.LinuxSeccomp
+	for _, overlay := range overlays {
+		o, err := loadProfile(ctx, overlay)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, o)
+	}
+	merged := seccomputil.Merge(p, ps...)
+	if err := seccomputil.Validate(merged, nil); err != nil {
+		return fmt.Errorf("the merged profile is invalid: %w", err)
+	}
+	return printProfile(options.Stdout, merged)
+}
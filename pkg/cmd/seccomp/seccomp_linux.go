@@ -0,0 +1,46 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/nerdctl/v2/pkg/defaults"
+	"github.com/containerd/nerdctl/v2/pkg/seccomputil"
+)
+
+// loadProfile loads the profile at path, or the default profile when path is defaults.SeccompProfileName.
+func loadProfile(ctx context.Context, path string) (*specs.LinuxSeccomp, error) {
+	if path == defaults.SeccompProfileName {
+		return seccomputil.DefaultProfile(ctx, nil)
+	}
+	return seccomputil.LoadProfile(path)
+}
+
+func printProfile(w io.Writer, p *specs.LinuxSeccomp) error {
+	b, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
@@ -0,0 +1,52 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/seccomputil"
+)
+
+// Validate validates the profiles, and returns an error if one of them is invalid.
+func Validate(ctx context.Context, profiles []string, options types.SeccompValidateOptions) error {
+	arches := make([]specs.Arch, len(options.Arches))
+	for i, arch := range options.Arches {
+		arches[i] = specs.Arch(arch)
+	}
+	var errs []error
+	for _, profile := range profiles {
+		p, err := loadProfile(ctx, profile)
+		if err == nil {
+			err = seccomputil.Validate(p, arches)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid profile %q: %w", profile, err))
+			continue
+		}
+		fmt.Fprintln(options.Stdout, profile)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors:\n%w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/containerd/log"
+	"github.com/containerd/platforms"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/binfmt"
+	"github.com/containerd/nerdctl/v2/pkg/formatter"
+)
+
+type binfmtPrintable struct {
+	Name        string
+	Platform    string // empty for the entries of unknown architectures
+	Enabled     bool
+	Flags       string
+	Interpreter string
+	Status      string // "ok", or the reason why the entry cannot run the executables in containers
+}
+
+// BinfmtList lists the entries of binfmt_misc.
+func BinfmtList(options types.SystemBinfmtListOptions) error {
+	quiet := options.Quiet
+	w := options.Stdout
+	var tmpl *template.Template
+	format := options.Format
+	switch format {
+	case "", "table", "wide":
+		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
+		if !quiet {
+			fmt.Fprintln(w, "NAME\tPLATFORM\tENABLED\tFLAGS\tINTERPRETER\tSTATUS")
+		}
+	case "raw":
+		return errors.New("unsupported format: \"raw\"")
+	default:
+		if quiet {
+			return errors.New("format and quiet must not be specified together")
+		}
+		var err error
+		tmpl, err = formatter.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := binfmt.List(binfmt.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read binfmt_misc: %w", err)
+	}
+	if enabled, err := binfmt.Enabled(binfmt.Dir); err == nil && !enabled {
+		log.L.Warn("binfmt_misc is disabled")
+	}
+
+	for _, e := range entries {
+		p := binfmtPrintable{
+			Name:        e.Name,
+			Enabled:     e.Enabled,
+			Flags:       e.Flags,
+			Interpreter: e.Interpreter,
+			Status:      "ok",
+		}
+		if arch := e.Architecture(); arch != "" {
+			p.Platform = "linux/" + arch
+		}
+		if problem := e.Problem(); problem != nil {
+			p.Status = problem.Error()
+		}
+		if tmpl != nil {
+			var b bytes.Buffer
+			if err := tmpl.Execute(&b, p); err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintln(w, b.String()); err != nil {
+				return err
+			}
+		} else if quiet {
+			fmt.Fprintln(w, p.Name)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\t%s\n", p.Name, p.Platform, p.Enabled, p.Flags, p.Interpreter, p.Status)
+		}
+	}
+	if f, ok := w.(formatter.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// BinfmtInstall registers the static QEMU binaries installed on the host as the interpreters of the platforms.
+func BinfmtInstall(options types.SystemBinfmtInstallOptions) error {
+	all := len(options.Platforms) == 0 || slices.Contains(options.Platforms, "all")
+	var archs []binfmt.Arch
+	if all {
+		for _, a := range binfmt.Archs {
+			if !a.Native() {
+				archs = append(archs, a)
+			}
+		}
+	} else {
+		for _, s := range options.Platforms {
+			a, err := parseArch(s)
+			if err != nil {
+				return err
+			}
+			if a.Native() {
+				return fmt.Errorf("platform %q is native to the host, and must not be emulated", s)
+			}
+			archs = append(archs, a)
+		}
+	}
+	if err := binfmt.Mount(binfmt.Dir); err != nil {
+		return fmt.Errorf("failed to mount binfmt_misc on %s: %w", binfmt.Dir, err)
+	}
+
+	installed := 0
+	for _, a := range archs {
+		if err := binfmt.Check(binfmt.Dir, a.OCI); err == nil {
+			fmt.Fprintf(options.Stdout, "linux/%s is already emulated\n", a.OCI)
+			installed++
+			continue
+		}
+		interpreter, err := findQEMU(a)
+		if err != nil {
+			if all {
+				log.L.WithError(err).Debugf("skipping linux/%s", a.OCI)
+				continue
+			}
+			return err
+		}
+		name := "qemu-" + a.QEMU
+		if _, err := binfmt.Read(binfmt.Dir, name); err == nil {
+			// replace the unusable entry, e.g., without the "F" flag
+			if err := binfmt.Unregister(binfmt.Dir, name); err != nil {
+				return err
+			}
+		}
+		// "F" opens the interpreter now, so that it does not have to exist inside the containers
+		e := binfmt.Entry{
+			Name:        name,
+			Interpreter: interpreter,
+			Flags:       "OCF",
+			Magic:       a.Magic,
+			Mask:        a.Mask,
+		}
+		if err := binfmt.Register(binfmt.Dir, e); err != nil {
+			return err
+		}
+		fmt.Fprintf(options.Stdout, "installed linux/%s (%s)\n", a.OCI, interpreter)
+		installed++
+	}
+	if installed == 0 {
+		return errors.New("no static QEMU binary (qemu-<ARCH>-static) was found in PATH (hint: install the qemu-user-static package)")
+	}
+	return nil
+}
+
+// BinfmtUninstall unregisters the entries of binfmt_misc.
+func BinfmtUninstall(options types.SystemBinfmtUninstallOptions) error {
+	entries, err := binfmt.List(binfmt.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read binfmt_misc: %w", err)
+	}
+	for _, s := range options.Names {
+		var names []string
+		if i := slices.IndexFunc(entries, func(e binfmt.Entry) bool { return e.Name == s }); i >= 0 {
+			names = append(names, s)
+		} else {
+			var arch string
+			if s != "all" {
+				a, err := parseArch(s)
+				if err != nil {
+					return fmt.Errorf("no such binfmt_misc entry or platform: %q", s)
+				}
+				arch = a.OCI
+			}
+			for _, e := range entries {
+				entryArch := e.Architecture()
+				if entryArch == "" || (arch != "" && entryArch != arch) {
+					continue
+				}
+				if a, _ := binfmt.LookupArch(entryArch); s == "all" && a.Native() {
+					continue
+				}
+				names = append(names, e.Name)
+			}
+			if len(names) == 0 && s != "all" {
+				return fmt.Errorf("no binfmt_misc entry for %q", s)
+			}
+		}
+		for _, name := range names {
+			if err := binfmt.Unregister(binfmt.Dir, name); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					// already unregistered by a previous argument
+					continue
+				}
+				return err
+			}
+			fmt.Fprintf(options.Stdout, "uninstalled %s\n", name)
+		}
+	}
+	return nil
+}
+
+// parseArch parses the architecture of the platform s, e.g., "arm64" or "linux/arm64".
+func parseArch(s string) (binfmt.Arch, error) {
+	p, err := platforms.Parse(s)
+	if err != nil {
+		return binfmt.Arch{}, err
+	}
+	if p.OS != "linux" {
+		return binfmt.Arch{}, fmt.Errorf("platform %q cannot be emulated with binfmt_misc", s)
+	}
+	return binfmt.LookupArch(p.Architecture)
+}
+
+// findQEMU finds the static QEMU binary of the architecture in PATH.
+// The dynamically linked binaries do not work in containers, which do not have their libraries.
+func findQEMU(a binfmt.Arch) (string, error) {
+	var dynamic string
+	for _, name := range []string{"qemu-" + a.QEMU + "-static", "qemu-" + a.QEMU} {
+		p, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		if p, err = filepath.Abs(p); err != nil {
+			return "", err
+		}
+		static, err := isStatic(p)
+		if err != nil {
+			return "", err
+		}
+		if static {
+			return p, nil
+		}
+		dynamic = p
+	}
+	if dynamic != "" {
+		return "", fmt.Errorf("QEMU binary %s for linux/%s is dynamically linked (hint: install the qemu-user-static package)", dynamic, a.OCI)
+	}
+	return "", fmt.Errorf("no QEMU binary (qemu-%s-static) for linux/%s was found in PATH (hint: install the qemu-user-static package)", a.QEMU, a.OCI)
+}
+
+// isStatic reports whether the ELF executable p is statically linked, i.e., has no program interpreter.
+func isStatic(p string) (bool, error) {
+	f, err := elf.Open(p)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	defer f.Close()
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_INTERP {
+			return false, nil
+		}
+	}
+	return true, nil
+}
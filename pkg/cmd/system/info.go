@@ -108,6 +108,7 @@ func fulfillNativeInfo(di *native.DaemonInfo, globalOptions types.GlobalCommandO
 	info.Snapshotter = globalOptions.Snapshotter
 	info.CgroupManager = globalOptions.CgroupManager
 	info.Rootless = rootlessutil.IsRootless()
+	info.EmulatedPlatforms = infoutil.EmulatedPlatforms()
 	return info, nil
 }
 
@@ -116,6 +117,9 @@ func prettyPrintInfoNative(w io.Writer, info *native.Info) error {
 	fmt.Fprintf(w, "Snapshotter:        %s\n", info.Snapshotter)
 	fmt.Fprintf(w, "Cgroup Manager:     %s\n", info.CgroupManager)
 	fmt.Fprintf(w, "Rootless:           %v\n", info.Rootless)
+	if len(info.EmulatedPlatforms) > 0 {
+		fmt.Fprintf(w, "Emulated Platforms: %s\n", strings.Join(info.EmulatedPlatforms, ", "))
+	}
 	fmt.Fprintf(w, "containerd Version: %s (%s)\n", info.Daemon.Version.Version, info.Daemon.Version.Revision)
 	fmt.Fprintf(w, "containerd UUID:    %s\n", info.Daemon.Server.UUID)
 	var disabledPlugins, enabledPlugins []*introspection.Plugin
@@ -169,6 +173,9 @@ func prettyPrintInfoDockerCompat(stdout io.Writer, stderr io.Writer, info *docke
 	fmt.Fprintf(w, " Operating System: %s\n", info.OperatingSystem)
 	fmt.Fprintf(w, " OSType:           %s\n", info.OSType)
 	fmt.Fprintf(w, " Architecture:     %s\n", info.Architecture)
+	if len(info.EmulatedPlatforms) > 0 {
+		fmt.Fprintf(w, " Emulated Platforms: %s\n", strings.Join(info.EmulatedPlatforms, ", "))
+	}
 	fmt.Fprintf(w, " CPUs:             %d\n", info.NCPU)
 	fmt.Fprintf(w, " Total Memory:     %s\n", units.BytesSize(float64(info.MemTotal)))
 	fmt.Fprintf(w, " Name:             %s\n", info.Name)
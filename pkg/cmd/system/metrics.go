@@ -0,0 +1,202 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/runtime/restart"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/api/types"
+	"github.com/containerd/nerdctl/v2/pkg/cmd/container"
+	"github.com/containerd/nerdctl/v2/pkg/healthcheck"
+	"github.com/containerd/nerdctl/v2/pkg/labels"
+	"github.com/containerd/nerdctl/v2/pkg/statsutil"
+)
+
+// MetricsServe serves the metrics of the containers in the Prometheus text format at /metrics on options.Addr
+// (a TCP address, or a "unix://" socket path), until ctx is done. The statistics are collected in the background, as `nerdctl stats` does.
+func MetricsServe(ctx context.Context, client *containerd.Client, options types.SystemMetricsServeOptions) error {
+	if options.Interval < 0 {
+		return fmt.Errorf("invalid interval %s: must be positive", options.Interval)
+	}
+	nsList := options.Namespaces
+	if options.AllNamespaces {
+		var err error
+		nsList, err = client.NamespaceService().List(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if len(nsList) == 0 {
+		nsList = []string{options.GOptions.Namespace}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := make(chan error, len(nsList)+1)
+	collectors := make(map[string]*container.StatsCollector, len(nsList))
+	for _, ns := range nsList {
+		gOptions := options.GOptions
+		gOptions.Namespace = ns
+		sc, err := container.NewStatsCollector(namespaces.WithNamespace(ctx, ns), client, gOptions, true, options.Interval)
+		if err != nil {
+			return err
+		}
+		collectors[ns] = sc
+		go func() {
+			select {
+			case err := <-sc.Err():
+				errCh <- err
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		m := statsutil.NewMetrics()
+		for _, ns := range nsList {
+			if err := containerMetrics(namespaces.WithNamespace(r.Context(), ns), client, ns, collectors[ns], m); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := m.WriteTo(w); err != nil {
+			log.G(ctx).WithError(err).Debug("failed to write the metrics")
+		}
+	})
+	network, addr := "tcp", options.Addr
+	if p, ok := strings.CutPrefix(addr, "unix://"); ok {
+		network, addr = "unix", p
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.G(ctx).Infof("serving the metrics of the containers on %s (path /metrics)", options.Addr)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		return ctx.Err()
+	case err := <-errCh:
+		srv.Close()
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// containerMetrics adds the metrics of the containers of the namespace ns collected by sc to m.
+func containerMetrics(ctx context.Context, client *containerd.Client, ns string, sc *container.StatsCollector, m *statsutil.Metrics) error {
+	for _, e := range sc.Stats() {
+		c, err := client.LoadContainer(ctx, e.ID)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		info, err := c.Info(ctx, containerd.WithoutRefreshedMetadata)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		l := []statsutil.Label{
+			{Name: "namespace", Value: ns},
+			{Name: "id", Value: info.ID},
+			{Name: "name", Value: info.Labels[labels.Name]},
+			{Name: "image", Value: info.Image},
+			{Name: "compose_project", Value: info.Labels[labels.ComposeProject]},
+			{Name: "compose_service", Value: info.Labels[labels.ComposeService]},
+		}
+
+		running := false
+		if task, err := c.Task(ctx, nil); err == nil {
+			if st, err := task.Status(ctx); err == nil {
+				running = st.Status == containerd.Running
+			}
+		}
+		m.Add("nerdctl_container_running", statsutil.Gauge, "Whether the container is running (1) or not (0).", l, boolValue(running))
+		restarts, _ := strconv.Atoi(info.Labels[restart.CountLabel])
+		m.Add("nerdctl_container_restarts_total", statsutil.Counter, "Number of restarts of the container by its restart policy.", l, float64(restarts))
+		if stateJSON, ok := info.Labels[labels.HealthState]; ok {
+			if state, err := healthcheck.HealthStateFromJSON(stateJSON); err == nil {
+				for _, status := range []healthcheck.HealthStatus{healthcheck.Starting, healthcheck.Healthy, healthcheck.Unhealthy} {
+					hl := append(l[:len(l):len(l)], statsutil.Label{Name: "status", Value: status})
+					m.Add("nerdctl_container_health_status", statsutil.Gauge, "Health status of the container (1 for the current status).", hl, boolValue(state.Status == status))
+				}
+			}
+		}
+
+		if !running || e.IsInvalid {
+			continue
+		}
+		m.Add("nerdctl_container_cpu_usage_seconds_total", statsutil.Counter, "Total CPU time consumed by the container, in seconds.", l, e.CPUUsage)
+		m.Add("nerdctl_container_memory_usage_bytes", statsutil.Gauge, "Memory usage of the container, excluding the inactive file cache, in bytes.", l, e.Memory)
+		m.Add("nerdctl_container_memory_limit_bytes", statsutil.Gauge, "Memory limit of the container, in bytes.", l, e.MemoryLimit)
+		m.Add("nerdctl_container_oom_kills_total", statsutil.Counter, "Number of processes of the container killed by the OOM killer.", l, float64(e.OOMKills))
+		m.Add("nerdctl_container_network_receive_bytes_total", statsutil.Counter, "Bytes received by the container over the network.", l, e.NetworkRx)
+		m.Add("nerdctl_container_network_transmit_bytes_total", statsutil.Counter, "Bytes transmitted by the container over the network.", l, e.NetworkTx)
+		m.Add("nerdctl_container_blkio_read_bytes_total", statsutil.Counter, "Bytes read by the container from block devices.", l, e.BlockRead)
+		m.Add("nerdctl_container_blkio_write_bytes_total", statsutil.Counter, "Bytes written by the container to block devices.", l, e.BlockWrite)
+		m.Add("nerdctl_container_pids", statsutil.Gauge, "Number of processes of the container.", l, float64(e.PidsCurrent))
+		if p := e.Pressure; p != nil {
+			for _, r := range []struct {
+				name     string
+				pressure statsutil.Pressure
+			}{{"cpu", p.CPU}, {"memory", p.Memory}, {"io", p.IO}} {
+				m.Add("nerdctl_container_pressure_"+r.name+"_waiting_seconds_total", statsutil.Counter,
+					"Total time some tasks of the container were stalled waiting for "+r.name+" (PSI), in seconds.", l, r.pressure.Some.Total)
+				m.Add("nerdctl_container_pressure_"+r.name+"_stalled_seconds_total", statsutil.Counter,
+					"Total time all the tasks of the container were stalled waiting for "+r.name+" (PSI), in seconds.", l, r.pressure.Full.Total)
+			}
+		}
+	}
+	return nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
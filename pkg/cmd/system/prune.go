@@ -19,8 +19,11 @@ package system
 import (
 	"context"
 	"fmt"
+	"io"
 
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/log"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/cmd/builder"
@@ -33,6 +36,9 @@ import (
 // Prune will remove all unused containers, networks,
 // images (dangling only or both dangling and unreferenced), and optionally, volumes.
 func Prune(ctx context.Context, client *containerd.Client, options types.SystemPruneOptions) error {
+	if options.DryRun {
+		return pruneDryRun(ctx, client, options)
+	}
 	if err := container.Prune(ctx, client, types.ContainerPruneOptions{
 		GOptions: options.GOptions,
 		Stdout:   options.Stdout,
@@ -87,3 +93,74 @@ func Prune(ctx context.Context, client *containerd.Client, options types.SystemP
 
 	return nil
 }
+
+// pruneDryRun prints what Prune would remove, except the build cache.
+func pruneDryRun(ctx context.Context, client *containerd.Client, options types.SystemPruneOptions) error {
+	w := options.Stdout
+	prunedContainers, err := container.PruneCandidates(ctx, client)
+	if err != nil {
+		return err
+	}
+	ignored := make(map[string]bool, len(prunedContainers))
+	var ignoredInfo []containers.Container
+	if len(prunedContainers) > 0 {
+		fmt.Fprintln(w, "Would delete containers:")
+		for _, c := range prunedContainers {
+			info, err := c.Info(ctx, containerd.WithoutRefreshedMetadata)
+			if err != nil {
+				return err
+			}
+			ignored[c.ID()] = true
+			ignoredInfo = append(ignoredInfo, info)
+			fmt.Fprintln(w, c.ID())
+		}
+		fmt.Fprintln(w, "")
+	}
+
+	networks, err := network.PruneCandidates(ctx, client, types.NetworkPruneOptions{
+		GOptions:             options.GOptions,
+		NetworkDriversToKeep: options.NetworkDriversToKeep,
+	}, ignored)
+	if err != nil {
+		return err
+	}
+	printNames(w, "Would delete networks:", networks)
+
+	if options.Volumes {
+		volumes, err := volume.PruneCandidates(ctx, client, types.VolumePruneOptions{
+			GOptions: options.GOptions,
+		}, ignored)
+		if err != nil {
+			return err
+		}
+		printNames(w, "Would delete volumes:", volumes)
+	}
+
+	plan, err := image.NewPrunePlan(ctx, client, types.ImagePruneOptions{
+		GOptions: options.GOptions,
+		All:      options.All,
+		DryRun:   true,
+	}, ignoredInfo)
+	if err != nil {
+		return err
+	}
+	if err := plan.Print(w); err != nil {
+		return err
+	}
+
+	if options.BuildKitHost != "" {
+		log.G(ctx).Info("The build cache which would be deleted is not listed in dry-run mode")
+	}
+	return nil
+}
+
+func printNames(w io.Writer, header string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintln(w, header)
+	for _, name := range names {
+		fmt.Fprintln(w, name)
+	}
+	fmt.Fprintln(w, "")
+}
@@ -19,6 +19,7 @@ package volume
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -45,29 +46,8 @@ func Prune(ctx context.Context, client *containerd.Client, options types.VolumeP
 			return nil, err
 		}
 
-		usedVolumesList, err := usedVolumes(ctx, containers)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, volume := range volumes {
-			if _, ok := usedVolumesList[volume.Name]; ok {
-				continue
-			}
-			if !options.All {
-				if volume.Labels == nil {
-					continue
-				}
-				val, ok := (*volume.Labels)[labels.AnonymousVolumes]
-				// skip the named volume and only remove the anonymous volume
-				if !ok || val != "" {
-					continue
-				}
-			}
-			toRemove = append(toRemove, volume.Name)
-		}
-
-		return toRemove, nil
+		toRemove, err = pruneCandidates(ctx, volumes, containers, options.All)
+		return toRemove, err
 	})
 
 	if err != nil {
@@ -82,3 +62,62 @@ func Prune(ctx context.Context, client *containerd.Client, options types.VolumeP
 
 	return nil
 }
+
+// PruneCandidates returns the names of the volumes which Prune would remove, as if the containers ignoredContainers
+// were removed (e.g., the stopped containers in `nerdctl system prune`).
+func PruneCandidates(ctx context.Context, client *containerd.Client, options types.VolumePruneOptions, ignoredContainers map[string]bool) ([]string, error) {
+	volStore, err := Store(options.GOptions.Namespace, options.GOptions.DataRoot, options.GOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+	vols, err := volStore.List(false)
+	if err != nil {
+		return nil, err
+	}
+	volumes := make([]*native.Volume, 0, len(vols))
+	for _, v := range vols {
+		volumes = append(volumes, &v)
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Name < volumes[j].Name
+	})
+	allContainers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var containers []containerd.Container
+	for _, c := range allContainers {
+		if !ignoredContainers[c.ID()] {
+			containers = append(containers, c)
+		}
+	}
+	return pruneCandidates(ctx, volumes, containers, options.All)
+}
+
+// pruneCandidates returns the names of the volumes which are not used by the containers,
+// and are anonymous unless all is true.
+func pruneCandidates(ctx context.Context, volumes []*native.Volume, containers []containerd.Container, all bool) ([]string, error) {
+	usedVolumesList, err := usedVolumes(ctx, containers)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	for _, volume := range volumes {
+		if _, ok := usedVolumesList[volume.Name]; ok {
+			continue
+		}
+		if !all {
+			if volume.Labels == nil {
+				continue
+			}
+			val, ok := (*volume.Labels)[labels.AnonymousVolumes]
+			// skip the named volume and only remove the anonymous volume
+			if !ok || val != "" {
+				continue
+			}
+		}
+		candidates = append(candidates, volume.Name)
+	}
+	return candidates, nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/compose-spec/compose-go/v2/types"
 
@@ -47,8 +48,9 @@ func (c *Composer) Build(ctx context.Context, bo BuildOptions, services []string
 	}, types.IgnoreDependencies)
 }
 
-func (c *Composer) buildServiceImage(ctx context.Context, image string, b *serviceparser.Build, platform string, bo BuildOptions) error {
-	log.G(ctx).Infof("Building image %s", image)
+// buildServiceImage builds image. The result is also tagged as extraImages, if any.
+func (c *Composer) buildServiceImage(ctx context.Context, image string, b *serviceparser.Build, platform string, bo BuildOptions, extraImages ...string) error {
+	log.G(ctx).Infof("Building image %s", strings.Join(append([]string{image}, extraImages...), ", "))
 
 	var args []string // nolint: prealloc
 	if platform != "" {
@@ -63,6 +65,9 @@ func (c *Composer) buildServiceImage(ctx context.Context, image string, b *servi
 	if bo.Progress != "" {
 		args = append(args, "--progress="+bo.Progress)
 	}
+	for _, img := range extraImages {
+		args = append(args, "-t="+img)
+	}
 	args = append(args, b.BuildArgs...)
 
 	cmd := c.createNerdctlCmd(ctx, append([]string{"build"}, args...)...)
@@ -52,6 +52,11 @@ type Options struct {
 	DebugPrintFull   bool // full debug print, may leak secret env var to logs
 	Experimental     bool // enable experimental features
 	IPFSAddress      string
+	// FileObjectsDir is the directory where configs and secrets that cannot be bind-mounted as is are materialized.
+	FileObjectsDir string
+	// ToHostUser translates a container uid/gid pair to the host, for the ownership of materialized configs and secrets.
+	// nil means the identity mapping.
+	ToHostUser func(uid, gid uint32) (uint32, uint32, error)
 }
 
 func New(o Options, client *containerd.Client) (*Composer, error) {
@@ -0,0 +1,91 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package composer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
+	"github.com/containerd/nerdctl/v2/pkg/labels"
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+)
+
+// configHash returns the hash of the configuration of a service container.
+// The hash is stored in the labels.ComposeConfigHash label of the container,
+// so that `compose up` only recreates the containers that diverge from the compose model,
+// and the changes made with `nerdctl update` are kept.
+func (c *Composer) configHash(ctx context.Context, service *serviceparser.Service, container serviceparser.Container) (string, error) {
+	var imageDigest string
+	// the container is recreated when the image was updated (e.g., with `compose pull` or `compose build`)
+	if ref, err := referenceutil.Parse(service.Image); err == nil {
+		if img, err := c.client.GetImage(ctx, ref.String()); err == nil {
+			imageDigest = img.Target().Digest.String()
+		}
+	}
+	return hashConfig(container, c.EnvFile, imageDigest)
+}
+
+// hashConfig returns the hash of the run arguments and of the contents of the file objects and of the env file.
+func hashConfig(container serviceparser.Container, envFile, imageDigest string) (string, error) {
+	config := struct {
+		RunArgs     []string
+		FileObjects []serviceparser.FileObject
+		EnvFile     string `json:",omitempty"`
+		Image       string `json:",omitempty"`
+	}{
+		RunArgs: container.RunArgs,
+		Image:   imageDigest,
+	}
+	for _, f := range container.FileObjects {
+		// the files of the configs and the secrets are copied into the containers, so their contents are hashed.
+		// the files may be removed, in that case the container is recreated, and fails with an explicit error
+		if f.Source != "" && f.Content == nil {
+			if b, err := os.ReadFile(f.Source); err == nil {
+				f.Content = b
+			}
+		}
+		config.FileObjects = append(config.FileObjects, f)
+	}
+	if envFile != "" {
+		if b, err := os.ReadFile(envFile); err == nil {
+			config.EnvFile = string(b)
+		}
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// isUpToDate returns true if the existing container was created with the same configuration hash.
+func (c *Composer) isUpToDate(ctx context.Context, id, hash string) (bool, error) {
+	container, err := c.client.LoadContainer(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	containerLabels, err := container.Labels(ctx)
+	if err != nil {
+		return false, err
+	}
+	return containerLabels[labels.ComposeConfigHash] == hash, nil
+}
@@ -0,0 +1,72 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package composer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
+)
+
+func TestHashConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "config.txt")
+	assert.NilError(t, os.WriteFile(source, []byte("foo"), 0o644))
+	container := serviceparser.Container{
+		Name:        "compose-foo-1",
+		RunArgs:     []string{"--name=compose-foo-1", "alpine"},
+		FileObjects: []serviceparser.FileObject{{Name: "config", Source: source, Target: "/config.txt", Mode: 0o444}},
+	}
+
+	hash, err := hashConfig(container, "", "sha256:abc")
+	assert.NilError(t, err)
+	same, err := hashConfig(container, "", "sha256:abc")
+	assert.NilError(t, err)
+	assert.Equal(t, hash, same)
+
+	// the contents of the file objects are hashed, not only their paths
+	assert.NilError(t, os.WriteFile(source, []byte("bar"), 0o644))
+	changed, err := hashConfig(container, "", "sha256:abc")
+	assert.NilError(t, err)
+	assert.Assert(t, changed != hash)
+	assert.NilError(t, os.WriteFile(source, []byte("foo"), 0o644))
+
+	changed, err = hashConfig(container, "", "sha256:def")
+	assert.NilError(t, err)
+	assert.Assert(t, changed != hash, "the image was updated")
+
+	modified := container
+	modified.RunArgs = []string{"--name=compose-foo-1", "--memory=1g", "alpine"}
+	changed, err = hashConfig(modified, "", "sha256:abc")
+	assert.NilError(t, err)
+	assert.Assert(t, changed != hash, "the service was modified")
+
+	envFile := filepath.Join(dir, ".env")
+	assert.NilError(t, os.WriteFile(envFile, []byte("A=1"), 0o644))
+	withEnv, err := hashConfig(container, envFile, "sha256:abc")
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(envFile, []byte("A=2"), 0o644))
+	changed, err = hashConfig(container, envFile, "sha256:abc")
+	assert.NilError(t, err)
+	assert.Assert(t, changed != withEnv, "the env file was modified")
+}
@@ -40,8 +40,7 @@ const (
 	// RecreateForce specifies always force-recreating service containers
 	RecreateForce = "force"
 	// RecreateDiverged specifies only recreating service containers which diverges from compose model.
-	// (Unimplemented, currently equal to `RecreateNever`) In docker-compose,
-	// service config is hashed and stored in a label.
+	// As in docker-compose, the service config is hashed and stored in a label (labels.ComposeConfigHash).
 	// FYI: https://github.com/docker/compose/blob/v2.14.1/pkg/compose/convergence.go#L244
 	RecreateDiverged = "diverged"
 )
@@ -114,15 +113,17 @@ func (c *Composer) Create(ctx context.Context, opt CreateOptions, services []str
 	}
 
 	// ensure images
-	// TODO: parallelize loop for ensuring images (make sure not to mess up tty)
 	parsedServices, err := c.Services(ctx, services...)
 	if err != nil {
 		return err
 	}
-	for _, ps := range parsedServices {
-		if err := c.ensureServiceImage(ctx, ps, !opt.NoBuild, opt.Build, BuildOptions{}, false, ""); err != nil {
-			return err
-		}
+	eo := ensureOptions{
+		AllowBuild: !opt.NoBuild,
+		ForceBuild: opt.Build,
+		Parallel:   -1,
+	}
+	if err := c.ensureServiceImages(ctx, parsedServices, eo); err != nil {
+		return err
 	}
 
 	for _, ps := range parsedServices {
@@ -180,6 +181,11 @@ func (c *Composer) createServiceContainer(ctx context.Context, service *servicep
 		log.G(ctx).Infof("Creating container %s", container.Name)
 	}
 
+	configHash, err := c.configHash(ctx, service, container)
+	if err != nil {
+		return "", err
+	}
+
 	tempDir, err := os.MkdirTemp(os.TempDir(), "compose-")
 	if err != nil {
 		return "", fmt.Errorf("error while creating/re-creating container %s: %w", container.Name, err)
@@ -187,11 +193,18 @@ func (c *Composer) createServiceContainer(ctx context.Context, service *servicep
 	defer os.RemoveAll(tempDir)
 	cidFilename := filepath.Join(tempDir, "cid")
 
+	fileObjectArgs, err := c.materializeFileObjects(ctx, container)
+	if err != nil {
+		return "", err
+	}
+	container.RunArgs = append(fileObjectArgs, container.RunArgs...)
+
 	//add metadata labels to container https://github.com/compose-spec/compose-spec/blob/master/spec.md#labels
 	container.RunArgs = append([]string{
 		"--cidfile=" + cidFilename,
 		fmt.Sprintf("-l=%s=%s", labels.ComposeProject, c.project.Name),
 		fmt.Sprintf("-l=%s=%s", labels.ComposeService, service.Unparsed.Name),
+		fmt.Sprintf("-l=%s=%s", labels.ComposeConfigHash, configHash),
 	}, container.RunArgs...)
 
 	cmd := c.createNerdctlCmd(ctx, append([]string{"create"}, container.RunArgs...)...)
@@ -19,6 +19,7 @@ package composer
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/containerd/log"
 
@@ -69,6 +70,13 @@ func (c *Composer) Down(ctx context.Context, downOptions DownOptions) error {
 		}
 	}
 
+	if c.FileObjectsDir != "" {
+		// remove the configs and the secrets left by containers that are no longer defined
+		if err := os.RemoveAll(c.fileObjectsProjectDir()); err != nil {
+			log.G(ctx).WithError(err).Warn("failed to remove configs and secrets")
+		}
+	}
+
 	for shortName := range c.project.Networks {
 		if err := c.downNetwork(ctx, shortName); err != nil {
 			return err
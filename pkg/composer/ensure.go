@@ -0,0 +1,208 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package composer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/jobs"
+)
+
+type ensureOptions struct {
+	AllowBuild bool
+	ForceBuild bool
+	BuildOpts  BuildOptions
+	Quiet      bool
+	PullMode   string // overrides the pull_policy of the services when non-empty
+	Parallel   int    // max number of builds and pulls running concurrently, -1 for unlimited
+}
+
+// serviceBuild is a build shared by the services having the same build definition.
+type serviceBuild struct {
+	images   []string
+	build    *serviceparser.Build
+	platform string
+}
+
+// servicePull is a pull shared by the services referring to the same image.
+type servicePull struct {
+	image    string
+	mode     string
+	platform string
+	ps       *serviceparser.Service
+}
+
+// ensureServiceImages builds or pulls the images of parsedServices.
+//
+// Builds run first, as the images built for some services may be referred to by other services.
+// Identical builds and identical image references are only processed once.
+func (c *Composer) ensureServiceImages(ctx context.Context, parsedServices []*serviceparser.Service, eo ensureOptions) error {
+	var (
+		builds    []*serviceBuild
+		buildKeys = make(map[string]*serviceBuild)
+		pulls     []*servicePull
+		pullKeys  = make(map[string]struct{})
+	)
+	for _, ps := range parsedServices {
+		if ps.Build != nil && eo.AllowBuild {
+			needsBuild := ps.Build.Force || eo.ForceBuild
+			if !needsBuild {
+				ok, err := c.ImageExists(ctx, ps.Image)
+				if err != nil {
+					return err
+				}
+				needsBuild = !ok
+			}
+			if needsBuild {
+				key := buildKey(ps.Build, ps.Unparsed.Platform)
+				if b, ok := buildKeys[key]; ok {
+					if !slices.Contains(b.images, ps.Image) {
+						b.images = append(b.images, ps.Image)
+					}
+					continue
+				}
+				b := &serviceBuild{
+					images:   []string{ps.Image},
+					build:    ps.Build,
+					platform: ps.Unparsed.Platform,
+				}
+				buildKeys[key] = b
+				builds = append(builds, b)
+				continue
+			}
+			// even when c.ImageExists returns true, we need to call c.EnsureImage
+			// because ps.PullMode can be "always".
+			log.G(ctx).Debugf("Image %s already exists, not building", ps.Image)
+		}
+
+		mode := ps.PullMode
+		if eo.PullMode != "" {
+			mode = eo.PullMode
+		}
+		key := pullKey(ps, mode)
+		if _, ok := pullKeys[key]; ok {
+			continue
+		}
+		pullKeys[key] = struct{}{}
+		pulls = append(pulls, &servicePull{
+			image:    ps.Image,
+			mode:     mode,
+			platform: ps.Unparsed.Platform,
+			ps:       ps,
+		})
+	}
+
+	if err := c.runServiceBuilds(ctx, builds, eo); err != nil {
+		return err
+	}
+	return c.runServicePulls(ctx, pulls, eo)
+}
+
+func (c *Composer) runServiceBuilds(ctx context.Context, builds []*serviceBuild, eo ensureOptions) error {
+	bo := eo.BuildOpts
+	if len(builds) > 1 && eo.Parallel != 1 && bo.Progress == "" {
+		// concurrent tty progress displays would overwrite each other
+		bo.Progress = "plain"
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(eo.Parallel)
+	for _, b := range builds {
+		eg.Go(func() error {
+			return c.buildServiceImage(ctx, b.images[0], b.build, b.platform, bo, b.images[1:]...)
+		})
+	}
+	return eg.Wait()
+}
+
+func (c *Composer) runServicePulls(ctx context.Context, pulls []*servicePull, eo ensureOptions) error {
+	if len(pulls) == 0 {
+		return nil
+	}
+	for _, p := range pulls {
+		log.G(ctx).Infof("Ensuring image %s", p.image)
+	}
+
+	progressDone := make(chan struct{})
+	pctx, stopProgress := context.WithCancel(ctx)
+	if eo.Quiet || c.client == nil {
+		close(progressDone)
+	} else {
+		// a single display is shared by all the pulls, so that they do not mess up the tty
+		group := jobs.NewGroup()
+		ctx = jobs.WithGroup(ctx, group)
+		go func() {
+			jobs.ShowGroupProgress(pctx, group, c.client.ContentStore(), os.Stderr)
+			close(progressDone)
+		}()
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(eo.Parallel)
+	for _, p := range pulls {
+		eg.Go(func() error {
+			if err := c.EnsureImage(ctx, p.image, p.mode, p.platform, p.ps, eo.Quiet); err != nil {
+				return fmt.Errorf("error while ensuring image %s: %w", p.image, err)
+			}
+			return nil
+		})
+	}
+	err := eg.Wait()
+	stopProgress()
+	<-progressDone
+	return err
+}
+
+// buildKey identifies the build definition of b regardless of the image name,
+// so that services sharing a build context, a Dockerfile and args are built only once.
+func buildKey(b *serviceparser.Build, platform string) string {
+	var args []string
+	for _, a := range b.BuildArgs {
+		if !strings.HasPrefix(a, "-t=") {
+			args = append(args, a)
+		}
+	}
+	// the args are generated from maps, so their order is not stable
+	slices.Sort(args)
+	return platform + "\x00" + strings.Join(args, "\x00")
+}
+
+// pullKey identifies the pull of the image of ps.
+// The verification settings are part of the key, so that a pull is never shared
+// between services that require different verifications.
+func pullKey(ps *serviceparser.Service, mode string) string {
+	key := []string{ps.Image, ps.Unparsed.Platform, mode}
+	for _, ext := range []string{
+		serviceparser.ComposeVerify,
+		serviceparser.ComposeCosignPublicKey,
+		serviceparser.ComposeCosignCertificateIdentity,
+		serviceparser.ComposeCosignCertificateIdentityRegexp,
+		serviceparser.ComposeCosignCertificateOidcIssuer,
+		serviceparser.ComposeCosignCertificateOidcIssuerRegexp,
+	} {
+		key = append(key, fmt.Sprint(ps.Unparsed.Extensions[ext]))
+	}
+	return strings.Join(key, "\x00")
+}
@@ -0,0 +1,57 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package composer
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
+)
+
+func TestBuildKey(t *testing.T) {
+	t.Parallel()
+
+	foo := &serviceparser.Build{BuildArgs: []string{"-t=foo", "--build-arg=A=1", "--build-arg=B=2", "/ctx"}}
+	bar := &serviceparser.Build{BuildArgs: []string{"-t=bar", "--build-arg=B=2", "--build-arg=A=1", "/ctx"}}
+	baz := &serviceparser.Build{BuildArgs: []string{"-t=baz", "--build-arg=A=1", "/ctx"}}
+
+	assert.Equal(t, buildKey(foo, ""), buildKey(bar, ""))
+	assert.Assert(t, buildKey(foo, "") != buildKey(baz, ""))
+	assert.Assert(t, buildKey(foo, "linux/amd64") != buildKey(foo, "linux/arm64"))
+}
+
+func TestPullKey(t *testing.T) {
+	t.Parallel()
+
+	service := func(image string, extensions types.Extensions) *serviceparser.Service {
+		return &serviceparser.Service{
+			Image:    image,
+			Unparsed: &types.ServiceConfig{Image: image, Extensions: extensions},
+		}
+	}
+
+	foo := service("alpine", nil)
+	bar := service("alpine", nil)
+	signed := service("alpine", types.Extensions{serviceparser.ComposeVerify: "cosign"})
+
+	assert.Equal(t, pullKey(foo, "missing"), pullKey(bar, "missing"))
+	assert.Assert(t, pullKey(foo, "missing") != pullKey(bar, "always"))
+	assert.Assert(t, pullKey(foo, "missing") != pullKey(signed, "missing"))
+}
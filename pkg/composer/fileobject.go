@@ -0,0 +1,119 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package composer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
+)
+
+// fileObjectsProjectDir returns the directory where the configs and the secrets of the project are materialized.
+func (c *Composer) fileObjectsProjectDir() string {
+	return filepath.Join(c.FileObjectsDir, c.project.Name)
+}
+
+// materializeFileObjects writes the configs and the secrets of the container into
+// a directory dedicated to the container, and returns the `-v` flags for mounting them.
+func (c *Composer) materializeFileObjects(ctx context.Context, container serviceparser.Container) ([]string, error) {
+	if len(container.FileObjects) == 0 {
+		return nil, nil
+	}
+	if c.FileObjectsDir == "" {
+		return nil, errors.New("configs and secrets with uid, gid, mode, content, or environment are not supported")
+	}
+	dir := filepath.Join(c.fileObjectsProjectDir(), container.Name)
+	// drop the objects of the previous incarnation of the container
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	var args []string
+	for _, obj := range container.FileObjects {
+		objType := "configs"
+		if obj.Secret {
+			objType = "secrets"
+		}
+		objDir := filepath.Join(dir, objType)
+		if err := os.MkdirAll(objDir, 0o700); err != nil {
+			return nil, err
+		}
+		p := filepath.Join(objDir, obj.Name)
+		log.G(ctx).Debugf("Materializing %s %q into %q", objType[:len(objType)-1], obj.Name, p)
+		if err := c.writeFileObject(p, obj); err != nil {
+			return nil, fmt.Errorf("failed to materialize %s %q for container %s: %w", objType[:len(objType)-1], obj.Name, container.Name, err)
+		}
+		args = append(args, fmt.Sprintf("-v=%s:%s:ro", p, obj.Target))
+	}
+	return args, nil
+}
+
+func (c *Composer) writeFileObject(p string, obj serviceparser.FileObject) error {
+	uid, gid := obj.UID, obj.GID
+	if c.ToHostUser != nil {
+		var err error
+		uid, gid, err = c.ToHostUser(uid, gid)
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if obj.Source != "" {
+		src, err := os.Open(obj.Source)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if _, err := io.Copy(f, src); err != nil {
+			return err
+		}
+	} else if _, err := f.Write(obj.Content); err != nil {
+		return err
+	}
+	// chown before chmod, so that the file is never readable by a wrong user
+	if runtime.GOOS != "windows" {
+		if err := f.Chown(int(uid), int(gid)); err != nil {
+			return err
+		}
+	}
+	if err := f.Chmod(obj.Mode); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// removeFileObjects removes the configs and the secrets materialized for the container.
+func (c *Composer) removeFileObjects(ctx context.Context, containerName string) {
+	if c.FileObjectsDir == "" {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(c.fileObjectsProjectDir(), containerName)); err != nil {
+		log.G(ctx).WithError(err).Warnf("failed to remove configs and secrets of container %s", containerName)
+	}
+}
@@ -18,74 +18,33 @@ package composer
 
 import (
 	"context"
-	"fmt"
-	"os"
 
 	"github.com/compose-spec/compose-go/v2/types"
 
-	"github.com/containerd/log"
-
 	"github.com/containerd/nerdctl/v2/pkg/composer/serviceparser"
 )
 
 type PullOptions struct {
-	Quiet bool
+	Quiet    bool
+	Parallel int // max number of images pulled concurrently, -1 for unlimited
 }
 
 func (c *Composer) Pull(ctx context.Context, po PullOptions, services []string) error {
-	return c.project.ForEachService(services, func(name string, svc *types.ServiceConfig) error {
+	var parsedServices []*serviceparser.Service
+	if err := c.project.ForEachService(services, func(name string, svc *types.ServiceConfig) error {
 		ps, err := serviceparser.Parse(c.project, *svc)
 		if err != nil {
 			return err
 		}
-		return c.pullServiceImage(ctx, ps.Image, ps.Unparsed.Platform, ps, po)
-	})
-}
-
-func (c *Composer) pullServiceImage(ctx context.Context, image string, platform string, ps *serviceparser.Service, po PullOptions) error {
-	log.G(ctx).Infof("Pulling image %s", image)
-
-	var args []string // nolint: prealloc
-	if platform != "" {
-		args = append(args, "--platform="+platform)
-	}
-	if po.Quiet {
-		args = append(args, "--quiet")
-	}
-	if verifier, ok := ps.Unparsed.Extensions[serviceparser.ComposeVerify]; ok {
-		args = append(args, "--verify="+verifier.(string))
-	}
-	if publicKey, ok := ps.Unparsed.Extensions[serviceparser.ComposeCosignPublicKey]; ok {
-		args = append(args, "--cosign-key="+publicKey.(string))
-	}
-	if certificateIdentity, ok := ps.Unparsed.Extensions[serviceparser.ComposeCosignCertificateIdentity]; ok {
-		args = append(args, "--cosign-certificate-identity="+certificateIdentity.(string))
-	}
-	if certificateIdentityRegexp, ok := ps.Unparsed.Extensions[serviceparser.ComposeCosignCertificateIdentityRegexp]; ok {
-		args = append(args, "--cosign-certificate-identity-regexp="+certificateIdentityRegexp.(string))
-	}
-	if certificateOidcIssuer, ok := ps.Unparsed.Extensions[serviceparser.ComposeCosignCertificateOidcIssuer]; ok {
-		args = append(args, "--cosign-certificate-oidc-issuer="+certificateOidcIssuer.(string))
-	}
-	if certificateOidcIssuerRegexp, ok := ps.Unparsed.Extensions[serviceparser.ComposeCosignCertificateOidcIssuerRegexp]; ok {
-		args = append(args, "--cosign-certificate-oidc-issuer-regexp="+certificateOidcIssuerRegexp.(string))
-	}
-
-	if c.Options.Experimental {
-		args = append(args, "--experimental")
-	}
-
-	args = append(args, image)
-
-	cmd := c.createNerdctlCmd(ctx, append([]string{"pull"}, args...)...)
-	if c.DebugPrintFull {
-		log.G(ctx).Debugf("Running %v", cmd.Args)
+		parsedServices = append(parsedServices, ps)
+		return nil
+	}); err != nil {
+		return err
 	}
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error while pulling image %s: %w", image, err)
+	eo := ensureOptions{
+		Quiet:    po.Quiet,
+		PullMode: "always",
+		Parallel: po.Parallel,
 	}
-	return nil
+	return c.ensureServiceImages(ctx, parsedServices, eo)
 }
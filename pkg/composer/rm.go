@@ -88,7 +88,9 @@ func (c *Composer) removeContainers(ctx context.Context, containers []containerd
 			log.G(ctx).Infof("Removing container %s", info.Labels[labels.Name])
 			if err := c.runNerdctlCmd(ctx, append(args, container.ID())...); err != nil {
 				log.G(ctx).Warn(err)
+				return
 			}
+			c.removeFileObjects(ctx, info.Labels[labels.Name])
 		}()
 	}
 	rmWG.Wait()
@@ -107,7 +109,9 @@ func (c *Composer) removeContainersFromParsedServices(ctx context.Context, conta
 			log.G(ctx).Infof("Removing container %s", container.Name)
 			if err := c.runNerdctlCmd(ctx, "rm", "-f", id); err != nil {
 				log.G(ctx).Warn(err)
+				return
 			}
+			c.removeFileObjects(ctx, container.Name)
 		}()
 	}
 	rmWG.Wait()
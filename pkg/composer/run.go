@@ -213,11 +213,14 @@ func (c *Composer) runServices(ctx context.Context, parsedServices []*servicepar
 		return errors.New("no service was provided")
 	}
 
-	// TODO: parallelize loop for ensuring images (make sure not to mess up tty)
-	for _, ps := range parsedServices {
-		if err := c.ensureServiceImage(ctx, ps, !ro.NoBuild, ro.ForceBuild, BuildOptions{}, ro.QuietPull, ""); err != nil {
-			return err
-		}
+	eo := ensureOptions{
+		AllowBuild: !ro.NoBuild,
+		ForceBuild: ro.ForceBuild,
+		Quiet:      ro.QuietPull,
+		Parallel:   -1,
+	}
+	if err := c.ensureServiceImages(ctx, parsedServices, eo); err != nil {
+		return err
 	}
 
 	var (
@@ -69,6 +69,7 @@ func warnUnknownFields(svc types.ServiceConfig) {
 		"ContainerName",
 		"DependsOn",
 		"Deploy",
+		"DeviceCgroupRules",
 		"Devices",
 		"Dockerfile", // handled by the loader (normalizer)
 		"DNS",
@@ -189,9 +190,23 @@ func warnUnknownFields(svc types.ServiceConfig) {
 }
 
 type Container struct {
-	Name    string   // e.g., "compose-wordpress_wordpress_1"
-	RunArgs []string // {"--pull=never", ...}
-	Mkdir   []string // For Bind.CreateHostPath
+	Name        string       // e.g., "compose-wordpress_wordpress_1"
+	RunArgs     []string     // {"--pull=never", ...}
+	Mkdir       []string     // For Bind.CreateHostPath
+	FileObjects []FileObject // configs and secrets to be materialized on the host before creating the container
+}
+
+// FileObject is a config or a secret that cannot be bind-mounted from its source as is,
+// because it has inline content, or because its ownership or its mode has to be changed.
+type FileObject struct {
+	Secret  bool
+	Name    string // name of the config or the secret, e.g., "db_password"
+	Source  string // absolute path of the source file, empty when Content is used
+	Content []byte // content of `content:` and `environment:` objects
+	Target  string // absolute path in the container
+	UID     uint32 // in the container
+	GID     uint32 // in the container
+	Mode    os.FileMode
 }
 
 type Build struct {
@@ -518,6 +533,10 @@ func newContainer(project *types.Project, parsed *Service, i int) (*Container, e
 		c.RunArgs = append(c.RunArgs, fmt.Sprintf("--device=%s:%s:%s", v.Source, v.Target, v.Permissions))
 	}
 
+	for _, v := range svc.DeviceCgroupRules {
+		c.RunArgs = append(c.RunArgs, fmt.Sprintf("--device-cgroup-rule=%s", v))
+	}
+
 	for _, v := range svc.DNS {
 		c.RunArgs = append(c.RunArgs, fmt.Sprintf("--dns=%s", v))
 	}
@@ -704,19 +723,27 @@ func newContainer(project *types.Project, parsed *Service, i int) (*Container, e
 
 	for _, config := range svc.Configs {
 		fileRef := types.FileReferenceConfig(config)
-		vStr, err := fileReferenceConfigToFlagV(fileRef, project, false)
+		vStr, obj, err := fileReferenceConfigToFlagV(fileRef, project, false)
 		if err != nil {
 			return nil, err
 		}
+		if obj != nil {
+			c.FileObjects = append(c.FileObjects, *obj)
+			continue
+		}
 		c.RunArgs = append(c.RunArgs, "-v="+vStr)
 	}
 
 	for _, secret := range svc.Secrets {
 		fileRef := types.FileReferenceConfig(secret)
-		vStr, err := fileReferenceConfigToFlagV(fileRef, project, true)
+		vStr, obj, err := fileReferenceConfigToFlagV(fileRef, project, true)
 		if err != nil {
 			return nil, err
 		}
+		if obj != nil {
+			c.FileObjects = append(c.FileObjects, *obj)
+			continue
+		}
 		c.RunArgs = append(c.RunArgs, "-v="+vStr)
 	}
 
@@ -846,7 +873,9 @@ func serviceVolumeConfigToFlagV(c types.ServiceVolumeConfig, project *types.Proj
 	return s, mkdir, nil
 }
 
-func fileReferenceConfigToFlagV(c types.FileReferenceConfig, project *types.Project, secret bool) (string, error) {
+// fileReferenceConfigToFlagV returns the `-v` flag for bind-mounting a config or a secret.
+// When the object has to be materialized on the host, a FileObject is returned instead.
+func fileReferenceConfigToFlagV(c types.FileReferenceConfig, project *types.Project, secret bool) (string, *FileObject, error) {
 	objType := "config"
 	if secret {
 		objType = "secret"
@@ -858,29 +887,23 @@ func fileReferenceConfigToFlagV(c types.FileReferenceConfig, project *types.Proj
 	}
 
 	if err := identifiers.ValidateDockerCompat(c.Source); err != nil {
-		return "", fmt.Errorf("invalid source name for %s: %w", objType, err)
+		return "", nil, fmt.Errorf("invalid source name for %s: %w", objType, err)
 	}
 
 	var obj types.FileObjectConfig
 	if secret {
 		secret, ok := project.Secrets[c.Source]
 		if !ok {
-			return "", fmt.Errorf("secret %s is undefined", c.Source)
+			return "", nil, fmt.Errorf("secret %s is undefined", c.Source)
 		}
 		obj = types.FileObjectConfig(secret)
 	} else {
 		config, ok := project.Configs[c.Source]
 		if !ok {
-			return "", fmt.Errorf("config %s is undefined", c.Source)
+			return "", nil, fmt.Errorf("config %s is undefined", c.Source)
 		}
 		obj = types.FileObjectConfig(config)
 	}
-	src := project.RelativePath(obj.File)
-	var err error
-	src, err = filepath.Abs(src)
-	if err != nil {
-		return "", fmt.Errorf("%s %s: invalid relative path %q: %w", objType, c.Source, src, err)
-	}
 
 	target := c.Target
 	if target == "" {
@@ -895,24 +918,64 @@ func fileReferenceConfigToFlagV(c types.FileReferenceConfig, project *types.Proj
 			if secret {
 				target = filepath.Join("/run/secrets", target)
 			} else {
-				return "", fmt.Errorf("config %s: target %q must be an absolute path", c.Source, c.Target)
+				return "", nil, fmt.Errorf("config %s: target %q must be an absolute path", c.Source, c.Target)
 			}
 		}
 	}
 
+	fo := &FileObject{
+		Secret: secret,
+		Name:   c.Source,
+		Target: target,
+		Mode:   0o444,
+	}
+	materialize := c.UID != "" || c.GID != "" || c.Mode != nil
+	switch {
+	case obj.Content != "":
+		fo.Content = []byte(obj.Content)
+		materialize = true
+	case obj.Environment != "":
+		v, ok := project.Environment[obj.Environment]
+		if !ok {
+			return "", nil, fmt.Errorf("%s %s: environment variable %q is not set", objType, c.Source, obj.Environment)
+		}
+		fo.Content = []byte(v)
+		materialize = true
+	case obj.File != "":
+		src, err := filepath.Abs(project.RelativePath(obj.File))
+		if err != nil {
+			return "", nil, fmt.Errorf("%s %s: invalid relative path %q: %w", objType, c.Source, obj.File, err)
+		}
+		fo.Source = src
+	default:
+		return "", nil, fmt.Errorf("%s %s: one of file, content, or environment must be specified", objType, c.Source)
+	}
+
+	if !materialize {
+		return fmt.Sprintf("%s:%s:ro", fo.Source, target), nil, nil
+	}
+
 	if c.UID != "" {
-		// Raise an error rather than ignoring the value, for avoiding any security issue
-		return "", fmt.Errorf("%s %s: unsupported field: UID", objType, c.Source)
+		uid, err := strconv.ParseUint(c.UID, 10, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s %s: uid must be numeric, got %q", objType, c.Source, c.UID)
+		}
+		fo.UID = uint32(uid)
 	}
 	if c.GID != "" {
-		return "", fmt.Errorf("%s %s: unsupported field: GID", objType, c.Source)
+		gid, err := strconv.ParseUint(c.GID, 10, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s %s: gid must be numeric, got %q", objType, c.Source, c.GID)
+		}
+		fo.GID = uint32(gid)
 	}
 	if c.Mode != nil {
-		return "", fmt.Errorf("%s %s: unsupported field: Mode", objType, c.Source)
+		if *c.Mode&^0o777 != 0 {
+			return "", nil, fmt.Errorf("%s %s: invalid mode %o", objType, c.Source, *c.Mode)
+		}
+		fo.Mode = os.FileMode(*c.Mode)
 	}
-
-	s := fmt.Sprintf("%s:%s:ro", src, target)
-	return s, nil
+	return "", fo, nil
 }
 
 // DefaultImageName returns the image name following compose naming logic.
@@ -18,6 +18,7 @@ package serviceparser
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -346,6 +347,8 @@ services:
       - /dev/a
       - /dev/b:/dev/b
       - /dev/c:/dev/c:rw
+    device_cgroup_rules:
+      - "c 1:3 mr"
 `
 	comp := testutil.NewComposeDir(t, dockerComposeYAML)
 	defer comp.CleanUp()
@@ -364,6 +367,7 @@ services:
 		assert.Assert(t, in(c.RunArgs, "--device=/dev/a:/dev/a:rwm"))
 		assert.Assert(t, in(c.RunArgs, "--device=/dev/b:/dev/b:rwm"))
 		assert.Assert(t, in(c.RunArgs, "--device=/dev/c:/dev/c:rw"))
+		assert.Assert(t, in(c.RunArgs, "--device-cgroup-rule=c 1:3 mr"))
 	}
 }
 
@@ -541,6 +545,79 @@ configs:
 	}
 }
 
+func TestParseFileObjects(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("test is not compatible with windows")
+	}
+	const dockerComposeYAML = `
+services:
+  foo:
+    image: nginx:alpine
+    secrets:
+    - source: secret1
+      uid: "1000"
+      gid: "1001"
+      mode: 0400
+    - secret2
+    configs:
+    - source: config1
+      target: /etc/config1
+    - config2
+secrets:
+  secret1:
+    file: ./secret1
+  secret2:
+    environment: SECRET2
+configs:
+  config1:
+    file: ./config1
+  config2:
+    content: inline-config
+`
+	comp := testutil.NewComposeDir(t, dockerComposeYAML)
+	defer comp.CleanUp()
+
+	project, err := testutil.LoadProject(comp.YAMLFullPath(), comp.ProjectName(), map[string]string{"SECRET2": "env-secret"})
+	assert.NilError(t, err)
+
+	for _, f := range []string{"secret1", "config1"} {
+		err = filesystem.WriteFile(filepath.Join(project.WorkingDir, f), []byte("content-"+f), 0444)
+		assert.NilError(t, err)
+	}
+
+	fooSvc, err := project.GetService("foo")
+	assert.NilError(t, err)
+
+	foo, err := Parse(project, fooSvc)
+	assert.NilError(t, err)
+
+	for _, c := range foo.Containers {
+		assert.Assert(t, in(c.RunArgs, fmt.Sprintf("-v=%s:/etc/config1:ro", filepath.Join(project.WorkingDir, "config1"))))
+		// configs come before secrets
+		assert.Equal(t, 3, len(c.FileObjects))
+
+		secret1 := c.FileObjects[1]
+		assert.Equal(t, true, secret1.Secret)
+		assert.Equal(t, filepath.Join(project.WorkingDir, "secret1"), secret1.Source)
+		assert.Equal(t, "/run/secrets/secret1", secret1.Target)
+		assert.Equal(t, uint32(1000), secret1.UID)
+		assert.Equal(t, uint32(1001), secret1.GID)
+		assert.Equal(t, os.FileMode(0o400), secret1.Mode)
+
+		config2 := c.FileObjects[0]
+		assert.Equal(t, false, config2.Secret)
+		assert.Equal(t, "", config2.Source)
+		assert.Equal(t, "inline-config", string(config2.Content))
+		assert.Equal(t, "/config2", config2.Target)
+		assert.Equal(t, os.FileMode(0o444), config2.Mode)
+
+		secret2 := c.FileObjects[2]
+		assert.Equal(t, true, secret2.Secret)
+		assert.Equal(t, "env-secret", string(secret2.Content))
+	}
+}
+
 func TestParseRestartPolicy(t *testing.T) {
 	t.Parallel()
 	const dockerComposeYAML = `
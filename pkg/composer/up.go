@@ -43,6 +43,7 @@ type UpOptions struct {
 	NoRecreate           bool
 	Scale                map[string]int // map of service name to replicas
 	Pull                 string
+	Parallel             int // max number of images ensured concurrently, -1 for unlimited
 }
 
 func (opts UpOptions) recreateStrategy() string {
@@ -122,12 +123,15 @@ func (c *Composer) Up(ctx context.Context, uo UpOptions, services []string) erro
 }
 
 func validateFileObjectConfig(obj types.FileObjectConfig, shortName, objType string, project *types.Project) error {
-	if unknown := reflectutil.UnknownNonEmptyFields(&obj, "Name", "External", "File"); len(unknown) > 0 {
+	if unknown := reflectutil.UnknownNonEmptyFields(&obj, "Name", "External", "File", "Content", "Environment"); len(unknown) > 0 {
 		log.L.Warnf("Ignoring: %s %s: %+v", objType, shortName, unknown)
 	}
 
+	if obj.Content != "" || obj.Environment != "" {
+		return nil
+	}
 	if obj.File == "" {
-		return fmt.Errorf("%s %q: lacks file path", objType, shortName)
+		return fmt.Errorf("%s %q: lacks file path, content, or environment", objType, shortName)
 	}
 	fullPath := project.RelativePath(obj.File)
 	if _, err := os.Stat(fullPath); err != nil {
@@ -40,11 +40,15 @@ func (c *Composer) upServices(ctx context.Context, parsedServices []*servicepars
 		return errors.New("no service was provided")
 	}
 
-	// TODO: parallelize loop for ensuring images (make sure not to mess up tty)
-	for _, ps := range parsedServices {
-		if err := c.ensureServiceImage(ctx, ps, !uo.NoBuild, uo.ForceBuild, BuildOptions{}, uo.QuietPull, uo.Pull); err != nil {
-			return err
-		}
+	eo := ensureOptions{
+		AllowBuild: !uo.NoBuild,
+		ForceBuild: uo.ForceBuild,
+		Quiet:      uo.QuietPull,
+		PullMode:   uo.Pull,
+		Parallel:   uo.Parallel,
+	}
+	if err := c.ensureServiceImages(ctx, parsedServices, eo); err != nil {
+		return err
 	}
 
 	recreate := uo.recreateStrategy()
@@ -102,28 +106,6 @@ func (c *Composer) upServices(ctx context.Context, parsedServices []*servicepars
 	return nil
 }
 
-func (c *Composer) ensureServiceImage(ctx context.Context, ps *serviceparser.Service, allowBuild, forceBuild bool, bo BuildOptions, quiet bool, pullModeArg string) error {
-	if ps.Build != nil && allowBuild {
-		if ps.Build.Force || forceBuild {
-			return c.buildServiceImage(ctx, ps.Image, ps.Build, ps.Unparsed.Platform, bo)
-		}
-		if ok, err := c.ImageExists(ctx, ps.Image); err != nil {
-			return err
-		} else if !ok {
-			return c.buildServiceImage(ctx, ps.Image, ps.Build, ps.Unparsed.Platform, bo)
-		}
-		// even when c.ImageExists returns true, we need to call c.EnsureImage
-		// because ps.PullMode can be "always". So no return here.
-		log.G(ctx).Debugf("Image %s already exists, not building", ps.Image)
-	}
-
-	log.G(ctx).Infof("Ensuring image %s", ps.Image)
-	if pullModeArg != "" {
-		return c.EnsureImage(ctx, ps.Image, pullModeArg, ps.Unparsed.Platform, ps, quiet)
-	}
-	return c.EnsureImage(ctx, ps.Image, ps.PullMode, ps.Unparsed.Platform, ps, quiet)
-}
-
 // upServiceContainer must be called after ensureServiceImage
 // upServiceContainer returns container ID
 func (c *Composer) upServiceContainer(ctx context.Context, service *serviceparser.Service, container serviceparser.Container, recreate string) (string, error) {
@@ -138,6 +120,21 @@ func (c *Composer) upServiceContainer(ctx context.Context, service *serviceparse
 		return "", fmt.Errorf("currently StdinOpen(-i) and Tty(-t) should be same")
 	}
 
+	configHash, err := c.configHash(ctx, service, container)
+	if err != nil {
+		return "", err
+	}
+	if existingCid != "" && recreate == RecreateDiverged {
+		upToDate, err := c.isUpToDate(ctx, existingCid, configHash)
+		if err != nil {
+			return "", err
+		}
+		if upToDate {
+			log.G(ctx).Debugf("Container %q is up-to-date", container.Name)
+			recreate = RecreateNever
+		}
+	}
+
 	var runFlagD bool
 	if !service.Unparsed.StdinOpen && !service.Unparsed.Tty {
 		container.RunArgs = append([]string{"-d"}, container.RunArgs...)
@@ -179,6 +176,12 @@ func (c *Composer) upServiceContainer(ctx context.Context, service *serviceparse
 	defer os.RemoveAll(tempDir)
 	cidFilename := filepath.Join(tempDir, "cid")
 
+	fileObjectArgs, err := c.materializeFileObjects(ctx, container)
+	if err != nil {
+		return "", err
+	}
+	container.RunArgs = append(fileObjectArgs, container.RunArgs...)
+
 	if c.EnvFile != "" {
 		container.RunArgs = append([]string{"--env-file=" + c.EnvFile}, container.RunArgs...)
 	}
@@ -188,6 +191,7 @@ func (c *Composer) upServiceContainer(ctx context.Context, service *serviceparse
 		"--cidfile=" + cidFilename,
 		fmt.Sprintf("-l=%s=%s", labels.ComposeProject, c.project.Name),
 		fmt.Sprintf("-l=%s=%s", labels.ComposeService, service.Unparsed.Name),
+		fmt.Sprintf("-l=%s=%s", labels.ComposeConfigHash, configHash),
 	}, container.RunArgs...)
 
 	cmd := c.createNerdctlCmd(ctx, append([]string{"run"}, container.RunArgs...)...)
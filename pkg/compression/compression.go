@@ -0,0 +1,85 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package compression provides the compression of image archives.
+package compression
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/errdefs"
+
+	compzstd "github.com/containerd/nerdctl/v2/pkg/compression/zstd"
+)
+
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+
+	// DefaultZstdLevel is the default level of zstd, as in the zstd CLI.
+	DefaultZstdLevel = 3
+)
+
+// Spec is a compression algorithm and its level.
+type Spec struct {
+	Algorithm string
+	Level     int
+}
+
+// ParseSpec parses a string like "zstd", "gzip" or "zstd:19".
+func ParseSpec(s string) (Spec, error) {
+	algo, levelStr, hasLevel := strings.Cut(s, ":")
+	spec := Spec{Algorithm: algo}
+	var minLevel, maxLevel int
+	switch algo {
+	case Gzip:
+		spec.Level = gzip.DefaultCompression
+		minLevel, maxLevel = gzip.BestSpeed, gzip.BestCompression
+	case Zstd:
+		spec.Level = DefaultZstdLevel
+		minLevel, maxLevel = 1, compzstd.GetCompressor().MaxCompressionLevel()
+	default:
+		return Spec{}, fmt.Errorf("unsupported compression %q, should be %q or %q: %w", algo, Gzip, Zstd, errdefs.ErrInvalidArgument)
+	}
+	if hasLevel {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			return Spec{}, fmt.Errorf("invalid compression level %q: %w", levelStr, errdefs.ErrInvalidArgument)
+		}
+		if level < minLevel || level > maxLevel {
+			return Spec{}, fmt.Errorf("%s compression level must be between %d and %d, got %d: %w", algo, minLevel, maxLevel, level, errdefs.ErrInvalidArgument)
+		}
+		spec.Level = level
+	}
+	return spec, nil
+}
+
+// NewWriter returns a writer compressing into w.
+// Closing the writer flushes the compressed stream, but does not close w.
+func NewWriter(w io.Writer, spec Spec) (io.WriteCloser, error) {
+	switch spec.Algorithm {
+	case Gzip:
+		return gzip.NewWriterLevel(w, spec.Level)
+	case Zstd:
+		return compzstd.GetCompressor().NewWriter(w, spec.Level)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q: %w", spec.Algorithm, errdefs.ErrInvalidArgument)
+	}
+}
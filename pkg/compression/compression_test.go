@@ -0,0 +1,73 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+)
+
+func TestParseSpec(t *testing.T) {
+	t.Parallel()
+
+	spec, err := ParseSpec("zstd")
+	assert.NilError(t, err)
+	assert.Equal(t, spec, Spec{Algorithm: Zstd, Level: DefaultZstdLevel})
+
+	spec, err = ParseSpec("gzip:9")
+	assert.NilError(t, err)
+	assert.Equal(t, spec, Spec{Algorithm: Gzip, Level: 9})
+
+	_, err = ParseSpec("gzip:10")
+	assert.ErrorContains(t, err, "must be between 1 and 9")
+
+	_, err = ParseSpec("zstd:fast")
+	assert.ErrorContains(t, err, "invalid compression level")
+
+	_, err = ParseSpec("xz")
+	assert.ErrorContains(t, err, "unsupported compression")
+}
+
+func TestNewWriter(t *testing.T) {
+	t.Parallel()
+
+	payload := bytes.Repeat([]byte("nerdctl"), 1024)
+	for _, s := range []string{"gzip", "zstd:1"} {
+		spec, err := ParseSpec(s)
+		assert.NilError(t, err)
+
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, spec)
+		assert.NilError(t, err)
+		_, err = w.Write(payload)
+		assert.NilError(t, err)
+		assert.NilError(t, w.Close())
+		assert.Assert(t, buf.Len() < len(payload))
+
+		// the compression is detected when loading
+		r, err := compression.DecompressStream(&buf)
+		assert.NilError(t, err)
+		got, err := io.ReadAll(r)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, payload)
+	}
+}
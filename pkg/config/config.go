@@ -26,27 +26,29 @@ import (
 // Config corresponds to nerdctl.toml .
 // See docs/config.md .
 type Config struct {
-	Debug            bool                `toml:"debug"`
-	DebugFull        bool                `toml:"debug_full"`
-	Address          string              `toml:"address"`
-	Namespace        string              `toml:"namespace"`
-	Snapshotter      string              `toml:"snapshotter"`
-	CNIPath          string              `toml:"cni_path"`
-	CNINetConfPath   string              `toml:"cni_netconfpath"`
-	DataRoot         string              `toml:"data_root"`
-	CgroupManager    string              `toml:"cgroup_manager"`
-	InsecureRegistry bool                `toml:"insecure_registry"`
-	HostsDir         []string            `toml:"hosts_dir"`
-	Experimental     bool                `toml:"experimental"`
-	HostGatewayIP    string              `toml:"host_gateway_ip"`
-	BridgeIP         string              `toml:"bridge_ip, omitempty"`
-	KubeHideDupe     bool                `toml:"kube_hide_dupe"`
-	CDISpecDirs      []string            `toml:"cdi_spec_dirs,omitempty"` // CDISpecDirs is a list of directories in which CDI specifications can be found.
-	UsernsRemap      string              `toml:"userns_remap, omitempty"`
-	DNS              []string            `toml:"dns,omitempty"`
-	DNSOpts          []string            `toml:"dns_opts,omitempty"`
-	DNSSearch        []string            `toml:"dns_search,omitempty"`
-	Compression      *CompressionConfig  `toml:"compression,omitempty"`
+	Debug            bool               `toml:"debug"`
+	DebugFull        bool               `toml:"debug_full"`
+	Address          string             `toml:"address"`
+	Namespace        string             `toml:"namespace"`
+	Snapshotter      string             `toml:"snapshotter"`
+	CNIPath          string             `toml:"cni_path"`
+	CNINetConfPath   string             `toml:"cni_netconfpath"`
+	DataRoot         string             `toml:"data_root"`
+	CgroupManager    string             `toml:"cgroup_manager"`
+	InsecureRegistry bool               `toml:"insecure_registry"`
+	HostsDir         []string           `toml:"hosts_dir"`
+	Experimental     bool               `toml:"experimental"`
+	HostGatewayIP    string             `toml:"host_gateway_ip"`
+	BridgeIP         string             `toml:"bridge_ip, omitempty"`
+	KubeHideDupe     bool               `toml:"kube_hide_dupe"`
+	CDISpecDirs      []string           `toml:"cdi_spec_dirs,omitempty"` // CDISpecDirs is a list of directories in which CDI specifications can be found.
+	UsernsRemap      string             `toml:"userns_remap, omitempty"`
+	DNS              []string           `toml:"dns,omitempty"`
+	DNSOpts          []string           `toml:"dns_opts,omitempty"`
+	DNSSearch        []string           `toml:"dns_search,omitempty"`
+	Compression      *CompressionConfig `toml:"compression,omitempty"`
+	ImagePolicy      string             `toml:"image_policy,omitempty"`     // ImagePolicy is the path of the image trust policy file.
+	RegistryMirrors  []string           `toml:"registry_mirrors,omitempty"` // RegistryMirrors are tried before the hosts of hosts_dir, in the form of "[REGISTRY=]URL".
 }
 
 // CompressionConfig contains compression-related settings
@@ -211,6 +211,12 @@ func Start(ctx context.Context, container containerd.Container, isAttach bool, i
 			UpdateErrorLabel(ctx, container, err)
 		}
 	}()
+	started := false
+	defer func() {
+		if isAttach && !started {
+			err = errutil.NewStartError(err)
+		}
+	}()
 	lab, err := container.Labels(ctx)
 	if err != nil {
 		return err
@@ -283,6 +289,7 @@ func Start(ctx context.Context, container containerd.Container, isAttach bool, i
 	if err := task.Start(ctx); err != nil {
 		return err
 	}
+	started = true
 	if !isAttach {
 		return nil
 	}
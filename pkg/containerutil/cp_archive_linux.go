@@ -0,0 +1,535 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package containerutil
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/moby/sys/user"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+)
+
+// overflowID is the id of the files owned by ids which are not mapped in the container (see /proc/sys/kernel/overflowuid)
+const overflowID = 65534
+
+// idMap translates the uids and gids of a container to the host, and back.
+// An empty idMap is the identity.
+type idMap struct {
+	UIDs []specs.LinuxIDMapping `json:"uids,omitempty"`
+	GIDs []specs.LinuxIDMapping `json:"gids,omitempty"`
+}
+
+func newIDMap(spec *specs.Spec) *idMap {
+	if spec.Linux == nil {
+		return &idMap{}
+	}
+	return &idMap{UIDs: spec.Linux.UIDMappings, GIDs: spec.Linux.GIDMappings}
+}
+
+// toHost translates the uid and the gid of the container to the host.
+func (m *idMap) toHost(uid, gid int) (int, int, error) {
+	if m == nil {
+		return uid, gid, nil
+	}
+	hostUID, ok := translateID(m.UIDs, uid, true)
+	if !ok {
+		return 0, 0, fmt.Errorf("uid %d is not mapped in the container", uid)
+	}
+	hostGID, ok := translateID(m.GIDs, gid, true)
+	if !ok {
+		return 0, 0, fmt.Errorf("gid %d is not mapped in the container", gid)
+	}
+	return hostUID, hostGID, nil
+}
+
+// toContainer translates the uid and the gid of the host to the container, as the overflow id when they are not mapped.
+func (m *idMap) toContainer(uid, gid int) (int, int) {
+	if m == nil {
+		return uid, gid
+	}
+	containerUID, ok := translateID(m.UIDs, uid, false)
+	if !ok {
+		containerUID = overflowID
+	}
+	containerGID, ok := translateID(m.GIDs, gid, false)
+	if !ok {
+		containerGID = overflowID
+	}
+	return containerUID, containerGID
+}
+
+func translateID(mappings []specs.LinuxIDMapping, id int, toHost bool) (int, bool) {
+	if len(mappings) == 0 {
+		return id, true
+	}
+	for _, m := range mappings {
+		from, to := int64(m.HostID), int64(m.ContainerID)
+		if toHost {
+			from, to = to, from
+		}
+		if int64(id) >= from && int64(id) < from+int64(m.Size) {
+			return int(to + int64(id) - from), true
+		}
+	}
+	return 0, false
+}
+
+// owner is a uid and a gid.
+type owner struct {
+	UID int `json:"uid"`
+	GID int `json:"gid"`
+}
+
+// parseChown parses the owner of `nerdctl cp --chown`, in the form of USER[:GROUP], where USER and GROUP are names or
+// numeric ids. The names are looked up in the passwd and group files. The gid is the uid when GROUP is omitted.
+func parseChown(s, passwdPath, groupPath string) (*owner, error) {
+	userName, groupName, hasGroup := strings.Cut(s, ":")
+	if userName == "" || (hasGroup && groupName == "") {
+		return nil, fmt.Errorf("invalid owner %q: must be USER[:GROUP]", s)
+	}
+	uid, err := strconv.Atoi(userName)
+	if err != nil {
+		users, err := user.ParsePasswdFileFilter(passwdPath, func(u user.User) bool { return u.Name == userName })
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("unable to find user %q: no matching entries in passwd file", userName)
+		}
+		uid = users[0].Uid
+	}
+	if !hasGroup {
+		return &owner{UID: uid, GID: uid}, nil
+	}
+	gid, err := strconv.Atoi(groupName)
+	if err != nil {
+		groups, err := user.ParseGroupFileFilter(groupPath, func(g user.Group) bool { return g.Name == groupName })
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("unable to find group %q: no matching entries in group file", groupName)
+		}
+		gid = groups[0].Gid
+	}
+	return &owner{UID: uid, GID: gid}, nil
+}
+
+// fileError returns the error of copying the entry name of an archive.
+// The path of a *fs.PathError, which is a path on the host, is replaced with name.
+func fileError(op, name string, err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// archiveOptions are the options to write the tar archive of a file or a directory.
+type archiveOptions struct {
+	// Path is the path of the file or the directory on the host
+	Path string `json:"path"`
+	// Name is the name of Path in the archive, or "." to archive the content of the directory Path
+	Name string `json:"name"`
+	// FollowSymLink archives the targets of the symbolic links, instead of the links
+	FollowSymLink bool `json:"followSymLink,omitempty"`
+	// IDMap translates the owners of the files (on the host) to the owners in the archive (in the container)
+	IDMap *idMap `json:"idMap,omitempty"`
+}
+
+type inode struct {
+	dev, ino uint64
+}
+
+type archiver struct {
+	tw    *tar.Writer
+	opts  archiveOptions
+	links map[inode]string
+	// the directories being archived, to detect the loops of symbolic links
+	dirs []inode
+	errs []error
+}
+
+// writeArchive writes the tar archive of opts.Path to w.
+// The files which cannot be archived are skipped, and their errors are returned once the archive is written.
+func writeArchive(w io.Writer, opts archiveOptions) error {
+	a := &archiver{
+		tw:    tar.NewWriter(w),
+		opts:  opts,
+		links: make(map[inode]string),
+	}
+	if err := a.add(opts.Path, opts.Name); err != nil {
+		return err
+	}
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return errors.Join(a.errs...)
+}
+
+// add archives the file p as name. It only returns the errors of writing the archive.
+func (a *archiver) add(p, name string) error {
+	stat := os.Lstat
+	if a.opts.FollowSymLink {
+		stat = os.Stat
+	}
+	fi, err := stat(p)
+	if err != nil {
+		a.errs = append(a.errs, fileError("archive", name, err))
+		return nil
+	}
+	if fi.Mode()&fs.ModeSocket != 0 {
+		// sockets cannot be archived, and are skipped like `docker cp` does
+		return nil
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unexpected file info of %q", p)
+	}
+	var link string
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			a.errs = append(a.errs, fileError("archive", name, err))
+			return nil
+		}
+	}
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		a.errs = append(a.errs, fileError("archive", name, err))
+		return nil
+	}
+	hdr.Name = name
+	hdr.Uid, hdr.Gid = a.opts.IDMap.toContainer(int(st.Uid), int(st.Gid))
+	hdr.Uname, hdr.Gname = "", ""
+	hdr.Format = tar.FormatPAX
+	id := inode{dev: st.Dev, ino: st.Ino}
+
+	switch {
+	case fi.IsDir():
+		if slices.Contains(a.dirs, id) {
+			a.errs = append(a.errs, fileError("archive", name, errors.New("loop of symbolic links")))
+			return nil
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			a.errs = append(a.errs, fileError("archive", name, err))
+			return nil
+		}
+		if name != "." {
+			hdr.Name += "/"
+			if err := a.tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+		}
+		a.dirs = append(a.dirs, id)
+		defer func() { a.dirs = a.dirs[:len(a.dirs)-1] }()
+		for _, e := range entries {
+			if err := a.add(filepath.Join(p, e.Name()), path.Join(name, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	case fi.Mode().IsRegular():
+		if st.Nlink > 1 {
+			if target, ok := a.links[id]; ok {
+				hdr.Typeflag = tar.TypeLink
+				hdr.Linkname = target
+				hdr.Size = 0
+				return a.tw.WriteHeader(hdr)
+			}
+			a.links[id] = name
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			a.errs = append(a.errs, fileError("archive", name, err))
+			return nil
+		}
+		defer f.Close()
+		if err := a.tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		// the file may be modified while it is archived: its size in the archive is the size in the header
+		n, err := io.Copy(a.tw, io.LimitReader(f, hdr.Size))
+		var pathErr *fs.PathError
+		if err != nil && !errors.As(err, &pathErr) {
+			// failed to write the archive, rather than to read the file
+			return err
+		}
+		if n < hdr.Size {
+			if err == nil {
+				err = errors.New("file was truncated while it was archived")
+			}
+			a.errs = append(a.errs, fileError("archive", name, err))
+			// pad the entry to keep the archive valid
+			if _, err := io.CopyN(a.tw, zeroReader{}, hdr.Size-n); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return a.tw.WriteHeader(hdr)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+// containerRoot is the root filesystem of a container on the host, with the mounts of the container.
+type containerRoot struct {
+	Root     *specs.Root   `json:"root"`
+	Mounts   []specs.Mount `json:"mounts,omitempty"`
+	HostRoot string        `json:"hostRoot"`
+}
+
+// extractOptions are the options to extract a tar archive.
+type extractOptions struct {
+	// Dir is the directory where the archive is extracted: a path on the host, or a path in the container when
+	// Container is set
+	Dir string `json:"dir"`
+	// Container resolves the paths in the container, following the symbolic links in the scope of its root filesystem
+	Container *containerRoot `json:"container,omitempty"`
+	// SameOwner sets the owners of the files to the owners in the archive
+	SameOwner bool `json:"sameOwner,omitempty"`
+	// Owner sets the owner of the files, instead of the owners in the archive
+	Owner *owner `json:"owner,omitempty"`
+	// IDMap translates the owners (in the container) to the host
+	IDMap *idMap `json:"idMap,omitempty"`
+}
+
+type extractor struct {
+	opts extractOptions
+	res  *resolver
+	// the directories whose times are set once their content is extracted
+	dirs []*tar.Header
+	errs []error
+}
+
+// extractArchive extracts the tar archive read from r into opts.Dir.
+// The entries which cannot be extracted are skipped, and their errors are returned once the archive is read.
+func extractArchive(r io.Reader, opts extractOptions) error {
+	e := &extractor{opts: opts}
+	if c := opts.Container; c != nil {
+		e.res = &resolver{root: c.Root, mounts: c.Mounts, hostRoot: c.HostRoot}
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Join(append(e.errs, err)...)
+		}
+		if err := e.extract(hdr, tr); err != nil {
+			e.errs = append(e.errs, fileError("extract", hdr.Name, err))
+		}
+	}
+	for i := len(e.dirs) - 1; i >= 0; i-- {
+		hdr := e.dirs[i]
+		p, err := e.hostPath(hdr.Name)
+		if err == nil {
+			err = os.Chmod(p, fileMode(hdr))
+		}
+		if err == nil {
+			err = setTimes(p, hdr)
+		}
+		if err != nil {
+			e.errs = append(e.errs, fileError("extract", hdr.Name, err))
+		}
+	}
+	return errors.Join(e.errs...)
+}
+
+// hostPath returns the path on the host of the entry name, resolving the symbolic links of its parent directories
+// in the scope of the destination (the root filesystem of the container, or the directory on the host).
+func (e *extractor) hostPath(name string) (string, error) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." {
+		name = ""
+	}
+	if !filepath.IsLocal(name) && name != "" {
+		return "", errors.New("path escapes from the destination")
+	}
+	dir, base := path.Split(name)
+	if e.res == nil {
+		parent, err := securejoin.SecureJoin(e.opts.Dir, dir)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(parent, base), nil
+	}
+	parent, err := e.res.resolvePath(path.Join(e.opts.Dir, dir))
+	if err != nil {
+		return "", err
+	}
+	containerPath := path.Join(parent, base)
+	if mnt, _ := e.res.getMount(containerPath); mnt.readonly {
+		return "", syscall.EROFS
+	}
+	return e.res.pathOnHost(containerPath), nil
+}
+
+func (e *extractor) extract(hdr *tar.Header, r io.Reader) error {
+	if path.Clean(strings.TrimPrefix(hdr.Name, "/")) == "." {
+		if hdr.Typeflag != tar.TypeDir {
+			return errors.New("invalid name of a non-directory")
+		}
+		// do not change the destination directory itself
+		return nil
+	}
+	p, err := e.hostPath(hdr.Name)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Lstat(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	exists := err == nil
+	if exists && hdr.Typeflag != tar.TypeDir {
+		if fi.IsDir() {
+			return errors.New("cannot overwrite a directory with a non-directory")
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if exists && !fi.IsDir() {
+			return errors.New("cannot overwrite a non-directory with a directory")
+		}
+		if !exists {
+			if err := os.Mkdir(p, 0o700); err != nil {
+				return err
+			}
+		}
+		// the mode and the times of the directory are set once its content is extracted,
+		// as the directory may not be writable
+		e.dirs = append(e.dirs, hdr)
+		return e.chown(p, hdr)
+	case tar.TypeReg, tar.TypeRegA: //nolint:staticcheck // TypeRegA is still written by old archivers
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, r)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.Symlink(hdr.Linkname, p); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		target, err := e.hostPath(hdr.Linkname)
+		if err != nil {
+			return fmt.Errorf("invalid link target %q: %w", hdr.Linkname, err)
+		}
+		if err := os.Link(target, p); err != nil {
+			return err
+		}
+		return nil
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		devMode := uint32(unix.S_IFIFO)
+		switch hdr.Typeflag {
+		case tar.TypeChar:
+			devMode = unix.S_IFCHR
+		case tar.TypeBlock:
+			devMode = unix.S_IFBLK
+		}
+		if err := unix.Mknod(p, devMode|0o600, int(unix.Mkdev(uint32(hdr.Devmajor), uint32(hdr.Devminor)))); err != nil {
+			return &fs.PathError{Op: "mknod", Path: p, Err: err}
+		}
+	default:
+		return fmt.Errorf("unsupported type %q", hdr.Typeflag)
+	}
+
+	if err := e.chown(p, hdr); err != nil {
+		return err
+	}
+	if hdr.Typeflag == tar.TypeSymlink {
+		return setTimes(p, hdr)
+	}
+	// chmod after chown, which clears the setuid and setgid bits
+	if err := os.Chmod(p, fileMode(hdr)); err != nil {
+		return err
+	}
+	return setTimes(p, hdr)
+}
+
+func fileMode(hdr *tar.Header) fs.FileMode {
+	return hdr.FileInfo().Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
+
+func (e *extractor) chown(p string, hdr *tar.Header) error {
+	var uid, gid int
+	switch {
+	case e.opts.Owner != nil:
+		uid, gid = e.opts.Owner.UID, e.opts.Owner.GID
+	case e.opts.SameOwner:
+		uid, gid = hdr.Uid, hdr.Gid
+	default:
+		return nil
+	}
+	uid, gid, err := e.opts.IDMap.toHost(uid, gid)
+	if err != nil {
+		return err
+	}
+	return os.Lchown(p, uid, gid)
+}
+
+func setTimes(p string, hdr *tar.Header) error {
+	atime := hdr.AccessTime
+	if atime.IsZero() {
+		atime = hdr.ModTime
+	}
+	ts := []unix.Timespec{timespec(atime), timespec(hdr.ModTime)}
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, p, ts, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &fs.PathError{Op: "utimes", Path: p, Err: err}
+	}
+	return nil
+}
+
+func timespec(t time.Time) unix.Timespec {
+	if t.IsZero() {
+		return unix.Timespec{Nsec: unix.UTIME_OMIT}
+	}
+	return unix.NsecToTimespec(t.UnixNano())
+}
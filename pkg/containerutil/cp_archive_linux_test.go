@@ -0,0 +1,169 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package containerutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(src, "dir", "sub"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "dir", "file"), []byte("content"), 0o640))
+	assert.NilError(t, os.Link(filepath.Join(src, "dir", "file"), filepath.Join(src, "dir", "sub", "hardlink")))
+	assert.NilError(t, os.Symlink("../file", filepath.Join(src, "dir", "sub", "symlink")))
+	assert.NilError(t, os.Chmod(filepath.Join(src, "dir", "sub"), 0o555))
+	t.Cleanup(func() { os.Chmod(filepath.Join(src, "dir", "sub"), 0o755) })
+
+	var buf bytes.Buffer
+	assert.NilError(t, writeArchive(&buf, archiveOptions{Path: filepath.Join(src, "dir"), Name: "renamed"}))
+
+	dst := t.TempDir()
+	assert.NilError(t, extractArchive(&buf, extractOptions{Dir: dst}))
+	t.Cleanup(func() { os.Chmod(filepath.Join(dst, "renamed", "sub"), 0o755) })
+
+	b, err := os.ReadFile(filepath.Join(dst, "renamed", "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "content")
+	fi, err := os.Stat(filepath.Join(dst, "renamed", "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Perm(), fs.FileMode(0o640))
+	link, err := os.Readlink(filepath.Join(dst, "renamed", "sub", "symlink"))
+	assert.NilError(t, err)
+	assert.Equal(t, link, "../file")
+	hardlink, err := os.Stat(filepath.Join(dst, "renamed", "sub", "hardlink"))
+	assert.NilError(t, err)
+	assert.Assert(t, os.SameFile(fi, hardlink))
+	fi, err = os.Stat(filepath.Join(dst, "renamed", "sub"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Perm(), fs.FileMode(0o555))
+}
+
+func TestExtractArchiveErrors(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"../escape", "ok", "dir/file"} {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Typeflag: tar.TypeReg}))
+	}
+	assert.NilError(t, tw.Close())
+
+	dst := t.TempDir()
+	// "dir" is a file: the entries in it cannot be extracted
+	assert.NilError(t, os.WriteFile(filepath.Join(dst, "dir"), nil, 0o644))
+	err := extractArchive(&buf, extractOptions{Dir: dst})
+	assert.ErrorContains(t, err, "extract ../escape: path escapes from the destination")
+	assert.ErrorContains(t, err, "extract dir/file: ")
+	_, err = os.Stat(filepath.Join(dst, "ok"))
+	assert.NilError(t, err)
+}
+
+func TestExtractArchiveInContainer(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	volume := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "usr", "lib"), 0o755))
+	// an absolute symbolic link, which must be resolved in the root filesystem of the container
+	assert.NilError(t, os.Symlink("/usr/lib", filepath.Join(root, "lib")))
+	c := &containerRoot{
+		Root:     &specs.Root{},
+		Mounts:   []specs.Mount{{Destination: "/data", Source: volume}, {Destination: "/ro", Source: t.TempDir(), Options: []string{"ro"}}},
+		HostRoot: root,
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"lib/file", "data/file", "ro/file"} {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Typeflag: tar.TypeReg}))
+	}
+	assert.NilError(t, tw.Close())
+
+	err := extractArchive(&buf, extractOptions{Dir: "/", Container: c})
+	assert.Assert(t, err != nil)
+	assert.ErrorIs(t, err, syscall.EROFS)
+	_, err = os.Stat(filepath.Join(root, "usr", "lib", "file"))
+	assert.NilError(t, err)
+	_, err = os.Stat(filepath.Join(volume, "file"))
+	assert.NilError(t, err)
+}
+
+func TestIDMap(t *testing.T) {
+	t.Parallel()
+	m := newIDMap(&specs.Spec{Linux: &specs.Linux{
+		UIDMappings: []specs.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GIDMappings: []specs.LinuxIDMapping{{ContainerID: 0, HostID: 200000, Size: 1000}},
+	}})
+	uid, gid, err := m.toHost(1000, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uid, 101000)
+	assert.Equal(t, gid, 200010)
+	_, _, err = m.toHost(0, 1000)
+	assert.ErrorContains(t, err, "gid 1000 is not mapped")
+
+	uid, gid = m.toContainer(101000, 0)
+	assert.Equal(t, uid, 1000)
+	assert.Equal(t, gid, overflowID)
+
+	var identity *idMap
+	uid, gid = identity.toContainer(5, 6)
+	assert.Equal(t, uid, 5)
+	assert.Equal(t, gid, 6)
+}
+
+func TestParseChown(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	passwd, group := filepath.Join(dir, "passwd"), filepath.Join(dir, "group")
+	assert.NilError(t, os.WriteFile(passwd, []byte("root:x:0:0:root:/root:/bin/sh\napp:x:1000:1001::/home/app:/bin/sh\n"), 0o644))
+	assert.NilError(t, os.WriteFile(group, []byte("root:x:0:\nstaff:x:50:\n"), 0o644))
+
+	for _, tc := range []struct {
+		spec     string
+		expected owner
+		err      string
+	}{
+		{spec: "app", expected: owner{UID: 1000, GID: 1000}},
+		{spec: "app:staff", expected: owner{UID: 1000, GID: 50}},
+		{spec: "42:43", expected: owner{UID: 42, GID: 43}},
+		{spec: "42", expected: owner{UID: 42, GID: 42}},
+		{spec: "nobody", err: `unable to find user "nobody"`},
+		{spec: "app:", err: "must be USER[:GROUP]"},
+	} {
+		o, err := parseChown(tc.spec, passwd, group)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.spec)
+			continue
+		}
+		assert.NilError(t, err, tc.spec)
+		assert.Equal(t, *o, tc.expected, tc.spec)
+	}
+
+	// the names cannot be looked up without passwd
+	o, err := parseChown("7", filepath.Join(dir, "none"), group)
+	assert.NilError(t, err)
+	assert.Equal(t, *o, owner{UID: 7, GID: 7})
+}
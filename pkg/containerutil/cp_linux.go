@@ -19,23 +19,28 @@ package containerutil
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/containerd/v2/pkg/oci"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
-	"github.com/containerd/nerdctl/v2/pkg/tarutil"
 )
 
 // See https://docs.docker.com/engine/reference/commandline/cp/ for the specification.
@@ -81,19 +86,11 @@ func getRoot(ctx context.Context, container containerd.Container) (string, int,
 // CopyFiles implements `nerdctl cp`
 // It currently depends on the following assumptions:
 // - linux only
-// - tar binary exists on the system
-// - nsenter binary exists on the system
-// - if rootless, the container is running (aka: /proc/pid/root)
+// - if rootless, the container is running (aka: /proc/pid/root), and the nsenter binary exists on the system
+//
+// The files are copied with archive/tar. In rootless mode, the files of the container are archived or extracted by
+// `nerdctl internal cp` in the user namespace of the container (see RunCopyHelper), to preserve the uid/gid mapping.
 func CopyFiles(ctx context.Context, client *containerd.Client, container containerd.Container, options types.ContainerCpOptions) (err error) {
-	// We do rely on the tar binary as a shortcut - could also be replaced by archive/tar, though that would mean
-	// we need to replace nsenter calls with re-exec
-	tarBinary, isGNUTar, err := tarutil.FindTarBinary()
-	if err != nil {
-		return err
-	}
-
-	log.G(ctx).Debugf("Detected tar binary %q (GNU=%v)", tarBinary, isGNUTar)
-
 	// This can happen if the container being passed has been deleted since in a racy way
 	conSpec, err := container.Spec(ctx)
 	if err != nil {
@@ -143,12 +140,27 @@ func CopyFiles(ctx context.Context, client *containerd.Client, container contain
 		log.G(ctx).Debugf("Got new root %s", root)
 	}
 
+	c := &copier{
+		root:  &containerRoot{Root: conSpec.Root, Mounts: conSpec.Mounts, HostRoot: root},
+		idMap: newIDMap(conSpec),
+	}
+	if rootlessutil.IsRootless() {
+		// the helper runs in the user namespace of the container, where the ids need no translation
+		c.helperPid = pid
+		c.idMap = nil
+	}
+
 	var sourceSpec, destinationSpec *pathSpecifier
 	var sourceErr, destErr error
-	if options.Container2Host {
+	switch {
+	case options.SrcPath == "-":
+		destinationSpec, destErr = getPathSpecFromContainer(options.DestPath, conSpec, root)
+	case options.DestPath == "-":
+		sourceSpec, sourceErr = getPathSpecFromContainer(options.SrcPath, conSpec, root)
+	case options.Container2Host:
 		sourceSpec, sourceErr = getPathSpecFromContainer(options.SrcPath, conSpec, root)
 		destinationSpec, destErr = getPathSpecFromHost(options.DestPath)
-	} else {
+	default:
 		sourceSpec, sourceErr = getPathSpecFromHost(options.SrcPath)
 		destinationSpec, destErr = getPathSpecFromContainer(options.DestPath, conSpec, root)
 	}
@@ -173,6 +185,58 @@ func CopyFiles(ctx context.Context, client *containerd.Client, container contain
 		return errors.Join(ErrFilesystem, sourceErr)
 	}
 
+	var chown *owner
+	if options.Chown != "" {
+		passwdPath, groupPath := "/etc/passwd", "/etc/group"
+		if !options.Container2Host {
+			passwdPath, groupPath = c.hostPath(conSpec, "/etc/passwd"), c.hostPath(conSpec, "/etc/group")
+		}
+		if chown, err = parseChown(options.Chown, passwdPath, groupPath); err != nil {
+			return err
+		}
+	} else if !options.Container2Host && !options.Archive {
+		// the files copied into the container are owned by the root of the container, like `docker cp`
+		chown = &owner{}
+	}
+
+	// Cp from a tar archive on stdin, or to a tar archive on stdout
+	if options.SrcPath == "-" {
+		// The destination must be an existing directory
+		if !destinationSpec.exists {
+			return ErrDestinationDirMustExist
+		}
+		if !destinationSpec.isADir {
+			return ErrDestinationIsNotADir
+		}
+		if destinationSpec.readOnly {
+			return ErrTargetIsReadOnly
+		}
+		stdin, err := compression.DecompressStream(options.Stdin)
+		if err != nil {
+			return err
+		}
+		defer stdin.Close()
+		return c.extractInContainer(ctx, stdin, extractOptions{
+			Dir:       destinationSpec.resolvedContainerPath,
+			SameOwner: options.Archive,
+			Owner:     chown,
+		})
+	}
+	if options.DestPath == "-" {
+		if !sourceSpec.exists {
+			return ErrSourceDoesNotExist
+		}
+		name := path.Base(sourceSpec.resolvedContainerPath)
+		if (sourceSpec.isADir && sourceSpec.endsWithSeparatorDot) || name == "/" {
+			name = "."
+		}
+		return c.archiveInContainer(ctx, options.Stdout, archiveOptions{
+			Path:          sourceSpec.resolvedPath,
+			Name:          name,
+			FollowSymLink: options.FollowSymLink,
+		})
+	}
+
 	// Now, resolve cp shenanigans
 	// First, cannot copy a non-existent resource
 	if !sourceSpec.exists {
@@ -194,131 +258,157 @@ func CopyFiles(ctx context.Context, client *containerd.Client, container contain
 		return ErrDestinationDirMustExist
 	}
 
-	// XXX FIXME: this seems wrong. What about ownership? We could be doing that inside a container
-	if !destinationSpec.exists {
-		if err = os.Mkdir(destinationSpec.resolvedPath, 0o755); err != nil {
-			return errors.Join(ErrFilesystem, err)
-		}
+	// The name of the source in the archive, and the directory where the archive is extracted
+	destinationPath := destinationSpec.resolvedPath
+	if !options.Container2Host {
+		destinationPath = destinationSpec.resolvedContainerPath
 	}
-
-	var tarCDir, tarCArg string
-	if sourceSpec.isADir {
-		if !destinationSpec.exists || sourceSpec.endsWithSeparatorDot {
-			// the content of the source directory is copied into this directory
-			tarCDir = sourceSpec.resolvedPath
-			tarCArg = "."
-		} else {
-			// the source directory is copied into this directory
-			tarCDir = filepath.Dir(sourceSpec.resolvedPath)
-			tarCArg = filepath.Base(sourceSpec.resolvedPath)
-		}
-	} else {
-		// Prepare a single-file directory to create an archive of the source file
-		td, err := os.MkdirTemp("", "nerdctl-cp")
-		if err != nil {
-			return err
-		}
-		defer os.RemoveAll(td)
-		tarCDir = td
-		cp := []string{"cp", "-a"}
-		if options.FollowSymLink {
-			cp = append(cp, "-L")
-		}
-		if destinationSpec.endsWithSeparator || (destinationSpec.exists && destinationSpec.isADir) {
-			tarCArg = filepath.Base(sourceSpec.resolvedPath)
-		} else {
-			// Handle `nerdctl cp /path/to/file some-container:/path/to/file-with-another-name`
-			tarCArg = filepath.Base(destinationSpec.resolvedPath)
-		}
-		cp = append(cp, sourceSpec.resolvedPath, filepath.Join(td, tarCArg))
-		cpCmd := exec.CommandContext(ctx, cp[0], cp[1:]...)
-		log.G(ctx).Debugf("executing %v", cpCmd.Args)
-		if out, err := cpCmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to execute %v: %w (out=%q)", cpCmd.Args, err, string(out))
-		}
+	sourceName := filepath.Base(sourceSpec.resolvedPath)
+	if options.Container2Host {
+		sourceName = path.Base(sourceSpec.resolvedContainerPath)
 	}
-	tarC := []string{tarBinary}
-	if options.FollowSymLink {
-		tarC = append(tarC, "-h")
+	var name, dir string
+	switch {
+	case sourceSpec.isADir && destinationSpec.exists && (sourceSpec.endsWithSeparatorDot || sourceName == "/"):
+		// the content of the source directory is copied into the destination directory
+		name, dir = ".", destinationPath
+	case sourceSpec.isADir && destinationSpec.exists,
+		!sourceSpec.isADir && (destinationSpec.endsWithSeparator || (destinationSpec.exists && destinationSpec.isADir)):
+		// the source is copied into the destination directory
+		name, dir = sourceName, destinationPath
+	default:
+		// the source is copied as the destination
+		// Handle `nerdctl cp /path/to/file some-container:/path/to/file-with-another-name`
+		name, dir = filepath.Base(destinationPath), filepath.Dir(destinationPath)
 	}
-	tarC = append(tarC, "-c", "-f", "-", tarCArg)
 
-	tarXDir := destinationSpec.resolvedPath
-	if !sourceSpec.isADir && !destinationSpec.endsWithSeparator && !(destinationSpec.exists && destinationSpec.isADir) {
-		tarXDir = filepath.Dir(destinationSpec.resolvedPath)
+	archive := archiveOptions{
+		Path:          sourceSpec.resolvedPath,
+		Name:          name,
+		FollowSymLink: options.FollowSymLink,
 	}
-	tarX := []string{tarBinary, "-x"}
-	if options.Container2Host && isGNUTar {
-		tarX = append(tarX, "--no-same-owner")
+	extract := extractOptions{
+		Dir:       dir,
+		SameOwner: options.Archive,
+		Owner:     chown,
 	}
-	tarX = append(tarX, "-f", "-")
-
-	if rootlessutil.IsRootless() {
-		nsenter := []string{"nsenter", "-t", strconv.Itoa(pid), "-U", "--preserve-credentials", "--"}
+	pr, pw := io.Pipe()
+	archiveErr := make(chan error, 1)
+	go func() {
+		var err error
 		if options.Container2Host {
-			tarC = append(nsenter, tarC...)
+			err = c.archiveInContainer(ctx, pw, archive)
 		} else {
-			tarX = append(nsenter, tarX...)
+			err = writeArchive(pw, archive)
 		}
+		// the archive is complete even when some files could not be archived
+		pw.Close()
+		archiveErr <- err
+	}()
+	if options.Container2Host {
+		err = extractArchive(pr, extract)
+	} else {
+		err = c.extractInContainer(ctx, pr, extract)
 	}
+	// unblock the archive when the extraction failed
+	pr.CloseWithError(errors.New("failed to extract the archive"))
+	return errors.Join(<-archiveErr, err)
+}
 
-	// FIXME: moving to archive/tar should allow better error management than this
-	// WARNING: some of our testing on stderr might not be portable across different versions of tar
-	// In these cases (readonly target), we will just get the straight tar output instead
-	tarCCmd := exec.CommandContext(ctx, tarC[0], tarC[1:]...)
-	tarCCmd.Dir = tarCDir
-	tarCCmd.Stdin = nil
-	tarCCmd.Stderr = os.Stderr
+// copier copies the files of a container.
+type copier struct {
+	root  *containerRoot
+	idMap *idMap
+	// helperPid is the pid of the container, whose user namespace the files of the container are copied in by
+	// `nerdctl internal cp` (rootless only)
+	helperPid int
+}
 
-	tarXCmd := exec.CommandContext(ctx, tarX[0], tarX[1:]...)
-	tarXCmd.Dir = tarXDir
-	tarXCmd.Stdin, err = tarCCmd.StdoutPipe()
+// hostPath returns the path on the host of the path p in the container, or p when it cannot be resolved.
+func (c *copier) hostPath(conSpec *oci.Spec, p string) string {
+	spec, err := getPathSpecFromContainer(p, conSpec, c.root.HostRoot)
 	if err != nil {
-		return err
+		return p
 	}
-	tarXCmd.Stdout = os.Stderr
-	var tarErr bytes.Buffer
-	tarXCmd.Stderr = &tarErr
+	return spec.resolvedPath
+}
 
-	log.G(ctx).Debugf("executing %v in %q", tarCCmd.Args, tarCCmd.Dir)
-	if err := tarCCmd.Start(); err != nil {
-		return errors.Join(fmt.Errorf("failed to execute %v", tarCCmd.Args), err)
+// archiveInContainer writes the tar archive of the files of the container.
+func (c *copier) archiveInContainer(ctx context.Context, w io.Writer, opts archiveOptions) error {
+	if c.helperPid != 0 {
+		return runCopyHelper(ctx, c.helperPid, nil, w, copyHelperArchive, opts)
 	}
+	opts.IDMap = c.idMap
+	return writeArchive(w, opts)
+}
 
-	log.G(ctx).Debugf("executing %v in %q", tarXCmd.Args, tarXCmd.Dir)
-	if err := tarXCmd.Start(); err != nil {
-		if strings.Contains(err.Error(), "permission denied") {
-			return ErrTargetIsReadOnly
-		}
+// extractInContainer extracts the tar archive into the directory opts.Dir of the container.
+func (c *copier) extractInContainer(ctx context.Context, r io.Reader, opts extractOptions) error {
+	opts.Container = c.root
+	var err error
+	if c.helperPid != 0 {
+		err = runCopyHelper(ctx, c.helperPid, r, nil, copyHelperExtract, opts)
+	} else {
+		opts.IDMap = c.idMap
+		err = extractArchive(r, opts)
+	}
+	if errors.Is(err, syscall.EROFS) || (err != nil && strings.Contains(err.Error(), syscall.EROFS.Error())) {
+		return errors.Join(ErrTargetIsReadOnly, err)
+	}
+	return err
+}
 
-		// Other errors, just put them back on stderr
-		_, fpErr := fmt.Fprint(os.Stderr, tarErr.String())
-		if fpErr != nil {
-			return errors.Join(fpErr, err)
-		}
+// the operations of `nerdctl internal cp`
+const (
+	copyHelperArchive = "archive"
+	copyHelperExtract = "extract"
+)
 
-		return errors.Join(fmt.Errorf("failed to execute %v", tarXCmd.Args), err)
+// runCopyHelper runs `nerdctl internal cp OPERATION OPTIONS` in the user namespace of the process pid.
+func runCopyHelper(ctx context.Context, pid int, stdin io.Reader, stdout io.Writer, op string, opts any) error {
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return err
 	}
-
-	if err := tarCCmd.Wait(); err != nil {
-		return fmt.Errorf("failed to wait %v: %w", tarCCmd.Args, err)
+	selfExe, err := os.Executable()
+	if err != nil {
+		return err
 	}
-
-	if err := tarXCmd.Wait(); err != nil {
-		if strings.Contains(tarErr.String(), "Read-only file system") {
-			return ErrTargetIsReadOnly
+	cmd := exec.CommandContext(ctx, "nsenter", "-t", strconv.Itoa(pid), "-U", "--preserve-credentials", "--", selfExe, "internal", "cp", op, string(b))
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	log.G(ctx).Debugf("executing %v", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
 		}
+		return fmt.Errorf("failed to execute %v: %w", cmd.Args, err)
+	}
+	return nil
+}
 
-		// Other errors, just put them back on stderr
-		_, fpErr := fmt.Fprint(os.Stderr, tarErr.String())
-		if fpErr != nil {
-			return errors.Join(fpErr, err)
+// RunCopyHelper implements `nerdctl internal cp (archive|extract) OPTIONS`, which archives the files of a container
+// to stdout, or extracts the archive read from stdin into the container.
+// It is executed by CopyFiles in the user namespace of the container, in rootless mode.
+func RunCopyHelper(stdin io.Reader, stdout io.Writer, op, opts string) error {
+	switch op {
+	case copyHelperArchive:
+		var o archiveOptions
+		if err := json.Unmarshal([]byte(opts), &o); err != nil {
+			return err
 		}
-
-		return errors.Join(fmt.Errorf("failed to wait %v", tarXCmd.Args), err)
+		return writeArchive(stdout, o)
+	case copyHelperExtract:
+		var o extractOptions
+		if err := json.Unmarshal([]byte(opts), &o); err != nil {
+			return err
+		}
+		return extractArchive(stdin, o)
+	default:
+		return fmt.Errorf("unknown operation %q", op)
 	}
-
-	return nil
 }
 
 func mountSnapshotForContainer(ctx context.Context, client *containerd.Client, conInfo containers.Container, snapshotter string) (string, func() error, error) {
@@ -55,6 +55,8 @@ type pathSpecifier struct {
 	isADir               bool
 	readOnly             bool
 	resolvedPath         string
+	// resolvedContainerPath is the fully resolved path in the container (only for container locations)
+	resolvedContainerPath string
 }
 
 // getPathSpecFromHost builds a pathSpecifier from a host location
@@ -196,6 +198,8 @@ func getPathSpecFromContainer(originalPath string, conSpec *oci.Spec, containerH
 		resolvedContainerPath = filepath.Join(resolvedContainerPath, base)
 	}
 
+	pathSpec.resolvedContainerPath = resolvedContainerPath
+
 	// Now, finally get the location of the fully resolved containerPath (in the root? in a volume?)
 	containerMount, relativePath := pathResolver.getMount(resolvedContainerPath)
 	pathSpec.resolvedPath = filepath.Join(containerMount.hostPath, relativePath)
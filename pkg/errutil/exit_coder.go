@@ -17,7 +17,22 @@
 package errutil
 
 import (
+	"errors"
 	"os"
+	"strings"
+
+	"github.com/containerd/log"
+)
+
+// Docker-compatible exit codes for the failures that happen before the process of a container starts,
+// in `nerdctl run`, `nerdctl start --attach` and `nerdctl exec`.
+const (
+	// ExitCodeStartFailed is used when nerdctl or the runtime failed, e.g., due to an invalid flag.
+	ExitCodeStartFailed = 125
+	// ExitCodeCannotInvoke is used when the command cannot be invoked, e.g., due to a permission error.
+	ExitCodeCannotInvoke = 126
+	// ExitCodeCommandNotFound is used when the command cannot be found.
+	ExitCodeCommandNotFound = 127
 )
 
 type ExitCoder interface {
@@ -44,11 +59,70 @@ func (e ExitCodeError) Error() string {
 	return ""
 }
 
+// StartError is an error that happened before the process of a container started.
+type StartError struct {
+	err      error
+	exitCode int
+}
+
+// NewStartError returns err with the exit code of the failures that happen before the process of a container starts:
+// ExitCodeCommandNotFound or ExitCodeCannotInvoke when the runtime failed to execute the command, ExitCodeStartFailed otherwise.
+// err is returned as is when it is nil or when it already has an exit code.
+// The exit codes are the same as `docker run`, `docker start --attach` and `docker exec`,
+// so that the scripts can tell the failures of nerdctl from the exit codes of the containers.
+func NewStartError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr ExitCoder
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	return &StartError{
+		err:      err,
+		exitCode: startErrorExitCode(err),
+	}
+}
+
+// startErrorExitCode classifies the errors of the OCI runtime like Docker does,
+// e.g., "OCI runtime create failed: ... exec: \"foo\": executable file not found in $PATH".
+func startErrorExitCode(err error) int {
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "oci runtime") || !strings.Contains(msg, "exec") {
+		return ExitCodeStartFailed
+	}
+	switch {
+	case strings.Contains(msg, "executable file not found"), strings.Contains(msg, "no such file or directory"):
+		return ExitCodeCommandNotFound
+	case strings.Contains(msg, "permission denied"), strings.Contains(msg, "is a directory"):
+		return ExitCodeCannotInvoke
+	}
+	return ExitCodeStartFailed
+}
+
+func (e *StartError) ExitCode() int {
+	return e.exitCode
+}
+
+func (e *StartError) Error() string {
+	return e.err.Error()
+}
+
+func (e *StartError) Unwrap() error {
+	return e.err
+}
+
+// HandleExitCoder exits with the exit code of err, if any.
+// The message of err is logged unless it is empty, e.g., for ExitCodeError.
 func HandleExitCoder(err error) {
 	if err == nil {
 		return
 	}
-	if exitErr, ok := err.(ExitCoder); ok {
+	var exitErr ExitCoder
+	if errors.As(err, &exitErr) {
+		if msg := err.Error(); msg != "" {
+			log.L.Error(msg)
+		}
 		os.Exit(exitErr.ExitCode())
 	}
 }
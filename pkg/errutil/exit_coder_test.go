@@ -0,0 +1,72 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package errutil
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNewStartError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err      error
+		exitCode int
+	}{
+		{
+			err:      errors.New("flags -d and --rm cannot be specified together"),
+			exitCode: ExitCodeStartFailed,
+		},
+		{
+			err:      errors.New(`failed to create shim task: OCI runtime create failed: runc create failed: unable to start container process: exec: "foo": executable file not found in $PATH: unknown`),
+			exitCode: ExitCodeCommandNotFound,
+		},
+		{
+			err:      errors.New(`failed to create shim task: OCI runtime create failed: runc create failed: unable to start container process: exec: "/foo": stat /foo: no such file or directory: unknown`),
+			exitCode: ExitCodeCommandNotFound,
+		},
+		{
+			err:      errors.New(`OCI runtime exec failed: exec failed: unable to start container process: exec: "/etc/passwd": permission denied: unknown`),
+			exitCode: ExitCodeCannotInvoke,
+		},
+		{
+			err:      errors.New(`failed to create shim task: OCI runtime create failed: runc create failed: unable to start container process: error during container init: error mounting "/foo" to rootfs at "/bar": no such file or directory: unknown`),
+			exitCode: ExitCodeStartFailed,
+		},
+		{
+			// not an error of the runtime
+			err:      errors.New("open /etc/nerdctl/nerdctl.toml: permission denied"),
+			exitCode: ExitCodeStartFailed,
+		},
+	}
+	for _, tc := range testCases {
+		err := NewStartError(fmt.Errorf("wrapped: %w", tc.err))
+		var exitErr ExitCoder
+		assert.Assert(t, errors.As(err, &exitErr))
+		assert.Equal(t, exitErr.ExitCode(), tc.exitCode, tc.err.Error())
+		assert.Assert(t, errors.Is(err, tc.err))
+	}
+
+	assert.NilError(t, NewStartError(nil))
+	// the exit code of the process is kept
+	err := NewStartError(NewExitCoderErr(42))
+	assert.Equal(t, err.(ExitCoder).ExitCode(), 42)
+}
@@ -54,6 +54,7 @@ import (
 	compzstd "github.com/containerd/nerdctl/v2/pkg/compression/zstd"
 	"github.com/containerd/nerdctl/v2/pkg/containerutil"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/squash"
 	"github.com/containerd/nerdctl/v2/pkg/labels"
 )
 
@@ -69,6 +70,8 @@ type Opts struct {
 	Changes     Changes
 	Compression types.CompressionType
 	Format      types.ImageFormat
+	// Squash squashes all the layers of the committed image into one layer
+	Squash bool
 	types.EstargzOptions
 	types.ZstdChunkedOptions
 }
@@ -231,9 +234,34 @@ func Commit(ctx context.Context, client *containerd.Client, container containerd
 		return emptyDigest, err
 	}
 
+	if opts.Squash {
+		return squashCommit(ctx, client, cimg, snName, platformMC, opts, globalOptions)
+	}
 	return configDigest, nil
 }
 
+// squashCommit squashes all the layers of the committed image, and returns the digest of its new config.
+func squashCommit(ctx context.Context, client *containerd.Client, img containerd.Image, snName string, platformMC platforms.MatchComparer, opts *Opts, globalOptions types.GlobalCommandOptions) (digest.Digest, error) {
+	spec, err := squash.CompressionSpec(string(opts.Compression), globalOptions.Compression)
+	if err != nil {
+		return emptyDigest, err
+	}
+	squashed, err := squash.Squash(ctx, client, img, opts.Ref, squash.Opts{
+		Compression: spec,
+		Snapshotter: snName,
+		Message:     opts.Message,
+		Platform:    platformMC,
+	})
+	if err != nil {
+		return emptyDigest, fmt.Errorf("failed to squash the committed image: %w", err)
+	}
+	configDesc, err := containerd.NewImageWithPlatform(client, squashed, platformMC).Config(ctx)
+	if err != nil {
+		return emptyDigest, err
+	}
+	return configDesc.Digest, nil
+}
+
 // generateCommitImageConfig returns commit oci image config based on the container's image.
 func generateCommitImageConfig(ctx context.Context, container containerd.Container, img containerd.Image, diffID digest.Digest, opts *Opts) (ocispec.Image, error) {
 	spec, err := container.Spec(ctx)
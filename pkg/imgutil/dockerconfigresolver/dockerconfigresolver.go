@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"time"
 
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/containerd/containerd/v2/core/remotes/docker"
@@ -35,6 +36,10 @@ type opts struct {
 	skipVerifyCerts bool
 	hostsDirs       []string
 	authCreds       AuthCreds
+	mirrors         []string
+	tracker         docker.StatusTracker
+	maxRetries      int
+	layerTimeout    time.Duration
 }
 
 // Opt for New
@@ -68,6 +73,38 @@ func WithAuthCreds(ac AuthCreds) Opt {
 	}
 }
 
+// WithRegistryMirrors specifies mirrors in the form of "[REGISTRY=]URL" (see ParseMirror),
+// tried before the hosts configured in the hosts directories.
+func WithRegistryMirrors(mirrors []string) Opt {
+	return func(o *opts) {
+		o.mirrors = mirrors
+	}
+}
+
+// WithTracker specifies the tracker of the push statuses, instead of PushTracker.
+func WithTracker(tracker docker.StatusTracker) Opt {
+	return func(o *opts) {
+		o.tracker = tracker
+	}
+}
+
+// WithMaxRetries specifies how many times a blob is retried after all the registry hosts failed,
+// resuming from the bytes already transferred.
+// For pushing, the blobs larger than ChunkSize are uploaded in chunks so that they can be resumed.
+func WithMaxRetries(n int) Opt {
+	return func(o *opts) {
+		o.maxRetries = n
+	}
+}
+
+// WithLayerTimeout specifies the time limit of each attempt to fetch a blob.
+// The next attempt resumes from the bytes already fetched.
+func WithLayerTimeout(d time.Duration) Opt {
+	return func(o *opts) {
+		o.layerTimeout = d
+	}
+}
+
 // NewHostOptions instantiates a HostOptions struct using $DOCKER_CONFIG/config.json .
 //
 // $DOCKER_CONFIG defaults to "~/.docker".
@@ -141,20 +178,57 @@ func NewHostOptions(ctx context.Context, refHostname string, optFuncs ...Opt) (*
 //
 // refHostname is like "docker.io".
 func New(ctx context.Context, refHostname string, optFuncs ...Opt) (remotes.Resolver, error) {
-	ho, err := NewHostOptions(ctx, refHostname, optFuncs...)
+	var o opts
+	for _, of := range optFuncs {
+		of(&o)
+	}
+	hosts, err := NewRegistryHosts(ctx, refHostname, optFuncs...)
 	if err != nil {
 		return nil, err
 	}
 
 	resolverOpts := docker.ResolverOptions{
 		Tracker: PushTracker,
-		Hosts:   dockerconfig.ConfigureHosts(ctx, *ho),
+		Hosts:   hosts,
+	}
+	if o.tracker != nil {
+		resolverOpts.Tracker = o.tracker
 	}
 
-	resolver := docker.NewResolver(resolverOpts)
+	resolver := newFailoverResolver(resolverOpts, retryPolicy{
+		maxRetries: o.maxRetries,
+		timeout:    o.layerTimeout,
+		backoff:    retryDelay,
+	})
 	return resolver, nil
 }
 
+// NewRegistryHosts instantiates the registry hosts used by New, for the requests not covered by remotes.Resolver
+// (e.g., the OCI referrers API).
+//
+// The mirrors are tried first, and the hosts that failed recently (see HostCooldown) are tried last.
+//
+// refHostname is like "docker.io".
+func NewRegistryHosts(ctx context.Context, refHostname string, optFuncs ...Opt) (docker.RegistryHosts, error) {
+	var o opts
+	for _, of := range optFuncs {
+		of(&o)
+	}
+	var mirrors []Mirror
+	for _, s := range o.mirrors {
+		m, err := ParseMirror(s)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, m)
+	}
+	ho, err := NewHostOptions(ctx, refHostname, optFuncs...)
+	if err != nil {
+		return nil, err
+	}
+	return withHealthTracking(withMirrors(dockerconfig.ConfigureHosts(ctx, *ho), mirrors), hostHealth), nil
+}
+
 // AuthCreds is for docker.WithAuthCreds
 type AuthCreds func(string) (string, string, error)
 
@@ -0,0 +1,482 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/containerd/containerd/v2/core/transfer"
+	"github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+)
+
+// HostCooldown is how long a registry host is tried after the healthy ones, after a failure.
+const HostCooldown = 30 * time.Second
+
+// healthTracker tracks the failures (5xx responses, network errors) of registry hosts.
+// The hosts that failed recently are tried last.
+type healthTracker struct {
+	mu       sync.Mutex
+	failures map[string]time.Time
+	cooldown time.Duration
+	now      func() time.Time
+}
+
+var hostHealth = newHealthTracker(HostCooldown)
+
+func newHealthTracker(cooldown time.Duration) *healthTracker {
+	return &healthTracker{
+		failures: make(map[string]time.Time),
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+}
+
+func hostKey(h docker.RegistryHost) string {
+	return h.Scheme + "://" + h.Host + h.Path
+}
+
+func (t *healthTracker) fail(key string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.failures[key]; !ok {
+		log.L.WithError(err).Debugf("registry host %s is unhealthy, trying it last for %v", key, t.cooldown)
+	}
+	t.failures[key] = t.now()
+}
+
+func (t *healthTracker) succeed(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, key)
+}
+
+func (t *healthTracker) healthy(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	failed, ok := t.failures[key]
+	return !ok || t.now().Sub(failed) >= t.cooldown
+}
+
+// order sorts the hosts so that the healthy ones come first, keeping the configured order otherwise.
+func (t *healthTracker) order(hosts []docker.RegistryHost) []docker.RegistryHost {
+	res := slices.Clone(hosts)
+	slices.SortStableFunc(res, func(a, b docker.RegistryHost) int {
+		ha, hb := t.healthy(hostKey(a)), t.healthy(hostKey(b))
+		switch {
+		case ha == hb:
+			return 0
+		case ha:
+			return -1
+		default:
+			return 1
+		}
+	})
+	return res
+}
+
+// withHealthTracking returns the registry hosts with their clients recording failures to t, ordered by health.
+func withHealthTracking(hosts docker.RegistryHosts, t *healthTracker) docker.RegistryHosts {
+	return func(host string) ([]docker.RegistryHost, error) {
+		res, err := hosts(host)
+		if err != nil {
+			return nil, err
+		}
+		for i, h := range res {
+			client := http.DefaultClient
+			if h.Client != nil {
+				client = h.Client
+			}
+			c := *client
+			c.Transport = &healthTransport{base: client.Transport, key: hostKey(h), tracker: t}
+			res[i].Client = &c
+		}
+		return t.order(res), nil
+	}
+}
+
+type healthTransport struct {
+	base    http.RoundTripper
+	key     string
+	tracker *healthTracker
+}
+
+func (t *healthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		if req.Context().Err() == nil {
+			t.tracker.fail(t.key, err)
+		}
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		t.tracker.fail(t.key, fmt.Errorf("unexpected status %s", resp.Status))
+	} else {
+		t.tracker.succeed(t.key)
+	}
+	resp.Body = &healthBody{ReadCloser: resp.Body, ctx: req.Context(), transport: t}
+	return resp, nil
+}
+
+// healthBody records the failures while reading response bodies, e.g., a connection reset in the middle of a blob.
+type healthBody struct {
+	io.ReadCloser
+	ctx       context.Context
+	transport *healthTransport
+}
+
+func (b *healthBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() == nil {
+		b.transport.tracker.fail(b.transport.key, err)
+	}
+	return n, err
+}
+
+// TransferObserver is notified of the transfers made by the resolvers created by New.
+type TransferObserver interface {
+	// Served is called with the registry host that served a fetched blob.
+	Served(desc ocispec.Descriptor, host string)
+	// Retrying is called before retrying a blob, with the attempt number starting from 1.
+	Retrying(desc ocispec.Descriptor, attempt int, err error)
+}
+
+type transferObserverKey struct{}
+
+// WithTransferObserver returns a context that makes the resolvers created by New report their transfers to o.
+func WithTransferObserver(ctx context.Context, o TransferObserver) context.Context {
+	return context.WithValue(ctx, transferObserverKey{}, o)
+}
+
+func observeHost(ctx context.Context, desc ocispec.Descriptor, host string) {
+	if o, ok := ctx.Value(transferObserverKey{}).(TransferObserver); ok {
+		o.Served(desc, host)
+	}
+	log.G(ctx).Debugf("fetched %s from %s", desc.Digest, host)
+}
+
+func observeRetry(ctx context.Context, desc ocispec.Descriptor, attempt int, err error) {
+	if o, ok := ctx.Value(transferObserverKey{}).(TransferObserver); ok {
+		o.Retrying(desc, attempt, err)
+	}
+}
+
+// maxRetryDelay is the maximum delay between two attempts.
+const maxRetryDelay = 30 * time.Second
+
+// retryDelay returns the exponential backoff before the attempt (starting from 1).
+func retryDelay(attempt int) time.Duration {
+	if attempt > 6 {
+		return maxRetryDelay
+	}
+	return min(time.Second<<(attempt-1), maxRetryDelay)
+}
+
+// retryable returns whether err may be transient: network errors, timeouts, and 429 and 5xx responses.
+func retryable(err error) bool {
+	var status remoteerrors.ErrUnexpectedStatus
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= http.StatusInternalServerError
+	}
+	return !errdefs.IsNotFound(err) && !errdefs.IsAlreadyExists(err) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, docker.ErrInvalidAuthorization)
+}
+
+// retryPolicy configures the retries of the transfers.
+type retryPolicy struct {
+	// maxRetries is the number of retries of a blob after all the hosts failed
+	maxRetries int
+	// timeout is the time limit of each attempt to fetch a blob, or zero
+	timeout time.Duration
+	backoff func(attempt int) time.Duration
+}
+
+// failoverResolver is a resolver whose fetchers switch to the next registry host (e.g., from a mirror to the registry)
+// when a host fails in the middle of a blob, resuming from the same offset with an HTTP Range request.
+type failoverResolver struct {
+	remotes.Resolver
+	hosts        docker.RegistryHosts
+	resolverOpts docker.ResolverOptions
+	retries      retryPolicy
+
+	mu      sync.Mutex
+	options []transfer.ImageResolverOption
+}
+
+func newFailoverResolver(resolverOpts docker.ResolverOptions, retries retryPolicy) *failoverResolver {
+	return &failoverResolver{
+		Resolver:     docker.NewResolver(resolverOpts),
+		hosts:        resolverOpts.Hosts,
+		resolverOpts: resolverOpts,
+		retries:      retries,
+	}
+}
+
+// SetOptions implements remotes.ResolverWithOptions.
+func (r *failoverResolver) SetOptions(options ...transfer.ImageResolverOption) {
+	if rwo, ok := r.Resolver.(remotes.ResolverWithOptions); ok {
+		rwo.SetOptions(options...)
+	}
+	r.mu.Lock()
+	r.options = append(r.options, options...)
+	r.mu.Unlock()
+}
+
+func (r *failoverResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	refspec, err := reference.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := r.hosts(refspec.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	// a fetcher is created for every host, so that the hosts can be tried one by one
+	f := &failoverFetcher{retries: r.retries}
+	r.mu.Lock()
+	options := slices.Clone(r.options)
+	r.mu.Unlock()
+	for _, h := range hosts {
+		if h.Capabilities&docker.HostCapabilityPull == 0 {
+			continue
+		}
+		opts := r.resolverOpts
+		opts.Hosts = func(string) ([]docker.RegistryHost, error) {
+			return []docker.RegistryHost{h}, nil
+		}
+		hr := docker.NewResolver(opts)
+		if rwo, ok := hr.(remotes.ResolverWithOptions); ok && len(options) > 0 {
+			rwo.SetOptions(options...)
+		}
+		fetcher, err := hr.Fetcher(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		f.hosts = append(f.hosts, h.Host)
+		f.fetchers = append(f.fetchers, fetcher)
+	}
+	if len(f.fetchers) == 0 {
+		return r.Resolver.Fetcher(ctx, ref)
+	}
+	return f, nil
+}
+
+type failoverFetcher struct {
+	hosts    []string
+	fetchers []remotes.Fetcher
+	retries  retryPolicy
+}
+
+func (f *failoverFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	r := &failoverReader{ctx: ctx, f: f, desc: desc, idx: -1}
+	if err := r.next(nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FetchByDigest implements remotes.FetcherByDigest, without failover in the middle of the content.
+func (f *failoverFetcher) FetchByDigest(ctx context.Context, dgst digest.Digest, opts ...remotes.FetchByDigestOpts) (io.ReadCloser, ocispec.Descriptor, error) {
+	var errs []error
+	for i, fetcher := range f.fetchers {
+		fbd, ok := fetcher.(remotes.FetcherByDigest)
+		if !ok {
+			continue
+		}
+		rc, desc, err := fbd.FetchByDigest(ctx, dgst, opts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.hosts[i], err))
+			continue
+		}
+		return rc, desc, nil
+	}
+	if len(errs) == 0 {
+		return nil, ocispec.Descriptor{}, errors.New("fetching by digest is not supported")
+	}
+	return nil, ocispec.Descriptor{}, errors.Join(errs...)
+}
+
+type failoverReader struct {
+	ctx     context.Context
+	f       *failoverFetcher
+	desc    ocispec.Descriptor
+	idx     int
+	rc      io.ReadCloser
+	cancel  context.CancelFunc
+	offset  int64
+	attempt int
+	errs    []error
+	done    bool
+}
+
+// next switches to the next host, resuming from the current offset.
+// When all the hosts failed, they are retried after a backoff, up to maxRetries times.
+func (r *failoverReader) next(cause error) error {
+	r.closeHost()
+	if cause != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: %w", r.f.hosts[r.idx], cause))
+	}
+	for {
+		for r.idx+1 < len(r.f.fetchers) {
+			r.idx++
+			if cause != nil {
+				log.G(r.ctx).WithError(cause).Warnf("failed to fetch %s at offset %d, trying %s", r.desc.Digest, r.offset, r.f.hosts[r.idx])
+			}
+			if err := r.open(); err != nil {
+				r.errs = append(r.errs, fmt.Errorf("%s: %w", r.f.hosts[r.idx], err))
+				cause = err
+				continue
+			}
+			return nil
+		}
+		last := cause
+		if len(r.errs) > 0 {
+			last = r.errs[len(r.errs)-1]
+		}
+		if r.attempt >= r.f.retries.maxRetries || last == nil || !retryable(last) || r.ctx.Err() != nil {
+			return errors.Join(r.errs...)
+		}
+		r.attempt++
+		delay := r.f.retries.backoff(r.attempt)
+		log.G(r.ctx).WithError(last).Warnf("failed to fetch %s at offset %d, retrying in %v (%d/%d)",
+			r.desc.Digest, r.offset, delay, r.attempt, r.f.retries.maxRetries)
+		observeRetry(r.ctx, r.desc, r.attempt, last)
+		select {
+		case <-time.After(delay):
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		}
+		r.idx, r.errs, cause = -1, nil, nil
+	}
+}
+
+// open opens the current host at the current offset, with the timeout of the attempt.
+func (r *failoverReader) open() error {
+	ctx, cancel := r.ctx, context.CancelFunc(func() {})
+	if r.f.retries.timeout > 0 {
+		ctx, cancel = context.WithTimeout(r.ctx, r.f.retries.timeout)
+	}
+	rc, err := r.f.fetchers[r.idx].Fetch(ctx, r.desc)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if r.offset > 0 {
+		seeker, ok := rc.(io.Seeker)
+		if !ok {
+			rc.Close()
+			cancel()
+			return fmt.Errorf("cannot resume at offset %d", r.offset)
+		}
+		if _, err := seeker.Seek(r.offset, io.SeekStart); err != nil {
+			rc.Close()
+			cancel()
+			return err
+		}
+	}
+	r.rc, r.cancel = rc, cancel
+	return nil
+}
+
+func (r *failoverReader) closeHost() error {
+	var err error
+	if r.rc != nil {
+		err = r.rc.Close()
+		r.rc = nil
+	}
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+	return err
+}
+
+// Seek implements io.Seeker, so that content.Copy resumes the partial ingests with a Range request
+// instead of discarding the beginning of the blob.
+func (r *failoverReader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart || offset < 0 {
+		return r.offset, fmt.Errorf("unsupported seek to %d (whence %d): %w", offset, whence, errdefs.ErrNotImplemented)
+	}
+	if r.rc == nil || offset == r.offset {
+		return r.offset, nil
+	}
+	r.offset = offset
+	if seeker, ok := r.rc.(io.Seeker); ok {
+		if _, err := seeker.Seek(offset, io.SeekStart); err == nil {
+			return offset, nil
+		}
+	}
+	// reopen the hosts at the new offset
+	r.closeHost()
+	r.idx = -1
+	if err := r.next(nil); err != nil {
+		return offset, err
+	}
+	return offset, nil
+}
+
+func (r *failoverReader) Read(p []byte) (int, error) {
+	for {
+		if r.done {
+			return 0, io.EOF
+		}
+		if r.rc == nil {
+			return 0, fmt.Errorf("failed to fetch %s: %w", r.desc.Digest, errors.Join(r.errs...))
+		}
+		n, err := r.rc.Read(p)
+		r.offset += int64(n)
+		switch {
+		case err == nil:
+			return n, nil
+		case err == io.EOF:
+			r.done = true
+			observeHost(r.ctx, r.desc, r.f.hosts[r.idx])
+			return n, err
+		case r.ctx.Err() != nil:
+			return n, err
+		}
+		if nextErr := r.next(err); nextErr != nil {
+			return n, nextErr
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *failoverReader) Close() error {
+	r.done = true
+	return r.closeHost()
+}
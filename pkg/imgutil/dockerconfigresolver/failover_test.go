@@ -0,0 +1,277 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/containerd/errdefs"
+)
+
+// faultyRegistry serves a single blob, with an injected fault.
+type faultyRegistry struct {
+	blob []byte
+	// cut makes the registry close the connection in the middle of the blob, and fail the later (Range) requests
+	cut bool
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func newFaultyRegistry(t *testing.T, blob []byte, cut bool) (*faultyRegistry, string) {
+	r := &faultyRegistry{blob: blob, cut: cut}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (r *faultyRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/v2/" {
+		return
+	}
+	if req.URL.Path != "/v2/test/app/blobs/"+digest.FromBytes(r.blob).String() {
+		http.NotFound(w, req)
+		return
+	}
+	r.mu.Lock()
+	r.ranges = append(r.ranges, req.Header.Get("Range"))
+	r.mu.Unlock()
+	if !r.cut {
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(r.blob))
+		return
+	}
+	if req.Header.Get("Range") != "" {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Length", "1024")
+	w.Write(r.blob[:512])
+	w.(http.Flusher).Flush()
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func noCreds(string) (string, string, error) {
+	return "", "", nil
+}
+
+// observer records the transfers reported to the TransferObserver.
+type observer struct {
+	mu      sync.Mutex
+	served  string
+	retries []int
+}
+
+func (o *observer) Served(desc ocispec.Descriptor, host string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.served = host
+}
+
+func (o *observer) Retrying(desc ocispec.Descriptor, attempt int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.retries = append(o.retries, attempt)
+}
+
+func noBackoff(int) time.Duration {
+	return 0
+}
+
+func TestFailoverFromMirror(t *testing.T) {
+	t.Parallel()
+	blob := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+	upstream, upstreamHost := newFaultyRegistry(t, blob, false)
+	mirror, mirrorHost := newFaultyRegistry(t, blob, true)
+
+	ctx := context.Background()
+	opts := []Opt{
+		WithAuthCreds(noCreds),
+		WithRegistryMirrors([]string{upstreamHost + "=http://" + mirrorHost}),
+	}
+	resolver, err := New(ctx, upstreamHost, opts...)
+	assert.NilError(t, err)
+	fetcher, err := resolver.Fetcher(ctx, upstreamHost+"/test/app:latest")
+	assert.NilError(t, err)
+
+	var o observer
+	ctx = WithTransferObserver(ctx, &o)
+	rc, err := fetcher.Fetch(ctx, desc)
+	assert.NilError(t, err)
+	b, err := io.ReadAll(rc)
+	assert.NilError(t, err)
+	assert.NilError(t, rc.Close())
+	assert.Assert(t, bytes.Equal(b, blob))
+	assert.Equal(t, o.served, upstreamHost)
+	assert.Equal(t, len(o.retries), 0)
+
+	// the upstream resumed the blob from the offset reached with the mirror
+	assert.Equal(t, mirror.ranges[0], "")
+	assert.DeepEqual(t, upstream.ranges, []string{"bytes=512-"})
+
+	// the mirror is now tried last
+	hosts, err := NewRegistryHosts(ctx, upstreamHost, opts...)
+	assert.NilError(t, err)
+	registryHosts, err := hosts(upstreamHost)
+	assert.NilError(t, err)
+	assert.Equal(t, len(registryHosts), 2)
+	assert.Equal(t, registryHosts[0].Host, upstreamHost)
+	assert.Equal(t, registryHosts[1].Host, mirrorHost)
+}
+
+// flakyRegistry serves a single blob, cutting the first response at 512 bytes and failing the next requests with 503.
+type flakyRegistry struct {
+	blob []byte
+	// failures is the number of requests failing after the cut
+	failures int
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (r *flakyRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/v2/test/app/blobs/"+digest.FromBytes(r.blob).String() {
+		http.NotFound(w, req)
+		return
+	}
+	r.mu.Lock()
+	r.ranges = append(r.ranges, req.Header.Get("Range"))
+	n := len(r.ranges)
+	r.mu.Unlock()
+	switch {
+	case n == 1:
+		w.Header().Set("Content-Length", strconv.Itoa(len(r.blob)))
+		w.Write(r.blob[:512])
+		w.(http.Flusher).Flush()
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	case n <= 1+r.failures:
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	default:
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(r.blob))
+	}
+}
+
+func TestFetchRetry(t *testing.T) {
+	t.Parallel()
+	blob := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+	ctx := context.Background()
+
+	fetch := func(t *testing.T, maxRetries, failures int) (*flakyRegistry, *observer, []byte, error) {
+		reg := &flakyRegistry{blob: blob, failures: failures}
+		srv := httptest.NewServer(reg)
+		t.Cleanup(srv.Close)
+		host := strings.TrimPrefix(srv.URL, "http://")
+		resolver, err := New(ctx, host, WithAuthCreds(noCreds), WithMaxRetries(maxRetries))
+		assert.NilError(t, err)
+		resolver.(*failoverResolver).retries.backoff = noBackoff
+		fetcher, err := resolver.Fetcher(ctx, host+"/test/app:latest")
+		assert.NilError(t, err)
+
+		o := &observer{}
+		rc, err := fetcher.Fetch(WithTransferObserver(ctx, o), desc)
+		assert.NilError(t, err)
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		return reg, o, b, err
+	}
+
+	_, _, _, err := fetch(t, 0, 2)
+	assert.ErrorContains(t, err, "unexpected EOF")
+
+	reg, o, b, err := fetch(t, 2, 2)
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.Equal(b, blob))
+	// the docker fetcher resumes once by itself after the cut, then the retry resumes from the same offset
+	assert.DeepEqual(t, reg.ranges, []string{"", "bytes=512-", "bytes=512-", "bytes=512-"})
+	assert.DeepEqual(t, o.retries, []int{1})
+	assert.Assert(t, o.served != "")
+}
+
+func TestRetryable(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{fmt.Errorf("blob: %w", errdefs.ErrNotFound), false},
+		{remoteerrors.ErrUnexpectedStatus{StatusCode: http.StatusServiceUnavailable}, true},
+		{fmt.Errorf("wrapped: %w", remoteerrors.ErrUnexpectedStatus{StatusCode: http.StatusTooManyRequests}), true},
+		{remoteerrors.ErrUnexpectedStatus{StatusCode: http.StatusForbidden}, false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, retryable(tc.err), tc.expected, tc.err.Error())
+	}
+	assert.Equal(t, retryDelay(1), time.Second)
+	assert.Equal(t, retryDelay(3), 4*time.Second)
+	assert.Equal(t, retryDelay(10), maxRetryDelay)
+}
+
+func TestHealthTracker(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	tracker := newHealthTracker(time.Minute)
+	tracker.now = func() time.Time { return now }
+	hosts := []docker.RegistryHost{
+		{Scheme: "https", Host: "mirror1.example.com", Path: "/v2"},
+		{Scheme: "https", Host: "mirror2.example.com", Path: "/v2"},
+		{Scheme: "https", Host: "registry.example.com", Path: "/v2"},
+	}
+	names := func(hosts []docker.RegistryHost) []string {
+		var res []string
+		for _, h := range hosts {
+			res = append(res, h.Host)
+		}
+		return res
+	}
+	assert.DeepEqual(t, names(tracker.order(hosts)), names(hosts))
+
+	tracker.fail("https://mirror1.example.com/v2", io.ErrUnexpectedEOF)
+	assert.DeepEqual(t, names(tracker.order(hosts)), []string{"mirror2.example.com", "registry.example.com", "mirror1.example.com"})
+
+	// healthy again after the cooldown
+	now = now.Add(time.Minute)
+	assert.DeepEqual(t, names(tracker.order(hosts)), names(hosts))
+
+	tracker.fail("https://mirror1.example.com/v2", io.ErrUnexpectedEOF)
+	tracker.succeed("https://mirror1.example.com/v2")
+	assert.DeepEqual(t, names(tracker.order(hosts)), names(hosts))
+}
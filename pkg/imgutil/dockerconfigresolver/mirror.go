@@ -0,0 +1,100 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+)
+
+// Mirror is a mirror of a registry, specified with `--registry-mirror`.
+type Mirror struct {
+	// Registry is the mirrored registry, e.g., "docker.io"
+	Registry string
+	// URL is the URL of the mirror, e.g., "https://mirror.example.com"
+	URL *url.URL
+}
+
+// ParseMirror parses a mirror in the form of "[REGISTRY=]URL", e.g., "ghcr.io=https://mirror.example.com".
+// REGISTRY defaults to "docker.io", and the scheme of URL defaults to "https".
+func ParseMirror(s string) (Mirror, error) {
+	registry, rawURL, ok := strings.Cut(s, "=")
+	if !ok || strings.Contains(registry, "/") {
+		// "=" in the URL
+		registry, rawURL = "docker.io", s
+	}
+	if registry == "" {
+		return Mirror{}, fmt.Errorf("invalid registry mirror %q: invalid registry %q", s, registry)
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Mirror{}, fmt.Errorf("invalid registry mirror %q: %w", s, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Mirror{}, fmt.Errorf("invalid registry mirror %q: unsupported scheme %q", s, u.Scheme)
+	}
+	if u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return Mirror{}, fmt.Errorf("invalid registry mirror %q: a URL like \"https://mirror.example.com\" is expected", s)
+	}
+	return Mirror{Registry: registry, URL: u}, nil
+}
+
+// withMirrors returns the registry hosts with the mirrors of the registry prepended, so that they are tried first.
+// The mirrors share the client and the authorizer of the last host, i.e., the registry itself.
+func withMirrors(hosts docker.RegistryHosts, mirrors []Mirror) docker.RegistryHosts {
+	if len(mirrors) == 0 {
+		return hosts
+	}
+	return func(host string) ([]docker.RegistryHost, error) {
+		res, err := hosts(host)
+		if err != nil || len(res) == 0 {
+			return res, err
+		}
+		upstream := res[len(res)-1]
+		var prepended []docker.RegistryHost
+		for _, m := range mirrors {
+			if m.Registry != host {
+				continue
+			}
+			h := upstream
+			h.Scheme = m.URL.Scheme
+			h.Host = m.URL.Host
+			h.Path = path.Join("/", m.URL.Path, "v2")
+			h.Capabilities = docker.HostCapabilityPull | docker.HostCapabilityResolve
+			if !containsHost(res, h) && !containsHost(prepended, h) {
+				prepended = append(prepended, h)
+			}
+		}
+		return append(prepended, res...), nil
+	}
+}
+
+func containsHost(hosts []docker.RegistryHost, h docker.RegistryHost) bool {
+	for _, x := range hosts {
+		if x.Scheme == h.Scheme && x.Host == h.Host && x.Path == h.Path {
+			return true
+		}
+	}
+	return false
+}
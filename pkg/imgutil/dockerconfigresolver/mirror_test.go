@@ -0,0 +1,85 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+)
+
+func TestParseMirror(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		s        string
+		registry string
+		url      string
+		err      string
+	}{
+		{s: "https://mirror.example.com", registry: "docker.io", url: "https://mirror.example.com"},
+		{s: "mirror.example.com:5000", registry: "docker.io", url: "https://mirror.example.com:5000"},
+		{s: "ghcr.io=http://mirror.example.com/ghcr", registry: "ghcr.io", url: "http://mirror.example.com/ghcr"},
+		{s: "=https://mirror.example.com", err: "invalid registry"},
+		{s: "ftp://mirror.example.com", err: "unsupported scheme"},
+		{s: "https://mirror.example.com?ns=docker.io", err: "a URL like"},
+	}
+	for _, tc := range testCases {
+		m, err := ParseMirror(tc.s)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.s)
+			continue
+		}
+		assert.NilError(t, err, tc.s)
+		assert.Equal(t, m.Registry, tc.registry, tc.s)
+		assert.Equal(t, m.URL.String(), tc.url, tc.s)
+	}
+}
+
+func TestWithMirrors(t *testing.T) {
+	t.Parallel()
+	upstream := docker.RegistryHost{
+		Client:       http.DefaultClient,
+		Scheme:       "https",
+		Host:         "registry-1.docker.io",
+		Path:         "/v2",
+		Capabilities: docker.HostCapabilityPull | docker.HostCapabilityResolve | docker.HostCapabilityPush,
+	}
+	hosts := func(string) ([]docker.RegistryHost, error) {
+		return []docker.RegistryHost{upstream}, nil
+	}
+	var mirrors []Mirror
+	for _, s := range []string{"https://mirror.example.com/docker", "ghcr.io=https://mirror.example.com", "https://mirror.example.com/docker"} {
+		m, err := ParseMirror(s)
+		assert.NilError(t, err)
+		mirrors = append(mirrors, m)
+	}
+	res, err := withMirrors(hosts, mirrors)("docker.io")
+	assert.NilError(t, err)
+	assert.Equal(t, len(res), 2)
+	assert.Equal(t, res[0].Host, "mirror.example.com")
+	assert.Equal(t, res[0].Path, "/docker/v2")
+	assert.Equal(t, res[0].Capabilities, docker.HostCapabilityPull|docker.HostCapabilityResolve)
+	assert.Equal(t, res[0].Client, upstream.Client)
+	assert.Equal(t, res[1].Host, upstream.Host)
+
+	res, err = withMirrors(hosts, mirrors)("quay.io")
+	assert.NilError(t, err)
+	assert.Equal(t, len(res), 1)
+}
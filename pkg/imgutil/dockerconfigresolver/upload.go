@@ -0,0 +1,492 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+)
+
+// ChunkSize is the size of the chunks of the resumable uploads.
+// The smaller blobs are uploaded in a single request.
+const ChunkSize = 16 << 20
+
+// errChunkedUnsupported is returned when the registry rejects the first chunk of an upload.
+var errChunkedUnsupported = errors.New("chunked uploads are not supported")
+
+// Pusher returns a pusher uploading the large blobs in chunks when the retries are enabled,
+// so that a failed upload resumes from the last chunk accepted by the registry.
+func (r *failoverResolver) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	pusher, err := r.Resolver.Pusher(ctx, ref)
+	if err != nil || r.retries.maxRetries == 0 {
+		return pusher, err
+	}
+	refspec, err := reference.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := r.hosts(refspec.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		if h.Capabilities&docker.HostCapabilityPush == 0 {
+			continue
+		}
+		return &chunkedPusher{
+			Pusher:    pusher,
+			refspec:   refspec,
+			host:      h,
+			tracker:   r.resolverOpts.Tracker,
+			retries:   r.retries,
+			chunkSize: ChunkSize,
+		}, nil
+	}
+	return pusher, nil
+}
+
+// chunkedPusher uploads the blobs larger than chunkSize with the chunked upload API of the registries,
+// and delegates the other blobs and the manifests to the docker pusher.
+type chunkedPusher struct {
+	remotes.Pusher
+	refspec   reference.Spec
+	host      docker.RegistryHost
+	tracker   docker.StatusTracker
+	retries   retryPolicy
+	chunkSize int64
+}
+
+// Writer implements content.Ingester, like the docker pusher.
+func (p *chunkedPusher) Writer(ctx context.Context, opts ...content.WriterOpt) (content.Writer, error) {
+	var wOpts content.WriterOpts
+	for _, opt := range opts {
+		if err := opt(&wOpts); err != nil {
+			return nil, err
+		}
+	}
+	if !p.chunked(wOpts.Desc) {
+		if ingester, ok := p.Pusher.(content.Ingester); ok {
+			return ingester.Writer(ctx, opts...)
+		}
+		return p.Pusher.Push(ctx, wOpts.Desc)
+	}
+	if wOpts.Ref == "" {
+		return nil, fmt.Errorf("ref must not be empty: %w", errdefs.ErrInvalidArgument)
+	}
+	return p.upload(ctx, wOpts.Ref, wOpts.Desc, true)
+}
+
+func (p *chunkedPusher) Push(ctx context.Context, desc ocispec.Descriptor) (content.Writer, error) {
+	if !p.chunked(desc) {
+		return p.Pusher.Push(ctx, desc)
+	}
+	return p.upload(ctx, remotes.MakeRefKey(ctx, desc), desc, false)
+}
+
+func (p *chunkedPusher) chunked(desc ocispec.Descriptor) bool {
+	return desc.Size > p.chunkSize && !images.IsManifestType(desc.MediaType) && !images.IsIndexType(desc.MediaType)
+}
+
+// upload starts the upload of desc, unless the blob already exists in the repository.
+func (p *chunkedPusher) upload(ctx context.Context, ref string, desc ocispec.Descriptor, unavailableOnFail bool) (content.Writer, error) {
+	if l, ok := p.tracker.(docker.StatusTrackLocker); ok {
+		l.Lock(ref)
+		defer l.Unlock(ref)
+	}
+	if status, err := p.tracker.GetStatus(ref); err == nil {
+		if status.Committed && status.Offset == status.Total {
+			return nil, fmt.Errorf("ref %v: %w", ref, errdefs.ErrAlreadyExists)
+		}
+		if unavailableOnFail && status.ErrClosed == nil {
+			return nil, fmt.Errorf("push is on-going: %w", errdefs.ErrUnavailable)
+		}
+	}
+	ctx, err := docker.ContextWithRepositoryScope(ctx, p.refspec, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.do(ctx, http.MethodHead, p.url("blobs", desc.Digest.String()), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		p.tracker.SetStatus(ref, docker.Status{
+			Committed:  true,
+			PushStatus: docker.PushStatus{Exists: true},
+			Status:     content.Status{Ref: ref, Total: desc.Size, Offset: desc.Size},
+		})
+		return nil, fmt.Errorf("content %v on remote: %w", desc.Digest, errdefs.ErrAlreadyExists)
+	}
+
+	resp, err = p.do(ctx, http.MethodPost, p.url("blobs", "uploads")+"/", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, remoteerrors.NewUnexpectedStatusErr(resp)
+	}
+	location, err := resolveLocation(resp)
+	if err != nil {
+		return nil, err
+	}
+	w := &chunkedWriter{
+		ctx:      ctx,
+		p:        p,
+		desc:     desc,
+		location: location,
+		digester: digest.Canonical.Digester(),
+		status: docker.Status{
+			Status: content.Status{
+				Ref:       ref,
+				Total:     desc.Size,
+				Expected:  desc.Digest,
+				StartedAt: time.Now(),
+			},
+			UploadUUID: resp.Header.Get("Docker-Upload-UUID"),
+		},
+	}
+	w.updateStatus()
+	return w, nil
+}
+
+func (p *chunkedPusher) url(elem ...string) string {
+	repo := strings.TrimPrefix(p.refspec.Locator, p.refspec.Hostname()+"/")
+	return p.host.Scheme + "://" + p.host.Host + path.Join(append([]string{"/", p.host.Path, repo}, elem...)...)
+}
+
+// do sends a request to the registry, authorizing it again after a 401 response.
+// The requests to other hosts (e.g., an upload location on a storage service) are not authorized.
+func (p *chunkedPusher) do(ctx context.Context, method, u string, body []byte, header http.Header) (*http.Response, error) {
+	client := p.host.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for retried := false; ; retried = true {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range p.host.Header {
+			req.Header[k] = v
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		authorize := p.host.Authorizer != nil && req.URL.Host == p.host.Host
+		if authorize {
+			if err := p.host.Authorizer.Authorize(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && authorize && !retried {
+			if err := p.host.Authorizer.AddResponses(ctx, []*http.Response{resp}); err == nil {
+				resp.Body.Close()
+				continue
+			}
+		}
+		return resp, nil
+	}
+}
+
+func resolveLocation(resp *http.Response) (*url.URL, error) {
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, fmt.Errorf("no upload location in the response of %s %s", resp.Request.Method, resp.Request.URL)
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse location %v: %w", location, err)
+	}
+	return resp.Request.URL.ResolveReference(u), nil
+}
+
+// chunkedWriter buffers a chunk and sends it with a PATCH request, retrying from the offset
+// reported by the registry after a failure.
+type chunkedWriter struct {
+	ctx      context.Context
+	p        *chunkedPusher
+	desc     ocispec.Descriptor
+	location *url.URL
+	// buf holds the bytes not yet accepted by the registry, starting at status.Offset
+	buf      []byte
+	digester digest.Digester
+	status   docker.Status
+	// fallback is the writer of the docker pusher, when the registry does not support chunked uploads
+	fallback content.Writer
+}
+
+func (w *chunkedWriter) updateStatus() {
+	status := w.status
+	status.Offset += int64(len(w.buf))
+	status.UpdatedAt = time.Now()
+	w.p.tracker.SetStatus(w.status.Ref, status)
+}
+
+func (w *chunkedWriter) Write(b []byte) (int, error) {
+	if w.fallback != nil {
+		return w.fallback.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	w.digester.Hash().Write(b)
+	// the last chunk is sent with the PUT request finishing the upload
+	for int64(len(w.buf)) > w.p.chunkSize {
+		err := w.send(int(w.p.chunkSize), false)
+		if errors.Is(err, errChunkedUnsupported) {
+			err = w.switchToMonolithic(err)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if w.fallback != nil {
+			return len(b), nil
+		}
+	}
+	w.updateStatus()
+	return len(b), nil
+}
+
+// switchToMonolithic hands the upload over to the docker pusher, which sends the whole blob in a single request.
+func (w *chunkedWriter) switchToMonolithic(cause error) error {
+	log.G(w.ctx).WithError(cause).Debugf("uploading %s in a single request", w.desc.Digest)
+	// cancel the chunked upload, so that it is not left on the registry
+	if resp, err := w.p.do(w.ctx, http.MethodDelete, w.location.String(), nil, nil); err != nil {
+		log.G(w.ctx).WithError(err).Debugf("failed to cancel the upload of %s", w.desc.Digest)
+	} else {
+		resp.Body.Close()
+	}
+	fw, err := w.p.Pusher.Push(w.ctx, w.desc)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(w.buf); err != nil {
+		fw.Close()
+		return err
+	}
+	w.buf, w.fallback = nil, fw
+	return nil
+}
+
+// send sends the first n bytes of the buffer, and finishes the upload if final is true.
+func (w *chunkedWriter) send(n int, final bool) error {
+	for attempt := 0; ; attempt++ {
+		err := w.request(n, final)
+		if err == nil {
+			return nil
+		}
+		if attempt == 0 && !final && w.status.Offset == 0 && chunkRejected(err) {
+			return fmt.Errorf("%w: %w", errChunkedUnsupported, err)
+		}
+		if attempt >= w.p.retries.maxRetries || !retryable(err) || w.ctx.Err() != nil {
+			return err
+		}
+		delay := w.p.retries.backoff(attempt + 1)
+		log.G(w.ctx).WithError(err).Warnf("failed to upload %s at offset %d, retrying in %v (%d/%d)",
+			w.desc.Digest, w.status.Offset, delay, attempt+1, w.p.retries.maxRetries)
+		observeRetry(w.ctx, w.desc, attempt+1, err)
+		select {
+		case <-time.After(delay):
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+		received, err := w.received()
+		if err != nil {
+			log.G(w.ctx).WithError(err).Debugf("failed to get the status of the upload of %s", w.desc.Digest)
+			continue
+		}
+		if received < w.status.Offset {
+			return fmt.Errorf("the registry lost the bytes %d-%d of the upload of %s", received, w.status.Offset, w.desc.Digest)
+		}
+		// a part of the chunk may have been received
+		k := int(min(received-w.status.Offset, int64(n)))
+		w.consume(k)
+		n -= k
+		if n == 0 && !final {
+			// the whole chunk was received, an empty PATCH request would have an invalid range
+			return nil
+		}
+	}
+}
+
+// request sends the first n bytes of the buffer with a PATCH request,
+// or with the PUT request finishing the upload if final is true.
+func (w *chunkedWriter) request(n int, final bool) error {
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	method, u, expected := http.MethodPatch, *w.location, http.StatusAccepted
+	if final {
+		q := u.Query()
+		q.Set("digest", w.desc.Digest.String())
+		u.RawQuery = q.Encode()
+		method, expected = http.MethodPut, http.StatusCreated
+	} else {
+		header.Set("Content-Range", fmt.Sprintf("%d-%d", w.status.Offset, w.status.Offset+int64(n)-1))
+	}
+	resp, err := w.p.do(w.ctx, method, u.String(), w.buf[:n], header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expected {
+		return remoteerrors.NewUnexpectedStatusErr(resp)
+	}
+	if !final {
+		if w.location, err = resolveLocation(resp); err != nil {
+			return err
+		}
+	}
+	w.consume(n)
+	return nil
+}
+
+// received returns the number of bytes received by the registry.
+func (w *chunkedWriter) received() (int64, error) {
+	resp, err := w.p.do(w.ctx, http.MethodGet, w.location.String(), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return 0, remoteerrors.NewUnexpectedStatusErr(resp)
+	}
+	if location, err := resolveLocation(resp); err == nil {
+		w.location = location
+	}
+	// "Range: 0-<end>", with an inclusive end
+	r := resp.Header.Get("Range")
+	if r == "" {
+		return 0, nil
+	}
+	_, end, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid upload range %q", r)
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid upload range %q: %w", r, err)
+	}
+	return last + 1, nil
+}
+
+func (w *chunkedWriter) consume(n int) {
+	w.status.Offset += int64(n)
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+}
+
+// chunkRejected returns whether err is a client error of the registry, which does not support chunked uploads.
+func chunkRejected(err error) bool {
+	var status remoteerrors.ErrUnexpectedStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	switch status.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusRequestedRangeNotSatisfiable:
+		return false
+	case http.StatusNotImplemented:
+		return true
+	}
+	return status.StatusCode >= 400 && status.StatusCode < 500
+}
+
+func (w *chunkedWriter) Commit(ctx context.Context, size int64, expected digest.Digest, opts ...content.Opt) error {
+	if w.fallback != nil {
+		if err := w.fallback.Commit(ctx, size, expected, opts...); err != nil {
+			return err
+		}
+		w.status.Offset, w.status.Committed, w.status.UploadUUID = w.desc.Size, true, ""
+		w.updateStatus()
+		return nil
+	}
+	if total := w.status.Offset + int64(len(w.buf)); size > 0 && size != total {
+		return fmt.Errorf("unexpected size %d, expected %d: %w", total, size, errdefs.ErrFailedPrecondition)
+	}
+	if expected != "" && w.digester.Digest() != expected {
+		return fmt.Errorf("unexpected digest %v, expected %v: %w", w.digester.Digest(), expected, errdefs.ErrFailedPrecondition)
+	}
+	if err := w.send(len(w.buf), true); err != nil {
+		return err
+	}
+	w.status.Committed = true
+	w.status.UploadUUID = ""
+	w.updateStatus()
+	return nil
+}
+
+func (w *chunkedWriter) Close() error {
+	if w.fallback != nil {
+		return w.fallback.Close()
+	}
+	if !w.status.Committed {
+		// allow the next attempt to push the blob again
+		w.status.ErrClosed = errors.New("closed")
+		w.updateStatus()
+	}
+	return nil
+}
+
+func (w *chunkedWriter) Status() (content.Status, error) {
+	if w.fallback != nil {
+		return w.fallback.Status()
+	}
+	status := w.status.Status
+	status.Offset += int64(len(w.buf))
+	return status, nil
+}
+
+func (w *chunkedWriter) Digest() digest.Digest {
+	if w.fallback != nil {
+		return w.fallback.Digest()
+	}
+	return w.digester.Digest()
+}
+
+func (w *chunkedWriter) Truncate(size int64) error {
+	if w.fallback != nil {
+		return w.fallback.Truncate(size)
+	}
+	if size != 0 || w.status.Offset != 0 {
+		return fmt.Errorf("cannot truncate the upload of %s: %w", w.desc.Digest, errdefs.ErrNotImplemented)
+	}
+	w.buf = nil
+	w.digester = digest.Canonical.Digester()
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+)
+
+// uploadRegistry accepts blob uploads, in chunks or in a single request.
+type uploadRegistry struct {
+	// noChunks makes the registry reject the PATCH requests
+	noChunks bool
+	// failAt makes the registry keep only the first half of the PATCH request number failAt, and fail it
+	failAt int
+	// keepFailed makes the registry keep the whole PATCH request number failAt, and still fail it
+	keepFailed bool
+
+	mu      sync.Mutex
+	upload  []byte
+	patches []string
+	deletes int
+	blobs   map[digest.Digest][]byte
+}
+
+func newUploadRegistry(t *testing.T, noChunks bool, failAt int) (*uploadRegistry, string) {
+	r := &uploadRegistry{noChunks: noChunks, failAt: failAt, blobs: make(map[digest.Digest][]byte)}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (r *uploadRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	const uploads = "/v2/test/app/blobs/uploads/"
+	location := uploads + "0123"
+	switch {
+	case req.Method == http.MethodHead && strings.HasPrefix(req.URL.Path, "/v2/test/app/blobs/"):
+		if _, ok := r.blobs[digest.Digest(strings.TrimPrefix(req.URL.Path, "/v2/test/app/blobs/"))]; !ok {
+			http.NotFound(w, req)
+		}
+	case req.Method == http.MethodPost && req.URL.Path == uploads:
+		r.upload = nil
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPatch && req.URL.Path == location:
+		if r.noChunks {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.patches = append(r.patches, req.Header.Get("Content-Range"))
+		if req.Header.Get("Content-Range") != fmt.Sprintf("%d-%d", len(r.upload), len(r.upload)+int(req.ContentLength)-1) {
+			http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		b, _ := io.ReadAll(req.Body)
+		if len(r.patches) == r.failAt {
+			if r.keepFailed {
+				r.upload = append(r.upload, b...)
+			} else {
+				r.upload = append(r.upload, b[:len(b)/2]...)
+			}
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		r.upload = append(r.upload, b...)
+		w.Header().Set("Location", location)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.upload)-1))
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodGet && req.URL.Path == location:
+		w.Header().Set("Location", location)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.upload)-1))
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodDelete && req.URL.Path == location:
+		r.upload = nil
+		r.deletes++
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodPut && req.URL.Path == location:
+		b, _ := io.ReadAll(req.Body)
+		r.upload = append(r.upload, b...)
+		dgst := digest.Digest(req.URL.Query().Get("digest"))
+		if digest.FromBytes(r.upload) != dgst {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		r.blobs[dgst] = r.upload
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestChunkedUpload(t *testing.T) {
+	t.Parallel()
+	blob := bytes.Repeat([]byte("0123456789abcdef"), 256)
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+	ctx := context.Background()
+
+	testCases := []struct {
+		name       string
+		noChunks   bool
+		failAt     int
+		keepFailed bool
+		patches    []string
+		retries    []int
+		deletes    int
+	}{
+		{
+			name:    "chunked",
+			patches: []string{"0-1023", "1024-2047", "2048-3071"},
+		},
+		{
+			name:    "resumed",
+			failAt:  2,
+			patches: []string{"0-1023", "1024-2047", "1536-2047", "2048-3071"},
+			retries: []int{1},
+		},
+		{
+			// the registry received the whole chunk of the failed request, so it is not sent again
+			name:       "resumed after the whole chunk",
+			failAt:     2,
+			keepFailed: true,
+			patches:    []string{"0-1023", "1024-2047", "2048-3071"},
+			retries:    []int{1},
+		},
+		{
+			// the chunked upload is cancelled before uploading the blob in a single request
+			name:     "monolithic",
+			noChunks: true,
+			deletes:  1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			reg, host := newUploadRegistry(t, tc.noChunks, tc.failAt)
+			reg.keepFailed = tc.keepFailed
+			tracker := docker.NewInMemoryTracker()
+			resolver, err := New(ctx, host, WithAuthCreds(noCreds), WithTracker(tracker), WithMaxRetries(2))
+			assert.NilError(t, err)
+			resolver.(*failoverResolver).retries.backoff = noBackoff
+			pusher, err := resolver.Pusher(ctx, host+"/test/app:latest")
+			assert.NilError(t, err)
+			pusher.(*chunkedPusher).chunkSize = 1024
+
+			o := &observer{}
+			ctx := WithTransferObserver(ctx, o)
+			w, err := pusher.(content.Ingester).Writer(ctx, content.WithRef("layer"), content.WithDescriptor(desc))
+			assert.NilError(t, err)
+			assert.NilError(t, content.Copy(ctx, w, bytes.NewReader(blob), desc.Size, desc.Digest))
+			assert.NilError(t, w.Close())
+
+			assert.Assert(t, bytes.Equal(reg.blobs[desc.Digest], blob))
+			assert.DeepEqual(t, reg.patches, tc.patches)
+			assert.DeepEqual(t, o.retries, tc.retries)
+			assert.Equal(t, reg.deletes, tc.deletes)
+			status, err := tracker.GetStatus("layer")
+			assert.NilError(t, err)
+			assert.Assert(t, status.Committed)
+			assert.Equal(t, status.Offset, desc.Size)
+
+			// the blob now exists in the repository
+			_, err = pusher.(content.Ingester).Writer(ctx, content.WithRef("layer2"), content.WithDescriptor(desc))
+			assert.ErrorContains(t, err, "already exists")
+		})
+	}
+}
@@ -0,0 +1,309 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package imagediff compares the configs, the layers and the filesystems of images,
+// and computes the blobs shared between images.
+package imagediff
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/nerdctl/v2/pkg/tarutil"
+)
+
+// Kind is the kind of a change, like `nerdctl diff`.
+type Kind string
+
+const (
+	Added   Kind = "A"
+	Changed Kind = "C"
+	Deleted Kind = "D"
+)
+
+// ConfigChange is a change of the config of an image.
+type ConfigChange struct {
+	Kind Kind
+	// Field is the field of the config, e.g., "Env"
+	Field string
+	// Key is the key of the changed entry for Env, Labels, ExposedPorts and Volumes, e.g., the name of a variable
+	Key string `json:",omitempty"`
+	Old string `json:",omitempty"`
+	New string `json:",omitempty"`
+}
+
+// DiffConfigs compares the configs of two images.
+func DiffConfigs(a, b ocispec.ImageConfig) []ConfigChange {
+	var changes []ConfigChange
+	scalar := func(field, old, new string) {
+		switch {
+		case old == new:
+		case old == "":
+			changes = append(changes, ConfigChange{Kind: Added, Field: field, New: new})
+		case new == "":
+			changes = append(changes, ConfigChange{Kind: Deleted, Field: field, Old: old})
+		default:
+			changes = append(changes, ConfigChange{Kind: Changed, Field: field, Old: old, New: new})
+		}
+	}
+	keyed := func(field string, old, new map[string]string) {
+		for _, k := range slices.Sorted(maps.Keys(mergeKeys(old, new))) {
+			o, inOld := old[k]
+			n, inNew := new[k]
+			switch {
+			case !inOld:
+				changes = append(changes, ConfigChange{Kind: Added, Field: field, Key: k, New: n})
+			case !inNew:
+				changes = append(changes, ConfigChange{Kind: Deleted, Field: field, Key: k, Old: o})
+			case o != n:
+				changes = append(changes, ConfigChange{Kind: Changed, Field: field, Key: k, Old: o, New: n})
+			}
+		}
+	}
+	scalar("Entrypoint", marshalArgs(a.Entrypoint), marshalArgs(b.Entrypoint))
+	scalar("Cmd", marshalArgs(a.Cmd), marshalArgs(b.Cmd))
+	keyed("Env", envMap(a.Env), envMap(b.Env))
+	keyed("Labels", a.Labels, b.Labels)
+	keyed("ExposedPorts", setMap(a.ExposedPorts), setMap(b.ExposedPorts))
+	keyed("Volumes", setMap(a.Volumes), setMap(b.Volumes))
+	scalar("User", a.User, b.User)
+	scalar("WorkingDir", a.WorkingDir, b.WorkingDir)
+	scalar("StopSignal", a.StopSignal, b.StopSignal)
+	return changes
+}
+
+func marshalArgs(args []string) string {
+	if args == nil {
+		return ""
+	}
+	b, _ := json.Marshal(args)
+	return string(b)
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func setMap(set map[string]struct{}) map[string]string {
+	m := make(map[string]string, len(set))
+	for k := range set {
+		m[k] = ""
+	}
+	return m
+}
+
+func mergeKeys(a, b map[string]string) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+// LayerDiff is the comparison of the layers of two images.
+type LayerDiff struct {
+	// Shared are the layers of the second image present in the first one
+	Shared []ocispec.Descriptor
+	// Removed are the layers of the first image absent from the second one
+	Removed []ocispec.Descriptor
+	// Added are the layers of the second image absent from the first one
+	Added []ocispec.Descriptor
+}
+
+// DiffLayers compares the layers of two images, by digest.
+func DiffLayers(a, b []ocispec.Descriptor) LayerDiff {
+	var d LayerDiff
+	inA := digestSet(a)
+	inB := digestSet(b)
+	for _, l := range b {
+		if _, ok := inA[l.Digest]; ok {
+			d.Shared = append(d.Shared, l)
+		} else {
+			d.Added = append(d.Added, l)
+		}
+	}
+	for _, l := range a {
+		if _, ok := inB[l.Digest]; !ok {
+			d.Removed = append(d.Removed, l)
+		}
+	}
+	return d
+}
+
+// DownloadSize returns the bytes downloaded to pull the second image where the first one is present.
+func (d LayerDiff) DownloadSize() int64 {
+	return Size(d.Added)
+}
+
+// Size returns the total size of the descriptors, counting each digest once.
+func Size(descs []ocispec.Descriptor) int64 {
+	var size int64
+	seen := make(map[digest.Digest]struct{})
+	for _, desc := range descs {
+		if _, ok := seen[desc.Digest]; ok {
+			continue
+		}
+		seen[desc.Digest] = struct{}{}
+		size += desc.Size
+	}
+	return size
+}
+
+func digestSet(descs []ocispec.Descriptor) map[digest.Digest]struct{} {
+	set := make(map[digest.Digest]struct{}, len(descs))
+	for _, desc := range descs {
+		set[desc.Digest] = struct{}{}
+	}
+	return set
+}
+
+// File is an entry of the filesystem of an image.
+type File struct {
+	Type     byte
+	Mode     int64
+	UID      int
+	GID      int
+	Size     int64
+	Linkname string
+	// Digest is the digest of the content of the regular files
+	Digest digest.Digest
+}
+
+// Tree is the filesystem of an image, keyed by absolute paths (e.g., "/etc/passwd").
+type Tree map[string]File
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// Apply applies a layer tarball (possibly compressed) to the tree, with the OCI whiteouts.
+func (t Tree) Apply(r io.Reader) error {
+	// the opaque whiteouts only hide the entries of the lower layers
+	added := make(map[string]struct{})
+	var opaque []string
+	err := tarutil.Walk(r, func(hdr *tar.Header, r io.Reader) error {
+		p := path.Clean("/" + hdr.Name)
+		if p == "/" {
+			return nil
+		}
+		dir, base := path.Split(p)
+		switch {
+		case base == whiteoutOpaque:
+			opaque = append(opaque, path.Clean(dir))
+			return nil
+		case strings.HasPrefix(base, whiteoutPrefix):
+			t.remove(path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			return nil
+		}
+		f := File{
+			Type: hdr.Typeflag,
+			Mode: hdr.Mode,
+			UID:  hdr.Uid,
+			GID:  hdr.Gid,
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA: //nolint:staticcheck // TypeRegA is still found in old layers
+			f.Type = tar.TypeReg
+			f.Size = hdr.Size
+			dgst, err := digest.Canonical.FromReader(r)
+			if err != nil {
+				return err
+			}
+			f.Digest = dgst
+		case tar.TypeLink:
+			f.Linkname = path.Clean("/" + hdr.Linkname)
+		case tar.TypeSymlink:
+			f.Linkname = hdr.Linkname
+		}
+		// a non-directory replaces the whole directory of the lower layers
+		if old, ok := t[p]; ok && old.Type == tar.TypeDir && f.Type != tar.TypeDir {
+			t.remove(p)
+		}
+		t[p] = f
+		added[p] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, dir := range opaque {
+		prefix := strings.TrimSuffix(dir, "/") + "/"
+		for p := range t {
+			if _, ok := added[p]; !ok && strings.HasPrefix(p, prefix) {
+				delete(t, p)
+			}
+		}
+	}
+	return nil
+}
+
+// remove removes p and its children.
+func (t Tree) remove(p string) {
+	delete(t, p)
+	prefix := p + "/"
+	for k := range t {
+		if strings.HasPrefix(k, prefix) {
+			delete(t, k)
+		}
+	}
+}
+
+// FileChange is a change of the filesystem between two images.
+type FileChange struct {
+	Kind Kind
+	Path string
+}
+
+// DiffTrees compares the filesystems of two images, ignoring the modification times.
+// The entries are changed when their type, content, mode, owner or link target changed.
+func DiffTrees(a, b Tree) []FileChange {
+	var changes []FileChange
+	for p, fb := range b {
+		fa, ok := a[p]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Kind: Added, Path: p})
+		case fa != fb:
+			changes = append(changes, FileChange{Kind: Changed, Path: p})
+		}
+	}
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			changes = append(changes, FileChange{Kind: Deleted, Path: p})
+		}
+	}
+	slices.SortFunc(changes, func(x, y FileChange) int {
+		return strings.Compare(x.Path, y.Path)
+	})
+	return changes
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package imagediff
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	t.Parallel()
+	a := ocispec.ImageConfig{
+		Env:          []string{"PATH=/usr/bin", "FOO=1", "BAR=1"},
+		Entrypoint:   []string{"/bin/sh"},
+		Labels:       map[string]string{"version": "1"},
+		ExposedPorts: map[string]struct{}{"80/tcp": {}},
+	}
+	b := ocispec.ImageConfig{
+		Env:          []string{"PATH=/usr/bin", "FOO=2", "BAZ=1"},
+		Entrypoint:   []string{"/bin/sh"},
+		Cmd:          []string{"-c", "true"},
+		Labels:       map[string]string{"version": "2"},
+		ExposedPorts: map[string]struct{}{"443/tcp": {}},
+	}
+	assert.DeepEqual(t, DiffConfigs(a, b), []ConfigChange{
+		{Kind: Added, Field: "Cmd", New: `["-c","true"]`},
+		{Kind: Deleted, Field: "Env", Key: "BAR", Old: "1"},
+		{Kind: Added, Field: "Env", Key: "BAZ", New: "1"},
+		{Kind: Changed, Field: "Env", Key: "FOO", Old: "1", New: "2"},
+		{Kind: Changed, Field: "Labels", Key: "version", Old: "1", New: "2"},
+		{Kind: Added, Field: "ExposedPorts", Key: "443/tcp"},
+		{Kind: Deleted, Field: "ExposedPorts", Key: "80/tcp"},
+	})
+	assert.Assert(t, DiffConfigs(a, a) == nil)
+}
+
+func TestDiffLayers(t *testing.T) {
+	t.Parallel()
+	l1 := ocispec.Descriptor{Digest: digest.FromString("1"), Size: 10}
+	l2 := ocispec.Descriptor{Digest: digest.FromString("2"), Size: 20}
+	l3 := ocispec.Descriptor{Digest: digest.FromString("3"), Size: 30}
+	d := DiffLayers([]ocispec.Descriptor{l1, l2}, []ocispec.Descriptor{l1, l3, l3})
+	assert.DeepEqual(t, d.Shared, []ocispec.Descriptor{l1})
+	assert.DeepEqual(t, d.Removed, []ocispec.Descriptor{l2})
+	assert.DeepEqual(t, d.Added, []ocispec.Descriptor{l3, l3})
+	assert.Equal(t, d.DownloadSize(), int64(30))
+}
+
+type entry struct {
+	name     string
+	typeflag byte
+	content  string
+}
+
+func layer(t *testing.T, entries ...entry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: 0o644, Size: int64(len(e.content))}
+		if e.typeflag == tar.TypeDir {
+			hdr.Mode = 0o755
+		}
+		assert.NilError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	assert.NilError(t, gz.Close())
+	return &buf
+}
+
+func TestDiffTrees(t *testing.T) {
+	t.Parallel()
+	base := layer(t,
+		entry{name: "etc/", typeflag: tar.TypeDir},
+		entry{name: "etc/passwd", typeflag: tar.TypeReg, content: "root"},
+		entry{name: "etc/hosts", typeflag: tar.TypeReg, content: "localhost"},
+		entry{name: "var/", typeflag: tar.TypeDir},
+		entry{name: "var/cache/", typeflag: tar.TypeDir},
+		entry{name: "var/cache/a", typeflag: tar.TypeReg, content: "a"},
+		entry{name: "opt/", typeflag: tar.TypeDir},
+		entry{name: "opt/app", typeflag: tar.TypeReg, content: "v1"},
+	)
+	a := Tree{}
+	assert.NilError(t, a.Apply(bytes.NewReader(base.Bytes())))
+
+	b := Tree{}
+	assert.NilError(t, b.Apply(bytes.NewReader(base.Bytes())))
+	assert.NilError(t, b.Apply(layer(t,
+		entry{name: "etc/.wh.hosts", typeflag: tar.TypeReg},
+		entry{name: "etc/passwd", typeflag: tar.TypeReg, content: "root\nuser"},
+		entry{name: "var/cache/", typeflag: tar.TypeDir},
+		entry{name: "var/cache/.wh..wh..opq", typeflag: tar.TypeReg},
+		entry{name: "var/cache/b", typeflag: tar.TypeReg, content: "b"},
+		entry{name: "opt", typeflag: tar.TypeReg, content: "file"},
+	)))
+
+	assert.DeepEqual(t, DiffTrees(a, b), []FileChange{
+		{Kind: Deleted, Path: "/etc/hosts"},
+		{Kind: Changed, Path: "/etc/passwd"},
+		{Kind: Changed, Path: "/opt"},
+		{Kind: Deleted, Path: "/opt/app"},
+		{Kind: Deleted, Path: "/var/cache/a"},
+		{Kind: Added, Path: "/var/cache/b"},
+	})
+	assert.Assert(t, DiffTrees(a, a) == nil)
+}
+
+func TestComputeUsage(t *testing.T) {
+	t.Parallel()
+	base := ocispec.Descriptor{Digest: digest.FromString("base"), Size: 100}
+	app1 := ocispec.Descriptor{Digest: digest.FromString("app1"), Size: 10}
+	app2 := ocispec.Descriptor{Digest: digest.FromString("app2"), Size: 20}
+	images := []ImageBlobs{
+		{Target: digest.FromString("t1"), Blobs: []ocispec.Descriptor{base, app1}},
+		// another name for the same image
+		{Target: digest.FromString("t1"), Blobs: []ocispec.Descriptor{base, app1}},
+		{Target: digest.FromString("t2"), Blobs: []ocispec.Descriptor{base, app2, app2}},
+	}
+	assert.DeepEqual(t, ComputeUsage(images), []Usage{
+		{Size: 110, Shared: 100, Unique: 10},
+		{Size: 110, Shared: 100, Unique: 10},
+		{Size: 120, Shared: 100, Unique: 20},
+	})
+	assert.Equal(t, TotalSize(images), int64(130))
+}
@@ -0,0 +1,78 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package imagediff
+
+import (
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ImageBlobs are the blobs of an image present in the content store.
+type ImageBlobs struct {
+	// Target is the digest of the target of the image; the images with the same target are counted once
+	Target digest.Digest
+	Blobs  []ocispec.Descriptor
+}
+
+// Usage is the content store usage of an image.
+type Usage struct {
+	// Size is the size of all the blobs of the image
+	Size int64
+	// Shared is the size of the blobs also used by images with another target
+	Shared int64
+	// Unique is the size of the blobs only used by the image, i.e., freed when it is removed
+	Unique int64
+}
+
+// ComputeUsage computes the usage of each image, in the same order as images.
+func ComputeUsage(images []ImageBlobs) []Usage {
+	users := make(map[digest.Digest]map[digest.Digest]struct{})
+	for _, img := range images {
+		for _, b := range img.Blobs {
+			if users[b.Digest] == nil {
+				users[b.Digest] = make(map[digest.Digest]struct{})
+			}
+			users[b.Digest][img.Target] = struct{}{}
+		}
+	}
+	usage := make([]Usage, len(images))
+	for i, img := range images {
+		seen := make(map[digest.Digest]struct{}, len(img.Blobs))
+		for _, b := range img.Blobs {
+			if _, ok := seen[b.Digest]; ok {
+				continue
+			}
+			seen[b.Digest] = struct{}{}
+			usage[i].Size += b.Size
+			if len(users[b.Digest]) > 1 {
+				usage[i].Shared += b.Size
+			} else {
+				usage[i].Unique += b.Size
+			}
+		}
+	}
+	return usage
+}
+
+// TotalSize returns the size of the blobs of all the images, counting each blob once.
+func TotalSize(images []ImageBlobs) int64 {
+	var all []ocispec.Descriptor
+	for _, img := range images {
+		all = append(all, img.Blobs...)
+	}
+	return Size(all)
+}
@@ -43,7 +43,9 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/healthcheck"
 	"github.com/containerd/nerdctl/v2/pkg/idutil/imagewalker"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/policy"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil/pull"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/referrers"
 	"github.com/containerd/nerdctl/v2/pkg/labels"
 	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
 )
@@ -62,6 +64,11 @@ type PullMode = string
 
 // GetExistingImage returns the specified image if exists in containerd. Return errdefs.NotFound() if not exists.
 func GetExistingImage(ctx context.Context, client *containerd.Client, snapshotter, rawRef string, platform ocispec.Platform) (*EnsuredImage, error) {
+	return getExistingImage(ctx, client, snapshotter, rawRef, platform, nil)
+}
+
+// getExistingImage is GetExistingImage, verifying the image with check (if not nil) before unpacking it.
+func getExistingImage(ctx context.Context, client *containerd.Client, snapshotter, rawRef string, platform ocispec.Platform, check func(*EnsuredImage) error) (*EnsuredImage, error) {
 	var res *EnsuredImage
 	imgwalker := &imagewalker.ImageWalker{
 		Client: client,
@@ -83,6 +90,11 @@ func GetExistingImage(ctx context.Context, client *containerd.Client, snapshotte
 				Snapshotter: snapshotter,
 				Remote:      getSnapshotterOpts(snapshotter).isRemote(),
 			}
+			if check != nil {
+				if err := check(res); err != nil {
+					return err
+				}
+			}
 			if unpacked, err := image.IsUnpacked(ctx, snapshotter); err == nil && !unpacked {
 				if err := image.Unpack(ctx, snapshotter); err != nil {
 					return err
@@ -115,9 +127,18 @@ func EnsureImage(ctx context.Context, client *containerd.Client, rawRef string,
 		return nil, fmt.Errorf("unexpected pull mode: %q", options.Mode)
 	}
 
+	pol, err := loadPolicy(options.GOptions)
+	if err != nil {
+		return nil, err
+	}
+
 	// if not `always` pull and given one platform and image found locally, return existing image directly.
 	if options.Mode != "always" && len(options.OCISpecPlatform) == 1 {
-		if res, err := GetExistingImage(ctx, client, options.GOptions.Snapshotter, rawRef, options.OCISpecPlatform[0]); err == nil {
+		// the images already present in the store are verified too, as the policy may have changed since they were pulled
+		check := func(res *EnsuredImage) error {
+			return checkPolicy(ctx, pol, res)
+		}
+		if res, err := getExistingImage(ctx, client, options.GOptions.Snapshotter, rawRef, options.OCISpecPlatform[0], check); err == nil {
 			return res, nil
 		} else if !errdefs.IsNotFound(err) {
 			return nil, err
@@ -133,40 +154,131 @@ func EnsureImage(ctx context.Context, client *containerd.Client, rawRef string,
 		return nil, err
 	}
 
+	if pol != nil {
+		// deny rejected images without contacting the registry
+		if err := pol.CheckName(ctx, parsedReference.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	return pullImageWithFallback(ctx, client, parsedReference, pol, options)
+}
+
+// loadPolicy loads the image trust policy configured with `image_policy` in nerdctl.toml.
+// It returns nil when no policy is configured.
+func loadPolicy(gOptions types.GlobalCommandOptions) (*policy.Policy, error) {
+	if gOptions.ImagePolicy == "" {
+		return nil, nil
+	}
+	return policy.Load(gOptions.ImagePolicy, dockerconfigresolver.WithHostsDirs(gOptions.HostsDir), dockerconfigresolver.WithSkipVerifyCerts(gOptions.InsecureRegistry))
+}
+
+func checkPolicy(ctx context.Context, pol *policy.Policy, ensured *EnsuredImage) error {
+	if pol == nil {
+		return nil
+	}
+	return pol.Check(ctx, ensured.Ref, ensured.Image.Target().Digest)
+}
+
+// policyResolver verifies the resolved images against the image trust policy, before anything is fetched,
+// so that the denied images are not downloaded, and the images pulled are the ones verified.
+type policyResolver struct {
+	remotes.Resolver
+	pol *policy.Policy
+}
+
+func withPolicy(resolver remotes.Resolver, pol *policy.Policy) remotes.Resolver {
+	if pol == nil {
+		return resolver
+	}
+	return &policyResolver{Resolver: resolver, pol: pol}
+}
+
+func (r *policyResolver) Resolve(ctx context.Context, ref string) (string, ocispec.Descriptor, error) {
+	name, desc, err := r.Resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", ocispec.Descriptor{}, err
+	}
+	if err := r.pol.Check(ctx, ref, desc.Digest); err != nil {
+		return "", ocispec.Descriptor{}, err
+	}
+	return name, desc, nil
+}
+
+// CheckPolicy verifies the image against the image trust policy configured with `image_policy` in nerdctl.toml.
+// It is a no-op when no policy is configured.
+// EnsureImage already verifies the images, so it only needs to be called for the images ensured by other means, e.g., IPFS.
+func CheckPolicy(ctx context.Context, ensured *EnsuredImage, gOptions types.GlobalCommandOptions) error {
+	pol, err := loadPolicy(gOptions)
+	if err != nil {
+		return err
+	}
+	return checkPolicy(ctx, pol, ensured)
+}
+
+func pullImageWithFallback(ctx context.Context, client *containerd.Client, parsedReference *referenceutil.ImageReference, pol *policy.Policy, options types.ImagePullOptions) (*EnsuredImage, error) {
 	var dOpts []dockerconfigresolver.Opt
 	if options.GOptions.InsecureRegistry {
 		log.G(ctx).Warnf("skipping verifying HTTPS certs for %q", parsedReference.Domain)
 		dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
 	}
-	dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.GOptions.HostsDir))
+	dOpts = append(dOpts, dockerconfigresolver.WithHostsDirs(options.GOptions.HostsDir), dockerconfigresolver.WithRegistryMirrors(options.GOptions.RegistryMirrors),
+		dockerconfigresolver.WithMaxRetries(options.MaxRetries), dockerconfigresolver.WithLayerTimeout(options.LayerTimeout))
 	resolver, err := dockerconfigresolver.New(ctx, parsedReference.Domain, dOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	img, err := PullImage(ctx, client, resolver, parsedReference.String(), options)
+	img, err := PullImage(ctx, client, withPolicy(resolver, pol), parsedReference.String(), options)
 	if err != nil {
 		// In some circumstance (e.g. people just use 80 port to support pure http), the error will contain message like "dial tcp <port>: connection refused".
 		if !errors.Is(err, http.ErrSchemeMismatch) && !errutil.IsErrConnectionRefused(err) {
 			return nil, err
 		}
-		if options.GOptions.InsecureRegistry {
-			log.G(ctx).WithError(err).Warnf("server %q does not seem to support HTTPS, falling back to plain HTTP", parsedReference.Domain)
-			dOpts = append(dOpts, dockerconfigresolver.WithPlainHTTP(true))
-			resolver, err = dockerconfigresolver.New(ctx, parsedReference.Domain, dOpts...)
-			if err != nil {
-				return nil, err
-			}
-			return PullImage(ctx, client, resolver, parsedReference.String(), options)
+		if !options.GOptions.InsecureRegistry {
+			log.G(ctx).WithError(err).Errorf("server %q does not seem to support HTTPS", parsedReference.Domain)
+			log.G(ctx).Info("Hint: you may want to try --insecure-registry to allow plain HTTP (if you are in a trusted network)")
+			return nil, err
+		}
+		log.G(ctx).WithError(err).Warnf("server %q does not seem to support HTTPS, falling back to plain HTTP", parsedReference.Domain)
+		dOpts = append(dOpts, dockerconfigresolver.WithPlainHTTP(true))
+		resolver, err = dockerconfigresolver.New(ctx, parsedReference.Domain, dOpts...)
+		if err != nil {
+			return nil, err
+		}
+		img, err = PullImage(ctx, client, withPolicy(resolver, pol), parsedReference.String(), options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.WithReferrers {
+		if err := fetchReferrers(ctx, client, resolver, parsedReference, img.Image.Target(), dOpts); err != nil {
+			return nil, fmt.Errorf("failed to fetch the referrers of %s: %w", parsedReference, err)
 		}
-		log.G(ctx).WithError(err).Errorf("server %q does not seem to support HTTPS", parsedReference.Domain)
-		log.G(ctx).Info("Hint: you may want to try --insecure-registry to allow plain HTTP (if you are in a trusted network)")
-		return nil, err
-
 	}
 	return img, nil
 }
 
+// fetchReferrers fetches the OCI referrers (e.g., SBOMs, signatures) of the pulled image into the content store.
+func fetchReferrers(ctx context.Context, client *containerd.Client, resolver remotes.Resolver, parsedReference *referenceutil.ImageReference, target ocispec.Descriptor, dOpts []dockerconfigresolver.Opt) error {
+	ctx, done, err := client.WithLease(ctx)
+	if err != nil {
+		return err
+	}
+	defer done(ctx)
+
+	hosts, err := dockerconfigresolver.NewRegistryHosts(ctx, parsedReference.Domain, dOpts...)
+	if err != nil {
+		return err
+	}
+	descs, err := referrers.Fetch(ctx, client.ContentStore(), hosts, resolver, parsedReference.Name(), target.Digest)
+	if err != nil {
+		return err
+	}
+	log.G(ctx).Infof("fetched %d referrer(s) of %s", len(descs), target.Digest)
+	return nil
+}
+
 // ResolveDigest resolves `rawRef` and returns its descriptor digest.
 func ResolveDigest(ctx context.Context, rawRef string, insecure bool, hostsDirs []string) (string, error) {
 	parsedReference, err := referenceutil.Parse(rawRef)
@@ -223,6 +335,10 @@ func PullImage(ctx context.Context, client *containerd.Client, resolver remotes.
 		}
 	}
 
+	if options.MaxConcurrentDownloads > 0 {
+		config.RemoteOpts = append(config.RemoteOpts, containerd.WithMaxConcurrentDownloads(options.MaxConcurrentDownloads))
+	}
+
 	snOpt := getSnapshotterOpts(options.GOptions.Snapshotter)
 	if unpackB {
 		log.G(ctx).Debugf("The image will be unpacked for platform %q, snapshotter %q.", options.OCISpecPlatform[0], options.GOptions.Snapshotter)
@@ -425,55 +541,6 @@ func UnpackedImageSize(ctx context.Context, s snapshots.Snapshotter, img contain
 	return total.Size, err
 }
 
-// GetUnusedImages returns the list of all images which are not referenced by a container.
-func GetUnusedImages(ctx context.Context, client *containerd.Client, filters ...Filter) ([]images.Image, error) {
-	var (
-		imageStore     = client.ImageService()
-		containerStore = client.ContainerService()
-	)
-
-	containers, err := containerStore.List(ctx)
-	if err != nil {
-		return []images.Image{}, err
-	}
-
-	usedImages := make(map[string]struct{})
-	for _, container := range containers {
-		usedImages[container.Image] = struct{}{}
-	}
-
-	allImages, err := imageStore.List(ctx)
-	if err != nil {
-		return []images.Image{}, err
-	}
-
-	unusedImages := make([]images.Image, 0, len(allImages))
-	for _, image := range allImages {
-		if _, ok := usedImages[image.Name]; ok {
-			continue
-		}
-		unusedImages = append(unusedImages, image)
-	}
-
-	return ApplyFilters(unusedImages, filters...)
-}
-
-// GetDanglingImages returns the list of all images which are not tagged.
-func GetDanglingImages(ctx context.Context, client *containerd.Client, filters ...Filter) ([]images.Image, error) {
-	var (
-		imageStore = client.ImageService()
-	)
-
-	allImages, err := imageStore.List(ctx)
-	if err != nil {
-		return []images.Image{}, err
-	}
-
-	filters = append([]Filter{FilterDanglingImages()}, filters...)
-
-	return ApplyFilters(allImages, filters...)
-}
-
 // addHealthCheckToImageConfig extracts health check information from the image content store and adds it to the labels
 func addHealthCheckToImageConfig(rawConfigContent []byte, config *ocispec.ImageConfig) error {
 	var imgConfig struct {
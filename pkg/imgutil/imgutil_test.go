@@ -17,9 +17,18 @@
 package imgutil
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/policy"
 )
 
 func TestParseRepoTag(t *testing.T) {
@@ -57,3 +66,38 @@ func TestParseRepoTag(t *testing.T) {
 		assert.Equal(t, tc.tag, tag)
 	}
 }
+
+// fakeResolver resolves all the references to the same manifest, and cannot fetch anything.
+type fakeResolver struct {
+	remotes.Resolver
+	desc ocispec.Descriptor
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, ref string) (string, ocispec.Descriptor, error) {
+	return ref, r.desc, nil
+}
+
+func TestPolicyResolver(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "policy.json")
+	assert.NilError(t, os.WriteFile(p, []byte(`{
+  "default": [{"type": "reject"}],
+  "transports": {"docker": {"docker.io/library": [{"type": "insecureAcceptAnything"}]}}
+}`), 0o644))
+	pol, err := policy.Load(p)
+	assert.NilError(t, err)
+
+	resolver := &fakeResolver{desc: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, Digest: digest.FromString("index")}}
+	assert.Equal(t, withPolicy(resolver, nil), remotes.Resolver(resolver))
+
+	ctx := context.Background()
+	name, desc, err := withPolicy(resolver, pol).Resolve(ctx, "docker.io/library/alpine:latest")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "docker.io/library/alpine:latest")
+	assert.Equal(t, desc.Digest, resolver.desc.Digest)
+
+	// the denied images are not fetched
+	_, _, err = withPolicy(resolver, pol).Resolve(ctx, "registry.example.com/app:latest")
+	assert.ErrorIs(t, err, policy.ErrDenied)
+}
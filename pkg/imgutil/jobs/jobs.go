@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
@@ -39,6 +40,16 @@ import (
 //
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L219-L336
 func ShowProgress(ctx context.Context, ongoing *Jobs, cs content.Store, out io.Writer) {
+	showProgress(ctx, func() []*Jobs { return []*Jobs{ongoing} }, cs, out)
+}
+
+// ShowGroupProgress is like ShowProgress, but renders all the Jobs registered to
+// the group in a single display, so that concurrent pulls do not overwrite each other.
+func ShowGroupProgress(ctx context.Context, g *Group, cs content.Store, out io.Writer) {
+	showProgress(ctx, g.List, cs, out)
+}
+
+func showProgress(ctx context.Context, list func() []*Jobs, cs content.Store, out io.Writer) {
 	var (
 		ticker   = time.NewTicker(100 * time.Millisecond)
 		fw       = progress.NewWriter(out)
@@ -56,15 +67,24 @@ outer:
 
 			tw := tabwriter.NewWriter(fw, 1, 8, 1, ' ', 0)
 
-			resolved := StatusResolved
-			if !ongoing.IsResolved() {
-				resolved = StatusResolving
-			}
-			statuses[ongoing.name] = StatusInfo{
-				Ref:    ongoing.name,
-				Status: resolved,
+			var (
+				keys     []string
+				keysSeen = map[string]struct{}{}
+			)
+			for _, ongoing := range list() {
+				resolved := StatusResolved
+				if !ongoing.IsResolved() {
+					resolved = StatusResolving
+				}
+				statuses[ongoing.name] = StatusInfo{
+					Ref:    ongoing.name,
+					Status: resolved,
+				}
+				if _, ok := keysSeen[ongoing.name]; !ok {
+					keys = append(keys, ongoing.name)
+					keysSeen[ongoing.name] = struct{}{}
+				}
 			}
-			keys := []string{ongoing.name}
 
 			activeSeen := map[string]struct{}{}
 			if !done {
@@ -88,9 +108,28 @@ outer:
 			}
 
 			// now, update the items in jobs that are not in active
-			for _, j := range ongoing.Jobs() {
+			var descs []ocispec.Descriptor
+			hosts := map[string]string{}
+			retries := map[string]int{}
+			for _, ongoing := range list() {
+				for _, j := range ongoing.Jobs() {
+					if host := ongoing.Host(j.Digest); host != "" {
+						hosts[remotes.MakeRefKey(ctx, j)] = host
+					}
+					if n := ongoing.Retries(j.Digest); n > 0 {
+						retries[remotes.MakeRefKey(ctx, j)] = n
+					}
+					descs = append(descs, j)
+				}
+			}
+			for _, j := range descs {
 				key := remotes.MakeRefKey(ctx, j)
+				// layers shared between images of the group are displayed only once
+				if _, ok := keysSeen[key]; ok {
+					continue
+				}
 				keys = append(keys, key)
+				keysSeen[key] = struct{}{}
 				if _, ok := activeSeen[key]; ok {
 					continue
 				}
@@ -138,7 +177,10 @@ outer:
 
 			var ordered []StatusInfo
 			for _, key := range keys {
-				ordered = append(ordered, statuses[key])
+				status := statuses[key]
+				status.Host = hosts[key]
+				status.Retries = retries[key]
+				ordered = append(ordered, status)
 			}
 
 			Display(tw, ordered, start)
@@ -165,6 +207,8 @@ type Jobs struct {
 	name     string
 	added    map[digest.Digest]struct{}
 	descs    []ocispec.Descriptor
+	hosts    map[digest.Digest]string
+	retries  map[digest.Digest]int
 	mu       sync.Mutex
 	resolved bool
 }
@@ -173,8 +217,10 @@ type Jobs struct {
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L351-L357
 func New(name string) *Jobs {
 	return &Jobs{
-		name:  name,
-		added: map[digest.Digest]struct{}{},
+		name:    name,
+		added:   map[digest.Digest]struct{}{},
+		hosts:   map[digest.Digest]string{},
+		retries: map[digest.Digest]int{},
 	}
 }
 
@@ -202,6 +248,35 @@ func (j *Jobs) Jobs() []ocispec.Descriptor {
 	return append(descs, j.descs...)
 }
 
+// Served records the registry host that served the descriptor, to be displayed with its status.
+// Served and Retrying implement dockerconfigresolver.TransferObserver.
+func (j *Jobs) Served(desc ocispec.Descriptor, host string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.hosts[desc.Digest] = host
+}
+
+// Retrying records that the descriptor is being retried, to be displayed with its status.
+func (j *Jobs) Retrying(desc ocispec.Descriptor, attempt int, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.retries[desc.Digest] = attempt
+}
+
+// Retries returns how many times the descriptor was retried.
+func (j *Jobs) Retries(dgst digest.Digest) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.retries[dgst]
+}
+
+// Host returns the registry host that served the descriptor, or an empty string.
+func (j *Jobs) Host(dgst digest.Digest) string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.hosts[dgst]
+}
+
 // IsResolved checks whether a descriptor has been resolved.
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L381-L386
 func (j *Jobs) IsResolved() bool {
@@ -210,6 +285,45 @@ func (j *Jobs) IsResolved() bool {
 	return j.resolved
 }
 
+// Group is a set of Jobs displayed together by ShowGroupProgress.
+type Group struct {
+	mu   sync.Mutex
+	jobs []*Jobs
+}
+
+// NewGroup creates an empty group.
+func NewGroup() *Group {
+	return &Group{}
+}
+
+// Add registers j to the group.
+func (g *Group) Add(j *Jobs) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.jobs = append(g.jobs, j)
+}
+
+// List returns the Jobs registered to the group.
+func (g *Group) List() []*Jobs {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]*Jobs{}, g.jobs...)
+}
+
+type groupKey struct{}
+
+// WithGroup returns a context that makes pull.Pull register its Jobs to g
+// instead of rendering its own progress display.
+func WithGroup(ctx context.Context, g *Group) context.Context {
+	return context.WithValue(ctx, groupKey{}, g)
+}
+
+// GroupFromContext returns the group set by WithGroup, or nil.
+func GroupFromContext(ctx context.Context) *Group {
+	g, _ := ctx.Value(groupKey{}).(*Group)
+	return g
+}
+
 // StatusInfoStatus describes status info for an upload or download.
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L388-L400
 type StatusInfoStatus string
@@ -234,6 +348,10 @@ type StatusInfo struct {
 	Total     int64
 	StartedAt time.Time
 	UpdatedAt time.Time
+	// Host is the registry host that served the content, if known
+	Host string
+	// Retries is how many times the transfer was retried
+	Retries int
 }
 
 // Display pretty prints out the download or upload progress.
@@ -248,11 +366,12 @@ func Display(w io.Writer, statuses []StatusInfo, start time.Time) {
 			if status.Total > 0.0 {
 				bar = progress.Bar(float64(status.Offset) / float64(status.Total))
 			}
-			fmt.Fprintf(w, "%s:\t%s\t%40r\t%8.8s/%s\t\n",
+			fmt.Fprintf(w, "%s:\t%s\t%40r\t%8.8s/%s\t%s\n",
 				status.Ref,
 				status.Status,
 				bar,
-				progress.Bytes(status.Offset), progress.Bytes(status.Total))
+				progress.Bytes(status.Offset), progress.Bytes(status.Total),
+				noteColumn(status))
 		case StatusResolving, StatusWaiting:
 			bar := progress.Bar(0.0)
 			fmt.Fprintf(w, "%s:\t%s\t%40r\t\n",
@@ -261,10 +380,11 @@ func Display(w io.Writer, statuses []StatusInfo, start time.Time) {
 				bar)
 		default:
 			bar := progress.Bar(1.0)
-			fmt.Fprintf(w, "%s:\t%s\t%40r\t\n",
+			fmt.Fprintf(w, "%s:\t%s\t%40r\t%s\n",
 				status.Ref,
 				status.Status,
-				bar)
+				bar,
+				noteColumn(status))
 		}
 	}
 
@@ -278,3 +398,18 @@ func Display(w io.Writer, statuses []StatusInfo, start time.Time) {
 		progress.Bytes(total),
 		progress.NewBytesPerSecond(total, time.Since(start)))
 }
+
+// noteColumn returns the column with the retries and the registry host of the status, if any.
+func noteColumn(status StatusInfo) string {
+	var notes []string
+	if status.Retries > 0 {
+		notes = append(notes, fmt.Sprintf("retry %d", status.Retries))
+	}
+	if status.Host != "" {
+		notes = append(notes, "from "+status.Host)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return strings.Join(notes, ", ") + "\t"
+}
@@ -0,0 +1,49 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package imgutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/images"
+
+	"github.com/containerd/nerdctl/v2/pkg/labels"
+)
+
+// MarkUsed records now as the last time the image name was used by a container, in the label labels.ImageLastUsed.
+func MarkUsed(ctx context.Context, is images.Store, name string, now time.Time) error {
+	img := images.Image{
+		Name: name,
+		Labels: map[string]string{
+			labels.ImageLastUsed: now.UTC().Format(time.RFC3339Nano),
+		},
+	}
+	_, err := is.Update(ctx, img, "labels."+labels.ImageLastUsed)
+	return err
+}
+
+// LastUsed returns the last time the image was used by a container, or the time the image was created
+// when it has never been used.
+func LastUsed(img images.Image) time.Time {
+	if s, ok := img.Labels[labels.ImageLastUsed]; ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t
+		}
+	}
+	return img.CreatedAt
+}
@@ -32,18 +32,29 @@ import (
 
 	"github.com/containerd/nerdctl/v2/pkg/api/types"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/ocilayout"
 	"github.com/containerd/nerdctl/v2/pkg/platformutil"
 )
 
 // FromArchive loads and unpacks the images from the tar archive specified in image load options.
+// The archive may be compressed with gzip or zstd. The input may also be an OCI image layout directory.
 func FromArchive(ctx context.Context, client *containerd.Client, options types.ImageLoadOptions) ([]images.Image, error) {
 	if options.Input != "" {
-		f, err := os.Open(options.Input)
-		if err != nil {
-			return nil, err
+		if st, err := os.Stat(options.Input); err == nil && st.IsDir() {
+			if !ocilayout.IsLayout(options.Input) {
+				return nil, fmt.Errorf("%s is a directory, but not an OCI image layout", options.Input)
+			}
+			rc := ocilayout.Tar(options.Input)
+			defer rc.Close()
+			options.Stdin = rc
+		} else {
+			f, err := os.Open(options.Input)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			options.Stdin = f
 		}
-		defer f.Close()
-		options.Stdin = f
 	} else {
 		// check if stdin is empty.
 		stdinStat, err := os.Stdin.Stat()
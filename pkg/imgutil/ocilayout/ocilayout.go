@@ -0,0 +1,255 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package ocilayout reads and writes OCI image layout directories.
+// https://github.com/opencontainers/image-spec/blob/v1.1.0/image-layout.md
+package ocilayout
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/log"
+	"github.com/containerd/platforms"
+)
+
+// IsLayout returns true if dir is an OCI image layout directory.
+func IsLayout(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ocispec.ImageLayoutFile)); err != nil {
+		return false
+	}
+	return true
+}
+
+// Write writes the images into the OCI image layout at dir, creating the layout if needed.
+// The images already present in the layout are kept, unless they have the same name as one of imgs.
+// With skipExisting, the blobs already present in the layout are not written again.
+func Write(ctx context.Context, provider content.Provider, dir string, imgs []images.Image, platMC platforms.MatchComparer, skipExisting bool) error {
+	if err := os.MkdirAll(filepath.Join(dir, ocispec.ImageBlobsDir), 0o755); err != nil {
+		return err
+	}
+	layout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, ocispec.ImageLayoutFile), layout); err != nil {
+		return err
+	}
+
+	var written, skipped int
+	seen := make(map[digest.Digest]struct{})
+	writeHandler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if _, ok := seen[desc.Digest]; ok {
+			return nil, nil
+		}
+		seen[desc.Digest] = struct{}{}
+		if images.IsNonDistributable(desc.MediaType) {
+			log.G(ctx).Debugf("skipping non-distributable blob %s", desc.Digest)
+			return nil, nil
+		}
+		ok, err := writeBlob(ctx, provider, dir, desc, skipExisting)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			written++
+		} else {
+			skipped++
+		}
+		return nil, nil
+	})
+	handler := images.Handlers(writeHandler, images.FilterPlatforms(images.ChildrenHandler(provider), platMC))
+
+	index, err := readIndex(dir)
+	if err != nil {
+		return err
+	}
+	for _, img := range imgs {
+		if err := images.Walk(ctx, handler, img.Target); err != nil {
+			return fmt.Errorf("failed to write image %s: %w", img.Name, err)
+		}
+		index.Manifests = addManifest(index.Manifests, img)
+	}
+	log.G(ctx).Debugf("wrote %d blobs, skipped %d existing blobs", written, skipped)
+
+	b, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, ocispec.ImageIndexFile), b)
+}
+
+// addManifest adds the descriptor of img to manifests, replacing the descriptor of an image with the same name.
+func addManifest(manifests []ocispec.Descriptor, img images.Image) []ocispec.Descriptor {
+	res := make([]ocispec.Descriptor, 0, len(manifests)+1)
+	for _, m := range manifests {
+		if m.Annotations[images.AnnotationImageName] != img.Name {
+			res = append(res, m)
+		}
+	}
+	desc := img.Target
+	desc.Annotations = make(map[string]string, len(img.Target.Annotations)+2)
+	for k, v := range img.Target.Annotations {
+		desc.Annotations[k] = v
+	}
+	// the same annotations as the archives written by containerd
+	desc.Annotations[images.AnnotationImageName] = img.Name
+	if spec, err := reference.Parse(img.Name); err == nil {
+		desc.Annotations[ocispec.AnnotationRefName] = spec.Object
+	} else {
+		desc.Annotations[ocispec.AnnotationRefName] = img.Name
+	}
+	return append(res, desc)
+}
+
+func readIndex(dir string) (*ocispec.Index, error) {
+	index := &ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+	}
+	b, err := os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return index, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, index); err != nil {
+		return nil, fmt.Errorf("failed to parse the index of the OCI layout %s: %w", dir, err)
+	}
+	return index, nil
+}
+
+// writeBlob writes the blob of desc into the layout, and returns false if the blob already existed.
+func writeBlob(ctx context.Context, provider content.Provider, dir string, desc ocispec.Descriptor, skipExisting bool) (bool, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return false, err
+	}
+	p := filepath.Join(dir, ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+	if skipExisting {
+		if st, err := os.Stat(p); err == nil && st.Size() == desc.Size {
+			return false, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return false, err
+	}
+	ra, err := provider.ReaderAt(ctx, desc)
+	if err != nil {
+		return false, err
+	}
+	defer ra.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-"+desc.Digest.Encoded())
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	verifier := desc.Digest.Verifier()
+	if _, err := io.Copy(io.MultiWriter(tmp, verifier), content.NewReader(ra)); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if !verifier.Verified() {
+		return false, fmt.Errorf("digest mismatch for blob %s", desc.Digest)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp.Name(), p)
+}
+
+func writeFileAtomic(p string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-"+filepath.Base(p))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Tar returns a tar stream of the OCI image layout at dir, so that the layout can be imported as an OCI archive.
+func Tar(dir string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !d.Type().IsRegular() || filepath.Base(rel)[0] == '.' {
+				return nil
+			}
+			st, err := d.Info()
+			if err != nil {
+				return err
+			}
+			hdr := &tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     filepath.ToSlash(rel),
+				Size:     st.Size(),
+				Mode:     0o644,
+				ModTime:  st.ModTime(),
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -0,0 +1,144 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ocilayout
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+)
+
+func writeContent(t *testing.T, cs content.Store, mediaType string, b []byte) ocispec.Descriptor {
+	t.Helper()
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+	assert.NilError(t, content.WriteBlob(context.Background(), cs, desc.Digest.String(), bytes.NewReader(b), desc))
+	return desc
+}
+
+func testImage(t *testing.T, cs content.Store, name string) images.Image {
+	t.Helper()
+	layer := writeContent(t, cs, ocispec.MediaTypeImageLayer, []byte("layer of "+name))
+	config, err := json.Marshal(ocispec.Image{Platform: platforms.DefaultSpec()})
+	assert.NilError(t, err)
+	configDesc := writeContent(t, cs, ocispec.MediaTypeImageConfig, config)
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	assert.NilError(t, err)
+	return images.Image{Name: name, Target: writeContent(t, cs, ocispec.MediaTypeImageManifest, manifest)}
+}
+
+func readLayoutIndex(t *testing.T, dir string) ocispec.Index {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+	assert.NilError(t, err)
+	var index ocispec.Index
+	assert.NilError(t, json.Unmarshal(b, &index))
+	return index
+}
+
+func blobPath(dir string, desc ocispec.Descriptor) string {
+	return filepath.Join(dir, ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cs, err := local.NewStore(t.TempDir())
+	assert.NilError(t, err)
+	dir := filepath.Join(t.TempDir(), "layout")
+
+	foo := testImage(t, cs, "docker.io/library/foo:1")
+	assert.NilError(t, Write(ctx, cs, dir, []images.Image{foo}, platforms.All, false))
+	assert.Assert(t, IsLayout(dir))
+	index := readLayoutIndex(t, dir)
+	assert.Equal(t, len(index.Manifests), 1)
+	assert.Equal(t, index.Manifests[0].Digest, foo.Target.Digest)
+	assert.Equal(t, index.Manifests[0].Annotations[images.AnnotationImageName], "docker.io/library/foo:1")
+	assert.Equal(t, index.Manifests[0].Annotations[ocispec.AnnotationRefName], "1")
+	_, err = os.Stat(blobPath(dir, foo.Target))
+	assert.NilError(t, err)
+
+	// an existing blob is not written again with skipExisting
+	manifestPath := blobPath(dir, foo.Target)
+	garbage := bytes.Repeat([]byte("x"), int(foo.Target.Size))
+	assert.NilError(t, os.WriteFile(manifestPath, garbage, 0o644))
+	bar := testImage(t, cs, "docker.io/library/bar:1")
+	assert.NilError(t, Write(ctx, cs, dir, []images.Image{foo, bar}, platforms.All, true))
+	b, err := os.ReadFile(manifestPath)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, b, garbage)
+
+	index = readLayoutIndex(t, dir)
+	assert.Equal(t, len(index.Manifests), 2)
+
+	// and is written again without skipExisting
+	assert.NilError(t, Write(ctx, cs, dir, []images.Image{foo}, platforms.All, false))
+	b, err = os.ReadFile(manifestPath)
+	assert.NilError(t, err)
+	assert.Equal(t, digest.FromBytes(b), foo.Target.Digest)
+	index = readLayoutIndex(t, dir)
+	assert.Equal(t, len(index.Manifests), 2)
+}
+
+func TestTar(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cs, err := local.NewStore(t.TempDir())
+	assert.NilError(t, err)
+	dir := t.TempDir()
+
+	foo := testImage(t, cs, "docker.io/library/foo:1")
+	assert.NilError(t, Write(ctx, cs, dir, []images.Image{foo}, platforms.All, false))
+
+	rc := Tar(dir)
+	defer rc.Close()
+	tr := tar.NewReader(rc)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NilError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Assert(t, slices.Contains(names, ocispec.ImageLayoutFile))
+	assert.Assert(t, slices.Contains(names, ocispec.ImageIndexFile))
+	assert.Assert(t, slices.Contains(names, "blobs/sha256/"+foo.Target.Digest.Encoded()))
+	assert.Equal(t, len(names), 5)
+}
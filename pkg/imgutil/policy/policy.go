@@ -0,0 +1,286 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package policy implements the host-wide image trust policy, configured with `image_policy` in nerdctl.toml.
+//
+// The format of the policy file follows containers-policy.json(5), with the "docker" transport:
+//
+//	{
+//	  "default": [{"type": "reject"}],
+//	  "transports": {
+//	    "docker": {
+//	      "docker.io/library": [{"type": "insecureAcceptAnything"}],
+//	      "registry.example.com/team": [{"type": "sigstoreSigned", "keyPath": "/etc/nerdctl/cosign.pub"}]
+//	    }
+//	  }
+//	}
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+	"github.com/containerd/nerdctl/v2/pkg/signutil/cosign"
+)
+
+const (
+	// TypeReject rejects the image.
+	TypeReject = "reject"
+	// TypeInsecureAcceptAnything accepts the image without any verification.
+	TypeInsecureAcceptAnything = "insecureAcceptAnything"
+	// TypeSigstoreSigned requires the image to be signed with the cosign key at KeyPath.
+	TypeSigstoreSigned = "sigstoreSigned"
+
+	// TransportDocker is the only transport used by nerdctl. The other transports are ignored.
+	TransportDocker = "docker"
+)
+
+// ErrDenied is returned when an image is denied by the policy.
+var ErrDenied = errors.New("denied by image policy")
+
+// Requirement is a requirement of a scope.
+type Requirement struct {
+	Type string `json:"type"`
+	// KeyPath is the path of the public key, for TypeSigstoreSigned.
+	KeyPath string `json:"keyPath,omitempty"`
+}
+
+func (r Requirement) String() string {
+	if r.KeyPath != "" {
+		return fmt.Sprintf("%s(keyPath=%s)", r.Type, r.KeyPath)
+	}
+	return r.Type
+}
+
+// Policy is the image trust policy.
+type Policy struct {
+	// Default is the requirements of the images not matching any scope.
+	Default []Requirement `json:"default"`
+	// Transports maps the transports to the requirements of the scopes.
+	Transports map[string]map[string][]Requirement `json:"transports,omitempty"`
+
+	path string
+	// verifySigstore verifies that the manifest dgst of the repository name is signed with the key at keyPath.
+	verifySigstore func(ctx context.Context, name string, dgst digest.Digest, keyPath string) error
+}
+
+// Load loads and validates the policy file at path.
+// opts are used to resolve the signatures of the images.
+func Load(path string, opts ...dockerconfigresolver.Opt) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the image policy: %w", err)
+	}
+	p, err := parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image policy %s: %w", path, err)
+	}
+	p.path = path
+	p.verifySigstore = func(ctx context.Context, name string, dgst digest.Digest, keyPath string) error {
+		pub, err := cosign.LoadPublicKey(keyPath)
+		if err != nil {
+			return err
+		}
+		return cosign.Verify(ctx, name, dgst, pub, opts...)
+	}
+	return p, nil
+}
+
+func parse(b []byte) (*Policy, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	p := &Policy{}
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+	if len(p.Default) == 0 {
+		return nil, errors.New(`"default" must have at least one requirement`)
+	}
+	if err := validateRequirements(p.Default); err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	for scope, reqs := range p.Transports[TransportDocker] {
+		if err := validateScope(scope); err != nil {
+			return nil, err
+		}
+		if len(reqs) == 0 {
+			return nil, fmt.Errorf("scope %q must have at least one requirement", scope)
+		}
+		if err := validateRequirements(reqs); err != nil {
+			return nil, fmt.Errorf("scope %q: %w", scope, err)
+		}
+	}
+	return p, nil
+}
+
+func validateScope(scope string) error {
+	if scope == "" {
+		return nil
+	}
+	if strings.HasPrefix(scope, "*.") {
+		if strings.ContainsAny(scope[2:], "/*:@") {
+			return fmt.Errorf("invalid scope %q: a wildcard scope must be a domain like \"*.example.com\"", scope)
+		}
+		return nil
+	}
+	if strings.Contains(scope, "*") {
+		return fmt.Errorf("invalid scope %q: a wildcard is only supported as the prefix of a domain", scope)
+	}
+	return nil
+}
+
+func validateRequirements(reqs []Requirement) error {
+	for _, r := range reqs {
+		switch r.Type {
+		case TypeReject, TypeInsecureAcceptAnything:
+			if r.KeyPath != "" {
+				return fmt.Errorf("%q does not take a keyPath", r.Type)
+			}
+		case TypeSigstoreSigned:
+			if r.KeyPath == "" {
+				return fmt.Errorf("%q requires a keyPath", r.Type)
+			}
+			if !filepath.IsAbs(r.KeyPath) {
+				return fmt.Errorf("keyPath %q must be absolute", r.KeyPath)
+			}
+		default:
+			return fmt.Errorf("unsupported requirement type %q, should be one of %q, %q, %q",
+				r.Type, TypeReject, TypeInsecureAcceptAnything, TypeSigstoreSigned)
+		}
+	}
+	return nil
+}
+
+// scopes returns the scopes matching the image reference, from the most specific to the least specific.
+// e.g., for "registry.example.com/team/app:v1":
+// "registry.example.com/team/app:v1", "registry.example.com/team/app", "registry.example.com/team",
+// "registry.example.com", "*.example.com", "*.com".
+func scopes(ref *referenceutil.ImageReference) []string {
+	if ref.Domain == "" {
+		return nil
+	}
+	name := ref.Name()
+	var res []string
+	if ref.Tag != "" {
+		res = append(res, name+":"+ref.Tag)
+	}
+	if ref.Digest != "" {
+		res = append(res, name+"@"+ref.Digest.String())
+	}
+	for s := name; s != ""; {
+		res = append(res, s)
+		i := strings.LastIndex(s, "/")
+		if i < 0 {
+			break
+		}
+		s = s[:i]
+	}
+	domain, _, _ := strings.Cut(ref.Domain, ":")
+	for labels := strings.Split(domain, "."); len(labels) > 1; labels = labels[1:] {
+		res = append(res, "*."+strings.Join(labels[1:], "."))
+	}
+	return res
+}
+
+// Requirements returns the requirements of the image reference, and the scope they belong to.
+// The scope is "default" for the default requirements.
+func (p *Policy) Requirements(ref *referenceutil.ImageReference) (string, []Requirement) {
+	docker := p.Transports[TransportDocker]
+	if ref != nil {
+		for _, s := range scopes(ref) {
+			if reqs, ok := docker[s]; ok {
+				return s, reqs
+			}
+		}
+	}
+	if reqs, ok := docker[""]; ok {
+		return TransportDocker, reqs
+	}
+	return "default", p.Default
+}
+
+// CheckName denies the image named rawRef before it is pulled, if its requirements contain "reject".
+func (p *Policy) CheckName(ctx context.Context, rawRef string) error {
+	ref, err := referenceutil.Parse(rawRef)
+	if err != nil {
+		ref = nil
+	}
+	scope, reqs := p.Requirements(ref)
+	for _, r := range reqs {
+		if r.Type == TypeReject {
+			return p.deny(ctx, rawRef, "", scope, r, errors.New("rejected"))
+		}
+	}
+	return nil
+}
+
+// Check verifies that the image named rawRef with the manifest (or index) digest dgst satisfies the policy.
+// All the requirements of the matching scope must be satisfied.
+func (p *Policy) Check(ctx context.Context, rawRef string, dgst digest.Digest) error {
+	ref, err := referenceutil.Parse(rawRef)
+	if err != nil {
+		ref = nil
+	}
+	scope, reqs := p.Requirements(ref)
+	for _, r := range reqs {
+		var reason error
+		switch r.Type {
+		case TypeReject:
+			reason = errors.New("rejected")
+		case TypeInsecureAcceptAnything:
+		case TypeSigstoreSigned:
+			if ref == nil || ref.Domain == "" {
+				reason = fmt.Errorf("%q is not a registry reference, so its signature cannot be verified", rawRef)
+			} else {
+				reason = p.verifySigstore(ctx, ref.Name(), dgst, r.KeyPath)
+			}
+		}
+		if reason != nil {
+			return p.deny(ctx, rawRef, dgst, scope, r, reason)
+		}
+	}
+	log.G(ctx).WithFields(log.Fields{
+		"image":  rawRef,
+		"digest": dgst,
+		"policy": p.path,
+		"scope":  scope,
+	}).Debug("image accepted by policy")
+	return nil
+}
+
+// deny logs the denial so that it can be audited, and returns an error wrapping ErrDenied.
+func (p *Policy) deny(ctx context.Context, rawRef string, dgst digest.Digest, scope string, r Requirement, reason error) error {
+	log.G(ctx).WithFields(log.Fields{
+		"image":       rawRef,
+		"digest":      dgst,
+		"policy":      p.path,
+		"scope":       scope,
+		"requirement": r.String(),
+	}).WithError(reason).Warn("image denied by policy")
+	return fmt.Errorf("image %q %w %s (scope %q, requirement %s): %w", rawRef, ErrDenied, p.path, scope, r, reason)
+}
@@ -0,0 +1,143 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+)
+
+const testPolicy = `{
+  "default": [{"type": "reject"}],
+  "transports": {
+    "docker": {
+      "docker.io/library": [{"type": "insecureAcceptAnything"}],
+      "docker.io/library/alpine:3.1": [{"type": "reject"}],
+      "registry.example.com/team": [{"type": "sigstoreSigned", "keyPath": "/etc/nerdctl/team.pub"}],
+      "*.internal.example.com": [{"type": "insecureAcceptAnything"}]
+    },
+    "docker-daemon": {
+      "": [{"type": "insecureAcceptAnything"}]
+    }
+  }
+}`
+
+func loadTestPolicy(t *testing.T, s string) *Policy {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "policy.json")
+	assert.NilError(t, os.WriteFile(p, []byte(s), 0o644))
+	pol, err := Load(p)
+	assert.NilError(t, err)
+	return pol
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]string{
+		`{}`:                                  `"default" must have at least one requirement`,
+		`{"default": [{"type": "signedBy"}]}`: `unsupported requirement type "signedBy"`,
+		`{"default": [{"type": "reject"}], "x": 1}`:                                                          `unknown field "x"`,
+		`{"default": [{"type": "sigstoreSigned"}]}`:                                                          `requires a keyPath`,
+		`{"default": [{"type": "sigstoreSigned", "keyPath": "key.pub"}]}`:                                    `must be absolute`,
+		`{"default": [{"type": "reject"}], "transports": {"docker": {"docker.io/*": [{"type": "reject"}]}}}`: `invalid scope`,
+		`{"default": [{"type": "reject"}], "transports": {"docker": {"docker.io": []}}}`:                     `at least one requirement`,
+	}
+	for s, expected := range testCases {
+		p := filepath.Join(t.TempDir(), "policy.json")
+		assert.NilError(t, os.WriteFile(p, []byte(s), 0o644))
+		_, err := Load(p)
+		assert.ErrorContains(t, err, expected, s)
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	t.Parallel()
+	pol := loadTestPolicy(t, testPolicy)
+	testCases := map[string]string{
+		"alpine":                                 "docker.io/library",
+		"alpine:3.1":                             "docker.io/library/alpine:3.1",
+		"docker.io/foo/bar":                      "default",
+		"registry.example.com/team/app:v1":       "registry.example.com/team",
+		"registry.example.com/teams/app:v1":      "default",
+		"registry.internal.example.com:5000/app": "*.internal.example.com",
+		"a.b.internal.example.com/app":           "*.internal.example.com",
+		"internal.example.com/app":               "default",
+	}
+	for s, expected := range testCases {
+		ref, err := referenceutil.Parse(s)
+		assert.NilError(t, err)
+		scope, _ := pol.Requirements(ref)
+		assert.Equal(t, scope, expected, s)
+	}
+	scope, reqs := pol.Requirements(nil)
+	assert.Equal(t, scope, "default")
+	assert.DeepEqual(t, reqs, []Requirement{{Type: TypeReject}})
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pol := loadTestPolicy(t, testPolicy)
+	dgst := digest.FromString("manifest")
+	signed := map[string]string{
+		"registry.example.com/team/app@" + dgst.String(): "/etc/nerdctl/team.pub",
+	}
+	pol.verifySigstore = func(ctx context.Context, name string, dgst digest.Digest, keyPath string) error {
+		if signed[name+"@"+dgst.String()] != keyPath {
+			return errors.New("no matching signatures")
+		}
+		return nil
+	}
+
+	assert.NilError(t, pol.Check(ctx, "docker.io/library/alpine:latest", dgst))
+	assert.NilError(t, pol.Check(ctx, "registry.example.com/team/app:v1", dgst))
+
+	err := pol.Check(ctx, "registry.example.com/team/app:v1", digest.FromString("other"))
+	assert.Assert(t, errors.Is(err, ErrDenied))
+	assert.ErrorContains(t, err, "no matching signatures")
+
+	err = pol.Check(ctx, "docker.io/library/alpine:3.1", dgst)
+	assert.Assert(t, errors.Is(err, ErrDenied))
+	assert.ErrorContains(t, err, `scope "docker.io/library/alpine:3.1"`)
+
+	err = pol.Check(ctx, "ghcr.io/foo/bar:latest", dgst)
+	assert.Assert(t, errors.Is(err, ErrDenied))
+	assert.ErrorContains(t, err, `scope "default", requirement reject`)
+}
+
+func TestCheckName(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pol := loadTestPolicy(t, testPolicy)
+	pol.verifySigstore = func(ctx context.Context, name string, dgst digest.Digest, keyPath string) error {
+		t.Fatal("signatures must not be verified before pulling")
+		return nil
+	}
+
+	assert.NilError(t, pol.CheckName(ctx, "alpine"))
+	assert.NilError(t, pol.CheckName(ctx, "registry.example.com/team/app:v1"))
+	assert.Assert(t, errors.Is(pol.CheckName(ctx, "alpine:3.1"), ErrDenied))
+	assert.Assert(t, errors.Is(pol.CheckName(ctx, "ghcr.io/foo/bar"), ErrDenied))
+}
@@ -0,0 +1,237 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package prunepolicy implements the retention policies of `nerdctl image prune --policy`.
+package prunepolicy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/opencontainers/go-digest"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Policy is a retention policy of images, loaded from a TOML file, e.g.,
+//
+//	keep = ["docker.io/library/*"]
+//	keep_last_tags = 3
+//	unused_for = "7d"
+//	max_total_size = "50GB"
+//
+// The images used by containers and the images matching Keep are never removed.
+type Policy struct {
+	// Keep is the list of the patterns (path.Match) of the names of the images which are never removed
+	Keep []string `toml:"keep"`
+	// KeepLastTags removes the tags of each repository but the KeepLastTags most recently used ones (0 to disable)
+	KeepLastTags int `toml:"keep_last_tags"`
+	// UnusedFor removes the images which were not used by containers for the duration, e.g., "7d" or "36h"
+	UnusedFor string `toml:"unused_for"`
+	// MaxTotalSize removes the least recently used images until the total size of the blobs of the images is under
+	// the size, e.g., "50GB"
+	MaxTotalSize string `toml:"max_total_size"`
+
+	unusedFor    time.Duration
+	maxTotalSize int64
+}
+
+// Image is an image which may be removed by a policy.
+type Image struct {
+	Name string
+	// LastUsed is the last time the image was used by a container, or the time the image was created
+	LastUsed time.Time
+	// InUse is true for the images used by containers
+	InUse bool
+	// Blobs are the sizes of the blobs of the image, shared with the other images
+	Blobs map[digest.Digest]int64
+}
+
+// Load loads the policy from the file p.
+func Load(p string) (*Policy, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	policy, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the prune policy from %q: %w", p, err)
+	}
+	return policy, nil
+}
+
+// Parse parses the policy from r.
+func Parse(r io.Reader) (*Policy, error) {
+	var p Policy
+	// set Strict to detect typo
+	if err := toml.NewDecoder(r).DisallowUnknownFields().Decode(&p); err != nil {
+		return nil, err
+	}
+	for _, pattern := range p.Keep {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid keep pattern %q: %w", pattern, err)
+		}
+	}
+	if p.KeepLastTags < 0 {
+		return nil, fmt.Errorf("invalid keep_last_tags %d", p.KeepLastTags)
+	}
+	if p.UnusedFor != "" {
+		d, err := ParseDuration(p.UnusedFor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unused_for %q: %w", p.UnusedFor, err)
+		}
+		p.unusedFor = d
+	}
+	if p.MaxTotalSize != "" {
+		size, err := units.RAMInBytes(p.MaxTotalSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_total_size %q: %w", p.MaxTotalSize, err)
+		}
+		p.maxTotalSize = size
+	}
+	if p.KeepLastTags == 0 && p.unusedFor == 0 && p.maxTotalSize == 0 {
+		return nil, errors.New("the policy has no rule: specify keep_last_tags, unused_for, or max_total_size")
+	}
+	return &p, nil
+}
+
+// ParseDuration parses the Go duration s, which may also be a number of days, e.g., "7d".
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %q", s)
+	}
+	return d, nil
+}
+
+// Select returns the names of the images removed by the policy at now, in the order of imgs.
+func (p *Policy) Select(imgs []Image, now time.Time) []string {
+	removed := make(map[string]bool)
+	protected := func(img Image) bool {
+		if img.InUse {
+			return true
+		}
+		for _, pattern := range p.Keep {
+			if ok, _ := path.Match(pattern, img.Name); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if p.unusedFor > 0 {
+		for _, img := range imgs {
+			if !protected(img) && now.Sub(img.LastUsed) > p.unusedFor {
+				removed[img.Name] = true
+			}
+		}
+	}
+
+	if p.KeepLastTags > 0 {
+		repos := make(map[string][]Image)
+		for _, img := range imgs {
+			repo := Repository(img.Name)
+			repos[repo] = append(repos[repo], img)
+		}
+		for _, tags := range repos {
+			sortByLastUsed(tags)
+			// the most recently used tags, including the ones in use
+			for _, img := range tags[min(p.KeepLastTags, len(tags)):] {
+				if !protected(img) {
+					removed[img.Name] = true
+				}
+			}
+		}
+	}
+
+	if p.maxTotalSize > 0 {
+		refs := make(map[digest.Digest]int)
+		var total int64
+		var lru []Image
+		for _, img := range imgs {
+			if removed[img.Name] {
+				continue
+			}
+			for dgst, size := range img.Blobs {
+				if refs[dgst] == 0 {
+					total += size
+				}
+				refs[dgst]++
+			}
+			if !protected(img) {
+				lru = append(lru, img)
+			}
+		}
+		sortByLastUsed(lru)
+		// remove the least recently used images first
+		for i := len(lru) - 1; i >= 0 && total > p.maxTotalSize; i-- {
+			img := lru[i]
+			removed[img.Name] = true
+			for dgst, size := range img.Blobs {
+				refs[dgst]--
+				if refs[dgst] == 0 {
+					total -= size
+				}
+			}
+		}
+	}
+
+	var names []string
+	for _, img := range imgs {
+		if removed[img.Name] {
+			names = append(names, img.Name)
+		}
+	}
+	return names
+}
+
+// sortByLastUsed sorts the images from the most recently used one.
+func sortByLastUsed(imgs []Image) {
+	sort.SliceStable(imgs, func(i, j int) bool {
+		if !imgs[i].LastUsed.Equal(imgs[j].LastUsed) {
+			return imgs[i].LastUsed.After(imgs[j].LastUsed)
+		}
+		return imgs[i].Name < imgs[j].Name
+	})
+}
+
+// Repository returns the repository of the image name, e.g., "docker.io/library/alpine" for
+// "docker.io/library/alpine:3.20" and "docker.io/library/alpine@sha256:...".
+func Repository(name string) string {
+	name, _, _ = strings.Cut(name, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name
+}
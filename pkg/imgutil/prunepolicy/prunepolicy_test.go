@@ -0,0 +1,110 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package prunepolicy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	p, err := Parse(strings.NewReader(`
+keep = ["docker.io/library/*"]
+keep_last_tags = 3
+unused_for = "7d"
+max_total_size = "50GB"
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.Keep, []string{"docker.io/library/*"})
+	assert.Equal(t, p.KeepLastTags, 3)
+	assert.Equal(t, p.unusedFor, 7*24*time.Hour)
+	assert.Equal(t, p.maxTotalSize, int64(50)<<30)
+
+	_, err = Parse(strings.NewReader(`unused_after = "7d"`))
+	assert.ErrorContains(t, err, "strict mode")
+	_, err = Parse(strings.NewReader(`keep = ["a"]`))
+	assert.ErrorContains(t, err, "no rule")
+	_, err = Parse(strings.NewReader(`unused_for = "7 days"`))
+	assert.ErrorContains(t, err, "invalid unused_for")
+	_, err = Parse(strings.NewReader(`max_total_size = "lots"`))
+	assert.ErrorContains(t, err, "invalid max_total_size")
+}
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+	d, err := ParseDuration("1.5d")
+	assert.NilError(t, err)
+	assert.Equal(t, d, 36*time.Hour)
+	d, err = ParseDuration("90m")
+	assert.NilError(t, err)
+	assert.Equal(t, d, 90*time.Minute)
+	_, err = ParseDuration("-1d")
+	assert.ErrorContains(t, err, "invalid number of days")
+}
+
+func TestRepository(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, Repository("docker.io/library/alpine:3.20"), "docker.io/library/alpine")
+	assert.Equal(t, Repository("localhost:5000/app@sha256:0123"), "localhost:5000/app")
+	assert.Equal(t, Repository("localhost:5000/app"), "localhost:5000/app")
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time {
+		return now.Add(-time.Duration(n) * 24 * time.Hour)
+	}
+	blobs := func(sizes map[string]int64) map[digest.Digest]int64 {
+		m := make(map[digest.Digest]int64)
+		for s, size := range sizes {
+			m[digest.FromString(s)] = size
+		}
+		return m
+	}
+	imgs := []Image{
+		{Name: "example.com/app:v1", LastUsed: day(9), Blobs: blobs(map[string]int64{"base": 100, "v1": 10})},
+		{Name: "example.com/app:v2", LastUsed: day(5), InUse: true, Blobs: blobs(map[string]int64{"base": 100, "v2": 10})},
+		{Name: "example.com/app:v3", LastUsed: day(2), Blobs: blobs(map[string]int64{"base": 100, "v3": 10})},
+		{Name: "example.com/app:v4", LastUsed: day(1), Blobs: blobs(map[string]int64{"base": 100, "v4": 10})},
+		{Name: "docker.io/library/alpine:3.19", LastUsed: day(30), Blobs: blobs(map[string]int64{"alpine": 50})},
+		{Name: "example.com/db:v1", LastUsed: day(3), Blobs: blobs(map[string]int64{"db": 200})},
+	}
+	keep := []string{"docker.io/library/*"}
+
+	p := &Policy{Keep: keep, KeepLastTags: 2}
+	assert.DeepEqual(t, p.Select(imgs, now), []string{"example.com/app:v1"})
+
+	p = &Policy{Keep: keep, KeepLastTags: 1}
+	// v2 is in use
+	assert.DeepEqual(t, p.Select(imgs, now), []string{"example.com/app:v1", "example.com/app:v3"})
+
+	p = &Policy{Keep: keep, unusedFor: 4 * 24 * time.Hour}
+	assert.DeepEqual(t, p.Select(imgs, now), []string{"example.com/app:v1"})
+
+	// the total size is 100+40+50+200 = 390: removing v1 frees 10, and then db (used before v3) frees 200
+	p = &Policy{Keep: keep, maxTotalSize: 300}
+	assert.DeepEqual(t, p.Select(imgs, now), []string{"example.com/app:v1", "example.com/db:v1"})
+
+	p = &Policy{maxTotalSize: 400}
+	assert.Assert(t, p.Select(imgs, now) == nil)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/containerd/log"
 
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil/jobs"
 	"github.com/containerd/nerdctl/v2/pkg/platformutil"
 )
@@ -55,14 +56,23 @@ func Pull(ctx context.Context, client *containerd.Client, ref string, config *Co
 	pctx, stopProgress := context.WithCancel(ctx)
 	progress := make(chan struct{})
 
+	group := jobs.GroupFromContext(ctx)
+	if group != nil {
+		// the progress is rendered by the owner of the group
+		group.Add(ongoing)
+	}
+
 	go func() {
-		if config.ProgressOutput != nil {
+		if config.ProgressOutput != nil && group == nil {
 			// no progress bar, because it hides some debug logs
 			jobs.ShowProgress(pctx, ongoing, client.ContentStore(), config.ProgressOutput)
 		}
 		close(progress)
 	}()
 
+	// show the registry host that served each blob, as it may be a mirror, and the retries
+	pctx = dockerconfigresolver.WithTransferObserver(pctx, ongoing)
+
 	h := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
 		if desc.MediaType != images.MediaTypeDockerSchema1Manifest {
 			ongoing.Add(desc)
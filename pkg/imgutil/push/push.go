@@ -25,6 +25,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/errgroup"
 
@@ -36,6 +37,7 @@ import (
 	"github.com/containerd/log"
 	"github.com/containerd/platforms"
 
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
 	"github.com/containerd/nerdctl/v2/pkg/imgutil/jobs"
 )
 
@@ -49,6 +51,8 @@ func Push(ctx context.Context, client *containerd.Client, resolver remotes.Resol
 	desc := img.Target
 
 	ongoing := newPushJobs(pushTracker)
+	// show the retries of the resumable uploads
+	ctx = dockerconfigresolver.WithTransferObserver(ctx, ongoing)
 
 	eg, ctx := errgroup.WithContext(ctx)
 
@@ -62,7 +66,7 @@ func Push(ctx context.Context, client *containerd.Client, resolver remotes.Resol
 
 		jobHandler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
 			if allowNonDist || !images.IsNonDistributable(desc.MediaType) {
-				ongoing.add(remotes.MakeRefKey(ctx, desc))
+				ongoing.add(remotes.MakeRefKey(ctx, desc), desc.Digest)
 			}
 			return nil, nil
 		})
@@ -115,20 +119,32 @@ func Push(ctx context.Context, client *containerd.Client, resolver remotes.Resol
 }
 
 type pushjobs struct {
-	jobs    map[string]struct{}
+	jobs    map[string]digest.Digest
 	ordered []string
+	retries map[digest.Digest]int
 	tracker docker.StatusTracker
 	mu      sync.Mutex
 }
 
 func newPushJobs(tracker docker.StatusTracker) *pushjobs {
 	return &pushjobs{
-		jobs:    make(map[string]struct{}),
+		jobs:    make(map[string]digest.Digest),
+		retries: make(map[digest.Digest]int),
 		tracker: tracker,
 	}
 }
 
-func (j *pushjobs) add(ref string) {
+// Served implements dockerconfigresolver.TransferObserver.
+func (j *pushjobs) Served(ocispec.Descriptor, string) {}
+
+// Retrying implements dockerconfigresolver.TransferObserver.
+func (j *pushjobs) Retrying(desc ocispec.Descriptor, attempt int, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.retries[desc.Digest] = attempt
+}
+
+func (j *pushjobs) add(ref string, dgst digest.Digest) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
@@ -136,7 +152,7 @@ func (j *pushjobs) add(ref string) {
 		return
 	}
 	j.ordered = append(j.ordered, ref)
-	j.jobs[ref] = struct{}{}
+	j.jobs[ref] = dgst
 }
 
 func (j *pushjobs) status() []jobs.StatusInfo {
@@ -146,7 +162,8 @@ func (j *pushjobs) status() []jobs.StatusInfo {
 	statuses := make([]jobs.StatusInfo, 0, len(j.jobs))
 	for _, name := range j.ordered {
 		si := jobs.StatusInfo{
-			Ref: name,
+			Ref:     name,
+			Retries: j.retries[j.jobs[name]],
 		}
 
 		status, err := j.tracker.GetStatus(name)
@@ -0,0 +1,201 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package rebase
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// the fields of the config of an image which are merged per key
+var keyedFields = map[string]bool{
+	"Labels":       true,
+	"ExposedPorts": true,
+	"Volumes":      true,
+}
+
+// MergeConfig merges the "config" objects of the old base, the new base and the image into the config of the rebased
+// image. The image keeps what it changed on top of the old base, and inherits the rest from the new base:
+//
+//   - Env, Labels, ExposedPorts and Volumes are merged per key. A key whose value the image inherited from the old base
+//     takes the value of the new base (or is removed when the new base does not have it), a key set or removed by the
+//     image keeps its value (or stays removed), and the other keys of the new base are added.
+//   - The other fields (e.g., Entrypoint, Cmd, User, WorkingDir, StopSignal and Healthcheck) are taken from the new base
+//     when the image inherited them from the old base, or kept otherwise.
+func MergeConfig(oldConfig, newConfig, config json.RawMessage) (json.RawMessage, error) {
+	var o, n, c map[string]json.RawMessage
+	for _, x := range []struct {
+		raw json.RawMessage
+		m   *map[string]json.RawMessage
+	}{{oldConfig, &o}, {newConfig, &n}, {config, &c}} {
+		if isNull(x.raw) {
+			continue
+		}
+		if err := json.Unmarshal(x.raw, x.m); err != nil {
+			return nil, err
+		}
+	}
+	merged := make(map[string]json.RawMessage)
+	for _, m := range []map[string]json.RawMessage{o, n, c} {
+		for k := range m {
+			if _, ok := merged[k]; ok {
+				continue
+			}
+			var (
+				v   json.RawMessage
+				err error
+			)
+			switch {
+			case k == "Env":
+				v, err = mergeEnv(o[k], n[k], c[k])
+			case keyedFields[k]:
+				v, err = mergeMap(o[k], n[k], c[k])
+			case jsonEqual(c[k], o[k]):
+				v = n[k]
+			default:
+				v = c[k]
+			}
+			if err != nil {
+				return nil, err
+			}
+			merged[k] = v
+		}
+	}
+	for k, v := range merged {
+		if isNull(v) {
+			delete(merged, k)
+		}
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(merged)
+}
+
+// mergeEnv merges the lists of environment variables, keeping the order of the new base and then of the image.
+func mergeEnv(oldEnv, newEnv, env json.RawMessage) (json.RawMessage, error) {
+	var o, n, c []string
+	for _, x := range []struct {
+		raw json.RawMessage
+		l   *[]string
+	}{{oldEnv, &o}, {newEnv, &n}, {env, &c}} {
+		if isNull(x.raw) {
+			continue
+		}
+		if err := json.Unmarshal(x.raw, x.l); err != nil {
+			return nil, err
+		}
+	}
+	oldVals, vals := envMap(o), envMap(c)
+	var merged []string
+	seen := make(map[string]bool)
+	for _, kv := range n {
+		k, _, _ := strings.Cut(kv, "=")
+		seen[k] = true
+		oldV, inOld := oldVals[k]
+		v, inImage := vals[k]
+		switch {
+		case inOld && !inImage:
+			// removed by the image
+		case inImage && (!inOld || v != oldV):
+			merged = append(merged, k+"="+v)
+		default:
+			merged = append(merged, kv)
+		}
+	}
+	for _, kv := range c {
+		k, v, _ := strings.Cut(kv, "=")
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		if oldV, inOld := oldVals[k]; inOld && v == oldV {
+			// inherited from the old base, and removed by the new base
+			continue
+		}
+		merged = append(merged, kv)
+	}
+	if merged == nil {
+		return nil, nil
+	}
+	return json.Marshal(merged)
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// mergeMap merges the JSON objects per key.
+func mergeMap(oldMap, newMap, m json.RawMessage) (json.RawMessage, error) {
+	var o, n, c map[string]json.RawMessage
+	for _, x := range []struct {
+		raw json.RawMessage
+		m   *map[string]json.RawMessage
+	}{{oldMap, &o}, {newMap, &n}, {m, &c}} {
+		if isNull(x.raw) {
+			continue
+		}
+		if err := json.Unmarshal(x.raw, x.m); err != nil {
+			return nil, err
+		}
+	}
+	merged := make(map[string]json.RawMessage, len(n)+len(c))
+	for k, v := range n {
+		merged[k] = v
+	}
+	for k, oldV := range o {
+		v, ok := c[k]
+		switch {
+		case !ok:
+			// removed by the image
+			delete(merged, k)
+		case !jsonEqual(v, oldV):
+			merged[k] = v
+		}
+	}
+	for k, v := range c {
+		if _, ok := o[k]; !ok {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(merged)
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// jsonEqual reports whether the JSON values are equal, regardless of their formatting.
+func jsonEqual(a, b json.RawMessage) bool {
+	if isNull(a) || isNull(b) {
+		return isNull(a) && isNull(b)
+	}
+	var bufA, bufB bytes.Buffer
+	if json.Compact(&bufA, a) != nil || json.Compact(&bufB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
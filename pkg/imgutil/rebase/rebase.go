@@ -0,0 +1,251 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package rebase replaces the base layers of an image with the layers of another base image, without rebuilding it.
+package rebase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+	"github.com/containerd/platforms"
+
+	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+)
+
+// the annotation of the attestation manifests of BuildKit, which reference the manifests of the original image
+const (
+	annotationReferenceType = "vnd.docker.reference.type"
+	attestationManifest     = "attestation-manifest"
+)
+
+// Rebase replaces the layers of oldBase at the bottom of img with the layers of newBase, merges their configs
+// (see MergeConfig), and stores the resulting image as name.
+//
+// The manifests of an index are rebased for the platforms matched by platMC, and the other manifests are dropped.
+// The base images must have a manifest for each rebased platform.
+func Rebase(ctx context.Context, client *containerd.Client, img, oldBase, newBase images.Image, name string, platMC platforms.MatchComparer) (images.Image, error) {
+	cs := client.ContentStore()
+	target := img.Target
+	var newTarget ocispec.Descriptor
+	switch {
+	case images.IsIndexType(target.MediaType):
+		idx, _, err := imgutil.ReadIndex(ctx, containerd.NewImage(client, img))
+		if err != nil {
+			return images.Image{}, err
+		}
+		var manifests []ocispec.Descriptor
+		for _, desc := range idx.Manifests {
+			if desc.Annotations[annotationReferenceType] == attestationManifest {
+				log.G(ctx).Debugf("dropping the attestation manifest %s, which references the original image", desc.Digest)
+				continue
+			}
+			if desc.Platform == nil || !platMC.Match(*desc.Platform) {
+				continue
+			}
+			if _, err := cs.Info(ctx, desc.Digest); errdefs.IsNotFound(err) {
+				log.G(ctx).Warnf("skipping platform %s of image %q, which is not present in the content store", platforms.Format(*desc.Platform), img.Name)
+				continue
+			}
+			rebased, err := rebaseManifest(ctx, client, desc, *desc.Platform, oldBase, newBase)
+			if err != nil {
+				return images.Image{}, err
+			}
+			manifests = append(manifests, rebased)
+		}
+		if len(manifests) == 0 {
+			return images.Image{}, fmt.Errorf("image %q has no manifest for the platforms to rebase: %w", img.Name, errdefs.ErrNotFound)
+		}
+		raw, err := content.ReadBlob(ctx, cs, target)
+		if err != nil {
+			return images.Image{}, err
+		}
+		b, err := imgutil.PatchJSON(raw, map[string]any{"manifests": manifests})
+		if err != nil {
+			return images.Image{}, err
+		}
+		if newTarget, err = imgutil.WriteBlob(ctx, cs, target.MediaType, b, nil, manifests...); err != nil {
+			return images.Image{}, err
+		}
+	case images.IsManifestType(target.MediaType):
+		config, _, err := imgutil.ReadImageConfig(ctx, containerd.NewImage(client, img))
+		if err != nil {
+			return images.Image{}, err
+		}
+		if newTarget, err = rebaseManifest(ctx, client, target, config.Platform, oldBase, newBase); err != nil {
+			return images.Image{}, err
+		}
+	default:
+		return images.Image{}, fmt.Errorf("unknown media type %q", target.MediaType)
+	}
+	return imgutil.StoreImage(ctx, client, name, newTarget)
+}
+
+// rebaseManifest rebases the manifest desc for platform, and returns the descriptor of the rebased manifest,
+// with the platform and the annotations of desc.
+func rebaseManifest(ctx context.Context, client *containerd.Client, desc ocispec.Descriptor, platform ocispec.Platform, oldBase, newBase images.Image) (ocispec.Descriptor, error) {
+	cs := client.ContentStore()
+	raw, err := content.ReadBlob(ctx, cs, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	rawConfig, config, err := readConfig(ctx, cs, manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	plat := platforms.Format(platform)
+	oldManifest, rawOldConfig, oldConfig, err := readBase(ctx, client, oldBase, platform)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	newManifest, rawNewConfig, newConfig, err := readBase(ctx, client, newBase, platform)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	diffIDs := config.RootFS.DiffIDs
+	oldDiffIDs := oldConfig.RootFS.DiffIDs
+	if len(diffIDs) != len(manifest.Layers) {
+		return ocispec.Descriptor{}, fmt.Errorf("manifest %s (%s) has %d layers but %d diff IDs", desc.Digest, plat, len(manifest.Layers), len(diffIDs))
+	}
+	if len(newConfig.RootFS.DiffIDs) != len(newManifest.Layers) || len(oldDiffIDs) != len(oldManifest.Layers) {
+		return ocispec.Descriptor{}, fmt.Errorf("the layers of the base images (%s) do not match their diff IDs", plat)
+	}
+	if len(diffIDs) < len(oldDiffIDs) || !slices.Equal(diffIDs[:len(oldDiffIDs)], oldDiffIDs) {
+		return ocispec.Descriptor{}, fmt.Errorf("image (%s) is not based on %q: its bottom layers differ: %w", plat, oldBase.Name, errdefs.ErrInvalidArgument)
+	}
+	k := len(oldDiffIDs)
+	newLayers := slices.Concat(newManifest.Layers, manifest.Layers[k:])
+	newDiffIDs := slices.Concat(newConfig.RootFS.DiffIDs, diffIDs[k:])
+	history := rebaseHistory(config.History, oldConfig.History, newConfig.History, k)
+	if history == nil && config.History != nil {
+		log.G(ctx).Warnf("The history of the image (%s) does not match the layers of %q, dropping it", plat, oldBase.Name)
+	}
+
+	mergedConfig, err := MergeConfig(field(rawOldConfig, "config"), field(rawNewConfig, "config"), field(rawConfig, "config"))
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	b, err := imgutil.PatchJSON(rawConfig, map[string]any{
+		"config":  mergedConfig,
+		"rootfs":  ocispec.RootFS{Type: "layers", DiffIDs: newDiffIDs},
+		"history": history,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	configDesc, err := imgutil.WriteBlob(ctx, cs, manifest.Config.MediaType, b, nil)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	b, err = imgutil.PatchJSON(raw, map[string]any{
+		"config": configDesc,
+		"layers": newLayers,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	// new manifest should reference the layers and config content
+	manifestDesc, err := imgutil.WriteBlob(ctx, cs, desc.MediaType, b, nil, append([]ocispec.Descriptor{configDesc}, newLayers...)...)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	rebased := desc
+	rebased.Digest, rebased.Size = manifestDesc.Digest, manifestDesc.Size
+	return rebased, nil
+}
+
+// readBase reads the manifest and the config of the base image for platform.
+func readBase(ctx context.Context, client *containerd.Client, base images.Image, platform ocispec.Platform) (*ocispec.Manifest, []byte, ocispec.Image, error) {
+	img := containerd.NewImageWithPlatform(client, base, platforms.OnlyStrict(platform))
+	manifest, _, err := imgutil.ReadManifest(ctx, img)
+	if err != nil {
+		return nil, nil, ocispec.Image{}, fmt.Errorf("failed to read base image %q: %w", base.Name, err)
+	}
+	if manifest == nil {
+		return nil, nil, ocispec.Image{}, fmt.Errorf("base image %q has no manifest for %s: %w", base.Name, platforms.Format(platform), errdefs.ErrNotFound)
+	}
+	raw, config, err := readConfig(ctx, client.ContentStore(), *manifest)
+	if err != nil {
+		return nil, nil, ocispec.Image{}, fmt.Errorf("failed to read the config of base image %q: %w", base.Name, err)
+	}
+	return manifest, raw, config, nil
+}
+
+// readConfig reads the raw config of the manifest, without the nerdctl extensions of imgutil.ReadImageConfig.
+func readConfig(ctx context.Context, provider content.Provider, manifest ocispec.Manifest) ([]byte, ocispec.Image, error) {
+	var config ocispec.Image
+	raw, err := content.ReadBlob(ctx, provider, manifest.Config)
+	if err != nil {
+		return nil, config, err
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, config, err
+	}
+	return raw, config, nil
+}
+
+// field returns the field k of the JSON object raw, or nil.
+func field(raw []byte, k string) json.RawMessage {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	return m[k]
+}
+
+// rebaseHistory replaces the history entries of the old base with the history of the new base.
+// The entries of the old base are the first entries of history when they match,
+// or the entries up to the k-th layer otherwise. It returns nil when history has less than k layers.
+func rebaseHistory(history, oldHistory, newHistory []ocispec.History, k int) []ocispec.History {
+	cut := -1
+	if len(oldHistory) > 0 && len(history) >= len(oldHistory) && slices.EqualFunc(history[:len(oldHistory)], oldHistory, func(a, b ocispec.History) bool {
+		return a.CreatedBy == b.CreatedBy && a.EmptyLayer == b.EmptyLayer
+	}) {
+		cut = len(oldHistory)
+	} else {
+		layers := 0
+		for i, h := range history {
+			if layers == k {
+				cut = i
+				break
+			}
+			if !h.EmptyLayer {
+				layers++
+			}
+		}
+		if cut < 0 && layers == k {
+			cut = len(history)
+		}
+	}
+	if cut < 0 {
+		return nil
+	}
+	return slices.Concat(newHistory, history[cut:])
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package rebase
+
+import (
+	"encoding/json"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestMergeConfig(t *testing.T) {
+	t.Parallel()
+	oldConfig := `{
+		"Env": ["PATH=/usr/bin", "BASE_VERSION=1", "OLD_ONLY=1", "REMOVED=1"],
+		"Labels": {"base.version": "1", "old.only": "1", "vendor": "a"},
+		"Cmd": ["sh"],
+		"WorkingDir": "/",
+		"StopSignal": "SIGTERM"
+	}`
+	newConfig := `{
+		"Env": ["PATH=/usr/local/bin:/usr/bin", "BASE_VERSION=2", "NEW_ONLY=1", "REMOVED=2"],
+		"Labels": {"base.version": "2", "new.only": "1", "vendor": "b"},
+		"Cmd": ["bash"],
+		"WorkingDir": "/root",
+		"User": "1000"
+	}`
+	config := `{
+		"Env": ["PATH=/usr/bin", "BASE_VERSION=1", "OLD_ONLY=1", "APP=1"],
+		"Labels": {"base.version": "1", "old.only": "1", "vendor": "c", "app": "1"},
+		"Cmd": ["sh"],
+		"Entrypoint": ["/app"],
+		"WorkingDir": "/app",
+		"StopSignal": "SIGTERM"
+	}`
+	merged, err := MergeConfig(json.RawMessage(oldConfig), json.RawMessage(newConfig), json.RawMessage(config))
+	assert.NilError(t, err)
+	var got ocispec.ImageConfig
+	assert.NilError(t, json.Unmarshal(merged, &got))
+	assert.DeepEqual(t, got, ocispec.ImageConfig{
+		Env:        []string{"PATH=/usr/local/bin:/usr/bin", "BASE_VERSION=2", "NEW_ONLY=1", "APP=1"},
+		Labels:     map[string]string{"base.version": "2", "new.only": "1", "vendor": "c", "app": "1"},
+		Cmd:        []string{"bash"},
+		Entrypoint: []string{"/app"},
+		WorkingDir: "/app",
+		User:       "1000",
+	})
+}
+
+func TestMergeConfigPreservesUnknownFields(t *testing.T) {
+	t.Parallel()
+	merged, err := MergeConfig(
+		json.RawMessage(`{"Healthcheck": {"Test": ["CMD", "true"]}}`),
+		json.RawMessage(`{"Healthcheck": {"Test": ["CMD", "false"]}}`),
+		json.RawMessage(`{"Healthcheck": {"Test": ["CMD",  "true"]}, "ArgsEscaped": true}`),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, string(merged), `{"ArgsEscaped":true,"Healthcheck":{"Test":["CMD","false"]}}`)
+
+	merged, err = MergeConfig(nil, nil, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, merged == nil)
+}
+
+func TestRebaseHistory(t *testing.T) {
+	t.Parallel()
+	oldHistory := []ocispec.History{
+		{CreatedBy: "ADD old"},
+		{CreatedBy: "CMD sh", EmptyLayer: true},
+	}
+	newHistory := []ocispec.History{
+		{CreatedBy: "ADD new"},
+		{CreatedBy: "RUN apt-get update"},
+		{CreatedBy: "CMD bash", EmptyLayer: true},
+	}
+	history := []ocispec.History{
+		{CreatedBy: "ADD old"},
+		{CreatedBy: "CMD sh", EmptyLayer: true},
+		{CreatedBy: "COPY app /app"},
+		{CreatedBy: "ENTRYPOINT /app", EmptyLayer: true},
+	}
+	assert.DeepEqual(t, rebaseHistory(history, oldHistory, newHistory, 1), []ocispec.History{
+		{CreatedBy: "ADD new"},
+		{CreatedBy: "RUN apt-get update"},
+		{CreatedBy: "CMD bash", EmptyLayer: true},
+		{CreatedBy: "COPY app /app"},
+		{CreatedBy: "ENTRYPOINT /app", EmptyLayer: true},
+	})
+
+	// the old base has no history: the entries up to its layers are replaced
+	assert.DeepEqual(t, rebaseHistory(history, nil, newHistory, 1), []ocispec.History{
+		{CreatedBy: "ADD new"},
+		{CreatedBy: "RUN apt-get update"},
+		{CreatedBy: "CMD bash", EmptyLayer: true},
+		{CreatedBy: "CMD sh", EmptyLayer: true},
+		{CreatedBy: "COPY app /app"},
+		{CreatedBy: "ENTRYPOINT /app", EmptyLayer: true},
+	})
+
+	// the history of less layers than the old base
+	assert.Assert(t, rebaseHistory(history, nil, newHistory, 3) == nil)
+}
@@ -0,0 +1,468 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package referrers lists, fetches and pushes the OCI 1.1 referrers of manifests (e.g., SBOMs, signatures).
+// https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
+//
+// The referrers fetched into the content store are recorded with labels of the subject (see LabelPrefix),
+// so that they can be listed and pushed again, and are kept by the garbage collection as long as the subject is.
+package referrers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+)
+
+const (
+	// MaxManifestSize is the maximum size of the manifests and indexes read from registries.
+	MaxManifestSize = 4 << 20
+
+	// LabelPrefix is the prefix of the labels of a subject pointing to its referrers in the content store.
+	// Being a "containerd.io/gc.ref.content" label, it also protects the referrers from the garbage collection.
+	LabelPrefix = "containerd.io/gc.ref.content.referrer."
+)
+
+// errUnsupported is returned when the registry does not support the referrers API.
+var errUnsupported = errors.New("referrers API is not supported")
+
+// TagSchema returns the tag of the index listing the referrers of dgst, for the registries
+// not supporting the referrers API, e.g., "sha256-<hex>".
+func TagSchema(dgst digest.Digest) string {
+	return dgst.Algorithm().String() + "-" + dgst.Encoded()
+}
+
+// List lists the referrers of the manifest dgst in the repository name (e.g., "docker.io/library/alpine").
+// The referrers API is used when the registry supports it, and the referrers tag schema otherwise.
+// When artifactType is not empty, only the referrers with that artifact type are returned.
+func List(ctx context.Context, hosts docker.RegistryHosts, resolver remotes.Resolver, name string, dgst digest.Digest, artifactType string) ([]ocispec.Descriptor, error) {
+	refspec, err := reference.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = docker.ContextWithRepositoryScope(ctx, refspec, false)
+	if err != nil {
+		return nil, err
+	}
+	domain := refspec.Hostname()
+	repo := strings.TrimPrefix(refspec.Locator, domain+"/")
+
+	registryHosts, err := hosts(domain)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, host := range registryHosts {
+		if host.Capabilities&docker.HostCapabilityResolve == 0 {
+			continue
+		}
+		index, err := fetchFromAPI(ctx, host, repo, dgst, artifactType)
+		if err == nil {
+			return filter(index.Manifests, artifactType), nil
+		}
+		if errors.Is(err, errUnsupported) {
+			log.G(ctx).WithError(err).Debugf("falling back to the referrers tag schema for host %q", host.Host)
+			break
+		}
+		log.G(ctx).WithError(err).Debugf("failed to list the referrers on host %q", host.Host)
+		errs = append(errs, err)
+	}
+	index, err := fetchFromTagSchema(ctx, resolver, refspec.Locator, dgst)
+	if err != nil {
+		return nil, errors.Join(append(errs, err)...)
+	}
+	return filter(index.Manifests, artifactType), nil
+}
+
+func filter(descs []ocispec.Descriptor, artifactType string) []ocispec.Descriptor {
+	if artifactType == "" {
+		return descs
+	}
+	var res []ocispec.Descriptor
+	for _, desc := range descs {
+		if desc.ArtifactType == artifactType {
+			res = append(res, desc)
+		}
+	}
+	return res
+}
+
+func fetchFromAPI(ctx context.Context, host docker.RegistryHost, repo string, dgst digest.Digest, artifactType string) (*ocispec.Index, error) {
+	u := url.URL{
+		Scheme: host.Scheme,
+		Host:   host.Host,
+		Path:   host.Path + "/" + repo + "/referrers/" + dgst.String(),
+	}
+	if artifactType != "" {
+		u.RawQuery = url.Values{"artifactType": {artifactType}}.Encode()
+	}
+	res := &ocispec.Index{}
+	for next := u.String(); next != ""; {
+		page, link, err := fetchPage(ctx, host, next)
+		if err != nil {
+			return nil, err
+		}
+		res.Manifests = append(res.Manifests, page.Manifests...)
+		next = ""
+		if link != "" {
+			nextURL, err := u.Parse(link)
+			if err != nil {
+				return nil, fmt.Errorf("invalid link %q: %w", link, err)
+			}
+			next = nextURL.String()
+		}
+	}
+	return res, nil
+}
+
+// fetchPage fetches a page of the referrers API, and returns the link of the next page if any.
+func fetchPage(ctx context.Context, host docker.RegistryHost, u string) (*ocispec.Index, string, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range host.Header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Accept", ocispec.MediaTypeImageIndex)
+		if host.Authorizer != nil {
+			if err := host.Authorizer.Authorize(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+		return req, nil
+	}
+	client := host.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := newRequest()
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && host.Authorizer != nil {
+		resp.Body.Close()
+		if err := host.Authorizer.AddResponses(ctx, []*http.Response{resp}); err != nil {
+			return nil, "", err
+		}
+		if req, err = newRequest(); err != nil {
+			return nil, "", err
+		}
+		if resp, err = client.Do(req); err != nil {
+			return nil, "", err
+		}
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, "", fmt.Errorf("%w by %s (status %s)", errUnsupported, host.Host, resp.Status)
+	default:
+		return nil, "", fmt.Errorf("unexpected status from GET %s: %s", u, resp.Status)
+	}
+	if mediaType := resp.Header.Get("Content-Type"); !strings.HasPrefix(mediaType, ocispec.MediaTypeImageIndex) {
+		// e.g., an HTML page of a registry not knowing the endpoint
+		return nil, "", fmt.Errorf("%w by %s (Content-Type %q)", errUnsupported, host.Host, mediaType)
+	}
+	var index ocispec.Index
+	if err := json.NewDecoder(io.LimitReader(resp.Body, MaxManifestSize)).Decode(&index); err != nil {
+		return nil, "", fmt.Errorf("failed to decode the referrers of %s: %w", u, err)
+	}
+	return &index, nextLink(resp.Header.Get("Link")), nil
+}
+
+// nextLink parses a Link header like `</v2/foo/referrers/sha256:...?n=10&last=...>; rel="next"`.
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		if strings.HasPrefix(target, "<") && strings.HasSuffix(target, ">") {
+			return target[1 : len(target)-1]
+		}
+	}
+	return ""
+}
+
+func fetchFromTagSchema(ctx context.Context, resolver remotes.Resolver, locator string, dgst digest.Digest) (*ocispec.Index, error) {
+	_, desc, err := resolver.Resolve(ctx, locator+":"+TagSchema(dgst))
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return &ocispec.Index{}, nil
+		}
+		return nil, err
+	}
+	b, err := FetchManifest(ctx, resolver, locator, desc)
+	if err != nil {
+		return nil, err
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode the referrers index %s: %w", desc.Digest, err)
+	}
+	return &index, nil
+}
+
+// FetchManifest fetches the manifest (or any blob) desc of the repository locator, and verifies its digest.
+func FetchManifest(ctx context.Context, resolver remotes.Resolver, locator string, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size > MaxManifestSize {
+		return nil, fmt.Errorf("%s is too large (%d bytes)", desc.Digest, desc.Size)
+	}
+	fetcher, err := resolver.Fetcher(ctx, locator)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, MaxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) != desc.Size || desc.Digest.Algorithm().FromBytes(b) != desc.Digest {
+		return nil, fmt.Errorf("content of %s does not match its descriptor", desc.Digest)
+	}
+	return b, nil
+}
+
+func labelKey(dgst digest.Digest) string {
+	return LabelPrefix + dgst.Algorithm().String() + "." + dgst.Encoded()
+}
+
+// Local returns the referrers of the subject recorded in the content store.
+func Local(ctx context.Context, cs content.Store, subject digest.Digest) ([]ocispec.Descriptor, error) {
+	info, err := cs.Info(ctx, subject)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var res []ocispec.Descriptor
+	for k, v := range info.Labels {
+		if !strings.HasPrefix(k, LabelPrefix) {
+			continue
+		}
+		dgst, err := digest.Parse(v)
+		if err != nil {
+			log.G(ctx).WithError(err).Warnf("invalid label %s=%s of %s", k, v, subject)
+			continue
+		}
+		desc, err := localDescriptor(ctx, cs, dgst)
+		if err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to read the referrer %s of %s", dgst, subject)
+			continue
+		}
+		res = append(res, desc)
+	}
+	slices.SortFunc(res, func(a, b ocispec.Descriptor) int {
+		return strings.Compare(a.Digest.String(), b.Digest.String())
+	})
+	return res, nil
+}
+
+// localDescriptor returns the descriptor of the referrer dgst in the content store, as listed by the referrers API.
+func localDescriptor(ctx context.Context, cs content.Store, dgst digest.Digest) (ocispec.Descriptor, error) {
+	info, err := cs.Info(ctx, dgst)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{Digest: dgst, Size: info.Size}
+	b, err := content.ReadBlob(ctx, cs, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	var m struct {
+		MediaType    string             `json:"mediaType"`
+		ArtifactType string             `json:"artifactType"`
+		Config       ocispec.Descriptor `json:"config"`
+		Annotations  map[string]string  `json:"annotations"`
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc.MediaType = m.MediaType
+	desc.ArtifactType = m.ArtifactType
+	if desc.ArtifactType == "" && m.MediaType == ocispec.MediaTypeImageManifest {
+		// https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
+		desc.ArtifactType = m.Config.MediaType
+	}
+	desc.Annotations = m.Annotations
+	return desc, nil
+}
+
+// Record records in the content store that desc is a referrer of the subject.
+// The subject must be present in the content store.
+func Record(ctx context.Context, cs content.Store, subject digest.Digest, desc ocispec.Descriptor) error {
+	key := labelKey(desc.Digest)
+	_, err := cs.Update(ctx, content.Info{
+		Digest: subject,
+		Labels: map[string]string{key: desc.Digest.String()},
+	}, "labels."+key)
+	return err
+}
+
+// Fetch fetches the referrers of the subject from the repository name into the content store, and records them.
+func Fetch(ctx context.Context, cs content.Store, hosts docker.RegistryHosts, resolver remotes.Resolver, name string, subject digest.Digest) ([]ocispec.Descriptor, error) {
+	descs, err := List(ctx, hosts, resolver, name, subject, "")
+	if err != nil {
+		return nil, err
+	}
+	refspec, err := reference.Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	fetcher, err := resolver.Fetcher(ctx, refspec.Locator)
+	if err != nil {
+		return nil, err
+	}
+	handler := images.Handlers(
+		remotes.FetchHandler(cs, fetcher),
+		images.SetChildrenLabels(cs, images.ChildrenHandler(cs)),
+	)
+	for _, desc := range descs {
+		if err := images.Dispatch(ctx, handler, nil, desc); err != nil {
+			return nil, fmt.Errorf("failed to fetch the referrer %s: %w", desc.Digest, err)
+		}
+		if err := Record(ctx, cs, subject, desc); err != nil {
+			return nil, err
+		}
+		log.G(ctx).Debugf("fetched the referrer %s (%s) of %s", desc.Digest, desc.ArtifactType, subject)
+	}
+	return descs, nil
+}
+
+// Push pushes the referrers (and their content) of the subject to the repository name.
+// When the registry does not support the referrers API, the index of the referrers tag schema is updated.
+func Push(ctx context.Context, cs content.Store, hosts docker.RegistryHosts, resolver remotes.Resolver, name string, subject digest.Digest, descs []ocispec.Descriptor) error {
+	if len(descs) == 0 {
+		return nil
+	}
+	refspec, err := reference.Parse(name)
+	if err != nil {
+		return err
+	}
+	for _, desc := range descs {
+		pusher, err := resolver.Pusher(ctx, refspec.Locator+"@"+desc.Digest.String())
+		if err != nil {
+			return err
+		}
+		if err := remotes.PushContent(ctx, pusher, desc, cs, nil, nil, nil); err != nil {
+			return fmt.Errorf("failed to push the referrer %s: %w", desc.Digest, err)
+		}
+		log.G(ctx).Debugf("pushed the referrer %s (%s) of %s", desc.Digest, desc.ArtifactType, subject)
+	}
+	supported, err := supportsAPI(ctx, hosts, refspec, subject)
+	if err != nil {
+		return err
+	}
+	if supported {
+		return nil
+	}
+	return updateTagSchema(ctx, resolver, refspec.Locator, subject, descs)
+}
+
+// supportsAPI returns true if the registry of refspec supports the referrers API.
+func supportsAPI(ctx context.Context, hosts docker.RegistryHosts, refspec reference.Spec, subject digest.Digest) (bool, error) {
+	ctx, err := docker.ContextWithRepositoryScope(ctx, refspec, false)
+	if err != nil {
+		return false, err
+	}
+	domain := refspec.Hostname()
+	registryHosts, err := hosts(domain)
+	if err != nil {
+		return false, err
+	}
+	for _, host := range registryHosts {
+		if host.Capabilities&docker.HostCapabilityPush == 0 {
+			continue
+		}
+		_, err := fetchFromAPI(ctx, host, strings.TrimPrefix(refspec.Locator, domain+"/"), subject, "")
+		if errors.Is(err, errUnsupported) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, fmt.Errorf("no host of %q supports pushing", domain)
+}
+
+// updateTagSchema adds descs to the index of the referrers tag schema of the subject.
+func updateTagSchema(ctx context.Context, resolver remotes.Resolver, locator string, subject digest.Digest, descs []ocispec.Descriptor) error {
+	index, err := fetchFromTagSchema(ctx, resolver, locator, subject)
+	if err != nil {
+		return err
+	}
+	index.Versioned = specs.Versioned{SchemaVersion: 2}
+	index.MediaType = ocispec.MediaTypeImageIndex
+	for _, desc := range descs {
+		if !slices.ContainsFunc(index.Manifests, func(d ocispec.Descriptor) bool { return d.Digest == desc.Digest }) {
+			index.Manifests = append(index.Manifests, desc)
+		}
+	}
+	b, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(b),
+		Size:      int64(len(b)),
+	}
+	pusher, err := resolver.Pusher(ctx, locator+":"+TagSchema(subject))
+	if err != nil {
+		return err
+	}
+	w, err := pusher.Push(ctx, indexDesc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	defer w.Close()
+	if err := content.Copy(ctx, w, bytes.NewReader(b), indexDesc.Size, indexDesc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to push the referrers index %s: %w", TagSchema(subject), err)
+	}
+	return nil
+}
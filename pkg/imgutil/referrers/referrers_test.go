@@ -0,0 +1,343 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package referrers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+func TestTagSchema(t *testing.T) {
+	t.Parallel()
+	dgst := digest.FromString("foo")
+	assert.Equal(t, TagSchema(dgst), "sha256-"+dgst.Encoded())
+}
+
+func TestNextLink(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, nextLink(""), "")
+	assert.Equal(t, nextLink(`</v2/foo/referrers/sha256:abc?n=1&last=x>; rel="next"`), "/v2/foo/referrers/sha256:abc?n=1&last=x")
+	assert.Equal(t, nextLink(`</prev>; rel="prev", </next>;rel="next"`), "/next")
+	assert.Equal(t, nextLink(`</prev>; rel="prev"`), "")
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	descs := []ocispec.Descriptor{
+		{ArtifactType: "application/vnd.example.sbom"},
+		{ArtifactType: "application/vnd.example.sig"},
+	}
+	assert.DeepEqual(t, filter(descs, ""), descs)
+	assert.DeepEqual(t, filter(descs, "application/vnd.example.sig"), descs[1:])
+	assert.Equal(t, len(filter(descs, "application/vnd.example.other")), 0)
+}
+
+// labelStore is an in-memory local.LabelStore.
+type labelStore struct {
+	mu     sync.Mutex
+	labels map[digest.Digest]map[string]string
+}
+
+func (s *labelStore) Get(dgst digest.Digest) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.labels[dgst]), nil
+}
+
+func (s *labelStore) Set(dgst digest.Digest, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.labels[dgst] = maps.Clone(labels)
+	return nil
+}
+
+func (s *labelStore) Update(dgst digest.Digest, update map[string]string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	labels := s.labels[dgst]
+	if labels == nil {
+		labels = make(map[string]string)
+		s.labels[dgst] = labels
+	}
+	for k, v := range update {
+		if v == "" {
+			delete(labels, k)
+		} else {
+			labels[k] = v
+		}
+	}
+	return maps.Clone(labels), nil
+}
+
+func writeContent(t *testing.T, cs content.Store, mediaType string, v any) ocispec.Descriptor {
+	t.Helper()
+	b, ok := v.([]byte)
+	if !ok {
+		var err error
+		b, err = json.Marshal(v)
+		assert.NilError(t, err)
+	}
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+	assert.NilError(t, content.WriteBlob(context.Background(), cs, desc.Digest.String(), bytes.NewReader(b), desc))
+	return desc
+}
+
+func TestLocal(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cs, err := local.NewLabeledStore(t.TempDir(), &labelStore{labels: make(map[digest.Digest]map[string]string)})
+	assert.NilError(t, err)
+
+	config := writeContent(t, cs, ocispec.MediaTypeImageConfig, []byte("{}"))
+	subject := writeContent(t, cs, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+	})
+	descs, err := Local(ctx, cs, subject.Digest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(descs), 0)
+
+	sbom := writeContent(t, cs, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/spdx+json",
+		Config:       ocispec.DescriptorEmptyJSON,
+		Subject:      &subject,
+		Annotations:  map[string]string{"org.example": "sbom"},
+	})
+	// the artifact type defaults to the media type of the config
+	sig := writeContent(t, cs, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: "application/vnd.example.sig", Digest: config.Digest, Size: config.Size},
+		Subject:   &subject,
+	})
+	assert.NilError(t, Record(ctx, cs, subject.Digest, sbom))
+	assert.NilError(t, Record(ctx, cs, subject.Digest, sig))
+	// recording twice is a no-op
+	assert.NilError(t, Record(ctx, cs, subject.Digest, sig))
+
+	descs, err = Local(ctx, cs, subject.Digest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(descs), 2)
+	for _, desc := range descs {
+		switch desc.Digest {
+		case sbom.Digest:
+			assert.Equal(t, desc.ArtifactType, "application/spdx+json")
+			assert.DeepEqual(t, desc.Annotations, map[string]string{"org.example": "sbom"})
+		case sig.Digest:
+			assert.Equal(t, desc.ArtifactType, "application/vnd.example.sig")
+		default:
+			t.Fatalf("unexpected referrer %s", desc.Digest)
+		}
+		assert.Equal(t, desc.MediaType, ocispec.MediaTypeImageManifest)
+	}
+
+	descs, err = Local(ctx, cs, digest.FromString("missing"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(descs), 0)
+}
+
+// fakeRegistry is a minimal registry serving and accepting the content of a single repository.
+type fakeRegistry struct {
+	mu           sync.Mutex
+	repo         string
+	manifests    map[string]digest.Digest // by tag and digest
+	blobs        map[digest.Digest][]byte
+	mediaTypes   map[digest.Digest]string
+	referrersAPI bool
+}
+
+func newFakeRegistry(t *testing.T, referrersAPI bool) (*fakeRegistry, string) {
+	r := &fakeRegistry{
+		repo:         "test/app",
+		manifests:    make(map[string]digest.Digest),
+		blobs:        make(map[digest.Digest][]byte),
+		mediaTypes:   make(map[digest.Digest]string),
+		referrersAPI: referrersAPI,
+	}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, strings.TrimPrefix(srv.URL, "http://") + "/" + r.repo
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prefix := "/v2/" + r.repo + "/"
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.HasPrefix(req.URL.Path, prefix+"manifests/") && req.Method == http.MethodPut:
+		b, _ := io.ReadAll(req.Body)
+		dgst := digest.FromBytes(b)
+		r.blobs[dgst] = b
+		r.mediaTypes[dgst] = req.Header.Get("Content-Type")
+		r.manifests[dgst.String()] = dgst
+		r.manifests[strings.TrimPrefix(req.URL.Path, prefix+"manifests/")] = dgst
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(req.URL.Path, prefix+"manifests/"):
+		dgst, ok := r.manifests[strings.TrimPrefix(req.URL.Path, prefix+"manifests/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		r.serveBlob(w, req, dgst)
+	case req.URL.Path == prefix+"blobs/uploads/" && req.Method == http.MethodPost:
+		w.Header().Set("Location", prefix+"blobs/uploads/upload")
+		w.WriteHeader(http.StatusAccepted)
+	case req.URL.Path == prefix+"blobs/uploads/upload" && req.Method == http.MethodPut:
+		b, _ := io.ReadAll(req.Body)
+		dgst := digest.FromBytes(b)
+		r.blobs[dgst] = b
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(req.URL.Path, prefix+"blobs/"):
+		r.serveBlob(w, req, digest.Digest(strings.TrimPrefix(req.URL.Path, prefix+"blobs/")))
+	case strings.HasPrefix(req.URL.Path, prefix+"referrers/") && r.referrersAPI:
+		subject := digest.Digest(strings.TrimPrefix(req.URL.Path, prefix+"referrers/"))
+		index := ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: []ocispec.Descriptor{},
+		}
+		for dgst, b := range r.blobs {
+			var m ocispec.Manifest
+			if r.mediaTypes[dgst] != ocispec.MediaTypeImageManifest || json.Unmarshal(b, &m) != nil {
+				continue
+			}
+			if m.Subject != nil && m.Subject.Digest == subject {
+				index.Manifests = append(index.Manifests, ocispec.Descriptor{
+					MediaType:    ocispec.MediaTypeImageManifest,
+					ArtifactType: m.ArtifactType,
+					Digest:       dgst,
+					Size:         int64(len(b)),
+				})
+			}
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		json.NewEncoder(w).Encode(index)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *fakeRegistry) serveBlob(w http.ResponseWriter, req *http.Request, dgst digest.Digest) {
+	b, ok := r.blobs[dgst]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	mediaType := r.mediaTypes[dgst]
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	if req.Method != http.MethodHead {
+		w.Write(b)
+	}
+}
+
+func TestPushFetch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, referrersAPI := range []bool{true, false} {
+		t.Run("referrersAPI="+strconv.FormatBool(referrersAPI), func(t *testing.T) {
+			t.Parallel()
+			_, name := newFakeRegistry(t, referrersAPI)
+			hosts := func(host string) ([]docker.RegistryHost, error) {
+				return []docker.RegistryHost{{
+					Client:       http.DefaultClient,
+					Host:         host,
+					Scheme:       "http",
+					Path:         "/v2",
+					Capabilities: docker.HostCapabilityPull | docker.HostCapabilityResolve | docker.HostCapabilityPush,
+				}}, nil
+			}
+			resolver := docker.NewResolver(docker.ResolverOptions{Hosts: hosts})
+
+			src, err := local.NewLabeledStore(t.TempDir(), &labelStore{labels: make(map[digest.Digest]map[string]string)})
+			assert.NilError(t, err)
+			subject := writeContent(t, src, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				MediaType: ocispec.MediaTypeImageManifest,
+				Config:    writeContent(t, src, ocispec.MediaTypeImageConfig, []byte("{}")),
+			})
+			var descs []ocispec.Descriptor
+			for _, artifactType := range []string{"application/spdx+json", "application/vnd.in-toto+json"} {
+				layer := writeContent(t, src, "application/octet-stream", []byte("content of "+artifactType))
+				desc := writeContent(t, src, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+					Versioned:    specs.Versioned{SchemaVersion: 2},
+					MediaType:    ocispec.MediaTypeImageManifest,
+					ArtifactType: artifactType,
+					Config:       writeContent(t, src, ocispec.MediaTypeEmptyJSON, []byte("{}")),
+					Layers:       []ocispec.Descriptor{layer},
+					Subject:      &subject,
+				})
+				desc.ArtifactType = artifactType
+				descs = append(descs, desc)
+			}
+			// pushing one by one, to test the update of the tag schema index
+			assert.NilError(t, Push(ctx, src, hosts, resolver, name, subject.Digest, descs[:1]))
+			assert.NilError(t, Push(ctx, src, hosts, resolver, name, subject.Digest, descs))
+
+			listed, err := List(ctx, hosts, resolver, name, subject.Digest, "")
+			assert.NilError(t, err)
+			assert.Equal(t, len(listed), 2)
+
+			dst, err := local.NewLabeledStore(t.TempDir(), &labelStore{labels: make(map[digest.Digest]map[string]string)})
+			assert.NilError(t, err)
+			// the subject must be present for recording the referrers
+			b, err := content.ReadBlob(ctx, src, subject)
+			assert.NilError(t, err)
+			writeContent(t, dst, subject.MediaType, b)
+			fetched, err := Fetch(ctx, dst, hosts, resolver, name, subject.Digest)
+			assert.NilError(t, err)
+			assert.Equal(t, len(fetched), 2)
+
+			recorded, err := Local(ctx, dst, subject.Digest)
+			assert.NilError(t, err)
+			assert.Equal(t, len(recorded), 2)
+			for _, desc := range descs {
+				_, err := content.ReadBlob(ctx, dst, desc)
+				assert.NilError(t, err)
+			}
+		})
+	}
+}
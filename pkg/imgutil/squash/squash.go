@@ -0,0 +1,377 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package squash squashes the layers of an image into one layer.
+package squash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/diff"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/containerd/v2/pkg/rootfs"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+	"github.com/containerd/platforms"
+
+	"github.com/containerd/nerdctl/v2/pkg/compression"
+	"github.com/containerd/nerdctl/v2/pkg/config"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+)
+
+// Opts are the options of Squash.
+type Opts struct {
+	// Layers is the range of the layers to squash, counted from the bottom layer, like a Go slice expression
+	// with negative indexes counted from the top layer, e.g., "" or ":" for all the layers, "-3:" for the top three layers
+	Layers string
+	// Compression is the compression of the squashed layer
+	Compression compression.Spec
+	// Snapshotter is the snapshotter used to apply the layers, and to unpack the squashed image
+	Snapshotter string
+	// Message is the comment of the history entry of the squashed layer
+	Message string
+	// Platform is the platform of img, to unpack the squashed image (the default platform if nil)
+	Platform platforms.MatchComparer
+}
+
+// CompressionSpec returns the compression spec of the squashed layers for the algorithm ("gzip" or "zstd", gzip if empty),
+// with the zstd level of the compression config.
+func CompressionSpec(algorithm string, conf *config.CompressionConfig) (compression.Spec, error) {
+	if algorithm == "" {
+		algorithm = compression.Gzip
+	}
+	spec, err := compression.ParseSpec(algorithm)
+	if err != nil {
+		return spec, err
+	}
+	if spec.Algorithm == compression.Zstd && conf != nil && conf.ZstdCompressionLevel > 0 {
+		return compression.ParseSpec(fmt.Sprintf("%s:%d", compression.Zstd, conf.ZstdCompressionLevel))
+	}
+	return spec, nil
+}
+
+// ParseRange parses the range of layers s (see Opts.Layers) for an image with n layers,
+// and returns the indexes of the first layer and of the layer after the last one.
+func ParseRange(s string, n int) (int, int, error) {
+	if s == "" {
+		return 0, n, nil
+	}
+	fromStr, toStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range of layers %q, should be like \"START:END\": %w", s, errdefs.ErrInvalidArgument)
+	}
+	index := func(v string, def int) (int, error) {
+		if v == "" {
+			return def, nil
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid layer index %q: %w", v, errdefs.ErrInvalidArgument)
+		}
+		if i < 0 {
+			i += n
+		}
+		return i, nil
+	}
+	from, err := index(fromStr, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := index(toStr, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from < 0 || to > n || from > to {
+		return 0, 0, fmt.Errorf("range of layers %q is out of the %d layers of the image: %w", s, n, errdefs.ErrInvalidArgument)
+	}
+	return from, to, nil
+}
+
+// Squash squashes a range of the layers of img, for the platform of img, into one layer,
+// and stores the resulting image as name.
+//
+// The layers are applied in order on a temporary snapshot, which is diffed again with its parent,
+// so the files deleted by the upper layers (i.e., the whiteouts) are not in the squashed layer.
+// All the layers of the range must be present in the content store.
+func Squash(ctx context.Context, client *containerd.Client, img containerd.Image, name string, opts Opts) (images.Image, error) {
+	cs := client.ContentStore()
+	manifest, manifestDesc, err := imgutil.ReadManifest(ctx, img)
+	if err != nil {
+		return images.Image{}, err
+	}
+	if manifest == nil {
+		return images.Image{}, fmt.Errorf("image %q has no manifest for the platform: %w", img.Name(), errdefs.ErrNotFound)
+	}
+	rawConfig, err := content.ReadBlob(ctx, cs, manifest.Config)
+	if err != nil {
+		return images.Image{}, err
+	}
+	var config ocispec.Image
+	if err := json.Unmarshal(rawConfig, &config); err != nil {
+		return images.Image{}, err
+	}
+	diffIDs := config.RootFS.DiffIDs
+	if len(diffIDs) != len(manifest.Layers) {
+		return images.Image{}, fmt.Errorf("image %q has %d layers but %d diff IDs", img.Name(), len(manifest.Layers), len(diffIDs))
+	}
+	from, to, err := ParseRange(opts.Layers, len(manifest.Layers))
+	if err != nil {
+		return images.Image{}, err
+	}
+	if to-from < 2 {
+		log.G(ctx).Infof("Nothing to squash in image %q: the range has %d layer(s)", img.Name(), to-from)
+		return storeImage(ctx, client, name, img.Target(), opts)
+	}
+
+	// Don't gc me and clean the dirty data after 1 hour!
+	ctx, done, err := client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
+	if err != nil {
+		return images.Image{}, fmt.Errorf("failed to create lease for squash: %w", err)
+	}
+	defer done(ctx)
+
+	if from > 0 {
+		// the layers below the range are the parent of the squashed layer
+		if unpacked, err := img.IsUnpacked(ctx, opts.Snapshotter); err != nil {
+			return images.Image{}, err
+		} else if !unpacked {
+			if err := img.Unpack(ctx, opts.Snapshotter); err != nil {
+				return images.Image{}, err
+			}
+		}
+	}
+	docker := manifestDesc.MediaType == images.MediaTypeDockerSchema2Manifest
+	layer, diffID, err := squashLayers(ctx, client, opts.Snapshotter, slices.Clone(diffIDs[:from]), manifest.Layers[from:to], opts.Compression, docker)
+	if err != nil {
+		return images.Image{}, err
+	}
+
+	newDiffIDs := slices.Concat(diffIDs[:from], []digest.Digest{diffID}, diffIDs[to:])
+	newLayers := slices.Concat(manifest.Layers[:from], []ocispec.Descriptor{layer}, manifest.Layers[to:])
+	now := time.Now().UTC()
+	comment := opts.Message
+	if comment == "" {
+		comment = fmt.Sprintf("squashed %d layers", to-from)
+	}
+	history := squashHistory(config.History, len(diffIDs), from, to, ocispec.History{
+		Created:   &now,
+		CreatedBy: "nerdctl image squash",
+		Comment:   comment,
+	})
+	if history == nil && config.History != nil {
+		log.G(ctx).Warnf("The history of image %q does not match its layers, dropping it", img.Name())
+	}
+
+	configDesc, err := writeConfig(ctx, cs, rawConfig, manifest.Config.MediaType, newDiffIDs, history, opts.Snapshotter)
+	if err != nil {
+		return images.Image{}, err
+	}
+	newManifestDesc, err := writeManifest(ctx, cs, *manifestDesc, configDesc, newLayers)
+	if err != nil {
+		return images.Image{}, err
+	}
+	return storeImage(ctx, client, name, newManifestDesc, opts)
+}
+
+// squashLayers applies layers on a snapshot of the parent layers, and returns the compressed diff of the snapshot
+// with its parent, and its diff ID. The snapshot is committed as the chain of the squashed layer.
+func squashLayers(ctx context.Context, client *containerd.Client, snapshotter string, parentDiffIDs []digest.Digest,
+	layers []ocispec.Descriptor, spec compression.Spec, docker bool) (ocispec.Descriptor, digest.Digest, error) {
+	parent := identity.ChainID(parentDiffIDs)
+	sn := client.SnapshotService(snapshotter)
+	differ := client.DiffService()
+	key := fmt.Sprintf("squash-%d-%s", time.Now().UnixNano(), parent)
+	mounts, err := sn.Prepare(ctx, key, parent.String())
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			// NOTE: the snapshot is held by the lease, the containerd gc deletes it when the removal fails.
+			if err := sn.Remove(ctx, key); err != nil {
+				log.G(ctx).WithError(err).Warnf("failed to remove snapshot %q", key)
+			}
+		}
+	}()
+
+	for _, l := range layers {
+		if _, err := differ.Apply(ctx, l, mounts); err != nil {
+			return ocispec.Descriptor{}, "", fmt.Errorf("failed to apply layer %s: %w", l.Digest, err)
+		}
+	}
+	uncompressed, err := rootfs.CreateDiff(ctx, key, sn, differ, diff.WithMediaType(ocispec.MediaTypeImageLayer))
+	if err != nil {
+		return ocispec.Descriptor{}, "", fmt.Errorf("failed to create the squashed layer: %w", err)
+	}
+	diffID := uncompressed.Digest
+	layer, err := compressLayer(ctx, client.ContentStore(), uncompressed, spec, docker)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	chainID := identity.ChainID(append(parentDiffIDs, diffID)).String()
+	if err := sn.Commit(ctx, chainID, key); err != nil && !errdefs.IsAlreadyExists(err) {
+		return ocispec.Descriptor{}, "", err
+	} else if err == nil {
+		committed = true
+	}
+	return layer, diffID, nil
+}
+
+// compressLayer compresses the uncompressed layer desc into the content store.
+func compressLayer(ctx context.Context, cs content.Store, desc ocispec.Descriptor, spec compression.Spec, docker bool) (ocispec.Descriptor, error) {
+	ra, err := cs.ReaderAt(ctx, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer ra.Close()
+
+	ref := "squash-" + spec.Algorithm + "-" + desc.Digest.String()
+	w, err := content.OpenWriter(ctx, cs, content.WithRef(ref))
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer w.Close()
+	if err := w.Truncate(0); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	cw, err := compression.NewWriter(w, spec)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if _, err := io.Copy(cw, content.NewReader(ra)); err != nil {
+		cw.Close()
+		return ocispec.Descriptor{}, err
+	}
+	if err := cw.Close(); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	layer := ocispec.Descriptor{
+		MediaType: layerMediaType(spec.Algorithm, docker),
+		Digest:    w.Digest(),
+		Size:      status.Offset,
+	}
+	labels := map[string]string{"containerd.io/uncompressed": desc.Digest.String()}
+	if err := w.Commit(ctx, layer.Size, layer.Digest, content.WithLabels(labels)); err != nil && !errdefs.IsAlreadyExists(err) {
+		return ocispec.Descriptor{}, err
+	}
+	return layer, nil
+}
+
+func layerMediaType(algorithm string, docker bool) string {
+	switch {
+	case algorithm == compression.Zstd && docker:
+		return images.MediaTypeDockerSchema2LayerZstd
+	case algorithm == compression.Zstd:
+		return ocispec.MediaTypeImageLayerZstd
+	case docker:
+		return images.MediaTypeDockerSchema2LayerGzip
+	default:
+		return ocispec.MediaTypeImageLayerGzip
+	}
+}
+
+// squashHistory replaces the history entries of the layers [from, to), and the empty layers between them, with entry.
+// It returns nil when the history does not match the n layers of the image.
+func squashHistory(history []ocispec.History, n, from, to int, entry ocispec.History) []ocispec.History {
+	var start, end, layer int
+	for i, h := range history {
+		if h.EmptyLayer {
+			continue
+		}
+		if layer == from {
+			start = i
+		}
+		if layer == to-1 {
+			end = i
+		}
+		layer++
+	}
+	if layer != n || to <= from {
+		return nil
+	}
+	squashed := append([]ocispec.History{}, history[:start]...)
+	squashed = append(squashed, entry)
+	return append(squashed, history[end+1:]...)
+}
+
+// writeConfig writes the config rawConfig with diffIDs and history, preserving the fields unknown to ocispec.Image.
+func writeConfig(ctx context.Context, cs content.Store, rawConfig []byte, mediaType string, diffIDs []digest.Digest, history []ocispec.History, snapshotter string) (ocispec.Descriptor, error) {
+	b, err := imgutil.PatchJSON(rawConfig, map[string]any{
+		"rootfs":  ocispec.RootFS{Type: "layers", DiffIDs: diffIDs},
+		"history": history,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	// config should reference to snapshotter
+	labels := map[string]string{
+		fmt.Sprintf("containerd.io/gc.ref.snapshot.%s", snapshotter): identity.ChainID(diffIDs).String(),
+	}
+	return imgutil.WriteBlob(ctx, cs, mediaType, b, labels)
+}
+
+// writeManifest writes the manifest of desc with config and layers, preserving its other fields (e.g., annotations).
+func writeManifest(ctx context.Context, cs content.Store, desc, config ocispec.Descriptor, layers []ocispec.Descriptor) (ocispec.Descriptor, error) {
+	raw, err := content.ReadBlob(ctx, cs, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	b, err := imgutil.PatchJSON(raw, map[string]any{
+		"config": config,
+		"layers": layers,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	// new manifest should reference the layers and config content
+	return imgutil.WriteBlob(ctx, cs, desc.MediaType, b, nil, append([]ocispec.Descriptor{config}, layers...)...)
+}
+
+// storeImage creates or updates the image name with target, and unpacks it.
+func storeImage(ctx context.Context, client *containerd.Client, name string, target ocispec.Descriptor, opts Opts) (images.Image, error) {
+	img, err := imgutil.StoreImage(ctx, client, name, target)
+	if err != nil {
+		return images.Image{}, err
+	}
+	platform := opts.Platform
+	if platform == nil {
+		platform = platforms.DefaultStrict()
+	}
+	if err := containerd.NewImageWithPlatform(client, img, platform).Unpack(ctx, opts.Snapshotter); err != nil {
+		return images.Image{}, err
+	}
+	return img, nil
+}
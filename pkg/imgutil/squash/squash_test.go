@@ -0,0 +1,97 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package squash
+
+import (
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/compression"
+	"github.com/containerd/nerdctl/v2/pkg/config"
+)
+
+func TestParseRange(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		s        string
+		from, to int
+		err      string
+	}{
+		{s: "", from: 0, to: 5},
+		{s: ":", from: 0, to: 5},
+		{s: "-3:", from: 2, to: 5},
+		{s: "1:3", from: 1, to: 3},
+		{s: ":-1", from: 0, to: 4},
+		{s: "3", err: "invalid range"},
+		{s: "a:", err: "invalid layer index"},
+		{s: "2:6", err: "out of the 5 layers"},
+		{s: "-6:", err: "out of the 5 layers"},
+		{s: "3:2", err: "out of the 5 layers"},
+	}
+	for _, tc := range testCases {
+		from, to, err := ParseRange(tc.s, 5)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.s)
+			continue
+		}
+		assert.NilError(t, err, tc.s)
+		assert.Equal(t, from, tc.from, tc.s)
+		assert.Equal(t, to, tc.to, tc.s)
+	}
+}
+
+func TestSquashHistory(t *testing.T) {
+	t.Parallel()
+	history := []ocispec.History{
+		{CreatedBy: "ADD rootfs"},
+		{CreatedBy: "ENV A=1", EmptyLayer: true},
+		{CreatedBy: "RUN a"},
+		{CreatedBy: "WORKDIR /app", EmptyLayer: true},
+		{CreatedBy: "RUN b"},
+		{CreatedBy: "CMD b", EmptyLayer: true},
+	}
+	entry := ocispec.History{CreatedBy: "nerdctl image squash"}
+
+	assert.DeepEqual(t, squashHistory(history, 3, 1, 3, entry), []ocispec.History{
+		{CreatedBy: "ADD rootfs"},
+		{CreatedBy: "ENV A=1", EmptyLayer: true},
+		entry,
+		{CreatedBy: "CMD b", EmptyLayer: true},
+	})
+	assert.DeepEqual(t, squashHistory(history, 3, 0, 3, entry), []ocispec.History{
+		entry,
+		{CreatedBy: "CMD b", EmptyLayer: true},
+	})
+	// the history of another number of layers
+	assert.Assert(t, squashHistory(history, 4, 0, 4, entry) == nil)
+}
+
+func TestCompressionSpec(t *testing.T) {
+	t.Parallel()
+	spec, err := CompressionSpec("", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, spec.Algorithm, compression.Gzip)
+
+	spec, err = CompressionSpec("zstd", &config.CompressionConfig{ZstdCompressionLevel: 9})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, spec, compression.Spec{Algorithm: compression.Zstd, Level: 9})
+
+	_, err = CompressionSpec("lz4", nil)
+	assert.ErrorContains(t, err, "unsupported compression")
+}
@@ -0,0 +1,95 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package imgutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+)
+
+// PatchJSON replaces the fields of the JSON object raw, preserving the other fields (e.g., the fields unknown to ocispec).
+// The fields whose values are marshaled to null are removed.
+func PatchJSON(raw []byte, fields map[string]any) ([]byte, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == "null" {
+			delete(m, k)
+			continue
+		}
+		m[k] = b
+	}
+	return json.Marshal(m)
+}
+
+// WriteBlob writes the manifest, index or config b into the content store.
+// The blob references children for the garbage collection, and is labeled with labels.
+func WriteBlob(ctx context.Context, cs content.Ingester, mediaType string, b []byte, labels map[string]string, children ...ocispec.Descriptor) (ocispec.Descriptor, error) {
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(b),
+		Size:      int64(len(b)),
+	}
+	allLabels := make(map[string]string, len(labels)+len(children))
+	for k, v := range labels {
+		allLabels[k] = v
+	}
+	for i, c := range children {
+		allLabels[fmt.Sprintf("containerd.io/gc.ref.content.%d", i)] = c.Digest.String()
+	}
+	if err := content.WriteBlob(ctx, cs, desc.Digest.String(), bytes.NewReader(b), desc, content.WithLabels(allLabels)); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// StoreImage creates the image name with target, or updates it when it exists.
+func StoreImage(ctx context.Context, client *containerd.Client, name string, target ocispec.Descriptor) (images.Image, error) {
+	img := images.Image{
+		Name:      name,
+		Target:    target,
+		CreatedAt: time.Now(),
+	}
+	is := client.ImageService()
+	updated, err := is.Update(ctx, img)
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			return images.Image{}, err
+		}
+		if updated, err = is.Create(ctx, img); err != nil {
+			return images.Image{}, fmt.Errorf("failed to create new image %s: %w", name, err)
+		}
+	}
+	return updated, nil
+}
@@ -33,6 +33,7 @@ import (
 	ptypes "github.com/containerd/containerd/v2/pkg/protobuf/types"
 	"github.com/containerd/log"
 
+	"github.com/containerd/nerdctl/v2/pkg/binfmt"
 	"github.com/containerd/nerdctl/v2/pkg/buildkitutil"
 	compzstd "github.com/containerd/nerdctl/v2/pkg/compression/zstd"
 	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
@@ -101,6 +102,15 @@ func Info(ctx context.Context, client *containerd.Client, snapshotter, cgroupMan
 	return &info, nil
 }
 
+// EmulatedPlatforms returns the platforms emulated with binfmt_misc, e.g., "linux/arm64".
+func EmulatedPlatforms() []string {
+	var ss []string
+	for _, arch := range binfmt.Emulated(binfmt.Dir) {
+		ss = append(ss, "linux/"+arch)
+	}
+	return ss
+}
+
 func GetSnapshotterNames(ctx context.Context, introService introspection.Service) ([]string, error) {
 	var names []string
 	plugins, err := introService.Plugins(ctx)
@@ -30,6 +30,7 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/defaults"
 	"github.com/containerd/nerdctl/v2/pkg/inspecttypes/dockercompat"
 	"github.com/containerd/nerdctl/v2/pkg/rootlessutil"
+	"github.com/containerd/nerdctl/v2/pkg/selinuxutil"
 )
 
 const UnameO = "GNU/Linux"
@@ -53,6 +54,9 @@ WARNING: AppArmor profile %q is not loaded.
 		}
 	}
 	info.SecurityOptions = append(info.SecurityOptions, "name=seccomp,profile="+defaults.SeccompProfileName)
+	if selinuxutil.Enabled() {
+		info.SecurityOptions = append(info.SecurityOptions, "name=selinux")
+	}
 	if defaults.CgroupnsMode() == "private" {
 		info.SecurityOptions = append(info.SecurityOptions, "name=cgroupns")
 	}
@@ -67,6 +71,7 @@ WARNING: AppArmor profile %q is not loaded.
 // SecurityOptions, CgroupDriver, CgroupVersion
 func fulfillPlatformInfo(info *dockercompat.Info) {
 	fulfillSecurityOptions(info)
+	info.EmulatedPlatforms = EmulatedPlatforms()
 	mobySysInfo := mobySysInfo(info)
 
 	if info.CgroupDriver == "none" {
@@ -37,6 +37,7 @@ import (
 
 	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -77,6 +78,10 @@ type Image struct {
 	RootFS   RootFS
 	Metadata ImageMetadata
 
+	// Referrers are the OCI referrers of the image (`nerdctl image inspect --referrers`).
+	// Not present in Docker.
+	Referrers []ocispec.Descriptor `json:",omitempty"`
+
 	// Deprecated: TODO: Container   string
 	// Deprecated: TODO: ContainerConfig *container.Config
 }
@@ -118,13 +123,15 @@ type Container struct {
 	HostsPath      string
 	LogPath        string
 	// Unimplemented: Node            *ContainerNode `json:",omitempty"` // Node is only propagated by Docker Swarm standalone API
-	Name         string
-	RestartCount int
-	Driver       string
-	Platform     string
-	// TODO: MountLabel      string
-	// TODO: ProcessLabel    string
+	Name            string
+	RestartCount    int
+	Driver          string
+	Platform        string
+	MountLabel      string
+	ProcessLabel    string
 	AppArmorProfile string
+	// SeccompProfile is the effective seccomp profile (nerdctl extension)
+	SeccompProfile *specs.LinuxSeccomp `json:",omitempty"`
 	// TODO: ExecIDs         []string
 	HostConfig *HostConfig
 	// TODO: GraphDriver     GraphDriverData
@@ -182,6 +189,7 @@ type HostConfig struct {
 	MemorySwap         int64             // Total memory usage (memory + swap); set `-1` to enable unlimited swap
 	OomKillDisable     bool              // specifies whether to disable OOM Killer
 	Devices            []DeviceMapping   // List of devices to map inside the container
+	DeviceCgroupRules  []string          // List of rule to be added to the device cgroup
 	LinuxBlkioSettings
 }
 
@@ -257,9 +265,10 @@ type DNSSettings struct {
 }
 
 type HostConfigLabel struct {
-	BlkioWeight uint16
-	CidFile     string
-	Devices     []DeviceMapping
+	BlkioWeight       uint16
+	CidFile           string
+	Devices           []DeviceMapping
+	DeviceCgroupRules []string `json:",omitempty"`
 }
 
 type DeviceMapping struct {
@@ -345,6 +354,11 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 				}
 			}
 			c.AppArmorProfile = p.ApparmorProfile
+			c.ProcessLabel = p.SelinuxLabel
+		}
+		if sp.Linux != nil {
+			c.MountLabel = sp.Linux.MountLabel
+			c.SeccompProfile = sp.Linux.Seccomp
 		}
 		c.Mounts = mountsFromNative(sp.Mounts)
 		for _, mount := range c.Mounts {
@@ -560,6 +574,7 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 	}
 
 	c.HostConfig.Devices = hostConfigLabel.Devices
+	c.HostConfig.DeviceCgroupRules = hostConfigLabel.DeviceCgroupRules
 
 	var pidMode string
 	if n.Labels[labels.PIDContainer] != "" {
@@ -619,6 +634,7 @@ func ImageFromNative(nativeImage *native.Image) (*Image, error) {
 		VirtualSize:  nativeImage.Size,
 		RepoTags:     []string{fmt.Sprintf("%s:%s", repository, tag)},
 		RepoDigests:  []string{fmt.Sprintf("%s@%s", repository, nativeImage.Image.Target.Digest.String())},
+		Referrers:    nativeImage.Referrers,
 	}
 
 	if len(imgOCI.History) > 0 {
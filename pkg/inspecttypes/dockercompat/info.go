@@ -59,6 +59,8 @@ type Info struct {
 	Name            string
 	ServerVersion   string
 	SecurityOptions []string
+	// EmulatedPlatforms are the platforms emulated with binfmt_misc, e.g., "linux/arm64" (nerdctl extension)
+	EmulatedPlatforms []string `json:",omitempty"`
 
 	Warnings []string
 }
@@ -34,4 +34,6 @@ type Image struct {
 	ImageConfigDesc ocispec.Descriptor `json:"ImageConfigDesc"`
 	ImageConfig     ocispec.Image      `json:"ImageConfig"`
 	Size            int64              `json:"size"`
+	// Referrers are the OCI referrers of the image (`nerdctl image inspect --referrers`)
+	Referrers []ocispec.Descriptor `json:"Referrers,omitempty"`
 }
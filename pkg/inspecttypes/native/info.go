@@ -22,11 +22,13 @@ import (
 )
 
 type Info struct {
-	Namespace     string      `json:"Namespace,omitempty"`
-	Snapshotter   string      `json:"Snapshotter,omitempty"`
-	CgroupManager string      `json:"CgroupManager,omitempty"`
-	Rootless      bool        `json:"Rootless,omitempty"`
-	Daemon        *DaemonInfo `json:"Daemon,omitempty"`
+	Namespace     string `json:"Namespace,omitempty"`
+	Snapshotter   string `json:"Snapshotter,omitempty"`
+	CgroupManager string `json:"CgroupManager,omitempty"`
+	Rootless      bool   `json:"Rootless,omitempty"`
+	// EmulatedPlatforms are the platforms emulated with binfmt_misc, e.g., "linux/arm64"
+	EmulatedPlatforms []string    `json:"EmulatedPlatforms,omitempty"`
+	Daemon            *DaemonInfo `json:"Daemon,omitempty"`
 }
 
 type DaemonInfo struct {
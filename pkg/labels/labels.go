@@ -41,6 +41,9 @@ const (
 	//Compose Volume Name
 	ComposeVolume = "com.docker.compose.volume"
 
+	// ComposeConfigHash is the hash of the configuration of a compose service container
+	ComposeConfigHash = "com.docker.compose.config-hash"
+
 	// Hostname
 	Hostname = Prefix + "hostname"
 
@@ -125,4 +128,7 @@ const (
 
 	// HealthState stores the current health state (status and failing streak).
 	HealthState = Prefix + "healthstate"
+
+	// ImageLastUsed is the label of the images, with the last time (RFC3339Nano) a container was created from the image.
+	ImageLastUsed = Prefix + "last-used"
 )
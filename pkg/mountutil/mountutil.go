@@ -17,6 +17,7 @@
 package mountutil
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -40,7 +41,14 @@ const (
 	Volume        = "volume"
 	Tmpfs         = "tmpfs"
 	Npipe         = "npipe"
+	Image         = "image"
 	pathSeparator = string(os.PathSeparator)
+
+	// IDMapMount is the mode of Processed.IDMap requiring an ID-mapped mount (`idmap`)
+	IDMapMount = "mount"
+	// IDMapChown is the mode of Processed.IDMap falling back to chown the volume when
+	// ID-mapped mounts are not supported (`idmap=chown`)
+	IDMapChown = "chown"
 )
 
 type Processed struct {
@@ -50,6 +58,14 @@ type Processed struct {
 	AnonymousVolume string // anonymous volume name
 	Mode            string
 	Opts            []oci.SpecOpts
+	// NoCopy disables copying the content of the image to an empty volume
+	NoCopy bool
+	// ImagePath is the path of the image mounted by a mount of type image (Name is the image)
+	ImagePath string
+	// IDMap maps the owners of the files of the mount to the user namespace of the container (IDMapMount, IDMapChown)
+	IDMap string
+	// Relabel is the SELinux relabeling of the source of the mount, "z" (shared by all containers) or "Z" (private)
+	Relabel string
 }
 
 type volumeSpec struct {
@@ -123,6 +139,14 @@ func ProcessFlagV(s string, volStore volumestore.VolumeStore, createDir bool) (*
 			if err != nil {
 				return nil, err
 			}
+			res.IDMap, err = parseIDMapOption(res.Type, rawOpts)
+			if err != nil {
+				return nil, err
+			}
+			res.Relabel, err = parseRelabelOption(rawOpts)
+			if err != nil {
+				return nil, err
+			}
 		}
 	default:
 		return nil, fmt.Errorf("failed to parse %q", s)
@@ -228,6 +252,42 @@ func getVolumeOptions(src string, vType string, rawOpts string) ([]string, []oci
 	return options, specOpts, nil
 }
 
+// parseIDMapOption parses the `idmap` and `idmap=chown` options of a mount of type vType.
+func parseIDMapOption(vType, rawOpts string) (string, error) {
+	var idmap string
+	for _, opt := range strings.Split(rawOpts, ",") {
+		switch opt {
+		case "idmap":
+			idmap = IDMapMount
+		case "idmap=chown":
+			if vType != Volume {
+				return "", fmt.Errorf("idmap=chown is only supported for volumes, not for %s mounts", vType)
+			}
+			idmap = IDMapChown
+		default:
+			if strings.HasPrefix(opt, "idmap=") {
+				return "", fmt.Errorf("invalid idmap option %q (must be idmap or idmap=chown)", opt)
+			}
+		}
+	}
+	return idmap, nil
+}
+
+// parseRelabelOption parses the `z` and `Z` options (SELinux relabeling) of a mount.
+func parseRelabelOption(rawOpts string) (string, error) {
+	var relabel string
+	for _, opt := range strings.Split(rawOpts, ",") {
+		switch opt {
+		case "z", "Z":
+			if relabel != "" && relabel != opt {
+				return "", errors.New("volume options z and Z cannot be used together")
+			}
+			relabel = opt
+		}
+	}
+	return relabel, nil
+}
+
 func createDirOnHost(src string, createDir bool) error {
 	_, err := os.Stat(src)
 	if err == nil {
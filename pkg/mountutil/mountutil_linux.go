@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 
+	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/docker/go-units"
 	mobymount "github.com/moby/sys/mount"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -121,6 +122,10 @@ func parseVolumeOptionsWithMountInfo(vType, src, optsRaw string, getMountInfoFun
 		case "bind", "rbind":
 			// bind means not recursively bind-mounted, rbind is the opposite
 			bindOpts = append(bindOpts, opt)
+		case "idmap", "idmap=chown":
+			// NOP (parsed by parseIDMapOption)
+		case "z", "Z":
+			// NOP (parsed by parseRelabelOption)
 		case "":
 			// NOP
 		default:
@@ -302,6 +307,10 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 		rwOption         string
 		tmpfsSize        int64
 		tmpfsMode        os.FileMode
+		imagePath        string
+		volumeSubpath    string
+		volumeNoCopy     bool
+		idmapOption      string
 		err              error
 	)
 
@@ -309,10 +318,11 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 	mountType = Volume
 	tmpfsMode = os.FileMode(01777)
 
-	// three types of mount(and examples):
+	// four types of mount(and examples):
 	// --mount type=bind,source="$(pwd)"/target,target=/app2,readonly,bind-propagation=shared
 	// --mount type=tmpfs,destination=/app,tmpfs-mode=1770,tmpfs-size=1MB
-	// --mount type=volume,src=vol-1,dst=/app,readonly
+	// --mount type=volume,src=vol-1,dst=/app,readonly,volume-subpath=sub,volume-nocopy
+	// --mount type=image,src=alpine,dst=/app,image-path=/etc
 	// if type not specified, default will be set to volume
 	// --mount src=`pwd`/tmp,target=/app
 
@@ -328,6 +338,12 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 			case "bind-nonrecursive":
 				bindNonRecursive = true
 				continue
+			case "volume-nocopy":
+				volumeNoCopy = true
+				continue
+			case "idmap":
+				idmapOption = "idmap"
+				continue
 			}
 		}
 
@@ -344,8 +360,10 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 			case "bind":
 				mountType = Bind
 			case "volume":
+			case "image":
+				mountType = Image
 			default:
-				return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs", value)
+				return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs/image", value)
 			}
 		case "source", "src":
 			src = value
@@ -379,11 +397,46 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 				return nil, fmt.Errorf("invalid value for %s: %s", key, value)
 			}
 			tmpfsMode = os.FileMode(ui64)
+		case "image-path", "image-subpath":
+			imagePath = value
+		case "volume-subpath":
+			volumeSubpath = value
+		case "volume-nocopy":
+			volumeNoCopy, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %s", key, value)
+			}
+		case "idmap":
+			if value == "chown" {
+				idmapOption = "idmap=chown"
+				break
+			}
+			trueValue, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %s", key, value)
+			}
+			idmapOption = ""
+			if trueValue {
+				idmapOption = "idmap"
+			}
 		default:
 			return nil, fmt.Errorf("unexpected key '%s' in '%s'", key, field)
 		}
 	}
 
+	if imagePath != "" && mountType != Image {
+		return nil, fmt.Errorf("image-path is only supported for mounts of type image")
+	}
+	if (volumeSubpath != "" || volumeNoCopy) && mountType != Volume {
+		return nil, fmt.Errorf("volume-subpath and volume-nocopy are only supported for mounts of type volume")
+	}
+	if idmapOption != "" && mountType != Volume && mountType != Bind {
+		return nil, fmt.Errorf("idmap is only supported for mounts of type volume or bind")
+	}
+	if mountType == Image {
+		return processImageMount(src, dst, imagePath, rwOption)
+	}
+
 	// compose new fileds and join into a string
 	// to call legacy ProcessFlagTmpfs or ProcessFlagV function
 	fields = []string{}
@@ -416,6 +469,9 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 				options = append(options, "rbind")
 			}
 		}
+		if idmapOption != "" {
+			options = append(options, idmapOption)
+		}
 	}
 
 	if len(options) > 0 {
@@ -431,9 +487,67 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeStore) (*Processed, e
 		return ProcessFlagTmpfs(fieldsStr)
 	case Volume, Bind:
 		// createDir=false for --mount option to disallow creating directories on host if not found
-		return ProcessFlagV(fieldsStr, volStore, false)
+		res, err := ProcessFlagV(fieldsStr, volStore, false)
+		if err != nil {
+			return nil, err
+		}
+		if volumeSubpath != "" {
+			if res.Type != Volume || res.Name == "" {
+				return nil, fmt.Errorf("volume-subpath is only supported for named volumes")
+			}
+			if res.Mount.Source, err = volumeSubpathSource(res.Mount.Source, volumeSubpath); err != nil {
+				return nil, fmt.Errorf("invalid volume-subpath for volume %q: %w", res.Name, err)
+			}
+		}
+		res.NoCopy = volumeNoCopy
+		return res, nil
+	}
+	return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs/image", mountType)
+}
+
+// processImageMount processes `--mount type=image`. The source of the mount is set when the container is created,
+// as it requires the image to be unpacked (see Processed.ImagePath).
+func processImageMount(src, dst, imagePath, rwOption string) (*Processed, error) {
+	if src == "" {
+		return nil, fmt.Errorf("the source image of a mount of type image must be specified")
+	}
+	if _, err := isValidPath(dst); err != nil {
+		return nil, err
+	}
+	switch rwOption {
+	case "", "ro", "readonly":
+	default:
+		return nil, fmt.Errorf("mounts of type image are read-only, got %q", rwOption)
+	}
+	if imagePath != "" {
+		// the path is relative to the root of the image
+		imagePath = filepath.Clean(string(os.PathSeparator) + imagePath)
+	}
+	return &Processed{
+		Type:      Image,
+		Name:      src,
+		ImagePath: imagePath,
+		Mount: specs.Mount{
+			Destination: cleanMount(dst),
+		},
+		Mode: "ro",
+	}, nil
+}
+
+// volumeSubpathSource returns the path of subpath in the volume mounted from source, which must exist.
+func volumeSubpathSource(source, subpath string) (string, error) {
+	subpath = filepath.Clean(subpath)
+	if !filepath.IsLocal(subpath) {
+		return "", fmt.Errorf("%q must be a relative path in the volume", subpath)
+	}
+	p, err := securejoin.SecureJoin(source, subpath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(p); err != nil {
+		return "", err
 	}
-	return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs", mountType)
+	return p, nil
 }
 
 // copy from https://github.com/moby/moby/blob/085c6a98d54720e70b28354ccec6da9b1b9e7fcf/volume/mounts/linux_parser.go#L375
@@ -251,6 +251,35 @@ func TestProcessFlagV(t *testing.T) {
 					Options:     []string{"rbind"},
 				}},
 		},
+		// SELinux relabeling
+		{
+			rawSpec: "/mnt/foo:/mnt/foo:ro,Z",
+			wants: &Processed{
+				Type:    "bind",
+				Relabel: "Z",
+				Mount: specs.Mount{
+					Type:        "none",
+					Destination: `/mnt/foo`,
+					Source:      `/mnt/foo`,
+					Options:     []string{"ro", "rprivate", "rbind"},
+				}},
+		},
+		{
+			rawSpec: `TestVolume:/mnt/foo:z`,
+			wants: &Processed{
+				Type:    "volume",
+				Name:    "TestVolume",
+				Relabel: "z",
+				Mount: specs.Mount{
+					Type:        "none",
+					Destination: `/mnt/foo`,
+					Options:     []string{"rbind"},
+				}},
+		},
+		{
+			rawSpec: "/mnt/foo:/mnt/foo:z,Z",
+			err:     "volume options z and Z cannot be used together",
+		},
 		{
 			rawSpec: `/mnt/foo:TestVolume`,
 			err:     "expected an absolute path, got \"TestVolume\"",
@@ -273,6 +302,7 @@ func TestProcessFlagV(t *testing.T) {
 			assert.Equal(t, processedVolSpec.Mount.Type, tt.wants.Mount.Type)
 			assert.Equal(t, processedVolSpec.Mount.Destination, tt.wants.Mount.Destination)
 			assert.DeepEqual(t, processedVolSpec.Mount.Options, tt.wants.Mount.Options)
+			assert.Equal(t, processedVolSpec.Relabel, tt.wants.Relabel)
 
 			if tt.wants.Name != "" {
 				assert.Equal(t, processedVolSpec.Name, tt.wants.Name)
@@ -348,3 +378,128 @@ func TestProcessFlagVAnonymousVolumes(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessFlagMountImage(t *testing.T) {
+	tests := []struct {
+		rawSpec string
+		wants   *Processed
+		err     string
+	}{
+		{
+			rawSpec: "type=image,source=alpine,target=/mnt/foo",
+			wants: &Processed{
+				Type:  Image,
+				Name:  "alpine",
+				Mount: specs.Mount{Destination: "/mnt/foo"},
+				Mode:  "ro",
+			},
+		},
+		{
+			rawSpec: "type=image,src=alpine,dst=/mnt/foo,image-path=etc/../usr,readonly",
+			wants: &Processed{
+				Type:      Image,
+				Name:      "alpine",
+				ImagePath: "/usr",
+				Mount:     specs.Mount{Destination: "/mnt/foo"},
+				Mode:      "ro",
+			},
+		},
+		{
+			rawSpec: "type=image,target=/mnt/foo",
+			err:     "the source image of a mount of type image must be specified",
+		},
+		{
+			rawSpec: "type=image,source=alpine,target=foo",
+			err:     "expected an absolute path, got \"foo\"",
+		},
+		{
+			rawSpec: "type=image,source=alpine,target=/mnt/foo,rw",
+			err:     "mounts of type image are read-only, got \"rw\"",
+		},
+		{
+			rawSpec: "type=bind,source=/mnt/foo,target=/mnt/foo,image-path=/etc",
+			err:     "image-path is only supported for mounts of type image",
+		},
+		{
+			rawSpec: "type=tmpfs,target=/mnt/foo,volume-nocopy",
+			err:     "volume-subpath and volume-nocopy are only supported for mounts of type volume",
+		},
+		{
+			rawSpec: "type=volume,target=/mnt/foo,volume-subpath=sub",
+			err:     "volume-subpath is only supported for named volumes",
+		},
+		{
+			rawSpec: "type=volume,source=TestVolume,target=/mnt/foo,volume-subpath=../sub",
+			err:     "invalid volume-subpath for volume \"TestVolume\": \"../sub\" must be a relative path in the volume",
+		},
+		{
+			rawSpec: "type=volume,source=TestVolume,target=/mnt/foo,volume-nocopy=invalid",
+			err:     "invalid value for volume-nocopy: invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawSpec, func(t *testing.T) {
+			x, err := ProcessFlagMount(tt.rawSpec, mockVolumeStore)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, x, tt.wants)
+		})
+	}
+}
+
+func TestProcessFlagMountVolumeOptions(t *testing.T) {
+	x, err := ProcessFlagMount("type=volume,source=TestVolume,target=/mnt/foo,volume-nocopy", mockVolumeStore)
+	assert.NilError(t, err)
+	assert.Equal(t, x.Name, "TestVolume")
+	assert.Assert(t, x.NoCopy)
+
+	x, err = ProcessFlagMount("type=volume,source=TestVolume,target=/mnt/foo,volume-nocopy=false", mockVolumeStore)
+	assert.NilError(t, err)
+	assert.Assert(t, !x.NoCopy)
+}
+
+func TestProcessIDMapOption(t *testing.T) {
+	tests := []struct {
+		rawSpec string
+		flagV   bool
+		idmap   string
+		err     string
+	}{
+		{rawSpec: "/mnt/foo:/mnt/foo:ro,idmap", flagV: true, idmap: IDMapMount},
+		{rawSpec: "TestVolume:/mnt/foo:idmap=chown", flagV: true, idmap: IDMapChown},
+		{rawSpec: "TestVolume:/mnt/foo:ro", flagV: true},
+		{rawSpec: "/mnt/foo:/mnt/foo:idmap=chown", flagV: true, err: "idmap=chown is only supported for volumes, not for bind mounts"},
+		{rawSpec: "TestVolume:/mnt/foo:idmap=foo", flagV: true, err: "invalid idmap option \"idmap=foo\" (must be idmap or idmap=chown)"},
+		{rawSpec: "type=bind,source=/mnt/foo,target=/mnt/foo,idmap", idmap: IDMapMount},
+		{rawSpec: "type=volume,source=TestVolume,target=/mnt/foo,idmap=true", idmap: IDMapMount},
+		{rawSpec: "type=volume,source=TestVolume,target=/mnt/foo,idmap=false"},
+		{rawSpec: "type=volume,source=TestVolume,target=/mnt/foo,idmap=chown", idmap: IDMapChown},
+		{rawSpec: "type=volume,source=TestVolume,target=/mnt/foo,idmap=foo", err: "invalid value for idmap: foo"},
+		{rawSpec: "type=tmpfs,target=/mnt/foo,idmap", err: "idmap is only supported for mounts of type volume or bind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawSpec, func(t *testing.T) {
+			var (
+				x   *Processed
+				err error
+			)
+			if tt.flagV {
+				x, err = ProcessFlagV(tt.rawSpec, mockVolumeStore, false)
+			} else {
+				x, err = ProcessFlagMount(tt.rawSpec, mockVolumeStore)
+			}
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, x.IDMap, tt.idmap)
+			assert.Assert(t, !strings.Contains(strings.Join(x.Mount.Options, ","), "idmap"))
+		})
+	}
+}
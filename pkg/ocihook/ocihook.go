@@ -525,7 +525,7 @@ func applyNetworkSettings(opts *handlerOpts) (err error) {
 }
 
 func onCreateRuntime(opts *handlerOpts) error {
-	loadAppArmor()
+	loadAppArmor(opts.state.Annotations[labels.StateDir], opts.state.ID)
 
 	name := opts.state.Annotations[labels.Name]
 	ns := opts.state.Annotations[labels.Namespace]
@@ -24,10 +24,15 @@ import (
 	"github.com/containerd/nerdctl/v2/pkg/defaults"
 )
 
-func loadAppArmor() {
+func loadAppArmor(stateDir, id string) {
 	if !apparmorutil.CanLoadNewProfile() {
 		return
 	}
+	// ensure that the profile generated for the container with `--security-opt apparmor-policy` is still loaded,
+	// as the profiles do not survive a reboot of the host, e.g., when the container is restarted by the restart policy
+	if err := apparmorutil.EnsureContainerProfile(stateDir, id); err != nil {
+		log.L.WithError(err).Errorf("failed to load AppArmor profile %q", apparmorutil.ContainerProfileName(id))
+	}
 	// ensure that the default profile is loaded to the host
 	if err := apparmor.LoadDefaultProfile(defaults.AppArmorProfileName); err != nil {
 		log.L.WithError(err).Errorf("failed to load AppArmor profile %q", defaults.AppArmorProfileName)
@@ -18,6 +18,6 @@
 
 package ocihook
 
-func loadAppArmor() {
+func loadAppArmor(stateDir, id string) {
 	//noop
 }
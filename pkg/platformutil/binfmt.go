@@ -18,29 +18,12 @@ package platformutil
 
 import (
 	"fmt"
-	"os"
 	"runtime"
 
 	"github.com/containerd/platforms"
-)
 
-func qemuArchFromOCIArch(ociArch string) (string, error) {
-	switch ociArch {
-	case "amd64":
-		return "x86_64", nil
-	case "arm64":
-		return "aarch64", nil
-	case "386":
-		return "i386", nil
-	case "arm", "s390x", "ppc64le", "riscv64", "mips64":
-		return ociArch, nil
-	case "mips64le":
-		return "mips64el", nil // NOT typo
-	case "loong64":
-		return "loongarch64", nil // NOT typo
-	}
-	return "", fmt.Errorf("unknown OCI architecture string: %q", ociArch)
-}
+	"github.com/containerd/nerdctl/v2/pkg/binfmt"
+)
 
 func canExecProbably(s string) (bool, error) {
 	if s == "" {
@@ -54,28 +37,36 @@ func canExecProbably(s string) (bool, error) {
 		return true, nil
 	}
 	if runtime.GOOS == "linux" {
-		qemuArch, err := qemuArchFromOCIArch(p.Architecture)
+		arch, err := binfmt.LookupArch(p.Architecture)
 		if err != nil {
 			return false, err
 		}
-		candidates := []string{
-			"/proc/sys/fs/binfmt_misc/qemu-" + qemuArch,
-			"/proc/sys/fs/binfmt_misc/buildkit-qemu-" + qemuArch,
-		}
-		// Rosetta 2 for Linux on ARM Mac
-		// https://developer.apple.com/documentation/virtualization/running_intel_binaries_in_linux_vms_with_rosetta
-		if runtime.GOARCH == "arm64" && p.Architecture == "amd64" {
-			candidates = append(candidates, "/proc/sys/fs/binfmt_misc/rosetta")
-		}
-		for _, cand := range candidates {
-			if _, err := os.Stat(cand); err == nil {
-				return true, nil
-			}
-		}
+		return binfmt.Check(binfmt.Dir, arch.OCI) == nil, nil
 	}
 	return false, nil
 }
 
+// CheckExec returns nil when the containers of the platform s can run on the host, natively or with an emulator
+// registered in binfmt_misc, or why they cannot otherwise.
+func CheckExec(s string) error {
+	if ok, err := canExecProbably(s); ok || err != nil {
+		return err
+	}
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("platform %q is incompatible with the host platform %q", s, platforms.DefaultString())
+	}
+	p, err := platforms.Parse(s)
+	if err != nil {
+		return err
+	}
+	if err := binfmt.Check(binfmt.Dir, p.Architecture); err != nil {
+		return fmt.Errorf("platform %q cannot be emulated on the host platform %q: %w "+
+			"(hint: run `sudo nerdctl system binfmt install %s`, see https://github.com/containerd/nerdctl/blob/main/docs/multi-platform.md)",
+			s, platforms.DefaultString(), err, p.Architecture)
+	}
+	return fmt.Errorf("platform %q is incompatible with the host platform %q", s, platforms.DefaultString())
+}
+
 func CanExecProbably(ss ...string) (bool, error) {
 	for _, s := range ss {
 		ok, err := canExecProbably(s)
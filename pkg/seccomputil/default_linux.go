@@ -0,0 +1,41 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomputil
+
+import (
+	"context"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/containerd/v2/contrib/seccomp"
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/pkg/oci"
+)
+
+// DefaultProfile returns the default profile (`--security-opt seccomp=builtin`),
+// for a container with the default capabilities and the additional capabilities caps (e.g., "CAP_SYS_ADMIN").
+func DefaultProfile(ctx context.Context, caps []string) (*specs.LinuxSeccomp, error) {
+	if _, ok := namespaces.Namespace(ctx); !ok {
+		ctx = namespaces.WithNamespace(ctx, namespaces.Default)
+	}
+	spec, err := oci.GenerateSpec(ctx, nil, &containers.Container{ID: "seccomp-default"}, oci.WithAddedCapabilities(caps))
+	if err != nil {
+		return nil, err
+	}
+	return seccomp.DefaultProfile(spec), nil
+}
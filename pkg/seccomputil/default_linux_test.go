@@ -0,0 +1,48 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomputil
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+)
+
+func allowed(p *specs.LinuxSeccomp, name string) bool {
+	for _, rule := range p.Syscalls {
+		if rule.Action == specs.ActAllow && len(rule.Args) == 0 && slices.Contains(rule.Names, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDefaultProfile(t *testing.T) {
+	t.Parallel()
+	p, err := DefaultProfile(context.Background(), nil)
+	assert.NilError(t, err)
+	assert.NilError(t, Validate(p, nil))
+	assert.Assert(t, allowed(p, "read"))
+	assert.Assert(t, !allowed(p, "mount"))
+
+	p, err = DefaultProfile(context.Background(), []string{"CAP_SYS_ADMIN"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed(p, "mount"))
+}
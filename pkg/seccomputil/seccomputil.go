@@ -0,0 +1,392 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package seccomputil provides utilities for authoring seccomp profiles,
+// in the format of the OCI runtime spec (the format of `--security-opt seccomp=<PROFILE_JSON_FILE>`).
+package seccomputil
+
+//go:generate go run syscalls_generate.go
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// archGOARCHes maps the seccomp architectures to the GOARCH of their syscall table.
+var archGOARCHes = map[specs.Arch]string{
+	specs.ArchX86:         "386",
+	specs.ArchX86_64:      "amd64",
+	specs.ArchX32:         "amd64",
+	specs.ArchARM:         "arm",
+	specs.ArchAARCH64:     "arm64",
+	specs.ArchMIPS:        "mips",
+	specs.ArchMIPS64:      "mips64",
+	specs.ArchMIPS64N32:   "mips64",
+	specs.ArchMIPSEL:      "mipsle",
+	specs.ArchMIPSEL64:    "mips64le",
+	specs.ArchMIPSEL64N32: "mips64le",
+	specs.ArchPPC:         "ppc",
+	specs.ArchPPC64:       "ppc64",
+	specs.ArchPPC64LE:     "ppc64le",
+	specs.ArchS390:        "s390x",
+	specs.ArchS390X:       "s390x",
+	specs.ArchRISCV64:     "riscv64",
+	specs.ArchLOONGARCH64: "loong64",
+}
+
+// errnoEPERM is EPERM on Linux, returned for the syscalls of `--security-opt seccomp-drop`.
+const errnoEPERM uint = 1
+
+var (
+	syscallSets     map[string]map[string]struct{}
+	syscallSetsOnce sync.Once
+)
+
+// Syscalls returns the names of the syscalls of arch, or false if arch is not supported.
+func Syscalls(arch specs.Arch) (map[string]struct{}, bool) {
+	syscallSetsOnce.Do(func() {
+		syscallSets = make(map[string]map[string]struct{}, len(syscallTable))
+		for goarch, names := range syscallTable {
+			set := make(map[string]struct{})
+			for _, name := range strings.Fields(names) {
+				set[name] = struct{}{}
+			}
+			syscallSets[goarch] = set
+		}
+	})
+	goarch, ok := archGOARCHes[arch]
+	if !ok {
+		return nil, false
+	}
+	set, ok := syscallSets[goarch]
+	return set, ok
+}
+
+// NativeArches returns the architectures of the host, as used by the default profile.
+func NativeArches() []specs.Arch {
+	switch runtime.GOARCH {
+	case "amd64":
+		return []specs.Arch{specs.ArchX86_64, specs.ArchX86, specs.ArchX32}
+	case "arm64":
+		return []specs.Arch{specs.ArchARM, specs.ArchAARCH64}
+	case "s390x":
+		return []specs.Arch{specs.ArchS390, specs.ArchS390X}
+	}
+	for arch, goarch := range archGOARCHes {
+		if goarch == runtime.GOARCH {
+			return []specs.Arch{arch}
+		}
+	}
+	return nil
+}
+
+// LoadProfile reads the JSON profile at path.
+func LoadProfile(path string) (*specs.LinuxSeccomp, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p specs.LinuxSeccomp
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse seccomp profile %q: %w", path, err)
+	}
+	return &p, nil
+}
+
+// clone returns a deep copy of p.
+func clone(p *specs.LinuxSeccomp) *specs.LinuxSeccomp {
+	res := *p
+	res.Architectures = slices.Clone(p.Architectures)
+	res.Flags = slices.Clone(p.Flags)
+	res.Syscalls = make([]specs.LinuxSyscall, len(p.Syscalls))
+	for i, sc := range p.Syscalls {
+		sc.Names = slices.Clone(sc.Names)
+		sc.Args = slices.Clone(sc.Args)
+		res.Syscalls[i] = sc
+	}
+	return &res
+}
+
+// removeSyscalls removes names from the rules, and the rules left without any name.
+func removeSyscalls(rules []specs.LinuxSyscall, names []string) []specs.LinuxSyscall {
+	var res []specs.LinuxSyscall
+	for _, rule := range rules {
+		rule.Names = slices.DeleteFunc(slices.Clone(rule.Names), func(name string) bool {
+			return slices.Contains(names, name)
+		})
+		if len(rule.Names) > 0 {
+			res = append(res, rule)
+		}
+	}
+	return res
+}
+
+// allowsByDefault returns whether action lets the syscalls run.
+func allowsByDefault(action specs.LinuxSeccompAction) bool {
+	return action == specs.ActAllow || action == specs.ActLog
+}
+
+// Override returns a copy of p where the syscalls of add are allowed unconditionally
+// (`--security-opt seccomp-add`) and the syscalls of drop are denied with EPERM (`--security-opt seccomp-drop`).
+// The existing rules of these syscalls are removed, including the conditional ones.
+func Override(p *specs.LinuxSeccomp, add, drop []string) (*specs.LinuxSeccomp, error) {
+	if p == nil {
+		return nil, errors.New("seccomp-add and seccomp-drop cannot be used with seccomp=unconfined")
+	}
+	for _, name := range add {
+		if slices.Contains(drop, name) {
+			return nil, fmt.Errorf("syscall %q cannot be both added and dropped", name)
+		}
+	}
+	res := clone(p)
+	res.Syscalls = removeSyscalls(res.Syscalls, append(slices.Clone(add), drop...))
+	if len(add) > 0 {
+		res.Syscalls = append(res.Syscalls, specs.LinuxSyscall{
+			Names:  slices.Clone(add),
+			Action: specs.ActAllow,
+		})
+	}
+	if len(drop) > 0 && allowsByDefault(res.DefaultAction) {
+		eperm := errnoEPERM
+		res.Syscalls = append(res.Syscalls, specs.LinuxSyscall{
+			Names:    slices.Clone(drop),
+			Action:   specs.ActErrno,
+			ErrnoRet: &eperm,
+		})
+	}
+	return res, nil
+}
+
+// Merge returns base merged with the overlays, in order.
+// The default action, the default errno and the listener of an overlay replace those of the profile when set,
+// and the architectures and the flags are added to those of the profile.
+// The rules of an overlay replace all the rules of the profile for the syscalls they name.
+func Merge(base *specs.LinuxSeccomp, overlays ...*specs.LinuxSeccomp) *specs.LinuxSeccomp {
+	res := clone(base)
+	for _, o := range overlays {
+		if o.DefaultAction != "" {
+			res.DefaultAction = o.DefaultAction
+			res.DefaultErrnoRet = o.DefaultErrnoRet
+		}
+		if o.ListenerPath != "" {
+			res.ListenerPath = o.ListenerPath
+			res.ListenerMetadata = o.ListenerMetadata
+		}
+		for _, arch := range o.Architectures {
+			if !slices.Contains(res.Architectures, arch) {
+				res.Architectures = append(res.Architectures, arch)
+			}
+		}
+		for _, flag := range o.Flags {
+			if !slices.Contains(res.Flags, flag) {
+				res.Flags = append(res.Flags, flag)
+			}
+		}
+		var names []string
+		for _, rule := range o.Syscalls {
+			names = append(names, rule.Names...)
+		}
+		res.Syscalls = removeSyscalls(res.Syscalls, names)
+		res.Syscalls = append(res.Syscalls, clone(o).Syscalls...)
+	}
+	return res
+}
+
+var (
+	validActions = []specs.LinuxSeccompAction{
+		specs.ActKill, specs.ActKillProcess, specs.ActKillThread, specs.ActTrap, specs.ActErrno,
+		specs.ActTrace, specs.ActAllow, specs.ActLog, specs.ActNotify,
+	}
+	validOperators = []specs.LinuxSeccompOperator{
+		specs.OpNotEqual, specs.OpLessThan, specs.OpLessEqual, specs.OpEqualTo,
+		specs.OpGreaterEqual, specs.OpGreaterThan, specs.OpMaskedEqual,
+	}
+)
+
+// ValidateSyscalls returns an error if some of names are not syscalls of any of arches.
+func ValidateSyscalls(names []string, arches []specs.Arch) error {
+	var unknown []string
+	for _, name := range names {
+		found := false
+		for _, arch := range arches {
+			if syscalls, ok := Syscalls(arch); ok {
+				if _, ok := syscalls[name]; ok {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			unknown = append(unknown, strconv.Quote(name))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown syscalls for architectures %v: %s", arches, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Validate validates the architectures, the actions, the operators and the syscall names of p.
+// The syscall names are validated against the architectures of p, or arches when set.
+// When neither is set, the syscall names are validated against NativeArches.
+// A syscall is valid when it exists on one of the architectures at least,
+// as the rules of the syscalls missing on an architecture are ignored for that architecture.
+func Validate(p *specs.LinuxSeccomp, arches []specs.Arch) error {
+	var errs []error
+	for _, arch := range p.Architectures {
+		if _, ok := Syscalls(arch); !ok {
+			errs = append(errs, fmt.Errorf("unsupported architecture %q", arch))
+		}
+	}
+	if len(arches) == 0 {
+		arches = p.Architectures
+	} else {
+		for _, arch := range arches {
+			if _, ok := Syscalls(arch); !ok {
+				errs = append(errs, fmt.Errorf("unsupported architecture %q", arch))
+			}
+		}
+	}
+	if len(arches) == 0 {
+		arches = NativeArches()
+	}
+	if !slices.Contains(validActions, p.DefaultAction) {
+		errs = append(errs, fmt.Errorf("invalid default action %q", p.DefaultAction))
+	}
+	for i, rule := range p.Syscalls {
+		if len(rule.Names) == 0 {
+			errs = append(errs, fmt.Errorf("rule %d: no syscall names", i))
+		}
+		if !slices.Contains(validActions, rule.Action) {
+			errs = append(errs, fmt.Errorf("rule %d: invalid action %q", i, rule.Action))
+		}
+		for _, arg := range rule.Args {
+			if arg.Index > 5 {
+				errs = append(errs, fmt.Errorf("rule %d: invalid argument index %d", i, arg.Index))
+			}
+			if !slices.Contains(validOperators, arg.Op) {
+				errs = append(errs, fmt.Errorf("rule %d: invalid operator %q", i, arg.Op))
+			}
+		}
+		if err := ValidateSyscalls(rule.Names, arches); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// describeRules returns the descriptions of the rules of p, by syscall name.
+func describeRules(p *specs.LinuxSeccomp) map[string][]string {
+	res := make(map[string][]string)
+	for _, rule := range p.Syscalls {
+		desc := string(rule.Action)
+		if rule.ErrnoRet != nil {
+			desc += fmt.Sprintf(" (errno %d)", *rule.ErrnoRet)
+		}
+		for _, arg := range rule.Args {
+			desc += fmt.Sprintf(" arg%d %s %d", arg.Index, arg.Op, arg.Value)
+			if arg.Op == specs.OpMaskedEqual {
+				desc += fmt.Sprintf("/%d", arg.ValueTwo)
+			}
+		}
+		for _, name := range rule.Names {
+			res[name] = append(res[name], desc)
+		}
+	}
+	for name := range res {
+		sort.Strings(res[name])
+	}
+	return res
+}
+
+// Diff returns the differences between the profiles a and b, as lines prefixed with "-" (only in a)
+// and "+" (only in b). The rules are compared by syscall name, regardless of their order and grouping.
+func Diff(a, b *specs.LinuxSeccomp) []string {
+	var res []string
+	diffValue := func(field string, va, vb string) {
+		if va != vb {
+			if va != "" {
+				res = append(res, fmt.Sprintf("- %s: %s", field, va))
+			}
+			if vb != "" {
+				res = append(res, fmt.Sprintf("+ %s: %s", field, vb))
+			}
+		}
+	}
+	errnoString := func(errno *uint) string {
+		if errno == nil {
+			return ""
+		}
+		return fmt.Sprint(*errno)
+	}
+	diffValue("defaultAction", string(a.DefaultAction), string(b.DefaultAction))
+	diffValue("defaultErrnoRet", errnoString(a.DefaultErrnoRet), errnoString(b.DefaultErrnoRet))
+	diffValue("listenerPath", a.ListenerPath, b.ListenerPath)
+	diffSet := func(field string, sa, sb []string) {
+		for _, v := range sa {
+			if !slices.Contains(sb, v) {
+				res = append(res, fmt.Sprintf("- %s: %s", field, v))
+			}
+		}
+		for _, v := range sb {
+			if !slices.Contains(sa, v) {
+				res = append(res, fmt.Sprintf("+ %s: %s", field, v))
+			}
+		}
+	}
+	archStrings := func(arches []specs.Arch) []string {
+		res := make([]string, len(arches))
+		for i, arch := range arches {
+			res[i] = string(arch)
+		}
+		return res
+	}
+	flagStrings := func(flags []specs.LinuxSeccompFlag) []string {
+		res := make([]string, len(flags))
+		for i, flag := range flags {
+			res[i] = string(flag)
+		}
+		return res
+	}
+	diffSet("architecture", archStrings(a.Architectures), archStrings(b.Architectures))
+	diffSet("flag", flagStrings(a.Flags), flagStrings(b.Flags))
+
+	ra, rb := describeRules(a), describeRules(b)
+	names := make([]string, 0, len(ra)+len(rb))
+	for name := range ra {
+		names = append(names, name)
+	}
+	for name := range rb {
+		if _, ok := ra[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		diffSet("syscall "+name, ra[name], rb[name])
+	}
+	return res
+}
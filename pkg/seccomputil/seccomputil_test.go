@@ -0,0 +1,166 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomputil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+)
+
+func testProfile() *specs.LinuxSeccomp {
+	eperm := uint(1)
+	return &specs.LinuxSeccomp{
+		DefaultAction: specs.ActErrno,
+		Architectures: []specs.Arch{specs.ArchX86_64},
+		Syscalls: []specs.LinuxSyscall{
+			{
+				Names:  []string{"read", "write", "mount"},
+				Action: specs.ActAllow,
+			},
+			{
+				Names:  []string{"ptrace"},
+				Action: specs.ActAllow,
+				Args: []specs.LinuxSeccompArg{
+					{Index: 0, Value: 1, Op: specs.OpEqualTo},
+				},
+			},
+			{
+				Names:    []string{"reboot"},
+				Action:   specs.ActErrno,
+				ErrnoRet: &eperm,
+			},
+		},
+	}
+}
+
+func TestSyscalls(t *testing.T) {
+	t.Parallel()
+	for _, arch := range []specs.Arch{specs.ArchX86_64, specs.ArchAARCH64, specs.ArchS390X, specs.ArchRISCV64} {
+		syscalls, ok := Syscalls(arch)
+		assert.Assert(t, ok, arch)
+		_, ok = syscalls["read"]
+		assert.Assert(t, ok, arch)
+	}
+	x86_64, _ := Syscalls(specs.ArchX86_64)
+	_, ok := x86_64["arch_prctl"]
+	assert.Assert(t, ok)
+	aarch64, _ := Syscalls(specs.ArchAARCH64)
+	_, ok = aarch64["arch_prctl"]
+	assert.Assert(t, !ok)
+	arm, _ := Syscalls(specs.ArchARM)
+	_, ok = arm["set_tls"]
+	assert.Assert(t, ok)
+	_, ok = Syscalls("SCMP_ARCH_UNKNOWN")
+	assert.Assert(t, !ok)
+}
+
+func TestOverride(t *testing.T) {
+	t.Parallel()
+	p := testProfile()
+	res, err := Override(p, []string{"ptrace"}, []string{"mount"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res.Syscalls, []specs.LinuxSyscall{
+		{Names: []string{"read", "write"}, Action: specs.ActAllow},
+		p.Syscalls[2],
+		{Names: []string{"ptrace"}, Action: specs.ActAllow},
+	})
+	// the original profile is unchanged
+	assert.DeepEqual(t, p, testProfile())
+
+	p.DefaultAction = specs.ActAllow
+	res, err = Override(p, nil, []string{"mount"})
+	assert.NilError(t, err)
+	assert.Equal(t, res.Syscalls[len(res.Syscalls)-1].Action, specs.ActErrno)
+	assert.DeepEqual(t, res.Syscalls[len(res.Syscalls)-1].Names, []string{"mount"})
+
+	_, err = Override(p, []string{"mount"}, []string{"mount"})
+	assert.ErrorContains(t, err, "both added and dropped")
+	_, err = Override(nil, []string{"ptrace"}, nil)
+	assert.ErrorContains(t, err, "unconfined")
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	overlay := &specs.LinuxSeccomp{
+		Architectures: []specs.Arch{specs.ArchX86, specs.ArchX86_64},
+		Flags:         []specs.LinuxSeccompFlag{specs.LinuxSeccompFlagLog},
+		Syscalls: []specs.LinuxSyscall{
+			{Names: []string{"ptrace", "personality"}, Action: specs.ActAllow},
+		},
+	}
+	res := Merge(testProfile(), overlay)
+	assert.Equal(t, res.DefaultAction, specs.ActErrno)
+	assert.DeepEqual(t, res.Architectures, []specs.Arch{specs.ArchX86_64, specs.ArchX86})
+	assert.DeepEqual(t, res.Flags, []specs.LinuxSeccompFlag{specs.LinuxSeccompFlagLog})
+	assert.Equal(t, len(res.Syscalls), 3)
+	assert.DeepEqual(t, res.Syscalls[2], overlay.Syscalls[0])
+
+	res = Merge(testProfile(), &specs.LinuxSeccomp{DefaultAction: specs.ActAllow})
+	assert.Equal(t, res.DefaultAction, specs.ActAllow)
+	assert.DeepEqual(t, res.Syscalls, testProfile().Syscalls)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	assert.NilError(t, Validate(testProfile(), nil))
+
+	p := testProfile()
+	p.Syscalls = append(p.Syscalls, specs.LinuxSyscall{
+		Names:  []string{"arch_prctl", "no_such_syscall"},
+		Action: "SCMP_ACT_UNKNOWN",
+		Args:   []specs.LinuxSeccompArg{{Index: 6, Op: "SCMP_CMP_UNKNOWN"}},
+	})
+	err := Validate(p, nil)
+	assert.ErrorContains(t, err, `unknown syscalls for architectures [SCMP_ARCH_X86_64]: "no_such_syscall"`)
+	assert.ErrorContains(t, err, `invalid action "SCMP_ACT_UNKNOWN"`)
+	assert.ErrorContains(t, err, `invalid operator "SCMP_CMP_UNKNOWN"`)
+	assert.ErrorContains(t, err, "invalid argument index 6")
+	assert.Assert(t, !strings.Contains(err.Error(), `"arch_prctl"`))
+
+	err = Validate(p, []specs.Arch{specs.ArchAARCH64})
+	assert.ErrorContains(t, err, `unknown syscalls for architectures [SCMP_ARCH_AARCH64]: "arch_prctl", "no_such_syscall"`)
+
+	p = testProfile()
+	p.Architectures = []specs.Arch{"SCMP_ARCH_UNKNOWN"}
+	assert.ErrorContains(t, Validate(p, nil), `unsupported architecture "SCMP_ARCH_UNKNOWN"`)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, len(Diff(testProfile(), testProfile())), 0)
+
+	// the grouping of the rules does not matter
+	b := testProfile()
+	b.Syscalls[0].Names = []string{"write", "mount"}
+	b.Syscalls = append(b.Syscalls, specs.LinuxSyscall{Names: []string{"read"}, Action: specs.ActAllow})
+	assert.Equal(t, len(Diff(testProfile(), b)), 0)
+
+	b, err := Override(testProfile(), []string{"ptrace"}, nil)
+	assert.NilError(t, err)
+	b.DefaultAction = specs.ActKillProcess
+	b.Architectures = append(b.Architectures, specs.ArchX86)
+	assert.DeepEqual(t, Diff(testProfile(), b), []string{
+		"- defaultAction: SCMP_ACT_ERRNO",
+		"+ defaultAction: SCMP_ACT_KILL_PROCESS",
+		"+ architecture: SCMP_ARCH_X86",
+		"- syscall ptrace: SCMP_ACT_ALLOW arg0 SCMP_CMP_EQ 1",
+		"+ syscall ptrace: SCMP_ACT_ALLOW",
+	})
+}
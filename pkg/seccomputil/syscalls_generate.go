@@ -0,0 +1,139 @@
+//go:build ignore
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// syscalls_generate.go generates syscalls_table.go from the syscall numbers of golang.org/x/sys/unix.
+//
+// Usage: go generate ./pkg/seccomputil
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// multiplexedSyscalls are the socketcall(2) and ipc(2) syscalls that libseccomp resolves on the architectures
+// multiplexing them, though they are missing in golang.org/x/sys/unix.
+var multiplexedSyscalls = []string{"accept", "recv", "send", "semop", "semtimedop"}
+
+// extraSyscalls are the syscalls that are known to libseccomp but missing in golang.org/x/sys/unix.
+var extraSyscalls = map[string][]string{
+	// ARM private syscalls (__ARM_NR_*)
+	"arm":     {"breakpoint", "cacheflush", "set_tls", "usr26", "usr32"},
+	"386":     multiplexedSyscalls,
+	"mips":    multiplexedSyscalls,
+	"mipsle":  multiplexedSyscalls,
+	"ppc":     multiplexedSyscalls,
+	"ppc64":   multiplexedSyscalls,
+	"ppc64le": multiplexedSyscalls,
+	"s390x":   multiplexedSyscalls,
+}
+
+// unsupportedGOARCHes have no seccomp architecture in the OCI runtime spec.
+var unsupportedGOARCHes = []string{"sparc64"}
+
+const header = `/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+`
+
+var sysRegexp = regexp.MustCompile(`(?m)^\s*SYS_([A-Z0-9_]+)\s*=`)
+
+func main() {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "golang.org/x/sys").Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to locate golang.org/x/sys: %v\n", err)
+		os.Exit(1)
+	}
+	dir := filepath.Join(strings.TrimSpace(string(out)), "unix")
+	files, err := filepath.Glob(filepath.Join(dir, "zsysnum_linux_*.go"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.WriteString("// Code generated by syscalls_generate.go from golang.org/x/sys/unix; DO NOT EDIT.\n\n")
+	buf.WriteString("package seccomputil\n\n")
+	buf.WriteString("// syscallTable is the space-separated syscall names of each GOARCH.\n")
+	buf.WriteString("var syscallTable = map[string]string{\n")
+	for _, f := range files {
+		goarch := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "zsysnum_linux_"), ".go")
+		if slices.Contains(unsupportedGOARCHes, goarch) {
+			continue
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		var names []string
+		for _, m := range sysRegexp.FindAllSubmatch(b, -1) {
+			names = append(names, strings.ToLower(string(m[1])))
+		}
+		names = append(names, extraSyscalls[goarch]...)
+		slices.Sort(names)
+		names = slices.Compact(names)
+
+		fmt.Fprintf(&buf, "\t%q: `\n", goarch)
+		lineLen := 0
+		for _, name := range names {
+			if lineLen+len(name) > 100 {
+				buf.WriteString("\n")
+				lineLen = 0
+			} else if lineLen > 0 {
+				buf.WriteString(" ")
+				lineLen++
+			}
+			buf.WriteString(name)
+			lineLen += len(name)
+		}
+		buf.WriteString("\n`,\n")
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile("syscalls_table.go", src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,263 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package selinuxutil provides utilities for SELinux.
+//
+// The functions of this file do not depend on the SELinux support of the host,
+// so that the labels of the containers can be generated and tested anywhere.
+package selinuxutil
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/containerd/nerdctl/v2/pkg/store"
+)
+
+const (
+	// DefaultProcessLabel is the process label of the containers when the policy of the host does not define one.
+	DefaultProcessLabel = "system_u:system_r:container_t:s0"
+	// DefaultMountLabel is the label of the files of the containers when the policy of the host does not define one.
+	DefaultMountLabel = "system_u:object_r:container_file_t:s0"
+
+	// levelFileName is the name of the file storing the MCS level allocated to a container, in its state directory.
+	levelFileName = "selinux-level"
+)
+
+// Options represents the `--security-opt label=...` options of a container.
+type Options struct {
+	// Disable disables the confinement of the container (`label=disable`).
+	Disable bool
+	// User, Role, Type and Level replace the corresponding fields of the process label
+	// (`label=user:USER`, `label=role:ROLE`, `label=type:TYPE` and `label=level:LEVEL`).
+	// User and Level replace the fields of the mount label too.
+	User  string
+	Role  string
+	Type  string
+	Level string
+}
+
+// Labels represents the SELinux labels of a container.
+type Labels struct {
+	// ProcessLabel is the label of the processes of the container.
+	ProcessLabel string
+	// MountLabel is the label of the files of the container (rootfs, tmpfs mounts, and relabeled volumes).
+	MountLabel string
+}
+
+var levelRegexp = regexp.MustCompile(`^s[0-9]+(-s[0-9]+)?(:c[0-9]+(\.c[0-9]+)?(,c[0-9]+(\.c[0-9]+)?)*)?$`)
+
+// ParseOptions parses the `label=` entries of securityOpts, e.g., "label=disable" or "label=type:spc_t".
+// The other entries are ignored.
+func ParseOptions(securityOpts []string) (Options, error) {
+	var opts Options
+	for _, s := range securityOpts {
+		k, v, _ := strings.Cut(s, "=")
+		if k != "label" {
+			continue
+		}
+		if v == "disable" {
+			opts.Disable = true
+			continue
+		}
+		field, value, ok := strings.Cut(v, ":")
+		if !ok || value == "" {
+			return Options{}, fmt.Errorf("invalid security-opt %q: must be label=disable, label=user:USER, label=role:ROLE, label=type:TYPE or label=level:LEVEL", s)
+		}
+		switch field {
+		case "user":
+			opts.User = value
+		case "role":
+			opts.Role = value
+		case "type":
+			opts.Type = value
+		case "level":
+			if !levelRegexp.MatchString(value) {
+				return Options{}, fmt.Errorf("invalid SELinux level %q", value)
+			}
+			opts.Level = value
+		default:
+			return Options{}, fmt.Errorf("invalid security-opt %q: unknown label option %q", s, field)
+		}
+	}
+	return opts, nil
+}
+
+// GenerateLabels generates the labels of a container from the base labels of the host policy,
+// the options, and the MCS level allocated to the container (ignored when opts.Level is set).
+// When opts.Disable is set, the process label is empty and the mount label gets the level of
+// PrivilegedLevel, which is not usable by any confined container.
+func GenerateLabels(base Labels, opts Options, level string, catRange uint32) (Labels, error) {
+	if opts.Disable {
+		mountLabel, err := setFields(base.MountLabel, "", "", "", PrivilegedLevel(catRange))
+		if err != nil {
+			return Labels{}, err
+		}
+		return Labels{MountLabel: mountLabel}, nil
+	}
+	if opts.Level != "" {
+		level = opts.Level
+	}
+	processLabel, err := setFields(base.ProcessLabel, opts.User, opts.Role, opts.Type, level)
+	if err != nil {
+		return Labels{}, err
+	}
+	mountLabel, err := setFields(base.MountLabel, opts.User, "", "", level)
+	if err != nil {
+		return Labels{}, err
+	}
+	return Labels{ProcessLabel: processLabel, MountLabel: mountLabel}, nil
+}
+
+// setFields replaces the non-empty fields of a "user:role:type[:level]" label.
+func setFields(label, user, role, typ, level string) (string, error) {
+	fields := strings.SplitN(label, ":", 4)
+	if len(fields) < 3 {
+		return "", fmt.Errorf("invalid SELinux label %q", label)
+	}
+	for i, v := range []string{user, role, typ} {
+		if v != "" {
+			fields[i] = v
+		}
+	}
+	if level != "" {
+		fields = append(fields[:3], level)
+	}
+	return strings.Join(fields, ":"), nil
+}
+
+// PrivilegedLevel returns the level of the mount label of the containers that are not confined (`label=disable`).
+func PrivilegedLevel(catRange uint32) string {
+	return fmt.Sprintf("s0:c%d,c%d", catRange-2, catRange-1)
+}
+
+// AllocateLevel allocates an MCS level ("s0:cX,cY") to the container whose state directory is stateDir.
+// The level is persisted in the state directory, so that it is not allocated to another container,
+// even by another nerdctl process, until the container is removed along with its state directory.
+// The level already allocated to the container is returned when it exists.
+func AllocateLevel(dataStore, stateDir string, catRange uint32) (string, error) {
+	st, err := store.New(filepath.Join(dataStore, "selinux"), 0, 0)
+	if err != nil {
+		return "", err
+	}
+	levelFile := filepath.Join(stateDir, levelFileName)
+	var level string
+	err = st.WithLock(func() error {
+		if b, err := os.ReadFile(levelFile); err == nil {
+			level = string(b)
+			return nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		used, err := usedLevels(dataStore)
+		if err != nil {
+			return err
+		}
+		used[PrivilegedLevel(catRange)] = struct{}{}
+		level, err = allocateLevel(used, catRange, rand.Reader)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(levelFile, []byte(level), 0o600)
+	})
+	return level, err
+}
+
+// usedLevels returns the levels allocated to the containers of all the namespaces.
+func usedLevels(dataStore string) (map[string]struct{}, error) {
+	files, err := filepath.Glob(filepath.Join(dataStore, "containers", "*", "*", levelFileName))
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]struct{}, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// removed concurrently
+				continue
+			}
+			return nil, err
+		}
+		used[string(b)] = struct{}{}
+	}
+	return used, nil
+}
+
+// allocateLevel picks a random pair of distinct categories in [0, catRange) that is not used.
+// When the random picks keep colliding, the first free pair is returned.
+func allocateLevel(used map[string]struct{}, catRange uint32, rnd io.Reader) (string, error) {
+	if catRange < 2 {
+		return "", fmt.Errorf("invalid SELinux category range %d", catRange)
+	}
+	levelOf := func(c1, c2 uint32) string {
+		return fmt.Sprintf("s0:c%d,c%d", c1, c2)
+	}
+	const maxRandomAttempts = 100
+	for range maxRandomAttempts {
+		var n [2]uint32
+		if err := binary.Read(rnd, binary.LittleEndian, &n); err != nil {
+			return "", err
+		}
+		c1, c2 := n[0]%catRange, n[1]%catRange
+		if c1 == c2 {
+			continue
+		} else if c1 > c2 {
+			c1, c2 = c2, c1
+		}
+		if _, ok := used[levelOf(c1, c2)]; !ok {
+			return levelOf(c1, c2), nil
+		}
+	}
+	for c1 := uint32(0); c1 < catRange; c1++ {
+		for c2 := c1 + 1; c2 < catRange; c2++ {
+			if _, ok := used[levelOf(c1, c2)]; !ok {
+				return levelOf(c1, c2), nil
+			}
+		}
+	}
+	return "", errors.New("no SELinux MCS level is available")
+}
+
+// relabelDeniedPaths are the host directories that must not be relabeled, as it would break the host.
+var relabelDeniedPaths = []string{
+	"/", "/bin", "/boot", "/dev", "/etc", "/etc/passwd", "/etc/pki", "/etc/shadow", "/home",
+	"/lib", "/lib64", "/media", "/opt", "/proc", "/root", "/run", "/sbin", "/srv", "/sys",
+	"/tmp", "/usr", "/var", "/var/lib", "/var/log",
+}
+
+// ValidateRelabelPath returns an error if path is a system directory or the home directory of the user,
+// which must not be relabeled with `:z` or `:Z`.
+func ValidateRelabelPath(path string) error {
+	path = filepath.Clean(path)
+	denied := relabelDeniedPaths
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		denied = append(denied[:len(denied):len(denied)], filepath.Clean(home))
+	}
+	for _, p := range denied {
+		if path == p {
+			return fmt.Errorf("relabeling %q is not allowed", path)
+		}
+	}
+	return nil
+}
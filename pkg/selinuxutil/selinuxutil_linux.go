@@ -0,0 +1,58 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package selinuxutil
+
+import (
+	"github.com/opencontainers/selinux/go-selinux"
+	"github.com/opencontainers/selinux/go-selinux/label"
+)
+
+// Enabled returns whether SELinux is enabled on the host.
+func Enabled() bool {
+	return selinux.GetEnabled()
+}
+
+// CategoryRange returns the number of the MCS categories of the host.
+func CategoryRange() uint32 {
+	return selinux.CategoryRange
+}
+
+// HostLabels returns the base labels of the containers, as defined by the SELinux policy of the host.
+// The default labels are returned when the policy does not define them.
+func HostLabels() Labels {
+	processLabel, mountLabel := selinux.ContainerLabels()
+	if processLabel == "" || mountLabel == "" {
+		return Labels{ProcessLabel: DefaultProcessLabel, MountLabel: DefaultMountLabel}
+	}
+	// The level of the labels is allocated by AllocateLevel, which is persistent across nerdctl processes,
+	// unlike the in-memory level reserved by ContainerLabels.
+	selinux.ReleaseLabel(processLabel)
+	return Labels{ProcessLabel: processLabel, MountLabel: mountLabel}
+}
+
+// Relabel relabels path and its content recursively with mountLabel.
+// When shared is true (`:z`), the level of the label is set to "s0" so that all the containers can use the content.
+// Otherwise (`:Z`), only the container having mountLabel can use the content.
+func Relabel(path, mountLabel string, shared bool) error {
+	if mountLabel == "" || !Enabled() {
+		return nil
+	}
+	if err := ValidateRelabelPath(path); err != nil {
+		return err
+	}
+	return label.Relabel(path, mountLabel, shared)
+}
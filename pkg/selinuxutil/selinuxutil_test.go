@@ -0,0 +1,174 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package selinuxutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseOptions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		securityOpts []string
+		expected     Options
+		err          string
+	}{
+		{
+			securityOpts: []string{"seccomp=unconfined", "no-new-privileges"},
+		},
+		{
+			securityOpts: []string{"label=disable"},
+			expected:     Options{Disable: true},
+		},
+		{
+			securityOpts: []string{"label=user:user_u", "label=role:user_r", "label=type:spc_t", "label=level:s0:c1,c2"},
+			expected:     Options{User: "user_u", Role: "user_r", Type: "spc_t", Level: "s0:c1,c2"},
+		},
+		{
+			securityOpts: []string{"label=level:s0-s0:c0.c1023"},
+			expected:     Options{Level: "s0-s0:c0.c1023"},
+		},
+		{
+			securityOpts: []string{"label=level:c1,c2"},
+			err:          `invalid SELinux level "c1,c2"`,
+		},
+		{
+			securityOpts: []string{"label=type"},
+			err:          "must be label=disable",
+		},
+		{
+			securityOpts: []string{"label=filetype:container_file_t"},
+			err:          `unknown label option "filetype"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		opts, err := ParseOptions(tc.securityOpts)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, opts, tc.expected)
+	}
+}
+
+func TestGenerateLabels(t *testing.T) {
+	t.Parallel()
+
+	base := Labels{ProcessLabel: DefaultProcessLabel, MountLabel: DefaultMountLabel}
+	testCases := []struct {
+		opts     Options
+		level    string
+		expected Labels
+	}{
+		{
+			level: "s0:c1,c2",
+			expected: Labels{
+				ProcessLabel: "system_u:system_r:container_t:s0:c1,c2",
+				MountLabel:   "system_u:object_r:container_file_t:s0:c1,c2",
+			},
+		},
+		{
+			opts:  Options{User: "user_u", Role: "user_r", Type: "spc_t"},
+			level: "s0:c1,c2",
+			expected: Labels{
+				ProcessLabel: "user_u:user_r:spc_t:s0:c1,c2",
+				MountLabel:   "user_u:object_r:container_file_t:s0:c1,c2",
+			},
+		},
+		{
+			opts:  Options{Level: "s0:c3,c4"},
+			level: "s0:c1,c2",
+			expected: Labels{
+				ProcessLabel: "system_u:system_r:container_t:s0:c3,c4",
+				MountLabel:   "system_u:object_r:container_file_t:s0:c3,c4",
+			},
+		},
+		{
+			opts: Options{Disable: true, Type: "spc_t"},
+			expected: Labels{
+				MountLabel: "system_u:object_r:container_file_t:s0:c1022,c1023",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		labels, err := GenerateLabels(base, tc.opts, tc.level, 1024)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, labels, tc.expected)
+	}
+
+	_, err := GenerateLabels(Labels{ProcessLabel: "container_t", MountLabel: DefaultMountLabel}, Options{}, "s0:c1,c2", 1024)
+	assert.ErrorContains(t, err, `invalid SELinux label "container_t"`)
+}
+
+func TestAllocateLevel(t *testing.T) {
+	t.Parallel()
+
+	// With 3 categories, the levels are s0:c0,c1, s0:c0,c2 and s0:c1,c2, the last one being the privileged level.
+	const catRange = 3
+	dataStore := t.TempDir()
+	var levels []string
+	for _, id := range []string{"foo", "bar"} {
+		stateDir := filepath.Join(dataStore, "containers", "default", id)
+		assert.NilError(t, os.MkdirAll(stateDir, 0o700))
+		level, err := AllocateLevel(dataStore, stateDir, catRange)
+		assert.NilError(t, err)
+		levels = append(levels, level)
+
+		// The level is persisted
+		again, err := AllocateLevel(dataStore, stateDir, catRange)
+		assert.NilError(t, err)
+		assert.Equal(t, again, level)
+	}
+	assert.Assert(t, levels[0] != levels[1])
+	for _, level := range levels {
+		assert.Assert(t, level == "s0:c0,c1" || level == "s0:c0,c2", level)
+	}
+
+	stateDir := filepath.Join(dataStore, "containers", "other-namespace", "baz")
+	assert.NilError(t, os.MkdirAll(stateDir, 0o700))
+	_, err := AllocateLevel(dataStore, stateDir, catRange)
+	assert.ErrorContains(t, err, "no SELinux MCS level is available")
+}
+
+func TestAllocateLevelFallback(t *testing.T) {
+	t.Parallel()
+
+	// The random reader always picks c0 and c1, which is used
+	rnd := bytes.NewReader(bytes.Repeat([]byte{0, 0, 0, 0, 1, 0, 0, 0}, 100))
+	level, err := allocateLevel(map[string]struct{}{"s0:c0,c1": {}}, 1024, rnd)
+	assert.NilError(t, err)
+	assert.Equal(t, level, "s0:c0,c2")
+}
+
+func TestValidateRelabelPath(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"/", "/usr", "/etc/", "/var/lib"} {
+		assert.ErrorContains(t, ValidateRelabelPath(path), "is not allowed")
+	}
+	assert.NilError(t, ValidateRelabelPath("/var/lib/myapp"))
+	assert.NilError(t, ValidateRelabelPath("/srv/data"))
+}
@@ -0,0 +1,250 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package cosign verifies cosign signatures with public keys, without the cosign binary.
+//
+// The signatures are looked up in the `sha256-<hex>.sig` tag of the repository, and in the OCI 1.1 referrers
+// of the image. The transparency log (Rekor) is not consulted, like `cosign verify --insecure-ignore-tlog`.
+package cosign
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/referrers"
+)
+
+const (
+	// SimpleSigningMediaType is the media type of the layers holding the signed payloads.
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// ArtifactType is the artifact type of the signatures stored as OCI 1.1 referrers.
+	ArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// SignatureAnnotation is the annotation of the layers holding the base64-encoded signatures.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	payloadType = "cosign container image signature"
+)
+
+// Payload is the simple signing payload signed by cosign.
+// https://github.com/containers/image/blob/main/docs/containers-signature.5.md#json-data-format
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// LoadPublicKey loads a PEM-encoded ECDSA, ED25519 or RSA public key.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ParsePublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the public key %s: %w", path, err)
+	}
+	return pub, nil
+}
+
+// ParsePublicKey parses a PEM-encoded ECDSA, ED25519 or RSA public key.
+func ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	var (
+		pub any
+		err error
+	)
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch pub.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// VerifySignature verifies sig over payload, with the algorithms used by cosign:
+// ECDSA (ASN.1) and RSA (PKCS #1 v1.5) with SHA-256, and ED25519.
+func VerifySignature(pub crypto.PublicKey, payload, sig []byte) error {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		h := sha256.Sum256(payload)
+		if !ecdsa.VerifyASN1(k, h[:], sig) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, payload, sig) {
+			return errors.New("invalid ED25519 signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		h := sha256.Sum256(payload)
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig); err != nil {
+			return fmt.Errorf("invalid RSA signature: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// VerifyPayload verifies that the payload is a cosign signature payload for the manifest dgst.
+func VerifyPayload(b []byte, dgst digest.Digest) error {
+	var payload Payload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return fmt.Errorf("failed to decode the signature payload: %w", err)
+	}
+	if payload.Critical.Type != payloadType {
+		return fmt.Errorf("unexpected signature payload type %q", payload.Critical.Type)
+	}
+	if payload.Critical.Image.DockerManifestDigest != dgst {
+		return fmt.Errorf("the signature is for %s, not %s", payload.Critical.Image.DockerManifestDigest, dgst)
+	}
+	return nil
+}
+
+// SignatureTag returns the tag of the signatures of the manifest dgst, e.g., "sha256-<hex>.sig".
+func SignatureTag(dgst digest.Digest) string {
+	return referrers.TagSchema(dgst) + ".sig"
+}
+
+// Verify verifies that the manifest dgst of the repository name (e.g., "docker.io/library/alpine")
+// has a cosign signature made with the private key of pub.
+// opts are used to resolve the repository, like for pulling images.
+func Verify(ctx context.Context, name string, dgst digest.Digest, pub crypto.PublicKey, opts ...dockerconfigresolver.Opt) error {
+	refspec, err := reference.Parse(name)
+	if err != nil {
+		return err
+	}
+	resolver, err := dockerconfigresolver.New(ctx, refspec.Hostname(), opts...)
+	if err != nil {
+		return err
+	}
+	hosts, err := dockerconfigresolver.NewRegistryHosts(ctx, refspec.Hostname(), opts...)
+	if err != nil {
+		return err
+	}
+
+	var (
+		manifests []ocispec.Descriptor
+		errs      []error
+	)
+	if _, desc, err := resolver.Resolve(ctx, refspec.Locator+":"+SignatureTag(dgst)); err == nil {
+		manifests = append(manifests, desc)
+	} else if !errdefs.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to resolve the signature tag: %w", err))
+	}
+	if descs, err := referrers.List(ctx, hosts, resolver, refspec.Locator, dgst, ArtifactType); err == nil {
+		manifests = append(manifests, descs...)
+	} else {
+		errs = append(errs, fmt.Errorf("failed to list the signatures in the referrers: %w", err))
+	}
+
+	for _, desc := range manifests {
+		err := verifyManifest(ctx, resolver, refspec.Locator, desc, dgst, pub)
+		if err == nil {
+			log.G(ctx).Debugf("verified the cosign signature %s of %s@%s", desc.Digest, name, dgst)
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(manifests) == 0 {
+		errs = append(errs, errors.New("no signatures found"))
+	}
+	return fmt.Errorf("no valid cosign signature for %s@%s: %w", name, dgst, errors.Join(errs...))
+}
+
+// verifyManifest verifies the signatures of a signature manifest, and returns nil if one of them is valid.
+func verifyManifest(ctx context.Context, resolver remotes.Resolver, locator string, desc ocispec.Descriptor, dgst digest.Digest, pub crypto.PublicKey) error {
+	b, err := referrers.FetchManifest(ctx, resolver, locator, desc)
+	if err != nil {
+		return err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return fmt.Errorf("failed to decode the signature manifest %s: %w", desc.Digest, err)
+	}
+	var errs []error
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != SimpleSigningMediaType {
+			continue
+		}
+		if err := verifyLayer(ctx, resolver, locator, layer, dgst, pub); err != nil {
+			errs = append(errs, fmt.Errorf("signature %s: %w", layer.Digest, err))
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("signature manifest %s has no signatures", desc.Digest)
+	}
+	return errors.Join(errs...)
+}
+
+func verifyLayer(ctx context.Context, resolver remotes.Resolver, locator string, layer ocispec.Descriptor, dgst digest.Digest, pub crypto.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(layer.Annotations[SignatureAnnotation])
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("invalid %s annotation", SignatureAnnotation)
+	}
+	payload, err := referrers.FetchManifest(ctx, resolver, locator, layer)
+	if err != nil {
+		return err
+	}
+	if err := VerifySignature(pub, payload, sig); err != nil {
+		return err
+	}
+	return VerifyPayload(payload, dgst)
+}
@@ -0,0 +1,284 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cosign
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/referrers"
+)
+
+// fakeRegistry is a minimal registry serving a single repository.
+type fakeRegistry struct {
+	mu           sync.Mutex
+	repo         string
+	manifests    map[string]ocispec.Descriptor // by tag and digest
+	blobs        map[digest.Digest][]byte
+	referrers    map[digest.Digest][]ocispec.Descriptor
+	referrersAPI bool
+}
+
+func newFakeRegistry(t *testing.T, referrersAPI bool) (*fakeRegistry, string) {
+	r := &fakeRegistry{
+		repo:         "test/app",
+		manifests:    make(map[string]ocispec.Descriptor),
+		blobs:        make(map[digest.Digest][]byte),
+		referrers:    make(map[digest.Digest][]ocispec.Descriptor),
+		referrersAPI: referrersAPI,
+	}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, strings.TrimPrefix(srv.URL, "http://") + "/" + r.repo
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prefix := "/v2/" + r.repo + "/"
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.HasPrefix(req.URL.Path, prefix+"manifests/"):
+		desc, ok := r.manifests[strings.TrimPrefix(req.URL.Path, prefix+"manifests/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		r.serveBlob(w, req, desc.MediaType, desc.Digest)
+	case strings.HasPrefix(req.URL.Path, prefix+"blobs/"):
+		r.serveBlob(w, req, "application/octet-stream", digest.Digest(strings.TrimPrefix(req.URL.Path, prefix+"blobs/")))
+	case strings.HasPrefix(req.URL.Path, prefix+"referrers/") && r.referrersAPI:
+		dgst := digest.Digest(strings.TrimPrefix(req.URL.Path, prefix+"referrers/"))
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		json.NewEncoder(w).Encode(ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: r.referrers[dgst],
+		})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *fakeRegistry) serveBlob(w http.ResponseWriter, req *http.Request, mediaType string, dgst digest.Digest) {
+	b, ok := r.blobs[dgst]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	if req.Method != http.MethodHead {
+		w.Write(b)
+	}
+}
+
+func (r *fakeRegistry) push(t *testing.T, mediaType string, v any) ocispec.Descriptor {
+	t.Helper()
+	b, ok := v.([]byte)
+	if !ok {
+		var err error
+		b, err = json.Marshal(v)
+		assert.NilError(t, err)
+	}
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blobs[desc.Digest] = b
+	if mediaType == ocispec.MediaTypeImageManifest || mediaType == ocispec.MediaTypeImageIndex {
+		r.manifests[desc.Digest.String()] = desc
+	}
+	return desc
+}
+
+func (r *fakeRegistry) tag(tag string, desc ocispec.Descriptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manifests[tag] = desc
+}
+
+// pushImage pushes a dummy image manifest and returns its digest.
+func (r *fakeRegistry) pushImage(t *testing.T, title string) digest.Digest {
+	config := r.push(t, ocispec.MediaTypeImageConfig, []byte("{}"))
+	return r.push(t, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Config:      config,
+		Annotations: map[string]string{ocispec.AnnotationTitle: title},
+	}).Digest
+}
+
+// pushSignature pushes a signature of dgst like `cosign sign`, either with the signature tag or as a referrer.
+func (r *fakeRegistry) pushSignature(t *testing.T, name string, dgst digest.Digest, priv crypto.Signer, asReferrer bool) {
+	t.Helper()
+	var payload Payload
+	payload.Critical.Identity.DockerReference = name
+	payload.Critical.Image.DockerManifestDigest = dgst
+	payload.Critical.Type = payloadType
+	b, err := json.Marshal(payload)
+	assert.NilError(t, err)
+
+	var sig []byte
+	if _, ok := priv.(ed25519.PrivateKey); ok {
+		sig, err = priv.Sign(rand.Reader, b, crypto.Hash(0))
+	} else {
+		h := sha256.Sum256(b)
+		sig, err = priv.Sign(rand.Reader, h[:], crypto.SHA256)
+	}
+	assert.NilError(t, err)
+
+	layer := r.push(t, SimpleSigningMediaType, b)
+	layer.Annotations = map[string]string{SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)}
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    r.push(t, "application/vnd.oci.empty.v1+json", []byte("{}")),
+		Layers:    []ocispec.Descriptor{layer},
+	}
+	if !asReferrer {
+		r.tag(SignatureTag(dgst), r.push(t, ocispec.MediaTypeImageManifest, manifest))
+		return
+	}
+	manifest.ArtifactType = ArtifactType
+	manifest.Subject = &ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: dgst}
+	desc := r.push(t, ocispec.MediaTypeImageManifest, manifest)
+	desc.ArtifactType = ArtifactType
+	r.mu.Lock()
+	r.referrers[dgst] = append(r.referrers[dgst], desc)
+	referrersIndex := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: r.referrers[dgst],
+	}
+	r.mu.Unlock()
+	if !r.referrersAPI {
+		r.tag(referrers.TagSchema(dgst), r.push(t, ocispec.MediaTypeImageIndex, referrersIndex))
+	}
+}
+
+func marshalPublicKey(t *testing.T, pub crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NilError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func generateKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	return map[string]crypto.Signer{"ecdsa": ecdsaKey, "ed25519": ed25519Key, "rsa": rsaKey}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+	for name, priv := range generateKeys(t) {
+		pub, err := ParsePublicKey(marshalPublicKey(t, priv.Public()))
+		assert.NilError(t, err, name)
+		assert.Assert(t, priv.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub), name)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	pub, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}))
+	assert.NilError(t, err)
+	assert.Assert(t, rsaKey.PublicKey.Equal(pub))
+
+	_, err = ParsePublicKey([]byte("not a key"))
+	assert.ErrorContains(t, err, "no PEM block found")
+	_, err = ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{}}))
+	assert.ErrorContains(t, err, "unsupported PEM block type")
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	keys := generateKeys(t)
+	otherKeys := generateKeys(t)
+
+	testCases := []struct {
+		name         string
+		asReferrer   bool
+		referrersAPI bool
+	}{
+		{name: "tag"},
+		{name: "referrers API", asReferrer: true, referrersAPI: true},
+		{name: "referrers tag schema", asReferrer: true},
+	}
+	for _, tc := range testCases {
+		for keyType, priv := range keys {
+			t.Run(fmt.Sprintf("%s/%s", tc.name, keyType), func(t *testing.T) {
+				t.Parallel()
+				reg, name := newFakeRegistry(t, tc.referrersAPI)
+				signed := reg.pushImage(t, "signed")
+				unsigned := reg.pushImage(t, "unsigned")
+				reg.pushSignature(t, name, signed, priv, tc.asReferrer)
+
+				assert.NilError(t, Verify(ctx, name, signed, priv.Public()))
+
+				err := Verify(ctx, name, signed, otherKeys[keyType].Public())
+				assert.ErrorContains(t, err, "no valid cosign signature")
+
+				err = Verify(ctx, name, unsigned, priv.Public())
+				assert.ErrorContains(t, err, "no signatures found")
+			})
+		}
+	}
+}
+
+func TestVerifyPayloadDigest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	priv := generateKeys(t)["ecdsa"]
+	reg, name := newFakeRegistry(t, true)
+	signed := reg.pushImage(t, "signed")
+	other := reg.pushImage(t, "other")
+	// a valid signature of another image, stored as a signature of this image
+	reg.pushSignature(t, name, signed, priv, false)
+	reg.tag(SignatureTag(other), reg.manifests[SignatureTag(signed)])
+
+	err := Verify(ctx, name, other, priv.Public())
+	assert.ErrorContains(t, err, fmt.Sprintf("the signature is for %s, not %s", signed, other))
+}
@@ -27,6 +27,9 @@ import (
 	"github.com/containerd/log"
 
 	"github.com/containerd/nerdctl/v2/pkg/imgutil"
+	"github.com/containerd/nerdctl/v2/pkg/imgutil/dockerconfigresolver"
+	"github.com/containerd/nerdctl/v2/pkg/referenceutil"
+	"github.com/containerd/nerdctl/v2/pkg/signutil/cosign"
 )
 
 // SignCosign signs an image(`rawRef`) using a cosign private key (`keyRef`)
@@ -63,6 +66,7 @@ func SignCosign(rawRef string, keyRef string) error {
 
 // VerifyCosign verifies an image(`rawRef`) with a cosign public key(`keyRef`)
 // `hostsDirs` are used to resolve image `rawRef`
+// When `keyRef` is a local PEM file, the signature is verified without the cosign binary.
 // Either --cosign-certificate-identity or --cosign-certificate-identity-regexp and either --cosign-certificate-oidc-issuer or --cosign-certificate-oidc-issuer-regexp must be set for keyless flows.
 func VerifyCosign(ctx context.Context, rawRef string, keyRef string, hostsDirs []string,
 	certIdentity string, certIdentityRegexp string, certOidcIssuer string, certOidcIssuerRegexp string) (string, error) {
@@ -78,6 +82,10 @@ func VerifyCosign(ctx context.Context, rawRef string, keyRef string, hostsDirs [
 
 	log.G(ctx).Debugf("verifying image: %s", ref)
 
+	if isLocalKey(keyRef) {
+		return ref, verifyCosignWithKeyFile(ctx, ref, keyRef, hostsDirs)
+	}
+
 	cosignExecutable, err := exec.LookPath("cosign")
 	if err != nil {
 		log.G(ctx).WithError(err).Error("cosign executable not found in path $PATH")
@@ -128,6 +136,33 @@ func VerifyCosign(ctx context.Context, rawRef string, keyRef string, hostsDirs [
 	return ref, nil
 }
 
+// isLocalKey returns true if keyRef is a local file, not a KMS URI like "awskms://..." or a PKCS #11 URI.
+func isLocalKey(keyRef string) bool {
+	if keyRef == "" || strings.Contains(keyRef, "://") || strings.HasPrefix(keyRef, "pkcs11:") {
+		return false
+	}
+	_, err := os.Stat(keyRef)
+	return err == nil
+}
+
+// verifyCosignWithKeyFile verifies `ref` (with a digest) with the public key file `keyPath`, without the cosign binary.
+func verifyCosignWithKeyFile(ctx context.Context, ref string, keyPath string, hostsDirs []string) error {
+	parsedReference, err := referenceutil.Parse(ref)
+	if err != nil {
+		return err
+	}
+	pub, err := cosign.LoadPublicKey(keyPath)
+	if err != nil {
+		return err
+	}
+	if err := cosign.Verify(ctx, parsedReference.Name(), parsedReference.Digest, pub, dockerconfigresolver.WithHostsDirs(hostsDirs)); err != nil {
+		log.G(ctx).WithError(err).Errorf("failed to verify the cosign signature of %s", ref)
+		return err
+	}
+	log.G(ctx).Infof("verified the cosign signature of %s with %s", ref, keyPath)
+	return nil
+}
+
 func processCosignIO(cosignCmd *exec.Cmd) error {
 	stdout, err := cosignCmd.StdoutPipe()
 	if err != nil {
@@ -0,0 +1,130 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package statsutil
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metric types of Prometheus
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Label is a label of a sample of a Prometheus metric
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metrics is a set of Prometheus metrics, in the order they are added
+type Metrics struct {
+	families []*family
+	index    map[string]*family
+}
+
+type family struct {
+	name    string
+	typ     string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels []Label
+	value  float64
+}
+
+// NewMetrics returns an empty set of metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{index: make(map[string]*family)}
+}
+
+// Add adds a sample of the metric name of type typ (Counter or Gauge).
+// The type and the help of a metric are set by its first sample.
+func (m *Metrics) Add(name, typ, help string, labels []Label, value float64) {
+	f, ok := m.index[name]
+	if !ok {
+		f = &family{name: name, typ: typ, help: help}
+		m.index[name] = f
+		m.families = append(m.families, f)
+	}
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range m.families {
+		bw.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		bw.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+		for _, s := range f.samples {
+			bw.WriteString(f.name)
+			if len(s.labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					bw.WriteString(l.Name + `="` + escapeLabelValue(l.Value) + `"`)
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteString(" " + formatValue(s.value) + "\n")
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package statsutil
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMetricsWriteTo(t *testing.T) {
+	t.Parallel()
+	m := NewMetrics()
+	labels := []Label{{Name: "id", Value: "abc"}, {Name: "name", Value: "quote\"back\\slash\nnewline"}}
+	m.Add("nerdctl_container_cpu_usage_seconds_total", Counter, "Total CPU time\nconsumed", labels, 1.5)
+	m.Add("nerdctl_container_pids", Gauge, "Number of processes", labels, 3)
+	m.Add("nerdctl_container_cpu_usage_seconds_total", Counter, "ignored", []Label{{Name: "id", Value: "def"}}, 1e21)
+	m.Add("nerdctl_up", Gauge, "Up", nil, math.Inf(1))
+
+	var b strings.Builder
+	n, err := m.WriteTo(&b)
+	assert.NilError(t, err)
+	assert.Equal(t, n, int64(b.Len()))
+	assert.Equal(t, b.String(), `# HELP nerdctl_container_cpu_usage_seconds_total Total CPU time\nconsumed
+# TYPE nerdctl_container_cpu_usage_seconds_total counter
+nerdctl_container_cpu_usage_seconds_total{id="abc",name="quote\"back\\slash\nnewline"} 1.5
+nerdctl_container_cpu_usage_seconds_total{id="def"} 1e+21
+# HELP nerdctl_container_pids Number of processes
+# TYPE nerdctl_container_pids gauge
+nerdctl_container_pids{id="abc",name="quote\"back\\slash\nnewline"} 3
+# HELP nerdctl_up Up
+# TYPE nerdctl_up gauge
+nerdctl_up +Inf
+`)
+}
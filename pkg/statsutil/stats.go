@@ -44,7 +44,84 @@ type StatsEntry struct {
 	BlockRead        float64
 	BlockWrite       float64
 	PidsCurrent      uint64
-	IsInvalid        bool
+	// CPUUsage is the total CPU time consumed, in seconds
+	CPUUsage float64
+	// CPUThrottling is the throttling of the CPU bandwidth (CFS quota) of the container
+	CPUThrottling CPUThrottling
+	// MemoryStats is the breakdown of the memory usage
+	MemoryStats MemoryStats
+	// OOMKills is the number of the processes killed by the OOM killer
+	OOMKills uint64
+	// Pressure is the pressure stall information (cgroup v2 only)
+	Pressure *PressureStats
+	// Networks are the statistics of each network interface, excluding the loopback interface
+	Networks  []NetworkStats
+	IsInvalid bool
+}
+
+// CPUThrottling represents the throttling of the CPU bandwidth of a container
+type CPUThrottling struct {
+	// Periods is the number of the enforcement periods elapsed
+	Periods uint64
+	// ThrottledPeriods is the number of the periods the container was throttled in
+	ThrottledPeriods uint64
+	// ThrottledTime is the total time the container was throttled, in seconds
+	ThrottledTime float64
+}
+
+// MemoryStats represents the breakdown of the memory usage of a container, in bytes
+type MemoryStats struct {
+	// Anon is the anonymous memory (e.g., heaps and stacks)
+	Anon float64
+	// File is the file cache
+	File float64
+	// Kernel is the kernel memory (kernel stacks, slab and socket buffers with cgroup v2)
+	Kernel float64
+	// Slab is the memory of the kernel data structures (cgroup v2 only)
+	Slab float64
+}
+
+// PressureStats represents the pressure stall information (PSI) of a container
+type PressureStats struct {
+	CPU    Pressure
+	Memory Pressure
+	IO     Pressure
+}
+
+// Pressure represents the pressure stall information of a resource.
+// Some is the share of time some of the tasks were stalled, Full is the share of time all the tasks were stalled.
+type Pressure struct {
+	Some PressureData
+	Full PressureData
+}
+
+// PressureData represents the ratios (in percent) of time stalled in the last 10, 60 and 300 seconds,
+// and the total time stalled, in seconds
+type PressureData struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  float64
+}
+
+// NetworkStats represents the statistics of a network interface of a container
+type NetworkStats struct {
+	Name      string
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+// StatsJSON represents the statistics of the containers printed for each tick by `nerdctl stats --format json`
+type StatsJSON struct {
+	// Read is the time the statistics were read
+	Read       time.Time
+	Containers []StatsEntry
 }
 
 // FormattedStatsEntry represents a formatted StatsEntry
@@ -117,6 +194,12 @@ func (cs *Stats) SetErrorAndReset(err error) {
 	cs.BlockRead = 0
 	cs.BlockWrite = 0
 	cs.PidsCurrent = 0
+	cs.CPUUsage = 0
+	cs.CPUThrottling = CPUThrottling{}
+	cs.MemoryStats = MemoryStats{}
+	cs.OOMKills = 0
+	cs.Pressure = nil
+	cs.Networks = nil
 	cs.err = err
 	cs.IsInvalid = true
 }
@@ -57,6 +57,19 @@ func SetCgroupStatsFields(previousStats *ContainerStats, data *v1.Metrics, links
 		BlockRead:        float64(blkRead),
 		BlockWrite:       float64(blkWrite),
 		PidsCurrent:      pidsStatsCurrent,
+		CPUUsage:         float64(data.GetCPU().GetUsage().GetTotal()) / 1e9,
+		CPUThrottling: CPUThrottling{
+			Periods:          data.GetCPU().GetThrottling().GetPeriods(),
+			ThrottledPeriods: data.GetCPU().GetThrottling().GetThrottledPeriods(),
+			ThrottledTime:    float64(data.GetCPU().GetThrottling().GetThrottledTime()) / 1e9,
+		},
+		MemoryStats: MemoryStats{
+			Anon:   float64(data.GetMemory().GetTotalRSS()),
+			File:   float64(data.GetMemory().GetTotalCache()),
+			Kernel: float64(data.GetMemory().GetKernel().GetUsage()),
+		},
+		OOMKills: data.GetMemoryOomControl().GetOomKill(),
+		Networks: networkStats(links),
 	}, nil
 
 }
@@ -80,10 +93,45 @@ func SetCgroup2StatsFields(previousStats *ContainerStats, metrics *v2.Metrics, l
 		BlockRead:        float64(blkRead),
 		BlockWrite:       float64(blkWrite),
 		PidsCurrent:      pidsStatsCurrent,
+		CPUUsage:         float64(metrics.GetCPU().GetUsageUsec()) / 1e6,
+		CPUThrottling: CPUThrottling{
+			Periods:          metrics.GetCPU().GetNrPeriods(),
+			ThrottledPeriods: metrics.GetCPU().GetNrThrottled(),
+			ThrottledTime:    float64(metrics.GetCPU().GetThrottledUsec()) / 1e6,
+		},
+		MemoryStats: MemoryStats{
+			Anon:   float64(metrics.GetMemory().GetAnon()),
+			File:   float64(metrics.GetMemory().GetFile()),
+			Kernel: float64(metrics.GetMemory().GetKernelStack() + metrics.GetMemory().GetSlab() + metrics.GetMemory().GetSock()),
+			Slab:   float64(metrics.GetMemory().GetSlab()),
+		},
+		OOMKills: metrics.GetMemoryEvents().GetOomKill(),
+		Pressure: &PressureStats{
+			CPU:    cgroup2Pressure(metrics.GetCPU().GetPSI()),
+			Memory: cgroup2Pressure(metrics.GetMemory().GetPSI()),
+			IO:     cgroup2Pressure(metrics.GetIo().GetPSI()),
+		},
+		Networks: networkStats(links),
 	}, nil
 
 }
 
+// cgroup2Pressure converts the PSI of cgroup v2 (with the total in microseconds) to Pressure
+func cgroup2Pressure(psi *v2.PSIStats) Pressure {
+	data := func(d *v2.PSIData) PressureData {
+		return PressureData{
+			Avg10:  d.GetAvg10(),
+			Avg60:  d.GetAvg60(),
+			Avg300: d.GetAvg300(),
+			Total:  float64(d.GetTotal()) / 1e6,
+		}
+	}
+	return Pressure{
+		Some: data(psi.GetSome()),
+		Full: data(psi.GetFull()),
+	}
+}
+
 func getCgroupMemLimit(memLimit float64) float64 {
 	if memLimit == float64(^uint64(0)) {
 		return getHostMemLimit()
@@ -200,6 +248,25 @@ func calculateCgroup2IO(metrics *v2.Metrics) (uint64, uint64) {
 	return ioRead, ioWrite
 }
 
+func networkStats(links []netlink.Link) []NetworkStats {
+	var res []NetworkStats
+	for _, l := range links {
+		n := NetworkStats{Name: l.Attrs().Name}
+		if stats := l.Attrs().Statistics; stats != nil {
+			n.RxBytes = stats.RxBytes
+			n.TxBytes = stats.TxBytes
+			n.RxPackets = stats.RxPackets
+			n.TxPackets = stats.TxPackets
+			n.RxErrors = stats.RxErrors
+			n.TxErrors = stats.TxErrors
+			n.RxDropped = stats.RxDropped
+			n.TxDropped = stats.TxDropped
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
 func calculateCgroupNetwork(links []netlink.Link) (float64, float64) {
 	var rx, tx float64
 
@@ -0,0 +1,141 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package statsutil
+
+import (
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"gotest.tools/v3/assert"
+
+	v1 "github.com/containerd/cgroups/v3/cgroup1/stats"
+	v2 "github.com/containerd/cgroups/v3/cgroup2/stats"
+)
+
+func TestNetworkStats(t *testing.T) {
+	t.Parallel()
+
+	links := []netlink.Link{
+		&netlink.Veth{LinkAttrs: netlink.LinkAttrs{
+			Name: "eth0",
+			Statistics: &netlink.LinkStatistics{
+				RxBytes: 1, TxBytes: 2, RxPackets: 3, TxPackets: 4,
+				RxErrors: 5, TxErrors: 6, RxDropped: 7, TxDropped: 8,
+			},
+		}},
+		// the statistics are not always available
+		&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "dummy0"}},
+	}
+	assert.DeepEqual(t, networkStats(links), []NetworkStats{
+		{
+			Name: "eth0", RxBytes: 1, TxBytes: 2, RxPackets: 3, TxPackets: 4,
+			RxErrors: 5, TxErrors: 6, RxDropped: 7, TxDropped: 8,
+		},
+		{Name: "dummy0"},
+	})
+	assert.Assert(t, networkStats(nil) == nil)
+}
+
+func TestSetCgroupStatsFields(t *testing.T) {
+	t.Parallel()
+
+	metrics := &v1.Metrics{
+		CPU: &v1.CPUStat{
+			Usage:      &v1.CPUUsage{Total: 3e9},
+			Throttling: &v1.Throttle{Periods: 10, ThrottledPeriods: 4, ThrottledTime: 2e9},
+		},
+		Memory: &v1.MemoryStat{
+			TotalRSS:   100,
+			TotalCache: 200,
+			Usage:      &v1.MemoryEntry{Usage: 400, Limit: 1000},
+			Kernel:     &v1.MemoryEntry{Usage: 50},
+		},
+		MemoryOomControl: &v1.MemoryOomControl{OomKill: 2},
+		Pids:             &v1.PidsStat{Current: 5},
+		Blkio:            &v1.BlkIOStat{},
+	}
+	entry, err := SetCgroupStatsFields(&ContainerStats{}, metrics, nil, SystemInfo{})
+	assert.NilError(t, err)
+	assert.Equal(t, entry.CPUUsage, 3.0)
+	assert.Equal(t, entry.CPUThrottling, CPUThrottling{Periods: 10, ThrottledPeriods: 4, ThrottledTime: 2})
+	assert.Equal(t, entry.MemoryStats, MemoryStats{Anon: 100, File: 200, Kernel: 50})
+	assert.Equal(t, entry.OOMKills, uint64(2))
+	assert.Equal(t, entry.PidsCurrent, uint64(5))
+	// the pressure stall information is not available with cgroup v1
+	assert.Assert(t, entry.Pressure == nil)
+}
+
+func TestSetCgroup2StatsFields(t *testing.T) {
+	t.Parallel()
+
+	psi := func(some, full float64) *v2.PSIStats {
+		return &v2.PSIStats{
+			Some: &v2.PSIData{Avg10: some, Avg60: some * 2, Avg300: some * 3, Total: 1500000},
+			Full: &v2.PSIData{Avg10: full, Avg60: full * 2, Avg300: full * 3, Total: 500000},
+		}
+	}
+	metrics := &v2.Metrics{
+		CPU: &v2.CPUStat{
+			UsageUsec:     3e6,
+			NrPeriods:     10,
+			NrThrottled:   4,
+			ThrottledUsec: 2e6,
+			PSI:           psi(1, 0.5),
+		},
+		Memory: &v2.MemoryStat{
+			Anon:        100,
+			File:        200,
+			KernelStack: 10,
+			Slab:        20,
+			Sock:        30,
+			Usage:       400,
+			UsageLimit:  1000,
+			PSI:         psi(2, 1),
+		},
+		MemoryEvents: &v2.MemoryEvents{OomKill: 2},
+		Io:           &v2.IOStat{PSI: psi(3, 1.5)},
+		Pids:         &v2.PidsStat{Current: 5},
+	}
+	entry, err := SetCgroup2StatsFields(&ContainerStats{}, metrics, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, entry.CPUUsage, 3.0)
+	assert.Equal(t, entry.CPUThrottling, CPUThrottling{Periods: 10, ThrottledPeriods: 4, ThrottledTime: 2})
+	assert.Equal(t, entry.MemoryStats, MemoryStats{Anon: 100, File: 200, Kernel: 60, Slab: 20})
+	assert.Equal(t, entry.OOMKills, uint64(2))
+	assert.Equal(t, entry.PidsCurrent, uint64(5))
+	assert.DeepEqual(t, entry.Pressure, &PressureStats{
+		CPU: Pressure{
+			Some: PressureData{Avg10: 1, Avg60: 2, Avg300: 3, Total: 1.5},
+			Full: PressureData{Avg10: 0.5, Avg60: 1, Avg300: 1.5, Total: 0.5},
+		},
+		Memory: Pressure{
+			Some: PressureData{Avg10: 2, Avg60: 4, Avg300: 6, Total: 1.5},
+			Full: PressureData{Avg10: 1, Avg60: 2, Avg300: 3, Total: 0.5},
+		},
+		IO: Pressure{
+			Some: PressureData{Avg10: 3, Avg60: 6, Avg300: 9, Total: 1.5},
+			Full: PressureData{Avg10: 1.5, Avg60: 3, Avg300: 4.5, Total: 0.5},
+		},
+	})
+}
+
+func TestCgroup2PressureMissing(t *testing.T) {
+	t.Parallel()
+
+	// the PSI is missing when the kernel does not support it
+	assert.Equal(t, cgroup2Pressure(nil), Pressure{})
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tarutil
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+)
+
+// WalkFunc is called for each entry of a tar archive.
+// r reads the content of the entry, and must not be used after WalkFunc returns.
+type WalkFunc func(hdr *tar.Header, r io.Reader) error
+
+// Walk calls fn for each entry of the tar archive read from r, which may be compressed (gzip, zstd).
+func Walk(r io.Reader, fn WalkFunc) error {
+	ds, err := compression.DecompressStream(r)
+	if err != nil {
+		return err
+	}
+	defer ds.Close()
+	tr := tar.NewReader(ds)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}